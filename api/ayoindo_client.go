@@ -19,7 +19,9 @@ import (
 	"sync"
 	"time"
 
+	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/recording"
 )
 
 // AyoIndoClient handles interactions with the AYO Indonesia API
@@ -416,6 +418,11 @@ func (c *AyoIndoClient) GetBookings(date string) (map[string]interface{}, error)
 
 // SaveVideoAvailable notifies AYO API that a video is available
 func (c *AyoIndoClient) SaveVideoAvailable(bookingID, videoType, previewPath, imagePath, uniqueID string, startTime, endTime time.Time, duration int) (map[string]interface{}, error) {
+	return c.SaveVideoAvailableWithRenditions(bookingID, videoType, previewPath, imagePath, uniqueID, startTime, endTime, duration, nil)
+}
+
+// SaveVideoAvailableWithRenditions notifies AYO API that a video is available, including any additional renditions
+func (c *AyoIndoClient) SaveVideoAvailableWithRenditions(bookingID, videoType, previewPath, imagePath, uniqueID string, startTime, endTime time.Time, duration int, renditions []database.VideoRendition) (map[string]interface{}, error) {
 	// Check if venue code and secret key are configured
 	if c.venueCode == "" || c.secretKey == "" {
 		return nil, fmt.Errorf("venue code and secret key must be configured before saving video availability")
@@ -435,6 +442,9 @@ func (c *AyoIndoClient) SaveVideoAvailable(bookingID, videoType, previewPath, im
 		"end_timestamp":   endTime.UTC().Format(time.RFC3339),
 		"duration":        fmt.Sprintf("%d", duration),
 	}
+	if len(renditions) > 0 {
+		params["renditions"] = renditions
+	}
 
 	// Generate signature
 	signature, err := c.GenerateSignature(params)
@@ -572,8 +582,21 @@ func (c *AyoIndoClient) GetVideoRequests(date string) (map[string]interface{}, e
 	return result, nil
 }
 
+// SaveVideoAssets holds optional extra assets sent along with a saved video
+type SaveVideoAssets struct {
+	ThumbnailSpriteURL string // URL of the first thumbnail sprite sheet
+	ThumbnailVTTURL    string // URL of the WebVTT thumbnails track referencing the sprite sheets
+	SlowMotionURL      string // URL of the separate slow-motion replay of a button clip
+	DASHURL            string // URL of the DASH manifest (CMAF output)
+}
+
 // SaveVideo saves video path information to AYO API
 func (c *AyoIndoClient) SaveVideo(videoRequestID, bookingID, videoType, streamPath, downloadPath string, startTime, endTime time.Time) (map[string]interface{}, error) {
+	return c.SaveVideoWithAssets(videoRequestID, bookingID, videoType, streamPath, downloadPath, startTime, endTime, SaveVideoAssets{})
+}
+
+// SaveVideoWithAssets saves video path information to AYO API including optional extra assets
+func (c *AyoIndoClient) SaveVideoWithAssets(videoRequestID, bookingID, videoType, streamPath, downloadPath string, startTime, endTime time.Time, assets SaveVideoAssets) (map[string]interface{}, error) {
 	// Check if venue code and secret key are configured
 	if c.venueCode == "" || c.secretKey == "" {
 		return nil, fmt.Errorf("venue code and secret key must be configured before saving video")
@@ -608,6 +631,18 @@ func (c *AyoIndoClient) SaveVideo(videoRequestID, bookingID, videoType, streamPa
 		"download_path": downloadPath,
 		"resolution":    "", // Assuming 1080p, adjust if needed
 	}
+	if assets.ThumbnailSpriteURL != "" {
+		videoObjPost["thumbnail_sprite"] = assets.ThumbnailSpriteURL
+	}
+	if assets.ThumbnailVTTURL != "" {
+		videoObjPost["thumbnail_vtt"] = assets.ThumbnailVTTURL
+	}
+	if assets.SlowMotionURL != "" {
+		videoObjPost["slow_motion_path"] = assets.SlowMotionURL
+	}
+	if assets.DASHURL != "" {
+		videoObjPost["dash_path"] = assets.DASHURL
+	}
 	// Add signature to parameters
 	params["signature"] = signature
 	params["video"] = []map[string]interface{}{videoObjPost}
@@ -958,6 +993,186 @@ func (c *AyoIndoClient) getWatermarkInternal(resolution string, forceUpdate bool
 	return fallbackPath, nil
 }
 
+// GetBumperMetadata retrieves the venue intro/outro bumper metadata from AYO API
+func (c *AyoIndoClient) GetBumperMetadata() (map[string]interface{}, error) {
+	// Check if venue code and secret key are configured
+	if c.venueCode == "" || c.secretKey == "" {
+		return nil, fmt.Errorf("venue code and secret key must be configured before accessing bumper metadata")
+	}
+
+	// Prepare the parameters
+	params := map[string]interface{}{
+		"token":      c.apiToken,
+		"venue_code": c.venueCode,
+	}
+
+	// Generate signature
+	signature, err := c.GenerateSignature(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signature: %w", err)
+	}
+	params["signature"] = signature
+
+	endpoint := fmt.Sprintf("%s/api/v1/bumper", c.baseURL)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	for k, v := range params {
+		q.Add(k, fmt.Sprintf("%v", v))
+	}
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetBumper returns the local path of the venue's intro or outro bumper ("intro" or "outro").
+// A bumper uploaded through the admin API is used as is. Otherwise the bumper is downloaded
+// from AYO API and cached like watermarks, refreshing it once it is older than 24 hours.
+func (c *AyoIndoClient) GetBumper(kind string) (string, error) {
+	if kind != "intro" && kind != "outro" {
+		return "", fmt.Errorf("invalid bumper kind: %s", kind)
+	}
+
+	// Load storage path from database
+	db, err := database.NewSQLiteDB("./data/videos.db")
+	if err != nil {
+		log.Printf("Failed to connect to database, using fallback values: %v", err)
+	}
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+
+	storagePath := "./videos"
+	if db != nil {
+		if path, err := db.GetSystemConfig(database.ConfigStoragePath); err == nil && path.Value != "" {
+			storagePath = path.Value
+		}
+	}
+
+	// Uploaded bumpers take precedence over the AYO API
+	customPath := recording.BumperCustomPath(storagePath, c.venueCode, kind)
+	if _, err := os.Stat(customPath); err == nil {
+		log.Printf("GetBumper : Using uploaded %s bumper: %s", kind, customPath)
+		return customPath, nil
+	}
+
+	path := recording.BumperDownloadPath(storagePath, c.venueCode, kind)
+	stat, statErr := os.Stat(path)
+	if statErr == nil && time.Since(stat.ModTime()) <= 24*time.Hour {
+		log.Printf("GetBumper : %s bumper found: %s", kind, path)
+		return path, nil
+	}
+
+	// useCached returns the existing (possibly stale) bumper when refreshing fails
+	useCached := func(reason error) (string, error) {
+		if statErr == nil {
+			log.Printf("GetBumper : Warning: %v, using cached %s bumper", reason, kind)
+			return path, nil
+		}
+		return "", reason
+	}
+
+	// A URL configured in the bumper config overrides the one from the AYO API
+	bumperURL := ""
+	if db != nil {
+		if bumperConfig, err := config.NewBumperConfigService(db).GetBumperConfig(); err == nil {
+			bumperURL = bumperConfig.URLFor(kind)
+		}
+	}
+
+	if bumperURL == "" {
+		response, err := c.GetBumperMetadata()
+		if err != nil {
+			return useCached(fmt.Errorf("failed to get bumper metadata: %w", err))
+		}
+
+		data, ok := response["data"].([]interface{})
+		if !ok {
+			return useCached(fmt.Errorf("invalid response format from API"))
+		}
+
+		for _, entry := range data {
+			entryMap, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if entryType, _ := entryMap["type"].(string); entryType == kind {
+				bumperURL, _ = entryMap["path"].(string)
+				break
+			}
+		}
+	}
+	if bumperURL == "" {
+		return useCached(fmt.Errorf("venue has no %s bumper", kind))
+	}
+	if !strings.HasPrefix(bumperURL, "http://") && !strings.HasPrefix(bumperURL, "https://") {
+		bumperURL = "https://" + bumperURL
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	log.Printf("GetBumper : Downloading %s bumper from: %s", kind, bumperURL)
+	resp, err := http.Get(bumperURL)
+	if err != nil {
+		return useCached(fmt.Errorf("failed to download bumper: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return useCached(fmt.Errorf("failed to download bumper, status code: %d", resp.StatusCode))
+	}
+
+	// Download to a temporary file so a failed download never replaces a good bumper
+	tmpPath := path + ".download"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return useCached(fmt.Errorf("failed to create bumper file: %w", err))
+	}
+	_, err = io.Copy(f, resp.Body)
+	f.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return useCached(fmt.Errorf("failed to save bumper file: %w", err))
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return useCached(fmt.Errorf("failed to store bumper file: %w", err))
+	}
+
+	log.Printf("GetBumper : Successfully downloaded %s bumper", kind)
+	return path, nil
+}
+
 // GetVideoConfiguration retrieves video configuration from AYO API
 func (c *AyoIndoClient) GetVideoConfiguration() (map[string]interface{}, error) {
 	// Check if venue code and secret key are configured
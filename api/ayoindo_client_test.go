@@ -282,7 +282,7 @@ func TestHealthCheck(t *testing.T) {
 	}
 
 	// Call the API
-	result, err := client.HealthCheck("test-camera-token")
+	result, err := client.HealthCheck()
 	if err != nil {
 		t.Fatalf("HealthCheck failed: %v", err)
 	}
@@ -653,7 +653,7 @@ func TestMarkVideoRequestsInvalid(t *testing.T) {
 
 	// Test the function
 	videoRequestIDs := []string{"video1", "video2"}
-	result, err := client.MarkVideoRequestsInvalid(videoRequestIDs)
+	result, err := client.MarkVideoRequestsInvalid(videoRequestIDs, false)
 	if err != nil {
 		t.Fatalf("MarkVideoRequestsInvalid failed: %v", err)
 	}
@@ -776,6 +776,7 @@ func TestSaveVideoAvailable(t *testing.T) {
 		"TEST_UNIQUE_ID",
 		startTime,
 		endTime,
+		3600,
 	)
 	if err != nil {
 		t.Fatalf("SaveVideoAvailable failed: %v", err)
@@ -819,9 +820,7 @@ func TestAyoIndoClientWithRealCredentials(t *testing.T) {
 	// Test HealthCheck
 	t.Run("HealthCheck", func(t *testing.T) {
 		t.Logf("Attempting HealthCheck API call")
-		// Use a test camera token
-		cameraToken := "test-camera-token"
-		result, err := client.HealthCheck(cameraToken)
+		result, err := client.HealthCheck()
 		t.Logf("HealthCheck returned: result=%v, err=%v", result, err)
 		if err != nil {
 			t.Fatalf("HealthCheck failed: %v", err)
@@ -918,7 +917,7 @@ func TestAyoIndoClientWithRealCredentials(t *testing.T) {
 		startTime := time.Now()
 		endTime := startTime.Add(10 * time.Minute)
 
-		result, err := client.SaveVideoAvailable(bookingID, videoType, previewPath, imagePath, uniqueID, startTime, endTime)
+		result, err := client.SaveVideoAvailable(bookingID, videoType, previewPath, imagePath, uniqueID, startTime, endTime, int(endTime.Sub(startTime).Seconds()))
 		// Error is expected without actual video files, but URL and body should be printed
 		t.Logf("SaveVideoAvailable attempted, got: %v, err: %v", result, err)
 	})
@@ -995,7 +994,7 @@ func TestAyoIndoClientWithRealCredentials(t *testing.T) {
 		// Use test video request IDs - we use a specific prefix for test IDs to avoid affecting real data
 		// In a real integration test with actual video requests, you would use real IDs
 		videoRequestIDs := []string{"TEST-VID-001", "TEST-VID-002"}
-		result, err := client.MarkVideoRequestsInvalid(videoRequestIDs)
+		result, err := client.MarkVideoRequestsInvalid(videoRequestIDs, false)
 		
 		// Log request details
 		t.Logf("Attempting to mark %d video requests as invalid: %v", len(videoRequestIDs), videoRequestIDs)
@@ -1028,7 +1027,7 @@ func TestAyoIndoClientWithRealCredentials(t *testing.T) {
 		}
 		
 		// Test with empty ID array - should fail with validation error
-		_, err = client.MarkVideoRequestsInvalid([]string{})
+		_, err = client.MarkVideoRequestsInvalid([]string{}, false)
 		if err == nil {
 			t.Errorf("Expected error when passing empty video request IDs array, but got success")
 		} else {
@@ -1037,7 +1036,7 @@ func TestAyoIndoClientWithRealCredentials(t *testing.T) {
 		
 		// Test with too many IDs - should fail with validation error
 		tooManyIDs := []string{"id1", "id2", "id3", "id4", "id5", "id6", "id7", "id8", "id9", "id10", "id11"}
-		_, err = client.MarkVideoRequestsInvalid(tooManyIDs)
+		_, err = client.MarkVideoRequestsInvalid(tooManyIDs, false)
 		if err == nil {
 			t.Errorf("Expected error when passing too many video request IDs, but got success")
 		} else {
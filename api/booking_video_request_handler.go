@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
 	"ayo-mwr/offline"
 	"ayo-mwr/recording"
 	"ayo-mwr/service"
@@ -66,6 +68,11 @@ func cleanRetryWithBackoff(operation func() error, maxRetries int, operationName
 
 		lastErr = err
 
+		// A cancelled video must not be processed again
+		if errors.Is(err, ffmpeg.ErrCancelled) {
+			return err
+		}
+
 		// Log retry attempt - semua error akan di-retry
 		isRetryableError(err) // Ini akan log error yang akan di-retry
 
@@ -90,7 +97,7 @@ func cleanRetryWithBackoff(operation func() error, maxRetries int, operationName
 type BookingVideoRequestHandler struct {
 	config        *config.Config
 	db            database.Database
-	r2Storage     *storage.R2Storage
+	r2Storage     storage.ObjectStore
 	uploadService *service.UploadService
 	queueManager  *offline.QueueManager
 
@@ -101,7 +108,7 @@ type BookingVideoRequestHandler struct {
 }
 
 // NewBookingVideoRequestHandler creates a new booking video request handler instance
-func NewBookingVideoRequestHandler(cfg *config.Config, db database.Database, r2Storage *storage.R2Storage, uploadService *service.UploadService) *BookingVideoRequestHandler {
+func NewBookingVideoRequestHandler(cfg *config.Config, db database.Database, r2Storage storage.ObjectStore, uploadService *service.UploadService) *BookingVideoRequestHandler {
 	log.Printf("📦 OFFLINE QUEUE: Initializing offline queue system...")
 
 	// Initialize AYO client for queue manager
@@ -244,55 +251,8 @@ func (h *BookingVideoRequestHandler) ProcessBookingVideo(c *gin.Context) {
 		return
 	}
 
-	// Initialize R2 storage client with database configuration
-	r2Config := storage.R2Config{
-		AccessKey: h.config.R2AccessKey,
-		SecretKey: h.config.R2SecretKey,
-		AccountID: h.config.R2AccountID,
-		Bucket:    h.config.R2Bucket,
-		Endpoint:  h.config.R2Endpoint,
-		Region:    h.config.R2Region,
-		BaseURL:   h.config.R2BaseURL,
-	}
-
-	// Fallback to database if config values are empty
-	if r2Config.AccessKey == "" {
-		if config, err := h.db.GetSystemConfig(database.ConfigR2AccessKey); err == nil {
-			r2Config.AccessKey = config.Value
-		}
-	}
-	if r2Config.SecretKey == "" {
-		if config, err := h.db.GetSystemConfig(database.ConfigR2SecretKey); err == nil {
-			r2Config.SecretKey = config.Value
-		}
-	}
-	if r2Config.AccountID == "" {
-		if config, err := h.db.GetSystemConfig(database.ConfigR2AccountID); err == nil {
-			r2Config.AccountID = config.Value
-		}
-	}
-	if r2Config.Bucket == "" {
-		if config, err := h.db.GetSystemConfig(database.ConfigR2Bucket); err == nil {
-			r2Config.Bucket = config.Value
-		}
-	}
-	if r2Config.Endpoint == "" {
-		if config, err := h.db.GetSystemConfig(database.ConfigR2Endpoint); err == nil {
-			r2Config.Endpoint = config.Value
-		}
-	}
-	if r2Config.Region == "" {
-		if config, err := h.db.GetSystemConfig(database.ConfigR2Region); err == nil {
-			r2Config.Region = config.Value
-		}
-	}
-	if r2Config.BaseURL == "" {
-		if config, err := h.db.GetSystemConfig(database.ConfigR2BaseURL); err == nil {
-			r2Config.BaseURL = config.Value
-		}
-	}
-
-	r2Client, err := storage.NewR2Storage(r2Config)
+	// Initialize object storage selected in system config
+	r2Client, err := storage.NewObjectStore(h.config, h.db, 1)
 	if err != nil {
 		log.Printf("Error initializing R2 storage client: %v", err)
 		c.JSON(http.StatusInternalServerError, ApiResponse{
@@ -385,8 +345,6 @@ func (h *BookingVideoRequestHandler) ProcessBookingVideo(c *gin.Context) {
 			continue
 		}
 
-		bookingStartTime = bookingStartTime
-		bookingEndTime = bookingEndTime
 		log.Printf("Debug Booking Start Time: %v", bookingStartTime)
 		log.Printf("Debug Booking End Time: %v", bookingEndTime)
 		log.Printf("Debug End Time: %v", endTime)
@@ -512,6 +470,10 @@ func (h *BookingVideoRequestHandler) ProcessBookingVideo(c *gin.Context) {
 					return err
 				}, 5, "Async File Upload")
 
+				if errors.Is(err, ffmpeg.ErrCancelled) {
+					log.Printf("📤 ASYNC UPLOAD: Video %s was cancelled, skipping upload", uploadUniqueID)
+					return
+				}
 				if err != nil {
 					log.Printf("⚠️ WARNING: Async upload failed for task %s: %v", uploadTaskID, err)
 					log.Printf("📦 QUEUE: Adding failed upload to offline queue...")
@@ -522,7 +484,6 @@ func (h *BookingVideoRequestHandler) ProcessBookingVideo(c *gin.Context) {
 						uploadVideoPath,
 						uploadPreviewPath,
 						uploadThumbnailPath,
-						fmt.Sprintf("mp4/%s.ts", uploadUniqueID),
 						fmt.Sprintf("preview/%s.mp4", uploadUniqueID),
 						fmt.Sprintf("thumbnail/%s.png", uploadUniqueID),
 					)
@@ -590,7 +551,6 @@ func (h *BookingVideoRequestHandler) ProcessBookingVideo(c *gin.Context) {
 					uploadVideoPath,
 					uploadPreviewPath,
 					uploadThumbnailPath,
-					fmt.Sprintf("mp4/%s.ts", uploadUniqueID),
 					fmt.Sprintf("preview/%s.mp4", uploadUniqueID),
 					fmt.Sprintf("thumbnail/%s.png", uploadUniqueID),
 				)
@@ -660,6 +620,9 @@ func (h *BookingVideoRequestHandler) GetQueueStatus(c *gin.Context) {
 		return
 	}
 
+	// Live progress of the FFmpeg jobs currently processing videos
+	queueStats["ffmpeg_jobs"] = ffmpeg.ActiveJobs()
+
 	c.JSON(http.StatusOK, ApiResponse{
 		Success: true,
 		Message: "Queue status retrieved successfully (async processing)",
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bufio"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -15,9 +16,12 @@ import (
 
 	"ayo-mwr/config"
 	dbmod "ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
 	monitoring "ayo-mwr/monitoring"
 	recording "ayo-mwr/recording"
+	"ayo-mwr/service"
 	signaling "ayo-mwr/signaling"
+	"ayo-mwr/storage"
 	transcode "ayo-mwr/transcode"
 
 	"github.com/gin-gonic/gin"
@@ -273,6 +277,36 @@ func (s *Server) listVideos(c *gin.Context) {
 	c.JSON(200, out)
 }
 
+// GET /api/videos/:id/progress
+// Get the live FFmpeg progress (stage, percent, ETA and speed) of a video being processed
+func (s *Server) getVideoProgress(c *gin.Context) {
+	id := c.Param("id")
+	progress, running := ffmpeg.GetProgress(id)
+
+	video, err := s.db.GetVideo(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get video: %v", err)})
+		return
+	}
+	if video == nil && !running {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+
+	data := gin.H{"videoId": id, "progress": nil}
+	if video != nil {
+		data["status"] = video.Status
+	}
+	if running {
+		data["progress"] = progress
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
 func getVideoAction(status interface{}) string {
 	switch status {
 	case "ready":
@@ -352,14 +386,14 @@ func (s *Server) handleUpload(c *gin.Context) {
 	// --- R2 Upload Integration ---
 	// After successful transcoding, upload HLS and MP4 to R2
 	if s.r2Storage != nil {
-		_, hlsURL, err := s.r2Storage.UploadHLSStream(hlsDir, videoID)
+		_, hlsURL, err := storage.UploadHLSStream(s.r2Storage, nil, hlsDir, videoID)
 		if err != nil {
 			fmt.Printf("[R2] Failed to upload HLS: %v\n", err)
 		} else {
 			fmt.Printf("[R2] HLS uploaded: %s\n", hlsURL)
 		}
 
-		mp4URL, err := s.r2Storage.UploadMP4(mp4Path, videoID)
+		mp4URL, err := storage.UploadMP4(s.r2Storage, nil, mp4Path, videoID)
 		if err != nil {
 			fmt.Printf("[R2] Failed to upload MP4: %v\n", err)
 		} else {
@@ -904,6 +938,116 @@ func (s *Server) switchDiskAndRestartRecordings(c *gin.Context) {
 	})
 }
 
+// GET /api/admin/disk-health
+// Get the last probe result of every disk. With ?probe=true the disks are probed first.
+func (s *Server) getDiskHealth(c *gin.Context) {
+	if s.diskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Disk manager is not available"})
+		return
+	}
+
+	if c.Query("probe") == "true" {
+		s.diskManager.ProbeDisks()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Disk health retrieved successfully",
+		"data":    s.diskManager.DiskHealthStatus(),
+	})
+}
+
+// GET /api/admin/disk-forecast
+// Forecast the days until each disk reaches the minimum free space threshold from the recent
+// growth of every camera, and how much longer retention could be at lower bitrates
+func (s *Server) getDiskForecast(c *gin.Context) {
+	if s.diskManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Disk manager is not available"})
+		return
+	}
+
+	forecast, err := s.diskManager.ForecastDiskCapacity()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to forecast disk capacity",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Disk capacity forecast retrieved successfully",
+		"data":    forecast,
+	})
+}
+
+// GET /api/admin/disk-events
+// Get the most recent disk health events, newest first. ?alerts=true returns only alerts that
+// have not been acknowledged; ?limit= caps the number of events (default 100).
+func (s *Server) getDiskEvents(c *gin.Context) {
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid limit",
+				"details": "limit must be a positive integer",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := s.db.GetDiskEvents(limit, c.Query("alerts") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get disk events",
+			"details": err.Error(),
+		})
+		return
+	}
+	if events == nil {
+		events = []dbmod.DiskEvent{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Disk events retrieved successfully",
+		"data":    events,
+	})
+}
+
+// POST /api/admin/disk-events/:id/acknowledge
+// Acknowledge a disk alert so it no longer shows as open
+func (s *Server) acknowledgeDiskEvent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid event ID",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := s.db.AcknowledgeDiskEvent(id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Disk event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to acknowledge disk event",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Disk event acknowledged",
+	})
+}
+
 // PUT /api/admin/system-config
 // Update system configuration
 func (s *Server) updateSystemConfig(c *gin.Context) {
@@ -1069,6 +1213,23 @@ func (s *Server) updateSystemConfig(c *gin.Context) {
 				})
 				return
 			}
+		case dbmod.ConfigCodec:
+			// Output video codec; HEVC and AV1 fall back to H.264 when unavailable
+			if strVal, ok := value.(string); ok {
+				if err := config.ValidateCodec(strVal); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{
+						"error": err.Error(),
+					})
+					return
+				}
+				strValue = strVal
+				configType = "string"
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("Invalid value for %s: expected string", key),
+				})
+				return
+			}
 		case dbmod.ConfigVenueCode,
 			dbmod.ConfigVenueSecretKey,
 			// Arduino Configuration
@@ -1083,7 +1244,6 @@ func (s *Server) updateSystemConfig(c *gin.Context) {
 			// Storage Configuration
 			dbmod.ConfigStoragePath,
 			dbmod.ConfigHardwareAccel,
-			dbmod.ConfigCodec,
 			// Server Configuration
 			dbmod.ConfigBaseURL,
 			// R2 Storage Configuration
@@ -1365,3 +1525,971 @@ func (s *Server) saveFirstCamera(c *gin.Context) {
 		},
 	})
 }
+
+// ---------- Preview template handlers ----------
+
+// GET /api/admin/preview-templates
+// Get preview templates and the active template name
+func (s *Server) getPreviewTemplates(c *gin.Context) {
+	templateConfig, err := config.NewPreviewTemplateService(s.db).GetPreviewTemplateConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get preview templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templateConfig,
+	})
+}
+
+// PUT /api/admin/preview-templates
+// Replace preview templates and/or select the active template
+func (s *Server) updatePreviewTemplates(c *gin.Context) {
+	var templateConfig config.PreviewTemplateConfig
+	if err := c.ShouldBindJSON(&templateConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := templateConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid preview templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewPreviewTemplateService(s.db).SetPreviewTemplateConfig(&templateConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update preview templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Preview templates updated successfully",
+		"data":    templateConfig,
+	})
+}
+
+// ---------- Bumper handlers ----------
+
+// bumperLocation returns the storage path and venue code used to store bumpers,
+// resolved the same way as AyoIndoClient.GetBumper
+func (s *Server) bumperLocation() (string, string, error) {
+	venueConfig, err := s.db.GetSystemConfig(dbmod.ConfigVenueCode)
+	if err != nil || venueConfig.Value == "" {
+		return "", "", fmt.Errorf("venue code is not configured")
+	}
+
+	storagePath := "./videos"
+	if path, err := s.db.GetSystemConfig(dbmod.ConfigStoragePath); err == nil && path.Value != "" {
+		storagePath = path.Value
+	}
+
+	return storagePath, venueConfig.Value, nil
+}
+
+// GET /api/admin/bumpers
+// Get bumper configuration and which bumper files are available
+func (s *Server) getBumpers(c *gin.Context) {
+	bumperConfig, err := config.NewBumperConfigService(s.db).GetBumperConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get bumper configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	assets := gin.H{}
+	if storagePath, venueCode, err := s.bumperLocation(); err == nil {
+		for _, kind := range []string{config.BumperIntro, config.BumperOutro} {
+			asset := gin.H{"source": "none"}
+			if _, err := os.Stat(recording.BumperCustomPath(storagePath, venueCode, kind)); err == nil {
+				asset["source"] = "uploaded"
+			} else if _, err := os.Stat(recording.BumperDownloadPath(storagePath, venueCode, kind)); err == nil {
+				asset["source"] = "ayo_api"
+			}
+			assets[kind] = asset
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"config": bumperConfig,
+			"assets": assets,
+		},
+	})
+}
+
+// PUT /api/admin/bumpers/config
+// Update which video types get an intro and/or outro bumper
+func (s *Server) updateBumperConfig(c *gin.Context) {
+	var bumperConfig config.BumperConfig
+	if err := c.ShouldBindJSON(&bumperConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := bumperConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid bumper configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewBumperConfigService(s.db).SetBumperConfig(&bumperConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update bumper configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Bumper configuration updated successfully",
+		"data":    bumperConfig,
+	})
+}
+
+// POST /api/admin/bumpers/:kind
+// Upload a custom intro or outro bumper (multipart form field "file")
+func (s *Server) uploadBumper(c *gin.Context) {
+	kind := c.Param("kind")
+	if kind != config.BumperIntro && kind != config.BumperOutro {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Bumper kind must be intro or outro",
+		})
+		return
+	}
+
+	storagePath, venueCode, err := s.bumperLocation()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Cannot store bumper",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Missing bumper file",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read bumper file",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	path, err := recording.SaveCustomBumper(storagePath, venueCode, kind, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to save bumper",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("%s bumper uploaded successfully", kind),
+		"data": gin.H{
+			"kind": kind,
+			"path": path,
+		},
+	})
+}
+
+// DELETE /api/admin/bumpers/:kind
+// Remove an uploaded bumper so the one from the AYO API is used again
+func (s *Server) deleteBumper(c *gin.Context) {
+	kind := c.Param("kind")
+	if kind != config.BumperIntro && kind != config.BumperOutro {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Bumper kind must be intro or outro",
+		})
+		return
+	}
+
+	storagePath, venueCode, err := s.bumperLocation()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Cannot remove bumper",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := os.Remove(recording.BumperCustomPath(storagePath, venueCode, kind)); err != nil && !os.IsNotExist(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to remove bumper",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Uploaded %s bumper removed", kind),
+	})
+}
+
+// ---------- Text overlay handlers ----------
+
+// GET /api/admin/text-overlay
+// Get the booking info text overlay configuration
+func (s *Server) getTextOverlayConfig(c *gin.Context) {
+	overlayConfig, err := config.NewTextOverlayConfigService(s.db).GetTextOverlayConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get text overlay configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    overlayConfig,
+	})
+}
+
+// PUT /api/admin/text-overlay
+// Update the booking info text overlay layout (default and per venue)
+func (s *Server) updateTextOverlayConfig(c *gin.Context) {
+	var overlayConfig config.TextOverlayConfig
+	if err := c.ShouldBindJSON(&overlayConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := overlayConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid text overlay configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewTextOverlayConfigService(s.db).SetTextOverlayConfig(&overlayConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update text overlay configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Text overlay configuration updated successfully",
+		"data":    overlayConfig,
+	})
+}
+
+// ---------- Slow-motion replay handlers ----------
+
+// GET /api/admin/slow-motion
+// Get the slow-motion replay configuration for button clips
+func (s *Server) getSlowMotionConfig(c *gin.Context) {
+	slowMotionConfig, err := config.NewSlowMotionConfigService(s.db).GetSlowMotionConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get slow-motion configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    slowMotionConfig,
+	})
+}
+
+// PUT /api/admin/slow-motion
+// Update the slow-motion replay settings (default, per venue and per field)
+func (s *Server) updateSlowMotionConfig(c *gin.Context) {
+	var slowMotionConfig config.SlowMotionConfig
+	if err := c.ShouldBindJSON(&slowMotionConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := slowMotionConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid slow-motion configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewSlowMotionConfigService(s.db).SetSlowMotionConfig(&slowMotionConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update slow-motion configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Slow-motion configuration updated successfully",
+		"data":    slowMotionConfig,
+	})
+}
+
+// ---------- Vertical export handlers ----------
+
+// GET /api/admin/vertical-export
+// Get the 9:16 social export profile configuration
+func (s *Server) getVerticalExportConfig(c *gin.Context) {
+	exportConfig, err := config.NewVerticalExportConfigService(s.db).GetVerticalExportConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get vertical export configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    exportConfig,
+	})
+}
+
+// PUT /api/admin/vertical-export
+// Update the 9:16 social export profile (video types, output size and per camera crop)
+func (s *Server) updateVerticalExportConfig(c *gin.Context) {
+	var exportConfig config.VerticalExportConfig
+	if err := c.ShouldBindJSON(&exportConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := exportConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid vertical export configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewVerticalExportConfigService(s.db).SetVerticalExportConfig(&exportConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update vertical export configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Vertical export configuration updated successfully",
+		"data":    exportConfig,
+	})
+}
+
+// ---------- Animated preview handlers ----------
+
+// GET /api/admin/animated-preview
+// Get the animated WebP/GIF preview configuration
+func (s *Server) getAnimatedPreviewConfig(c *gin.Context) {
+	previewConfig, err := config.NewAnimatedPreviewConfigService(s.db).GetAnimatedPreviewConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get animated preview configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    previewConfig,
+	})
+}
+
+// PUT /api/admin/animated-preview
+// Update the animated preview size budgets and encoder settings
+func (s *Server) updateAnimatedPreviewConfig(c *gin.Context) {
+	var previewConfig config.AnimatedPreviewConfig
+	if err := c.ShouldBindJSON(&previewConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := previewConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid animated preview configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewAnimatedPreviewConfigService(s.db).SetAnimatedPreviewConfig(&previewConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update animated preview configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Animated preview configuration updated successfully",
+		"data":    previewConfig,
+	})
+}
+
+// ---------- Transcoding ladder handlers ----------
+
+// GET /api/admin/transcode-presets
+// Get the HLS transcoding ladder (resolution, bitrate and encoder settings per quality)
+func (s *Server) getTranscodePresets(c *gin.Context) {
+	ladderConfig, err := config.NewTranscodeLadderConfigService(s.db).GetTranscodeLadderConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get transcoding presets",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ladderConfig,
+	})
+}
+
+// PUT /api/admin/transcode-presets
+// Update the HLS transcoding ladder
+func (s *Server) updateTranscodePresets(c *gin.Context) {
+	var ladderConfig config.TranscodeLadderConfig
+	if err := c.ShouldBindJSON(&ladderConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := ladderConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid transcoding presets",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewTranscodeLadderConfigService(s.db).SetTranscodeLadderConfig(&ladderConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update transcoding presets",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Transcoding presets updated successfully",
+		"data":    ladderConfig,
+	})
+}
+
+// ---------- Encoder benchmark handlers ----------
+
+// GET /api/admin/encoder-benchmark
+// Get the last encoder benchmark results and the encoder selected for each workload
+func (s *Server) getEncoderBenchmark(c *gin.Context) {
+	benchmark := recording.GetEncoderBenchmark()
+	if benchmark == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Encoder benchmark has not run yet",
+		})
+		return
+	}
+
+	encoders := make(map[string]interface{})
+	for workload := range benchmark.Selected {
+		encoders[workload] = recording.EncoderForWorkload(workload).GetBenchmarkInfo()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"benchmark": benchmark,
+			"encoders":  encoders,
+		},
+	})
+}
+
+// POST /api/admin/encoder-benchmark
+// Re-run the encoder benchmark and select the fastest encoder per workload
+func (s *Server) runEncoderBenchmark(c *gin.Context) {
+	benchmark, err := recording.RunEncoderBenchmark(s.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Encoder benchmark failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Encoder benchmark completed successfully",
+		"data":    benchmark,
+	})
+}
+
+// ---------- Bucket reconciliation handlers ----------
+
+// GET /api/admin/bucket-reconciliation
+// Get the report of the last comparison between the bucket and the videos table
+func (s *Server) getBucketReconciliation(c *gin.Context) {
+	report, err := service.LoadReconciliationReport(s.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to load reconciliation report",
+			"details": err.Error(),
+		})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Bucket reconciliation has not run yet",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// POST /api/admin/bucket-reconciliation
+// Compare the bucket with the videos table now, repairing missing uploads from local files.
+// Orphaned objects are deleted when the body sets "deleteOrphans": true.
+func (s *Server) runBucketReconciliation(c *gin.Context) {
+	var req struct {
+		DeleteOrphans bool `json:"deleteOrphans"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request body",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	report, err := service.RunBucketReconciliation(s.db, s.r2Storage, req.DeleteOrphans)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to reconcile bucket",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Bucket reconciliation completed successfully",
+		"data":    report,
+	})
+}
+
+// ---------- Video processing handlers ----------
+
+// POST /api/admin/videos/:id/cancel
+// Cancel the processing of a video: stops its running FFmpeg processes, removes its temporary
+// files and marks it cancelled
+func (s *Server) cancelVideo(c *gin.Context) {
+	id := c.Param("id")
+	video, err := s.db.GetVideo(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get video",
+			"details": err.Error(),
+		})
+		return
+	}
+	if video == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+	if !service.IsVideoProcessing(video.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Video is not being processed",
+			"details": fmt.Sprintf("video status is %s", video.Status),
+		})
+		return
+	}
+
+	bookingService := service.NewBookingVideoService(s.db, nil, s.r2Storage, s.config)
+	if err := bookingService.CancelVideo(video, "Cancelled by admin"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to cancel video",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Video processing cancelled successfully",
+		"data": gin.H{
+			"videoId": id,
+			"status":  dbmod.StatusCancelled,
+		},
+	})
+}
+
+// GET /api/admin/videos/:id/remote-deletions
+// Get the objects deleted from object storage when the video expired
+func (s *Server) getVideoRemoteDeletions(c *gin.Context) {
+	id := c.Param("id")
+	deletions, err := s.db.GetRemoteDeletions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get remote deletions",
+			"details": err.Error(),
+		})
+		return
+	}
+	if deletions == nil {
+		deletions = []dbmod.RemoteDeletion{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Remote deletions retrieved successfully",
+		"data":    deletions,
+	})
+}
+
+// ---------- Object storage handlers ----------
+
+// GET /api/admin/object-storage
+// Get the object storage backend configuration
+func (s *Server) getObjectStorageConfig(c *gin.Context) {
+	storageConfig, err := config.NewObjectStorageConfigService(s.db).GetObjectStorageConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get object storage configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    storageConfig,
+	})
+}
+
+// PUT /api/admin/object-storage
+// Update the object storage backend (r2 or local). Takes effect after a restart.
+func (s *Server) updateObjectStorageConfig(c *gin.Context) {
+	var storageConfig config.ObjectStorageConfig
+	if err := c.ShouldBindJSON(&storageConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := storageConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid object storage configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+	replicationConfig, _ := config.NewReplicationConfigService(s.db).GetReplicationConfig()
+	if err := replicationConfig.CheckStorage(&storageConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid object storage configuration",
+			"details": "disable replication failover first: " + err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewObjectStorageConfigService(s.db).SetObjectStorageConfig(&storageConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update object storage configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Object storage configuration updated successfully, restart to apply",
+		"data":    storageConfig,
+	})
+}
+
+// ---------- Upload bandwidth handlers ----------
+
+// GET /api/admin/upload-bandwidth
+// Get the upload bandwidth schedule with the current limit and upload throughput
+func (s *Server) getUploadBandwidthConfig(c *gin.Context) {
+	bandwidthConfig, err := config.NewUploadBandwidthConfigService(s.db).GetUploadBandwidthConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get upload bandwidth configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    bandwidthConfig,
+		"status":  storage.UploadBandwidthStatus(),
+	})
+}
+
+// PUT /api/admin/upload-bandwidth
+// Update the upload bandwidth schedule; running uploads pick up the new limit immediately
+func (s *Server) updateUploadBandwidthConfig(c *gin.Context) {
+	var bandwidthConfig config.UploadBandwidthConfig
+	if err := c.ShouldBindJSON(&bandwidthConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := bandwidthConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid upload bandwidth configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.NewUploadBandwidthConfigService(s.db).SetUploadBandwidthConfig(&bandwidthConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update upload bandwidth configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+	storage.SetUploadBandwidth(&bandwidthConfig)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Upload bandwidth configuration updated successfully",
+		"data":    bandwidthConfig,
+		"status":  storage.UploadBandwidthStatus(),
+	})
+}
+
+// ---------- Replication handlers ----------
+
+// replicationSecretMask replaces destination secret keys in responses; a PUT sending it back
+// keeps the stored key
+const replicationSecretMask = "********"
+
+// GET /api/admin/replication
+// Get the secondary bucket replication configuration (secret keys masked) with the health of the
+// primary bucket
+func (s *Server) getReplicationConfig(c *gin.Context) {
+	replicationConfig, err := config.NewReplicationConfigService(s.db).GetReplicationConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get replication configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    maskReplicationSecrets(replicationConfig),
+		"status":  storage.PrimaryHealthStatus(),
+	})
+}
+
+// PUT /api/admin/replication
+// Update the replication configuration. Destinations sent with an empty or masked secret key keep
+// their stored key. Applies to videos delivered from now on.
+func (s *Server) updateReplicationConfig(c *gin.Context) {
+	var replicationConfig config.ReplicationConfig
+	if err := c.ShouldBindJSON(&replicationConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	replicationService := config.NewReplicationConfigService(s.db)
+	stored, _ := replicationService.GetReplicationConfig()
+	for i := range replicationConfig.Destinations {
+		destination := &replicationConfig.Destinations[i]
+		if destination.SecretKey != "" && destination.SecretKey != replicationSecretMask {
+			continue
+		}
+		destination.SecretKey = ""
+		if existing := stored.Destination(destination.Name); existing != nil {
+			destination.SecretKey = existing.SecretKey
+		}
+	}
+
+	if err := replicationConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid replication configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+	storageConfig, _ := config.NewObjectStorageConfigService(s.db).GetObjectStorageConfig()
+	if err := replicationConfig.CheckStorage(storageConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid replication configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := replicationService.SetReplicationConfig(&replicationConfig); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update replication configuration",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Replication configuration updated successfully",
+		"data":    maskReplicationSecrets(&replicationConfig),
+	})
+}
+
+// maskReplicationSecrets returns a copy of the configuration with the secret keys masked
+func maskReplicationSecrets(replicationConfig *config.ReplicationConfig) config.ReplicationConfig {
+	masked := *replicationConfig
+	masked.Destinations = make([]config.ReplicationDestination, len(replicationConfig.Destinations))
+	for i, destination := range replicationConfig.Destinations {
+		if destination.SecretKey != "" {
+			destination.SecretKey = replicationSecretMask
+		}
+		masked.Destinations[i] = destination
+	}
+	return masked
+}
+
+// GET /api/admin/videos/:id/replication
+// Get the replication status of a video on each secondary bucket
+func (s *Server) getVideoReplication(c *gin.Context) {
+	video, err := s.db.GetVideo(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get video",
+			"details": err.Error(),
+		})
+		return
+	}
+	if video == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+
+	replication := video.Replication
+	if replication == nil {
+		replication = map[string]dbmod.ReplicationStatus{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Replication status retrieved successfully",
+		"data":    replication,
+	})
+}
+
+// POST /api/admin/videos/:id/replicate
+// Queue replication of a video to every secondary bucket, e.g. for videos delivered before
+// replication was enabled
+func (s *Server) replicateVideo(c *gin.Context) {
+	video, err := s.db.GetVideo(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get video",
+			"details": err.Error(),
+		})
+		return
+	}
+	if video == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return
+	}
+
+	replicationConfig, _ := config.NewReplicationConfigService(s.db).GetReplicationConfig()
+	if !replicationConfig.Enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Replication is not enabled"})
+		return
+	}
+
+	if err := service.EnqueueReplication(s.db, video.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue replication",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Replication queued successfully",
+		"data": gin.H{
+			"videoId": video.ID,
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"ayo-mwr/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ManualClipHandlers contains handlers for admin-triggered clip creation
+type ManualClipHandlers struct {
+	clipService *service.ManualClipService
+}
+
+// NewManualClipHandlers creates new manual clip handlers
+func NewManualClipHandlers(clipService *service.ManualClipService) *ManualClipHandlers {
+	return &ManualClipHandlers{
+		clipService: clipService,
+	}
+}
+
+// CreateClipRequest is the request body for POST /api/admin/clips
+type CreateClipRequest struct {
+	Cameras   []string `json:"cameras"`
+	Camera    string   `json:"camera,omitempty"` // shorthand for a single camera
+	StartTime string   `json:"start_time" binding:"required"`
+	EndTime   string   `json:"end_time" binding:"required"`
+	BookingID string   `json:"booking_id,omitempty"`
+	VideoType string   `json:"video_type,omitempty"` // defaults to "clip"
+	Upload    *bool    `json:"upload,omitempty"`     // upload preview and thumbnail to R2 (default true)
+	UploadMP4 bool     `json:"upload_mp4,omitempty"` // also upload the full clip as MP4 to R2
+}
+
+// parseClipTime accepts RFC3339 or local "2006-01-02T15:04:05" timestamps
+func parseClipTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", value, time.Local)
+}
+
+// POST /api/admin/clips
+// CreateClip starts a manual clip for one or more cameras and a time range
+func (mh *ManualClipHandlers) CreateClip(c *gin.Context) {
+	var req CreateClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	cameras := req.Cameras
+	if req.Camera != "" {
+		cameras = append(cameras, req.Camera)
+	}
+	if len(cameras) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "At least one camera is required",
+		})
+		return
+	}
+
+	startTime, err := parseClipTime(req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid start_time format, expected RFC3339 or 2006-01-02T15:04:05",
+		})
+		return
+	}
+	endTime, err := parseClipTime(req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid end_time format, expected RFC3339 or 2006-01-02T15:04:05",
+		})
+		return
+	}
+
+	upload := true
+	if req.Upload != nil {
+		upload = *req.Upload
+	}
+
+	ayoClient, err := NewAyoIndoClient()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Error initializing API client",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	job, err := mh.clipService.CreateClip(service.ManualClipRequest{
+		Cameras:    cameras,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		BookingID:  req.BookingID,
+		VideoType:  req.VideoType,
+		UploadToR2: upload,
+		UploadMP4:  req.UploadMP4,
+	}, ayoClient)
+	if err != nil {
+		var noCoverage *service.NoCoverageError
+		if errors.As(err, &noCoverage) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "No recordings available for the requested time range",
+				"details": err.Error(),
+				"cameras": noCoverage.Cameras,
+			})
+			return
+		}
+		var invalid *service.InvalidClipRequestError
+		if errors.As(err, &invalid) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid clip request",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create clip",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Clip processing started",
+		"data":    job,
+	})
+}
+
+// GET /api/admin/clips/:id
+// GetClip returns progress and output URLs of a manual clip job
+func (mh *ManualClipHandlers) GetClip(c *gin.Context) {
+	job, ok := mh.clipService.GetJob(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Clip job not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// GET /api/admin/clips
+// ListClips returns all manual clip jobs since startup, newest first
+func (mh *ManualClipHandlers) ListClips(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    mh.clipService.ListJobs(),
+	})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/service"
+	"ayo-mwr/storage"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/media/*key
+// Resolve an object reference of a private bucket to a short-lived presigned URL. Requires a
+// dashboard login or `expires` and `signature` query parameters signed with the venue secret key
+// (see storage.SignMediaKey). Redirects to the presigned URL, or returns it with ?format=json.
+// HLS playlists, DASH manifests and sprite WebVTT tracks are returned with the objects they
+// reference presigned. DASH segment templates point back here with a signature valid for the
+// manifest's directory (`scope`). With replication failover enabled, objects are resolved from a
+// replica while the primary bucket is unhealthy.
+func (s *Server) getMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if key == "" || s.r2Storage == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Object not found"})
+		return
+	}
+
+	secret := ""
+	if secretConfig, err := s.db.GetSystemConfig(database.ConfigVenueSecretKey); err == nil {
+		secret = secretConfig.Value
+	}
+	if !s.mediaRequestAuthorized(c, key, secret) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	storageConfig, _ := config.NewObjectStorageConfigService(s.db).GetObjectStorageConfig()
+	ttl := storageConfig.PresignTTL()
+
+	// While the primary store is unhealthy, objects are resolved from a replica holding them
+	store := s.r2Storage
+	if !storage.PrimaryHealthy() {
+		store = storage.ResolveStore(s.r2Storage, service.FailoverReplicas(s.db), key)
+	}
+
+	// Manifests and tracks are returned with the objects they reference presigned
+	var rewrite func() ([]byte, error)
+	var contentType string
+	switch {
+	case strings.HasSuffix(key, ".m3u8"):
+		rewrite = func() ([]byte, error) { return storage.PresignPlaylist(store, secret, key, ttl) }
+		contentType = "application/vnd.apple.mpegurl"
+	case strings.HasSuffix(key, ".mpd"):
+		rewrite = func() ([]byte, error) { return storage.PresignDASHManifest(store, secret, key, ttl) }
+		contentType = "application/dash+xml"
+	case strings.HasSuffix(key, ".vtt"):
+		rewrite = func() ([]byte, error) { return storage.PresignSpriteTrack(store, key, ttl) }
+		contentType = "text/vtt"
+	}
+	if rewrite != nil {
+		body, err := rewrite()
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Object not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("[Media] Failed to presign references of %s: %v", key, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to resolve manifest",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, contentType, body)
+		return
+	}
+
+	url, err := store.PresignGetURL(key, ttl)
+	if err != nil {
+		log.Printf("[Media] Failed to presign %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to resolve object",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"url":       url,
+				"expiresAt": time.Now().Add(ttl).UTC().Format(time.RFC3339),
+			},
+		})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, url)
+}
+
+// mediaRequestAuthorized reports whether the request comes from a logged in dashboard user or
+// carries a valid signature for key or a scope containing it
+func (s *Server) mediaRequestAuthorized(c *gin.Context, key, secret string) bool {
+	if sessions.Default(c).Get("user_id") != nil {
+		return true
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return false
+	}
+	if scope := c.Query("scope"); scope != "" {
+		return storage.VerifyMediaScopeSignature(secret, scope, key, expires, c.Query("signature"))
+	}
+	return storage.VerifyMediaSignature(secret, key, expires, c.Query("signature"))
+}
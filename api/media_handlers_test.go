@@ -0,0 +1,114 @@
+package api
+
+import (
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+// newMediaTestServer returns a router serving the media endpoint of a private memory store.
+// Tables are only created for the first database of a process, so tests share one server.
+func newMediaTestServer(t *testing.T) (*gin.Engine, *storage.MemoryStorage) {
+	db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.SetSystemConfig(database.SystemConfig{Key: database.ConfigVenueSecretKey, Value: "secret", Type: "string"}); err != nil {
+		t.Fatalf("Failed to set venue secret key: %v", err)
+	}
+
+	store := storage.NewMemoryStorage("https://venue.example.com" + storage.MediaPathPrefix)
+	s := &Server{db: db, r2Storage: store}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(sessions.Sessions("ayo-session", cookie.NewStore([]byte("test"))))
+	r.GET("/api/media/*key", s.getMedia)
+	return r, store
+}
+
+// signedMediaPath returns the media endpoint path of key with a signature valid for a minute
+func signedMediaPath(key string) string {
+	expires := time.Now().Add(time.Minute)
+	return storage.SignedMediaURL(storage.NewMemoryStorage(storage.MediaPathPrefix), "secret", key, expires)
+}
+
+func getPath(r http.Handler, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestGetMedia(t *testing.T) {
+	r, store := newMediaTestServer(t)
+	t.Run("DASH manifest", func(t *testing.T) { testGetMediaDASHManifest(t, r, store) })
+	t.Run("sprite track", func(t *testing.T) { testGetMediaSpriteTrack(t, r, store) })
+}
+
+// testGetMediaDASHManifest checks that DASH segment templates resolve within the manifest's directory only
+func testGetMediaDASHManifest(t *testing.T, r http.Handler, store *storage.MemoryStorage) {
+	store.PutObject("hls/video_1/manifest.mpd", []byte(`<MPD><SegmentTemplate initialization="init_$RepresentationID$.m4s" media="chunk_$RepresentationID$_$Number%05d$.m4s"/></MPD>`))
+	store.PutObject("hls/video_1/chunk_0_00001.m4s", []byte("segment"))
+	store.PutObject("hls/video_2/chunk_0_00001.m4s", []byte("segment"))
+
+	recorder := getPath(r, signedMediaPath("hls/video_1/manifest.mpd"))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/dash+xml" {
+		t.Fatalf("expected the manifest, got %d %s: %s", recorder.Code, recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+
+	template := regexp.MustCompile(`media="([^"]*)"`).FindStringSubmatch(recorder.Body.String())
+	if template == nil {
+		t.Fatalf("manifest has no media template: %s", recorder.Body.String())
+	}
+	segmentURL, err := url.Parse(strings.NewReplacer("$RepresentationID$", "0", "$Number%05d$", "00001").Replace(html.UnescapeString(template[1])))
+	if err != nil {
+		t.Fatalf("invalid segment URL: %v", err)
+	}
+
+	// A segment the player builds from the template resolves to a presigned URL
+	recorder = getPath(r, segmentURL.RequestURI())
+	if recorder.Code != http.StatusFound || !strings.HasPrefix(recorder.Header().Get("Location"), store.ObjectURL("hls/video_1/chunk_0_00001.m4s")+"?expires=") {
+		t.Errorf("expected a redirect to the presigned segment, got %d %s", recorder.Code, recorder.Header().Get("Location"))
+	}
+
+	// The signature only covers the manifest's directory
+	otherVideo := strings.Replace(segmentURL.RequestURI(), "/hls/video_1/", "/hls/video_2/", 1)
+	if recorder := getPath(r, otherVideo); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected a segment of another video to be rejected, got %d", recorder.Code)
+	}
+}
+
+// testGetMediaSpriteTrack checks that sprite sheets referenced by a WebVTT track are presigned
+func testGetMediaSpriteTrack(t *testing.T, r http.Handler, store *storage.MemoryStorage) {
+	store.PutObject("sprites/video_1/sprites.vtt", []byte("WEBVTT\n\n00:00:00.000 --> 00:00:10.000\nsprite_001.jpg#xywh=0,0,160,90\n"))
+
+	recorder := getPath(r, signedMediaPath("sprites/video_1/sprites.vtt"))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "text/vtt" {
+		t.Fatalf("expected the track, got %d %s: %s", recorder.Code, recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+	if want := store.ObjectURL("sprites/video_1/sprite_001.jpg") + "?expires="; !strings.Contains(recorder.Body.String(), want) {
+		t.Errorf("expected the sprite sheet to be presigned, got:\n%s", recorder.Body.String())
+	}
+
+	if recorder := getPath(r, signedMediaPath("sprites/video_2/sprites.vtt")); recorder.Code != http.StatusNotFound {
+		t.Errorf("expected a missing track to return 404, got %d", recorder.Code)
+	}
+	if recorder := getPath(r, "/api/media/sprites/video_1/sprites.vtt"); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected an unsigned request to be rejected, got %d", recorder.Code)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/fs"
 	"net/http"
+	"os"
 	"path/filepath"
 	"sync"
 
@@ -21,10 +22,11 @@ import (
 type Server struct {
 	config              *config.Config
 	db                  database.Database
-	r2Storage           *storage.R2Storage
+	r2Storage           storage.ObjectStore
 	uploadService       *service.UploadService
 	videoRequestHandler *BookingVideoRequestHandler
 	chunkHandlers       *ChunkHandlers
+	clipHandlers        *ManualClipHandlers
 	dashboardFS         embed.FS
 	diskManager         *storage.DiskManager
 
@@ -33,7 +35,7 @@ type Server struct {
 	activeUploads map[string]bool // key: cameraName, value: isUploading
 }
 
-func NewServer(cfg *config.Config, db database.Database, r2Storage *storage.R2Storage, uploadService *service.UploadService, dashboardFS embed.FS, diskManager *storage.DiskManager) *Server {
+func NewServer(cfg *config.Config, db database.Database, r2Storage storage.ObjectStore, uploadService *service.UploadService, dashboardFS embed.FS, diskManager *storage.DiskManager) *Server {
 	// Initialize video request handler with chunk optimization
 	videoRequestHandler := NewBookingVideoRequestHandler(cfg, db, r2Storage, uploadService)
 
@@ -41,6 +43,9 @@ func NewServer(cfg *config.Config, db database.Database, r2Storage *storage.R2St
 	chunkConfigService := config.NewChunkConfigService(db)
 	chunkHandlers := NewChunkHandlers(chunkConfigService, db)
 
+	// Initialize manual clip service and handlers
+	clipHandlers := NewManualClipHandlers(service.NewManualClipService(db, cfg, r2Storage, diskManager))
+
 	return &Server{
 		config:              cfg,
 		db:                  db,
@@ -48,6 +53,7 @@ func NewServer(cfg *config.Config, db database.Database, r2Storage *storage.R2St
 		uploadService:       uploadService,
 		videoRequestHandler: videoRequestHandler,
 		chunkHandlers:       chunkHandlers,
+		clipHandlers:        clipHandlers,
 		dashboardFS:         dashboardFS,
 		diskManager:         diskManager,
 		activeUploads:       make(map[string]bool),
@@ -76,6 +82,18 @@ func (s *Server) setupCORS(r *gin.Engine) {
 	})
 }
 
+// objectsFileSystem serves the local storage objects, hiding the in-progress multipart uploads
+type objectsFileSystem struct {
+	http.FileSystem
+}
+
+func (f objectsFileSystem) Open(name string) (http.File, error) {
+	if storage.IsLocalUploadPath(name) {
+		return nil, os.ErrNotExist
+	}
+	return f.FileSystem.Open(name)
+}
+
 func (s *Server) setupRoutes(r *gin.Engine) {
 	// Setup session middleware with consistent secret key
 	sessionSecret := "ayo-mwr-session-secret-key-fixed-2024"
@@ -95,6 +113,11 @@ func (s *Server) setupRoutes(r *gin.Engine) {
 	// Static route for watermarks
 	r.Static("/watermarks", filepath.Join(s.config.StoragePath, "watermarks"))
 
+	// Static route for uploaded videos when they are stored on local disk/NAS instead of R2
+	if localStorage, ok := s.r2Storage.(*storage.LocalStorage); ok {
+		r.StaticFS("/objects", objectsFileSystem{gin.Dir(localStorage.Root(), false)})
+	}
+
 	// Authentication routes (no middleware)
 	r.GET("/login", s.handleLogin)
 	r.POST("/login", s.handleLogin)
@@ -145,6 +168,9 @@ func (s *Server) setupRoutes(r *gin.Engine) {
 		api.POST("/request-booking-video", s.videoRequestHandler.ProcessBookingVideo)
 		api.GET("/queue-status", s.videoRequestHandler.GetQueueStatus)
 
+		// Object references of private buckets (dashboard login or venue signature, checked by the handler)
+		api.GET("/media/*key", s.getMedia)
+
 		// Onboarding endpoints (public)
 		api.GET("/onboarding-status", s.getOnboardingStatus)
 		api.POST("/onboarding/venue-config", s.saveVenueConfig)
@@ -159,6 +185,7 @@ func (s *Server) setupRoutes(r *gin.Engine) {
 			dashboard.GET("/streams/:id", s.getStream)
 			dashboard.GET("/cameras", s.listCameras)
 			dashboard.GET("/videos", s.listVideos)
+			dashboard.GET("/videos/:id/progress", s.getVideoProgress)
 			dashboard.GET("/system_health", s.getSystemHealth)
 			dashboard.GET("/logs", s.getLogs)
 
@@ -192,6 +219,14 @@ func (s *Server) setupRoutes(r *gin.Engine) {
 			// Disk switching with recording restart
 			admin.POST("/disk/switch/:diskId", s.switchDiskAndRestartRecordings)
 
+			// Disk health probing and alerts
+			admin.GET("/disk-health", s.getDiskHealth)
+			admin.GET("/disk-events", s.getDiskEvents)
+			admin.POST("/disk-events/:id/acknowledge", s.acknowledgeDiskEvent)
+
+			// Disk capacity forecast
+			admin.GET("/disk-forecast", s.getDiskForecast)
+
 			// Chunk processing endpoints
 			admin.GET("/chunk-config", s.chunkHandlers.GetChunkConfig)
 			admin.PUT("/chunk-config", s.chunkHandlers.UpdateChunkConfig)
@@ -202,6 +237,65 @@ func (s *Server) setupRoutes(r *gin.Engine) {
 
 			// Watermark endpoints
 			admin.POST("/force-update-watermark", s.forceUpdateWatermark)
+
+			// Preview template endpoints
+			admin.GET("/preview-templates", s.getPreviewTemplates)
+			admin.PUT("/preview-templates", s.updatePreviewTemplates)
+			admin.GET("/animated-preview", s.getAnimatedPreviewConfig)
+			admin.PUT("/animated-preview", s.updateAnimatedPreviewConfig)
+
+			// Venue intro/outro bumper endpoints
+			admin.GET("/bumpers", s.getBumpers)
+			admin.PUT("/bumpers/config", s.updateBumperConfig)
+			admin.POST("/bumpers/:kind", s.uploadBumper)
+			admin.DELETE("/bumpers/:kind", s.deleteBumper)
+
+			// Booking info text overlay endpoints
+			admin.GET("/text-overlay", s.getTextOverlayConfig)
+			admin.PUT("/text-overlay", s.updateTextOverlayConfig)
+
+			// Slow-motion replay endpoints
+			admin.GET("/slow-motion", s.getSlowMotionConfig)
+			admin.PUT("/slow-motion", s.updateSlowMotionConfig)
+
+			// Vertical (9:16) export endpoints
+			admin.GET("/vertical-export", s.getVerticalExportConfig)
+			admin.PUT("/vertical-export", s.updateVerticalExportConfig)
+
+			// Transcoding ladder endpoints
+			admin.GET("/transcode-presets", s.getTranscodePresets)
+			admin.PUT("/transcode-presets", s.updateTranscodePresets)
+
+			// Object storage backend endpoints
+			admin.GET("/object-storage", s.getObjectStorageConfig)
+			admin.PUT("/object-storage", s.updateObjectStorageConfig)
+
+			// Upload bandwidth schedule endpoints
+			admin.GET("/upload-bandwidth", s.getUploadBandwidthConfig)
+			admin.PUT("/upload-bandwidth", s.updateUploadBandwidthConfig)
+
+			// Secondary bucket replication endpoints
+			admin.GET("/replication", s.getReplicationConfig)
+			admin.PUT("/replication", s.updateReplicationConfig)
+
+			// Bucket reconciliation endpoints
+			admin.GET("/bucket-reconciliation", s.getBucketReconciliation)
+			admin.POST("/bucket-reconciliation", s.runBucketReconciliation)
+
+			// Video processing endpoints
+			admin.POST("/videos/:id/cancel", s.cancelVideo)
+			admin.GET("/videos/:id/remote-deletions", s.getVideoRemoteDeletions)
+			admin.GET("/videos/:id/replication", s.getVideoReplication)
+			admin.POST("/videos/:id/replicate", s.replicateVideo)
+
+			// Encoder benchmark endpoints
+			admin.GET("/encoder-benchmark", s.getEncoderBenchmark)
+			admin.POST("/encoder-benchmark", s.runEncoderBenchmark)
+
+			// Manual clip endpoints
+			admin.POST("/clips", s.clipHandlers.CreateClip)
+			admin.GET("/clips", s.clipHandlers.ListClips)
+			admin.GET("/clips/:id", s.clipHandlers.GetClip)
 		}
 	}
 }
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"ayo-mwr/database"
+)
+
+// AnimatedPreviewConfigService handles animated WebP/GIF preview configuration
+type AnimatedPreviewConfigService struct {
+	db database.Database
+}
+
+// NewAnimatedPreviewConfigService creates a new animated preview configuration service
+func NewAnimatedPreviewConfigService(db database.Database) *AnimatedPreviewConfigService {
+	return &AnimatedPreviewConfigService{
+		db: db,
+	}
+}
+
+// AnimatedPreviewConfig represents the animated preview configuration.
+// When an encoded preview exceeds its size budget it is re-encoded with lower quality,
+// frame rate and width until it fits or the attempts run out.
+type AnimatedPreviewConfig struct {
+	Enabled         bool    `json:"enabled"`         // Whether animated previews are generated
+	Width           int     `json:"width"`           // Starting output width (height keeps the aspect ratio)
+	FPS             int     `json:"fps"`             // Starting frame rate
+	DurationSeconds float64 `json:"durationSeconds"` // Maximum length taken from the start of the preview MP4
+	WebPQuality     int     `json:"webpQuality"`     // Starting WebP quality (0-100)
+	MaxWebPKB       int     `json:"maxWebpKB"`       // WebP size budget in KB
+	GIFEnabled      bool    `json:"gifEnabled"`      // Also generate a GIF fallback
+	GIFColors       int     `json:"gifColors"`       // Starting GIF palette size (2-256)
+	MaxGIFKB        int     `json:"maxGifKB"`        // GIF size budget in KB
+	MaxAttempts     int     `json:"maxAttempts"`     // Encode attempts per format to meet the budget
+}
+
+// GetAnimatedPreviewConfig retrieves the animated preview configuration
+func (aps *AnimatedPreviewConfigService) GetAnimatedPreviewConfig() (*AnimatedPreviewConfig, error) {
+	config, err := aps.db.GetSystemConfig("animated_preview")
+	if err != nil {
+		// Return default configuration if not found
+		return aps.getDefaultAnimatedPreviewConfig(), nil
+	}
+
+	var previewConfig AnimatedPreviewConfig
+	if err := json.Unmarshal([]byte(config.Value), &previewConfig); err != nil {
+		log.Printf("[AnimatedPreview] Warning: Failed to parse animated preview config, using defaults: %v", err)
+		return aps.getDefaultAnimatedPreviewConfig(), nil
+	}
+
+	return &previewConfig, nil
+}
+
+// SetAnimatedPreviewConfig validates and saves the animated preview configuration
+func (aps *AnimatedPreviewConfigService) SetAnimatedPreviewConfig(config *AnimatedPreviewConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "animated_preview",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return aps.db.SetSystemConfig(systemConfig)
+}
+
+// Validate checks that the animated preview configuration is usable
+func (c *AnimatedPreviewConfig) Validate() error {
+	if c.Width < 64 || c.Width > 1280 || c.Width%2 != 0 {
+		return fmt.Errorf("width must be an even number between 64 and 1280")
+	}
+	if c.FPS < 1 || c.FPS > 30 {
+		return fmt.Errorf("fps must be between 1 and 30")
+	}
+	if c.DurationSeconds < 1 || c.DurationSeconds > 30 {
+		return fmt.Errorf("duration must be between 1 and 30 seconds")
+	}
+	if c.WebPQuality < 0 || c.WebPQuality > 100 {
+		return fmt.Errorf("webp quality must be between 0 and 100")
+	}
+	if c.MaxWebPKB <= 0 {
+		return fmt.Errorf("webp size budget must be positive")
+	}
+	if c.GIFEnabled {
+		if c.GIFColors < 2 || c.GIFColors > 256 {
+			return fmt.Errorf("gif colors must be between 2 and 256")
+		}
+		if c.MaxGIFKB <= 0 {
+			return fmt.Errorf("gif size budget must be positive")
+		}
+	}
+	if c.MaxAttempts < 1 || c.MaxAttempts > 10 {
+		return fmt.Errorf("max attempts must be between 1 and 10")
+	}
+	return nil
+}
+
+// getDefaultAnimatedPreviewConfig returns the default animated preview configuration
+func (aps *AnimatedPreviewConfigService) getDefaultAnimatedPreviewConfig() *AnimatedPreviewConfig {
+	return &AnimatedPreviewConfig{
+		Enabled:         true,
+		Width:           320,
+		FPS:             10,
+		DurationSeconds: 6,
+		WebPQuality:     60,
+		MaxWebPKB:       800,
+		GIFEnabled:      true,
+		GIFColors:       128,
+		MaxGIFKB:        2048,
+		MaxAttempts:     4,
+	}
+}
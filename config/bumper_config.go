@@ -0,0 +1,144 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+
+	"ayo-mwr/database"
+)
+
+// Bumper kinds
+const (
+	BumperIntro = "intro" // Venue intro card prepended to the video
+	BumperOutro = "outro" // Venue outro / call to action appended to the video
+)
+
+// BumperConfigService handles venue intro/outro bumper configuration
+type BumperConfigService struct {
+	db database.Database
+}
+
+// NewBumperConfigService creates a new bumper configuration service
+func NewBumperConfigService(db database.Database) *BumperConfigService {
+	return &BumperConfigService{
+		db: db,
+	}
+}
+
+// BumperVideoTypeConfig selects which bumpers are attached to one video type
+type BumperVideoTypeConfig struct {
+	Intro bool `json:"intro"` // Prepend the venue intro
+	Outro bool `json:"outro"` // Append the venue outro
+}
+
+// maxBumperSeconds is the longest bumper a venue may configure
+const maxBumperSeconds = 30
+
+// BumperConfig represents the bumper configuration
+type BumperConfig struct {
+	Enabled    bool                             `json:"enabled"`    // Whether bumpers are attached at all
+	VideoTypes map[string]BumperVideoTypeConfig `json:"videoTypes"` // Per video type ("full", "clip", ...) selection; missing types get no bumpers
+	IntroURL   string                           `json:"introUrl"`   // Download URL overriding the intro from the AYO API (empty = use AYO API)
+	OutroURL   string                           `json:"outroUrl"`   // Download URL overriding the outro from the AYO API (empty = use AYO API)
+	MaxSeconds float64                          `json:"maxSeconds"` // Bumpers longer than this are skipped (0 = no limit)
+}
+
+// GetBumperConfig retrieves the bumper configuration
+func (bcs *BumperConfigService) GetBumperConfig() (*BumperConfig, error) {
+	config, err := bcs.db.GetSystemConfig("bumper_config")
+	if err != nil {
+		// Return default configuration if not found
+		return bcs.getDefaultBumperConfig(), nil
+	}
+
+	var bumperConfig BumperConfig
+	if err := json.Unmarshal([]byte(config.Value), &bumperConfig); err != nil {
+		log.Printf("[BumperConfig] Warning: Failed to parse bumper config, using defaults: %v", err)
+		return bcs.getDefaultBumperConfig(), nil
+	}
+
+	return &bumperConfig, nil
+}
+
+// SetBumperConfig validates and saves the bumper configuration
+func (bcs *BumperConfigService) SetBumperConfig(config *BumperConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "bumper_config",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return bcs.db.SetSystemConfig(systemConfig)
+}
+
+// BumpersFor returns whether the intro and outro should be attached to a video type
+func (bcs *BumperConfigService) BumpersFor(videoType string) (intro bool, outro bool) {
+	config, err := bcs.GetBumperConfig()
+	if err != nil || !config.Enabled {
+		return false, false
+	}
+
+	typeConfig, ok := config.VideoTypes[videoType]
+	if !ok {
+		return false, false
+	}
+	return typeConfig.Intro, typeConfig.Outro
+}
+
+// URLFor returns the download URL configured to override the AYO API bumper of a kind, if any
+func (c *BumperConfig) URLFor(kind string) string {
+	switch kind {
+	case BumperIntro:
+		return c.IntroURL
+	case BumperOutro:
+		return c.OutroURL
+	}
+	return ""
+}
+
+// Validate checks that the bumper configuration is usable
+func (c *BumperConfig) Validate() error {
+	for videoType := range c.VideoTypes {
+		if videoType == "" {
+			return fmt.Errorf("video type name is required")
+		}
+	}
+	for kind, rawURL := range map[string]string{BumperIntro: c.IntroURL, BumperOutro: c.OutroURL} {
+		if rawURL == "" {
+			continue
+		}
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s URL must be an http(s) URL", kind)
+		}
+	}
+	if c.MaxSeconds < 0 || c.MaxSeconds > maxBumperSeconds {
+		return fmt.Errorf("max bumper length must be between 0 and %d seconds", maxBumperSeconds)
+	}
+	return nil
+}
+
+// getDefaultBumperConfig returns the default bumper configuration.
+// Bumpers are off until a venue opts in; when enabled, full videos get both and clips get none.
+func (bcs *BumperConfigService) getDefaultBumperConfig() *BumperConfig {
+	return &BumperConfig{
+		Enabled:    false,
+		MaxSeconds: 10,
+		VideoTypes: map[string]BumperVideoTypeConfig{
+			"full": {Intro: true, Outro: true},
+			"clip": {Intro: false, Outro: false},
+		},
+	}
+}
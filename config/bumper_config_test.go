@@ -0,0 +1,35 @@
+package config
+
+import "testing"
+
+func TestBumperConfigValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  BumperConfig
+		wantErr bool
+	}{
+		{"defaults", *NewBumperConfigService(nil).getDefaultBumperConfig(), false},
+		{"override URLs", BumperConfig{IntroURL: "https://cdn.example.com/intro.mp4", OutroURL: "http://cdn.example.com/outro.mp4"}, false},
+		{"URL without scheme", BumperConfig{IntroURL: "cdn.example.com/intro.mp4"}, true},
+		{"non-http URL", BumperConfig{OutroURL: "ftp://cdn.example.com/outro.mp4"}, true},
+		{"URL without host", BumperConfig{IntroURL: "https:///intro.mp4"}, true},
+		{"negative max length", BumperConfig{MaxSeconds: -1}, true},
+		{"max length too long", BumperConfig{MaxSeconds: 31}, true},
+		{"empty video type", BumperConfig{VideoTypes: map[string]BumperVideoTypeConfig{"": {Intro: true}}}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.config.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestBumperConfigURLFor(t *testing.T) {
+	config := BumperConfig{IntroURL: "https://a/intro.mp4", OutroURL: "https://a/outro.mp4"}
+	if config.URLFor(BumperIntro) != config.IntroURL || config.URLFor(BumperOutro) != config.OutroURL || config.URLFor("other") != "" {
+		t.Errorf("URLFor returned unexpected URLs")
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ayo-mwr/database"
+)
+
+// Object storage backends videos are delivered from
+const (
+	ObjectStorageR2    = "r2"    // Cloudflare R2 or any S3-compatible bucket (R2 settings)
+	ObjectStorageLocal = "local" // Local disk or NAS mount served by this server over the LAN
+)
+
+// ObjectStorageConfigService handles the object storage backend configuration
+type ObjectStorageConfigService struct {
+	db database.Database
+}
+
+// NewObjectStorageConfigService creates a new object storage configuration service
+func NewObjectStorageConfigService(db database.Database) *ObjectStorageConfigService {
+	return &ObjectStorageConfigService{
+		db: db,
+	}
+}
+
+// ObjectStorageConfig selects where uploaded videos, previews and streams are stored
+type ObjectStorageConfig struct {
+	Backend   string `json:"backend"`   // "r2" (default) or "local"
+	LocalPath string `json:"localPath"` // Root directory of the local backend (default <storage path>/objects)
+	BaseURL   string `json:"baseURL"`   // URL the local backend is reached at, e.g. http://192.168.1.10:3000/objects
+
+	// Private R2 buckets: videos are only reachable through short-lived presigned URLs
+	Private           bool   `json:"private"`
+	ResolverBaseURL   string `json:"resolverBaseURL"`   // Public URL of this server, references resolve at <url>/api/media/<key>
+	PresignTTLSeconds int    `json:"presignTtlSeconds"` // Lifetime of presigned URLs (default 900)
+}
+
+// Presigned URL lifetime bounds
+const (
+	defaultPresignTTLSeconds = 15 * 60
+	maxPresignTTLSeconds     = 7 * 24 * 60 * 60 // S3 limit
+)
+
+// GetObjectStorageConfig retrieves the object storage configuration
+func (oss *ObjectStorageConfigService) GetObjectStorageConfig() (*ObjectStorageConfig, error) {
+	config, err := oss.db.GetSystemConfig("object_storage")
+	if err != nil {
+		// Return default configuration if not found
+		return oss.getDefaultObjectStorageConfig(), nil
+	}
+
+	var storageConfig ObjectStorageConfig
+	if err := json.Unmarshal([]byte(config.Value), &storageConfig); err != nil {
+		log.Printf("[ObjectStorage] Warning: Failed to parse object storage config, using defaults: %v", err)
+		return oss.getDefaultObjectStorageConfig(), nil
+	}
+
+	if err := storageConfig.Validate(); err != nil {
+		log.Printf("[ObjectStorage] Warning: Stored object storage config is invalid, using defaults: %v", err)
+		return oss.getDefaultObjectStorageConfig(), nil
+	}
+
+	return &storageConfig, nil
+}
+
+// SetObjectStorageConfig validates and saves the object storage configuration
+func (oss *ObjectStorageConfigService) SetObjectStorageConfig(config *ObjectStorageConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "object_storage",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return oss.db.SetSystemConfig(systemConfig)
+}
+
+// Validate checks the object storage configuration
+func (c *ObjectStorageConfig) Validate() error {
+	switch c.Backend {
+	case ObjectStorageR2:
+	case ObjectStorageLocal:
+		if !strings.HasPrefix(c.BaseURL, "http://") && !strings.HasPrefix(c.BaseURL, "https://") {
+			return fmt.Errorf("local backend requires an http(s) base URL reachable by viewers")
+		}
+	default:
+		return fmt.Errorf("invalid backend '%s', valid options: r2, local", c.Backend)
+	}
+
+	if c.Private {
+		if c.Backend != ObjectStorageR2 {
+			return fmt.Errorf("private access is only supported by the r2 backend")
+		}
+		if !strings.HasPrefix(c.ResolverBaseURL, "http://") && !strings.HasPrefix(c.ResolverBaseURL, "https://") {
+			return fmt.Errorf("private access requires an http(s) resolverBaseURL of this server")
+		}
+	}
+	if c.PresignTTLSeconds < 0 || c.PresignTTLSeconds > maxPresignTTLSeconds {
+		return fmt.Errorf("presignTtlSeconds must be between 0 and %d", maxPresignTTLSeconds)
+	}
+	return nil
+}
+
+// PresignTTL returns the lifetime of presigned URLs
+func (c *ObjectStorageConfig) PresignTTL() time.Duration {
+	if c.PresignTTLSeconds <= 0 {
+		return defaultPresignTTLSeconds * time.Second
+	}
+	return time.Duration(c.PresignTTLSeconds) * time.Second
+}
+
+// getDefaultObjectStorageConfig returns the default object storage configuration
+func (oss *ObjectStorageConfigService) getDefaultObjectStorageConfig() *ObjectStorageConfig {
+	return &ObjectStorageConfig{
+		Backend: ObjectStorageR2,
+	}
+}
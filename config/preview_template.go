@@ -0,0 +1,190 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"ayo-mwr/database"
+)
+
+// Preview snippet placement strategies
+const (
+	PreviewPlacementEven        = "even"         // Snippets evenly spaced across the video
+	PreviewPlacementActivity    = "activity"     // Snippets placed where the most motion/scene changes happen
+	PreviewPlacementButtonPress = "button_press" // Snippets placed just before button presses (clip requests)
+)
+
+// PreviewTemplateService handles preview template configuration
+type PreviewTemplateService struct {
+	db database.Database
+}
+
+// NewPreviewTemplateService creates a new preview template service
+func NewPreviewTemplateService(db database.Database) *PreviewTemplateService {
+	return &PreviewTemplateService{
+		db: db,
+	}
+}
+
+// PreviewTemplate describes how a preview video is assembled from the full video
+type PreviewTemplate struct {
+	Name                 string  `json:"name"`                 // Unique template name
+	SnippetCount         int     `json:"snippetCount"`         // Maximum number of snippets in the preview
+	SnippetLengthSeconds float64 `json:"snippetLengthSeconds"` // Length of each snippet in seconds
+	Placement            string  `json:"placement"`            // "even", "activity" or "button_press"
+	Width                int     `json:"width"`                // Output width (0 keeps source resolution)
+	Height               int     `json:"height"`               // Output height (0 keeps source resolution)
+	MaxSizeMB            float64 `json:"maxSizeMB"`            // Maximum preview file size in MB (0 = unlimited)
+}
+
+// PreviewTemplateConfig represents all preview templates and which one is active
+type PreviewTemplateConfig struct {
+	ActiveTemplate string            `json:"activeTemplate"` // Name of the template used for new previews
+	Templates      []PreviewTemplate `json:"templates"`
+}
+
+// GetPreviewTemplateConfig retrieves the preview template configuration
+func (pts *PreviewTemplateService) GetPreviewTemplateConfig() (*PreviewTemplateConfig, error) {
+	if pts.db == nil {
+		return pts.getDefaultPreviewTemplateConfig(), nil
+	}
+
+	config, err := pts.db.GetSystemConfig("preview_templates")
+	if err != nil {
+		// Return default configuration if not found
+		return pts.getDefaultPreviewTemplateConfig(), nil
+	}
+
+	var templateConfig PreviewTemplateConfig
+	if err := json.Unmarshal([]byte(config.Value), &templateConfig); err != nil {
+		log.Printf("[PreviewTemplate] Warning: Failed to parse preview templates, using defaults: %v", err)
+		return pts.getDefaultPreviewTemplateConfig(), nil
+	}
+
+	return &templateConfig, nil
+}
+
+// SetPreviewTemplateConfig validates and saves the preview template configuration
+func (pts *PreviewTemplateService) SetPreviewTemplateConfig(config *PreviewTemplateConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "preview_templates",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return pts.db.SetSystemConfig(systemConfig)
+}
+
+// GetActiveTemplate returns the template that should be used for new previews
+func (pts *PreviewTemplateService) GetActiveTemplate() PreviewTemplate {
+	config, err := pts.GetPreviewTemplateConfig()
+	if err == nil {
+		for _, template := range config.Templates {
+			if template.Name == config.ActiveTemplate {
+				return template
+			}
+		}
+		log.Printf("[PreviewTemplate] Warning: Active template %q not found, using default", config.ActiveTemplate)
+	}
+
+	return pts.getDefaultPreviewTemplateConfig().Templates[0]
+}
+
+// Validate checks that the template configuration is usable
+func (c *PreviewTemplateConfig) Validate() error {
+	if len(c.Templates) == 0 {
+		return fmt.Errorf("at least one preview template is required")
+	}
+
+	names := make(map[string]bool)
+	for _, template := range c.Templates {
+		if err := template.Validate(); err != nil {
+			return err
+		}
+		if names[template.Name] {
+			return fmt.Errorf("duplicate preview template name: %s", template.Name)
+		}
+		names[template.Name] = true
+	}
+
+	if !names[c.ActiveTemplate] {
+		return fmt.Errorf("active template %q does not exist", c.ActiveTemplate)
+	}
+
+	return nil
+}
+
+// Validate checks that a single template is usable
+func (t PreviewTemplate) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("preview template name is required")
+	}
+	if t.SnippetCount < 1 || t.SnippetCount > 20 {
+		return fmt.Errorf("template %s: snippet count must be between 1 and 20", t.Name)
+	}
+	if t.SnippetLengthSeconds < 0.5 || t.SnippetLengthSeconds > 30 {
+		return fmt.Errorf("template %s: snippet length must be between 0.5 and 30 seconds", t.Name)
+	}
+	switch t.Placement {
+	case PreviewPlacementEven, PreviewPlacementActivity, PreviewPlacementButtonPress:
+	default:
+		return fmt.Errorf("template %s: placement must be one of %s, %s, %s", t.Name,
+			PreviewPlacementEven, PreviewPlacementActivity, PreviewPlacementButtonPress)
+	}
+	if (t.Width == 0) != (t.Height == 0) || t.Width < 0 || t.Height < 0 {
+		return fmt.Errorf("template %s: width and height must both be set or both be 0", t.Name)
+	}
+	if t.Width%2 != 0 || t.Height%2 != 0 {
+		return fmt.Errorf("template %s: width and height must be even", t.Name)
+	}
+	if t.MaxSizeMB < 0 {
+		return fmt.Errorf("template %s: max size cannot be negative", t.Name)
+	}
+	return nil
+}
+
+// getDefaultPreviewTemplateConfig returns the default preview templates.
+// The "default" template takes 5 evenly spaced 2-second snippets whatever the video length, unlike
+// the earlier fixed table that used 1 to 5 snippets at set positions depending on duration.
+func (pts *PreviewTemplateService) getDefaultPreviewTemplateConfig() *PreviewTemplateConfig {
+	return &PreviewTemplateConfig{
+		ActiveTemplate: "default",
+		Templates: []PreviewTemplate{
+			{
+				Name:                 "default",
+				SnippetCount:         5,
+				SnippetLengthSeconds: 2,
+				Placement:            PreviewPlacementEven,
+			},
+			{
+				Name:                 "activity",
+				SnippetCount:         5,
+				SnippetLengthSeconds: 2,
+				Placement:            PreviewPlacementActivity,
+				Width:                854,
+				Height:               480,
+				MaxSizeMB:            8,
+			},
+			{
+				Name:                 "highlights",
+				SnippetCount:         5,
+				SnippetLengthSeconds: 3,
+				Placement:            PreviewPlacementButtonPress,
+				Width:                854,
+				Height:               480,
+				MaxSizeMB:            8,
+			},
+		},
+	}
+}
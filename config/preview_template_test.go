@@ -0,0 +1,65 @@
+package config
+
+import "testing"
+
+func TestPreviewTemplateDefaults(t *testing.T) {
+	service := NewPreviewTemplateService(nil)
+
+	defaults, err := service.GetPreviewTemplateConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := defaults.Validate(); err != nil {
+		t.Errorf("default templates should be valid: %v", err)
+	}
+
+	active := service.GetActiveTemplate()
+	if active.Name != "default" || active.Placement != PreviewPlacementEven || active.SnippetCount != 5 {
+		t.Errorf("unexpected active template: %+v", active)
+	}
+}
+
+func TestPreviewTemplateValidate(t *testing.T) {
+	valid := PreviewTemplate{Name: "t", SnippetCount: 5, SnippetLengthSeconds: 2, Placement: PreviewPlacementEven}
+
+	cases := []struct {
+		name    string
+		mutate  func(*PreviewTemplate)
+		wantErr bool
+	}{
+		{"valid", func(t *PreviewTemplate) {}, false},
+		{"missing name", func(t *PreviewTemplate) { t.Name = "" }, true},
+		{"no snippets", func(t *PreviewTemplate) { t.SnippetCount = 0 }, true},
+		{"too many snippets", func(t *PreviewTemplate) { t.SnippetCount = 21 }, true},
+		{"snippet too short", func(t *PreviewTemplate) { t.SnippetLengthSeconds = 0.1 }, true},
+		{"unknown placement", func(t *PreviewTemplate) { t.Placement = "random" }, true},
+		{"only width set", func(t *PreviewTemplate) { t.Width = 640 }, true},
+		{"odd resolution", func(t *PreviewTemplate) { t.Width, t.Height = 641, 360 }, true},
+		{"even resolution", func(t *PreviewTemplate) { t.Width, t.Height = 640, 360 }, false},
+		{"negative size budget", func(t *PreviewTemplate) { t.MaxSizeMB = -1 }, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			template := valid
+			tc.mutate(&template)
+			if err := template.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewTemplateConfigValidate(t *testing.T) {
+	template := PreviewTemplate{Name: "t", SnippetCount: 5, SnippetLengthSeconds: 2, Placement: PreviewPlacementEven}
+
+	if err := (&PreviewTemplateConfig{ActiveTemplate: "t", Templates: []PreviewTemplate{template}}).Validate(); err != nil {
+		t.Errorf("expected valid config, got %v", err)
+	}
+	if err := (&PreviewTemplateConfig{ActiveTemplate: "missing", Templates: []PreviewTemplate{template}}).Validate(); err == nil {
+		t.Errorf("expected error for unknown active template")
+	}
+	if err := (&PreviewTemplateConfig{ActiveTemplate: "t", Templates: []PreviewTemplate{template, template}}).Validate(); err == nil {
+		t.Errorf("expected error for duplicate template names")
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"ayo-mwr/database"
+)
+
+// ReplicationConfigService handles the secondary bucket replication configuration
+type ReplicationConfigService struct {
+	db database.Database
+}
+
+// NewReplicationConfigService creates a new replication configuration service
+func NewReplicationConfigService(db database.Database) *ReplicationConfigService {
+	return &ReplicationConfigService{
+		db: db,
+	}
+}
+
+// ReplicationConfig copies every delivered video to secondary buckets, e.g. for premium venues
+// that want their videos stored with two providers
+type ReplicationConfig struct {
+	Enabled      bool                     `json:"enabled"`      // Whether delivered videos are replicated
+	Failover     bool                     `json:"failover"`     // Resolve media from a replica while the primary is unhealthy
+	Destinations []ReplicationDestination `json:"destinations"` // Secondary S3-compatible buckets
+}
+
+// ReplicationDestination is a secondary S3-compatible bucket videos are replicated to
+type ReplicationDestination struct {
+	Name      string `json:"name"` // Identifies the destination in the video's replication status
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	AccountID string `json:"accountId"` // Cloudflare account, used to build the endpoint if Endpoint is empty
+	Endpoint  string `json:"endpoint"`  // S3 endpoint, e.g. https://s3.ap-southeast-3.amazonaws.com
+	Region    string `json:"region"`    // Default "auto"
+	Bucket    string `json:"bucket"`
+	BaseURL   string `json:"baseURL"` // URL the bucket is publicly served under
+}
+
+// replicationDestinationName matches destination names, which are stored as JSON keys on videos
+var replicationDestinationName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// GetReplicationConfig retrieves the replication configuration
+func (rs *ReplicationConfigService) GetReplicationConfig() (*ReplicationConfig, error) {
+	config, err := rs.db.GetSystemConfig("replication")
+	if err != nil {
+		// Return default configuration if not found
+		return rs.getDefaultReplicationConfig(), nil
+	}
+
+	var replicationConfig ReplicationConfig
+	if err := json.Unmarshal([]byte(config.Value), &replicationConfig); err != nil {
+		log.Printf("[Replication] Warning: Failed to parse replication config, using defaults: %v", err)
+		return rs.getDefaultReplicationConfig(), nil
+	}
+
+	if err := replicationConfig.Validate(); err != nil {
+		log.Printf("[Replication] Warning: Stored replication config is invalid, using defaults: %v", err)
+		return rs.getDefaultReplicationConfig(), nil
+	}
+
+	return &replicationConfig, nil
+}
+
+// SetReplicationConfig validates and saves the replication configuration
+func (rs *ReplicationConfigService) SetReplicationConfig(config *ReplicationConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "replication",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return rs.db.SetSystemConfig(systemConfig)
+}
+
+// Validate checks the replication configuration
+func (c *ReplicationConfig) Validate() error {
+	if c.Enabled && len(c.Destinations) == 0 {
+		return fmt.Errorf("replication requires at least one destination")
+	}
+
+	names := make(map[string]bool)
+	for i, destination := range c.Destinations {
+		if !replicationDestinationName.MatchString(destination.Name) {
+			return fmt.Errorf("destination %d: name must be 1-32 lowercase letters, digits, '-' or '_'", i+1)
+		}
+		if names[destination.Name] {
+			return fmt.Errorf("destination %d: duplicate name '%s'", i+1, destination.Name)
+		}
+		names[destination.Name] = true
+
+		if destination.Bucket == "" {
+			return fmt.Errorf("destination '%s': bucket is required", destination.Name)
+		}
+		if destination.Endpoint == "" && destination.AccountID == "" {
+			return fmt.Errorf("destination '%s': endpoint or accountId is required", destination.Name)
+		}
+		if destination.AccessKey == "" || destination.SecretKey == "" {
+			return fmt.Errorf("destination '%s': accessKey and secretKey are required", destination.Name)
+		}
+		if !strings.HasPrefix(destination.BaseURL, "http://") && !strings.HasPrefix(destination.BaseURL, "https://") {
+			return fmt.Errorf("destination '%s': baseURL must be an http(s) URL", destination.Name)
+		}
+	}
+	return nil
+}
+
+// CheckStorage checks the replication configuration against the object storage configuration.
+// Failover only reaches players through our media endpoint, so it requires a private bucket:
+// public buckets hand out the primary's URLs, which keep pointing at the primary.
+func (c *ReplicationConfig) CheckStorage(storageConfig *ObjectStorageConfig) error {
+	if c.Enabled && c.Failover && (storageConfig == nil || !storageConfig.Private) {
+		return fmt.Errorf("failover requires private object storage, media URLs of public buckets cannot fail over to a replica")
+	}
+	return nil
+}
+
+// Destination returns the destination called name, or nil
+func (c *ReplicationConfig) Destination(name string) *ReplicationDestination {
+	for i := range c.Destinations {
+		if c.Destinations[i].Name == name {
+			return &c.Destinations[i]
+		}
+	}
+	return nil
+}
+
+// getDefaultReplicationConfig returns the default replication configuration
+func (rs *ReplicationConfigService) getDefaultReplicationConfig() *ReplicationConfig {
+	return &ReplicationConfig{
+		Enabled: false,
+	}
+}
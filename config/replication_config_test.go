@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestReplicationConfigCheckStorage(t *testing.T) {
+	private := &ObjectStorageConfig{Backend: ObjectStorageR2, Private: true, ResolverBaseURL: "https://venue.example.com"}
+	public := &ObjectStorageConfig{Backend: ObjectStorageR2}
+
+	cases := []struct {
+		name        string
+		replication ReplicationConfig
+		storage     *ObjectStorageConfig
+		wantErr     bool
+	}{
+		{"failover with private bucket", ReplicationConfig{Enabled: true, Failover: true}, private, false},
+		{"failover with public bucket", ReplicationConfig{Enabled: true, Failover: true}, public, true},
+		{"failover without storage config", ReplicationConfig{Enabled: true, Failover: true}, nil, true},
+		{"replication without failover on public bucket", ReplicationConfig{Enabled: true}, public, false},
+		{"disabled replication", ReplicationConfig{Failover: true}, public, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.replication.CheckStorage(tc.storage); (err != nil) != tc.wantErr {
+				t.Errorf("CheckStorage() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"ayo-mwr/database"
+)
+
+// Slow-motion replay output modes
+const (
+	SlowMotionAppend   = "append"   // Replay is appended to the end of the clip
+	SlowMotionSeparate = "separate" // Replay is uploaded to R2 as its own video
+)
+
+// SlowMotionConfigService handles slow-motion replay configuration for button clips
+type SlowMotionConfigService struct {
+	db database.Database
+}
+
+// NewSlowMotionConfigService creates a new slow-motion configuration service
+func NewSlowMotionConfigService(db database.Database) *SlowMotionConfigService {
+	return &SlowMotionConfigService{
+		db: db,
+	}
+}
+
+// SlowMotionSettings describes the slow-motion replay produced for a clip
+type SlowMotionSettings struct {
+	Enabled        bool    `json:"enabled"`        // Whether a replay is produced
+	ReplaySeconds  float64 `json:"replaySeconds"`  // Length of the clip tail that is replayed
+	Speed          float64 `json:"speed"`          // Playback speed of the replay (0.5 = half speed)
+	Interpolate    bool    `json:"interpolate"`    // Interpolate frames instead of repeating them (slower to encode)
+	Mode           string  `json:"mode"`           // "append" or "separate"
+	MaxClipSeconds float64 `json:"maxClipSeconds"` // Only clips up to this length get a replay (0 = no limit)
+}
+
+// SlowMotionConfig represents the slow-motion configuration. Field settings take precedence
+// over venue settings, which take precedence over the default.
+type SlowMotionConfig struct {
+	Default SlowMotionSettings            `json:"default"`
+	Venues  map[string]SlowMotionSettings `json:"venues"` // Keyed by venue code
+	Fields  map[string]SlowMotionSettings `json:"fields"` // Keyed by field ID
+}
+
+// GetSlowMotionConfig retrieves the slow-motion configuration
+func (sms *SlowMotionConfigService) GetSlowMotionConfig() (*SlowMotionConfig, error) {
+	config, err := sms.db.GetSystemConfig("slow_motion")
+	if err != nil {
+		// Return default configuration if not found
+		return sms.getDefaultSlowMotionConfig(), nil
+	}
+
+	var slowMotionConfig SlowMotionConfig
+	if err := json.Unmarshal([]byte(config.Value), &slowMotionConfig); err != nil {
+		log.Printf("[SlowMotion] Warning: Failed to parse slow-motion config, using defaults: %v", err)
+		return sms.getDefaultSlowMotionConfig(), nil
+	}
+
+	return &slowMotionConfig, nil
+}
+
+// SetSlowMotionConfig validates and saves the slow-motion configuration
+func (sms *SlowMotionConfigService) SetSlowMotionConfig(config *SlowMotionConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "slow_motion",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return sms.db.SetSystemConfig(systemConfig)
+}
+
+// SettingsFor returns the slow-motion settings for a field of a venue
+func (sms *SlowMotionConfigService) SettingsFor(venueCode string, fieldID int) SlowMotionSettings {
+	config, err := sms.GetSlowMotionConfig()
+	if err != nil {
+		return SlowMotionSettings{}
+	}
+
+	if settings, ok := config.Fields[fmt.Sprintf("%d", fieldID)]; ok && fieldID != 0 {
+		return settings
+	}
+	if settings, ok := config.Venues[venueCode]; ok {
+		return settings
+	}
+	return config.Default
+}
+
+// Validate checks that the default, venue and field settings are usable
+func (c *SlowMotionConfig) Validate() error {
+	if err := c.Default.Validate(); err != nil {
+		return fmt.Errorf("default: %v", err)
+	}
+	for venue, settings := range c.Venues {
+		if err := settings.Validate(); err != nil {
+			return fmt.Errorf("venue %s: %v", venue, err)
+		}
+	}
+	for field, settings := range c.Fields {
+		if err := settings.Validate(); err != nil {
+			return fmt.Errorf("field %s: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that slow-motion settings are usable
+func (s SlowMotionSettings) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if s.ReplaySeconds < 1 || s.ReplaySeconds > 30 {
+		return fmt.Errorf("replay length must be between 1 and 30 seconds")
+	}
+	// atempo (audio slow-down) supports 0.5x to 100x
+	if s.Speed < 0.5 || s.Speed >= 1 {
+		return fmt.Errorf("speed must be at least 0.5 and below 1")
+	}
+	if s.Mode != SlowMotionAppend && s.Mode != SlowMotionSeparate {
+		return fmt.Errorf("mode must be %s or %s", SlowMotionAppend, SlowMotionSeparate)
+	}
+	if s.MaxClipSeconds < 0 {
+		return fmt.Errorf("max clip length cannot be negative")
+	}
+	return nil
+}
+
+// getDefaultSlowMotionConfig returns the default slow-motion configuration.
+// Replays are off by default; when enabled, the last 5 seconds of clips up to 2 minutes are replayed at half speed.
+func (sms *SlowMotionConfigService) getDefaultSlowMotionConfig() *SlowMotionConfig {
+	return &SlowMotionConfig{
+		Default: SlowMotionSettings{
+			Enabled:        false,
+			ReplaySeconds:  5,
+			Speed:          0.5,
+			Interpolate:    false,
+			Mode:           SlowMotionAppend,
+			MaxClipSeconds: 120,
+		},
+		Venues: map[string]SlowMotionSettings{},
+		Fields: map[string]SlowMotionSettings{},
+	}
+}
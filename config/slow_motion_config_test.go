@@ -0,0 +1,82 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"ayo-mwr/database"
+)
+
+func TestSlowMotionSettingsValidate(t *testing.T) {
+	valid := SlowMotionSettings{Enabled: true, ReplaySeconds: 5, Speed: 0.5, Mode: SlowMotionAppend}
+
+	cases := []struct {
+		name    string
+		mutate  func(*SlowMotionSettings)
+		wantErr bool
+	}{
+		{"valid", func(s *SlowMotionSettings) {}, false},
+		{"disabled settings are not checked", func(s *SlowMotionSettings) { s.Enabled = false; s.Speed = 0 }, false},
+		{"replay too short", func(s *SlowMotionSettings) { s.ReplaySeconds = 0.5 }, true},
+		{"replay too long", func(s *SlowMotionSettings) { s.ReplaySeconds = 31 }, true},
+		{"speed below atempo range", func(s *SlowMotionSettings) { s.Speed = 0.25 }, true},
+		{"normal speed", func(s *SlowMotionSettings) { s.Speed = 1 }, true},
+		{"separate mode", func(s *SlowMotionSettings) { s.Mode = SlowMotionSeparate }, false},
+		{"unknown mode", func(s *SlowMotionSettings) { s.Mode = "overlay" }, true},
+		{"negative clip limit", func(s *SlowMotionSettings) { s.MaxClipSeconds = -1 }, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := valid
+			tc.mutate(&settings)
+			if err := settings.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSlowMotionConfigDefaultsAndPrecedence(t *testing.T) {
+	db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	service := NewSlowMotionConfigService(db)
+
+	defaults := service.SettingsFor("venue", 1)
+	if defaults.Enabled || defaults.Speed != 0.5 || defaults.ReplaySeconds != 5 || defaults.Mode != SlowMotionAppend {
+		t.Errorf("unexpected default settings: %+v", defaults)
+	}
+	if err := service.getDefaultSlowMotionConfig().Validate(); err != nil {
+		t.Errorf("default configuration should be valid: %v", err)
+	}
+
+	venue := SlowMotionSettings{Enabled: true, ReplaySeconds: 4, Speed: 0.5, Mode: SlowMotionAppend}
+	field := SlowMotionSettings{Enabled: true, ReplaySeconds: 8, Speed: 0.75, Mode: SlowMotionSeparate}
+	err = service.SetSlowMotionConfig(&SlowMotionConfig{
+		Default: defaults,
+		Venues:  map[string]SlowMotionSettings{"venue": venue},
+		Fields:  map[string]SlowMotionSettings{"7": field},
+	})
+	if err != nil {
+		t.Fatalf("SetSlowMotionConfig failed: %v", err)
+	}
+
+	if got := service.SettingsFor("venue", 7); got != field {
+		t.Errorf("field settings should win: %+v", got)
+	}
+	if got := service.SettingsFor("venue", 1); got != venue {
+		t.Errorf("venue settings should apply to other fields: %+v", got)
+	}
+	if got := service.SettingsFor("other", 0); got != defaults {
+		t.Errorf("default settings should apply to other venues: %+v", got)
+	}
+
+	invalid := &SlowMotionConfig{Default: defaults, Fields: map[string]SlowMotionSettings{"7": {Enabled: true, Speed: 2}}}
+	if err := service.SetSlowMotionConfig(invalid); err == nil {
+		t.Errorf("expected invalid field settings to be rejected")
+	}
+}
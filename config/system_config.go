@@ -431,6 +431,15 @@ func ValidateEnabledQualities(qualities []string) error {
 	return nil
 }
 
+// ValidateCodec validates the output video codec. It accepts the names transcode.NormalizeCodec maps.
+func ValidateCodec(codec string) error {
+	switch strings.ToLower(codec) {
+	case "avc", "h264", "hevc", "h265", "av1":
+		return nil
+	}
+	return fmt.Errorf("invalid codec '%s', valid options: avc, h264, hevc, h265, av1", codec)
+}
+
 // ValidateDiskManagerConfig validates disk manager configuration values
 func ValidateDiskManagerConfig(minimumFreeSpaceGB, priorityExternal, priorityMountedStorage, priorityInternalNVMe, priorityInternalSATA, priorityRootFilesystem int) error {
 	// Validate minimum free space
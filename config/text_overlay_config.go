@@ -0,0 +1,165 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"ayo-mwr/database"
+)
+
+// Text overlay positions
+const (
+	OverlayTopLeft      = "top_left"
+	OverlayTopCenter    = "top_center"
+	OverlayTopRight     = "top_right"
+	OverlayBottomLeft   = "bottom_left"
+	OverlayBottomCenter = "bottom_center"
+	OverlayBottomRight  = "bottom_right"
+)
+
+// TextOverlayConfigService handles booking information text overlay configuration
+type TextOverlayConfigService struct {
+	db database.Database
+}
+
+// NewTextOverlayConfigService creates a new text overlay configuration service
+func NewTextOverlayConfigService(db database.Database) *TextOverlayConfigService {
+	return &TextOverlayConfigService{
+		db: db,
+	}
+}
+
+// TextOverlayLayout describes how the booking information is drawn on the video
+type TextOverlayLayout struct {
+	FontFile        string `json:"fontFile"`        // Path to a TrueType font
+	FontSize        int    `json:"fontSize"`        // Font size in pixels
+	FontColor       string `json:"fontColor"`       // FFmpeg colour, e.g. "white" or "#FFFFFF@0.9"
+	BoxColor        string `json:"boxColor"`        // Background box colour (empty = no box)
+	Position        string `json:"position"`        // Position of the field name / booking time block
+	ClockPosition   string `json:"clockPosition"`   // Position of the running clock
+	Margin          int    `json:"margin"`          // Distance from the video edge in pixels
+	DateFormat      string `json:"dateFormat"`      // Go time layout for the booking date
+	ShowFieldName   bool   `json:"showFieldName"`   // Draw the field name
+	ShowBookingTime bool   `json:"showBookingTime"` // Draw the booking date and start/end time
+	ShowClock       bool   `json:"showClock"`       // Draw a running clock of the actual capture time
+}
+
+// TextOverlayConfig represents the text overlay configuration
+type TextOverlayConfig struct {
+	Enabled bool                         `json:"enabled"` // Whether the overlay is drawn at all
+	Default TextOverlayLayout            `json:"default"` // Layout used by venues without their own
+	Venues  map[string]TextOverlayLayout `json:"venues"`  // Per venue code layout overrides
+}
+
+// GetTextOverlayConfig retrieves the text overlay configuration
+func (tos *TextOverlayConfigService) GetTextOverlayConfig() (*TextOverlayConfig, error) {
+	config, err := tos.db.GetSystemConfig("text_overlay")
+	if err != nil {
+		// Return default configuration if not found
+		return tos.getDefaultTextOverlayConfig(), nil
+	}
+
+	var overlayConfig TextOverlayConfig
+	if err := json.Unmarshal([]byte(config.Value), &overlayConfig); err != nil {
+		log.Printf("[TextOverlay] Warning: Failed to parse text overlay config, using defaults: %v", err)
+		return tos.getDefaultTextOverlayConfig(), nil
+	}
+
+	return &overlayConfig, nil
+}
+
+// SetTextOverlayConfig validates and saves the text overlay configuration
+func (tos *TextOverlayConfigService) SetTextOverlayConfig(config *TextOverlayConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "text_overlay",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return tos.db.SetSystemConfig(systemConfig)
+}
+
+// LayoutFor returns the overlay layout for a venue, or false when the overlay is disabled
+func (tos *TextOverlayConfigService) LayoutFor(venueCode string) (TextOverlayLayout, bool) {
+	config, err := tos.GetTextOverlayConfig()
+	if err != nil || !config.Enabled {
+		return TextOverlayLayout{}, false
+	}
+
+	layout := config.Default
+	if venueLayout, ok := config.Venues[venueCode]; ok {
+		layout = venueLayout
+	}
+	if !layout.ShowFieldName && !layout.ShowBookingTime && !layout.ShowClock {
+		return TextOverlayLayout{}, false
+	}
+	return layout, true
+}
+
+// Validate checks that the default and all venue layouts are usable
+func (c *TextOverlayConfig) Validate() error {
+	if err := c.Default.Validate(); err != nil {
+		return fmt.Errorf("default layout: %v", err)
+	}
+	for venue, layout := range c.Venues {
+		if err := layout.Validate(); err != nil {
+			return fmt.Errorf("venue %s: %v", venue, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that a layout is usable
+func (l TextOverlayLayout) Validate() error {
+	if l.FontSize < 8 || l.FontSize > 200 {
+		return fmt.Errorf("font size must be between 8 and 200")
+	}
+	if l.FontColor == "" {
+		return fmt.Errorf("font colour is required")
+	}
+	if l.Margin < 0 {
+		return fmt.Errorf("margin cannot be negative")
+	}
+	for _, position := range []string{l.Position, l.ClockPosition} {
+		switch position {
+		case OverlayTopLeft, OverlayTopCenter, OverlayTopRight,
+			OverlayBottomLeft, OverlayBottomCenter, OverlayBottomRight:
+		default:
+			return fmt.Errorf("invalid position %q", position)
+		}
+	}
+	return nil
+}
+
+// getDefaultTextOverlayConfig returns the default text overlay configuration.
+// The overlay is off by default; the watermark usually sits top right, so the text goes to the bottom.
+func (tos *TextOverlayConfigService) getDefaultTextOverlayConfig() *TextOverlayConfig {
+	return &TextOverlayConfig{
+		Enabled: false,
+		Default: TextOverlayLayout{
+			FontFile:        "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+			FontSize:        28,
+			FontColor:       "white",
+			BoxColor:        "black@0.4",
+			Position:        OverlayBottomLeft,
+			ClockPosition:   OverlayBottomRight,
+			Margin:          20,
+			DateFormat:      "02 Jan 2006",
+			ShowFieldName:   true,
+			ShowBookingTime: true,
+			ShowClock:       false,
+		},
+		Venues: map[string]TextOverlayLayout{},
+	}
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ayo-mwr/database"
+)
+
+// TranscodeLadderConfigService handles the HLS transcoding ladder configuration
+type TranscodeLadderConfigService struct {
+	db database.Database
+}
+
+// NewTranscodeLadderConfigService creates a new transcoding ladder configuration service
+func NewTranscodeLadderConfigService(db database.Database) *TranscodeLadderConfigService {
+	return &TranscodeLadderConfigService{
+		db: db,
+	}
+}
+
+// TranscodePreset defines the resolution and encoder settings of one rung of the ladder.
+// Optional settings left at their zero value use the encoder default.
+type TranscodePreset struct {
+	Name    string `json:"name"`    // Quality name (1080p, 720p, 480p or 360p), matched against enabled qualities
+	Width   int    `json:"width"`   // Output width
+	Height  int    `json:"height"`  // Output height
+	Bitrate string `json:"bitrate"` // Target video bitrate, e.g. "2800k"
+	MaxRate string `json:"maxrate"` // Optional peak bitrate, e.g. "3000k"
+	BufSize string `json:"bufsize"` // Optional rate control buffer size, e.g. "4200k" (required with maxrate)
+	GOP     int    `json:"gop"`     // Optional keyframe interval in frames
+	Profile string `json:"profile"` // Optional H.264 profile (baseline, main or high)
+	CRF     int    `json:"crf"`     // Optional constant quality (1-51), 0 keeps the codec default
+}
+
+// Segment formats of the HLS output
+const (
+	SegmentFormatTS   = "ts"   // MPEG-TS segments, HLS only
+	SegmentFormatCMAF = "cmaf" // fMP4 segments shared by an HLS master playlist and a DASH manifest
+)
+
+// TranscodeLadderConfig represents the transcoding ladder used for HLS output.
+// HEVC and AV1 output (selected via the codec setting) always uses CMAF segments.
+type TranscodeLadderConfig struct {
+	SegmentFormat        string            `json:"segmentFormat"`        // "ts" (default) or "cmaf"
+	Presets              []TranscodePreset `json:"presets"`              // Quality ladder
+	CompatibilityPreset  string            `json:"compatibilityPreset"`  // Preset also encoded in H.264 for old phones when using HEVC/AV1
+	CodecDeadlineSeconds int               `json:"codecDeadlineSeconds"` // Fall back to H.264 when HEVC/AV1 is estimated to take longer (0 = no limit)
+	CodecProbeSeconds    int               `json:"codecProbeSeconds"`    // Length of the sample encode used for the estimate
+}
+
+var bitrateRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[kKmM]?$`)
+
+// transcodeQualityNames are the quality names the recorder and enabled qualities setting know about
+var transcodeQualityNames = map[string]bool{
+	"1080p": true,
+	"720p":  true,
+	"480p":  true,
+	"360p":  true,
+}
+
+// GetTranscodeLadderConfig retrieves the transcoding ladder configuration
+func (tls *TranscodeLadderConfigService) GetTranscodeLadderConfig() (*TranscodeLadderConfig, error) {
+	config, err := tls.db.GetSystemConfig("transcode_ladder")
+	if err != nil {
+		// Return default configuration if not found
+		return tls.getDefaultTranscodeLadderConfig(), nil
+	}
+
+	var ladderConfig TranscodeLadderConfig
+	if err := json.Unmarshal([]byte(config.Value), &ladderConfig); err != nil {
+		log.Printf("[TranscodeLadder] Warning: Failed to parse transcoding ladder config, using defaults: %v", err)
+		return tls.getDefaultTranscodeLadderConfig(), nil
+	}
+
+	if err := ladderConfig.Validate(); err != nil {
+		log.Printf("[TranscodeLadder] Warning: Stored transcoding ladder is invalid, using defaults: %v", err)
+		return tls.getDefaultTranscodeLadderConfig(), nil
+	}
+
+	return &ladderConfig, nil
+}
+
+// SetTranscodeLadderConfig validates and saves the transcoding ladder configuration
+func (tls *TranscodeLadderConfigService) SetTranscodeLadderConfig(config *TranscodeLadderConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "transcode_ladder",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return tls.db.SetSystemConfig(systemConfig)
+}
+
+// Validate checks that every preset of the ladder is usable
+func (c *TranscodeLadderConfig) Validate() error {
+	switch c.SegmentFormat {
+	case "", SegmentFormatTS, SegmentFormatCMAF:
+	default:
+		return fmt.Errorf("invalid segment format '%s', valid options: ts, cmaf", c.SegmentFormat)
+	}
+	if len(c.Presets) == 0 {
+		return fmt.Errorf("at least one preset is required")
+	}
+	if c.CodecDeadlineSeconds < 0 {
+		return fmt.Errorf("codec deadline must not be negative")
+	}
+	if c.CodecProbeSeconds < 0 || c.CodecProbeSeconds > 60 {
+		return fmt.Errorf("codec probe length must be between 0 and 60 seconds")
+	}
+
+	seen := make(map[string]bool)
+	for _, preset := range c.Presets {
+		if !transcodeQualityNames[preset.Name] {
+			return fmt.Errorf("invalid preset name '%s', valid options: 1080p, 720p, 480p, 360p", preset.Name)
+		}
+		if seen[preset.Name] {
+			return fmt.Errorf("duplicate preset '%s'", preset.Name)
+		}
+		seen[preset.Name] = true
+
+		if err := preset.Validate(); err != nil {
+			return fmt.Errorf("preset %s: %v", preset.Name, err)
+		}
+	}
+	if c.CompatibilityPreset != "" && !seen[c.CompatibilityPreset] {
+		return fmt.Errorf("compatibility preset '%s' is not in the ladder", c.CompatibilityPreset)
+	}
+	return nil
+}
+
+// Validate checks the resolution and encoder settings of a preset
+func (p *TranscodePreset) Validate() error {
+	if p.Width < 128 || p.Width > 7680 || p.Width%2 != 0 {
+		return fmt.Errorf("width must be an even number between 128 and 7680")
+	}
+	if p.Height < 72 || p.Height > 4320 || p.Height%2 != 0 {
+		return fmt.Errorf("height must be an even number between 72 and 4320")
+	}
+	if _, err := BitsPerSecond(p.Bitrate); err != nil {
+		return fmt.Errorf("invalid bitrate: %v", err)
+	}
+	if p.MaxRate != "" {
+		if _, err := BitsPerSecond(p.MaxRate); err != nil {
+			return fmt.Errorf("invalid maxrate: %v", err)
+		}
+		if p.BufSize == "" {
+			return fmt.Errorf("bufsize is required when maxrate is set")
+		}
+	}
+	if p.BufSize != "" {
+		if _, err := BitsPerSecond(p.BufSize); err != nil {
+			return fmt.Errorf("invalid bufsize: %v", err)
+		}
+	}
+	if p.GOP < 0 || p.GOP > 600 {
+		return fmt.Errorf("gop must be between 0 and 600 frames")
+	}
+	switch p.Profile {
+	case "", "baseline", "main", "high":
+	default:
+		return fmt.Errorf("invalid profile '%s', valid options: baseline, main, high", p.Profile)
+	}
+	if p.CRF < 0 || p.CRF > 51 {
+		return fmt.Errorf("crf must be between 0 and 51")
+	}
+	return nil
+}
+
+// BitsPerSecond converts an FFmpeg rate such as "2800k" or "5M" to bits per second
+func BitsPerSecond(rate string) (int, error) {
+	if !bitrateRegexp.MatchString(rate) {
+		return 0, fmt.Errorf("'%s' is not a rate like 2800k or 5M", rate)
+	}
+
+	multiplier := 1.0
+	switch strings.ToLower(rate[len(rate)-1:]) {
+	case "k":
+		multiplier = 1000
+		rate = rate[:len(rate)-1]
+	case "m":
+		multiplier = 1000000
+		rate = rate[:len(rate)-1]
+	}
+
+	value, err := strconv.ParseFloat(rate, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("rate must be positive")
+	}
+	return int(value * multiplier), nil
+}
+
+// DefaultTranscodePresets returns the built-in transcoding ladder
+func DefaultTranscodePresets() []TranscodePreset {
+	return []TranscodePreset{
+		{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "5000k", MaxRate: "5350k", BufSize: "7500k"},
+		{Name: "720p", Width: 1280, Height: 720, Bitrate: "2800k", MaxRate: "2996k", BufSize: "4200k"},
+		{Name: "480p", Width: 854, Height: 480, Bitrate: "1400k", MaxRate: "1498k", BufSize: "2100k"},
+		{Name: "360p", Width: 640, Height: 360, Bitrate: "800k", MaxRate: "856k", BufSize: "1200k"},
+	}
+}
+
+// getDefaultTranscodeLadderConfig returns the default transcoding ladder configuration
+func (tls *TranscodeLadderConfigService) getDefaultTranscodeLadderConfig() *TranscodeLadderConfig {
+	return &TranscodeLadderConfig{
+		SegmentFormat:        SegmentFormatTS,
+		Presets:              DefaultTranscodePresets(),
+		CompatibilityPreset:  "720p",
+		CodecDeadlineSeconds: 900,
+		CodecProbeSeconds:    10,
+	}
+}
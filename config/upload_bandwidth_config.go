@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"ayo-mwr/database"
+)
+
+// UploadBandwidthConfigService handles the upload bandwidth schedule configuration
+type UploadBandwidthConfigService struct {
+	db database.Database
+}
+
+// NewUploadBandwidthConfigService creates a new upload bandwidth configuration service
+func NewUploadBandwidthConfigService(db database.Database) *UploadBandwidthConfigService {
+	return &UploadBandwidthConfigService{
+		db: db,
+	}
+}
+
+// UploadBandwidthConfig limits the bandwidth used by uploads to cloud storage so they don't
+// saturate the venue's shared internet connection
+type UploadBandwidthConfig struct {
+	Enabled     bool                      `json:"enabled"`     // Whether uploads are rate limited
+	DefaultMbps float64                   `json:"defaultMbps"` // Limit outside the schedule windows, 0 = unlimited
+	Schedule    []UploadBandwidthSchedule `json:"schedule"`    // Time-of-day limits, first matching window wins
+}
+
+// UploadBandwidthSchedule limits uploads during a daily time window (venue local time)
+type UploadBandwidthSchedule struct {
+	Start string  `json:"start"` // Window start, HH:MM
+	End   string  `json:"end"`   // Window end, HH:MM; before Start for windows spanning midnight
+	Mbps  float64 `json:"mbps"`  // Limit in megabits per second, 0 = unlimited
+}
+
+// GetUploadBandwidthConfig retrieves the upload bandwidth configuration
+func (ubs *UploadBandwidthConfigService) GetUploadBandwidthConfig() (*UploadBandwidthConfig, error) {
+	config, err := ubs.db.GetSystemConfig("upload_bandwidth")
+	if err != nil {
+		// Return default configuration if not found
+		return ubs.getDefaultUploadBandwidthConfig(), nil
+	}
+
+	var bandwidthConfig UploadBandwidthConfig
+	if err := json.Unmarshal([]byte(config.Value), &bandwidthConfig); err != nil {
+		log.Printf("[UploadBandwidth] Warning: Failed to parse upload bandwidth config, using defaults: %v", err)
+		return ubs.getDefaultUploadBandwidthConfig(), nil
+	}
+
+	if err := bandwidthConfig.Validate(); err != nil {
+		log.Printf("[UploadBandwidth] Warning: Stored upload bandwidth config is invalid, using defaults: %v", err)
+		return ubs.getDefaultUploadBandwidthConfig(), nil
+	}
+
+	return &bandwidthConfig, nil
+}
+
+// SetUploadBandwidthConfig validates and saves the upload bandwidth configuration
+func (ubs *UploadBandwidthConfigService) SetUploadBandwidthConfig(config *UploadBandwidthConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "upload_bandwidth",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return ubs.db.SetSystemConfig(systemConfig)
+}
+
+// Validate checks the upload bandwidth configuration
+func (c *UploadBandwidthConfig) Validate() error {
+	if c.DefaultMbps < 0 {
+		return fmt.Errorf("defaultMbps must not be negative")
+	}
+	for i, window := range c.Schedule {
+		start, err := parseClockMinutes(window.Start)
+		if err != nil {
+			return fmt.Errorf("schedule[%d]: invalid start: %v", i, err)
+		}
+		end, err := parseClockMinutes(window.End)
+		if err != nil {
+			return fmt.Errorf("schedule[%d]: invalid end: %v", i, err)
+		}
+		if start == end {
+			return fmt.Errorf("schedule[%d]: start and end must differ", i)
+		}
+		if window.Mbps < 0 {
+			return fmt.Errorf("schedule[%d]: mbps must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// LimitAt returns the upload limit in Mbps at t, 0 meaning unlimited
+func (c *UploadBandwidthConfig) LimitAt(t time.Time) float64 {
+	if !c.Enabled {
+		return 0
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	for _, window := range c.Schedule {
+		start, err1 := parseClockMinutes(window.Start)
+		end, err2 := parseClockMinutes(window.End)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		inWindow := minute >= start && minute < end
+		if end < start {
+			// Window spans midnight, e.g. 22:00-06:00
+			inWindow = minute >= start || minute < end
+		}
+		if inWindow {
+			return window.Mbps
+		}
+	}
+	return c.DefaultMbps
+}
+
+// parseClockMinutes parses an HH:MM clock into minutes since midnight
+func parseClockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// getDefaultUploadBandwidthConfig returns the default upload bandwidth configuration
+func (ubs *UploadBandwidthConfigService) getDefaultUploadBandwidthConfig() *UploadBandwidthConfig {
+	return &UploadBandwidthConfig{
+		Enabled:     false,
+		DefaultMbps: 0,
+		Schedule: []UploadBandwidthSchedule{
+			{Start: "17:00", End: "23:00", Mbps: 2},
+		},
+	}
+}
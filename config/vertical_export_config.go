@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"ayo-mwr/database"
+)
+
+// Vertical export crop modes
+const (
+	VerticalCropFixed    = "fixed"    // Crop window stays at the camera's configured centre
+	VerticalCropActivity = "activity" // Crop window follows the region with the most motion
+)
+
+// VerticalExportConfigService handles the 9:16 social export profile configuration
+type VerticalExportConfigService struct {
+	db database.Database
+}
+
+// NewVerticalExportConfigService creates a new vertical export configuration service
+func NewVerticalExportConfigService(db database.Database) *VerticalExportConfigService {
+	return &VerticalExportConfigService{
+		db: db,
+	}
+}
+
+// VerticalCameraCrop is the crop setting of one camera
+type VerticalCameraCrop struct {
+	CropMode string  `json:"cropMode"` // "fixed" or "activity"
+	CenterX  float64 `json:"centerX"`  // Horizontal crop centre as a fraction of the width (0.0 - 1.0), used by "fixed" and as the activity fallback
+}
+
+// VerticalExportConfig represents the vertical export configuration
+type VerticalExportConfig struct {
+	Enabled    bool                          `json:"enabled"`    // Whether a vertical rendition is produced
+	VideoTypes []string                      `json:"videoTypes"` // Video types that get a vertical rendition
+	Width      int                           `json:"width"`      // Output width
+	Height     int                           `json:"height"`     // Output height
+	Default    VerticalCameraCrop            `json:"default"`    // Crop used by cameras without their own setting
+	Cameras    map[string]VerticalCameraCrop `json:"cameras"`    // Per camera name crop settings
+}
+
+// GetVerticalExportConfig retrieves the vertical export configuration
+func (ves *VerticalExportConfigService) GetVerticalExportConfig() (*VerticalExportConfig, error) {
+	config, err := ves.db.GetSystemConfig("vertical_export")
+	if err != nil {
+		// Return default configuration if not found
+		return ves.getDefaultVerticalExportConfig(), nil
+	}
+
+	var exportConfig VerticalExportConfig
+	if err := json.Unmarshal([]byte(config.Value), &exportConfig); err != nil {
+		log.Printf("[VerticalExport] Warning: Failed to parse vertical export config, using defaults: %v", err)
+		return ves.getDefaultVerticalExportConfig(), nil
+	}
+
+	return &exportConfig, nil
+}
+
+// SetVerticalExportConfig validates and saves the vertical export configuration
+func (ves *VerticalExportConfigService) SetVerticalExportConfig(config *VerticalExportConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	systemConfig := database.SystemConfig{
+		Key:       "vertical_export",
+		Value:     string(configJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	}
+
+	return ves.db.SetSystemConfig(systemConfig)
+}
+
+// EnabledFor reports whether a video type gets a vertical rendition
+func (c *VerticalExportConfig) EnabledFor(videoType string) bool {
+	if !c.Enabled {
+		return false
+	}
+	for _, t := range c.VideoTypes {
+		if t == videoType {
+			return true
+		}
+	}
+	return false
+}
+
+// CropFor returns the crop setting of a camera
+func (c *VerticalExportConfig) CropFor(cameraName string) VerticalCameraCrop {
+	if crop, ok := c.Cameras[cameraName]; ok {
+		return crop
+	}
+	return c.Default
+}
+
+// Validate checks that the vertical export configuration is usable
+func (c *VerticalExportConfig) Validate() error {
+	if c.Width <= 0 || c.Height <= 0 || c.Width%2 != 0 || c.Height%2 != 0 {
+		return fmt.Errorf("width and height must be positive even numbers")
+	}
+	if c.Width >= c.Height {
+		return fmt.Errorf("vertical export must be taller than it is wide")
+	}
+	if err := c.Default.Validate(); err != nil {
+		return fmt.Errorf("default: %v", err)
+	}
+	for camera, crop := range c.Cameras {
+		if err := crop.Validate(); err != nil {
+			return fmt.Errorf("camera %s: %v", camera, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that a camera crop setting is usable
+func (c VerticalCameraCrop) Validate() error {
+	if c.CropMode != VerticalCropFixed && c.CropMode != VerticalCropActivity {
+		return fmt.Errorf("crop mode must be %s or %s", VerticalCropFixed, VerticalCropActivity)
+	}
+	if c.CenterX < 0 || c.CenterX > 1 {
+		return fmt.Errorf("crop centre must be between 0 and 1")
+	}
+	return nil
+}
+
+// getDefaultVerticalExportConfig returns the default vertical export configuration.
+// The export is off by default; when enabled, clips get a 1080x1920 rendition following the activity.
+func (ves *VerticalExportConfigService) getDefaultVerticalExportConfig() *VerticalExportConfig {
+	return &VerticalExportConfig{
+		Enabled:    false,
+		VideoTypes: []string{"clip"},
+		Width:      1080,
+		Height:     1920,
+		Default: VerticalCameraCrop{
+			CropMode: VerticalCropActivity,
+			CenterX:  0.5,
+		},
+		Cameras: map[string]VerticalCameraCrop{},
+	}
+}
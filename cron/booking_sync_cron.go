@@ -9,6 +9,7 @@ import (
 	"ayo-mwr/api"
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/service"
 
 	"github.com/robfig/cron/v3"
 )
@@ -38,14 +39,14 @@ func StartBookingSyncCron(cfg *config.Config) {
 		time.Sleep(5 * time.Second)
 
 		// Run immediately once at startup
-		syncBookingsFromAPI(db, ayoClient)
+		syncBookingsFromAPI(cfg, db, ayoClient)
 
 		// Start the booking sync cron
 		schedule := cron.New()
 
 		// Schedule the task every 5 minutes
 		_, err = schedule.AddFunc("@every 5m", func() {
-			syncBookingsFromAPI(db, ayoClient)
+			syncBookingsFromAPI(cfg, db, ayoClient)
 		})
 		if err != nil {
 			log.Fatalf("Error scheduling booking sync cron: %v", err)
@@ -57,7 +58,7 @@ func StartBookingSyncCron(cfg *config.Config) {
 }
 
 // syncBookingsFromAPI handles fetching bookings from API and saving to database
-func syncBookingsFromAPI(db database.Database, ayoClient *api.AyoIndoClient) {
+func syncBookingsFromAPI(cfg *config.Config, db database.Database, ayoClient *api.AyoIndoClient) {
 	log.Println("bookingSync : Running booking synchronization task...")
 
 	// Reload configuration from database before API call
@@ -132,11 +133,35 @@ func syncBookingsFromAPI(db database.Database, ayoClient *api.AyoIndoClient) {
 		} else {
 			successCount++
 		}
+
+		// Stop any processing still running for a cancelled booking
+		if bookingData.Status == "cancelled" || bookingData.Status == "canceled" {
+			cancelBookingVideos(cfg, db, ayoClient, bookingID)
+		}
 	}
 
 	log.Printf("bookingSync : Synchronization completed - Success: %d, Errors: %d", successCount, errorCount)
 }
 
+// cancelBookingVideos cancels the videos of a booking that are still being processed
+func cancelBookingVideos(cfg *config.Config, db database.Database, ayoClient *api.AyoIndoClient, bookingID string) {
+	videos, err := db.GetVideosByBookingID(bookingID)
+	if err != nil {
+		log.Printf("bookingSync : Error getting videos of cancelled booking %s: %v", bookingID, err)
+		return
+	}
+
+	bookingService := service.NewBookingVideoService(db, ayoClient, nil, cfg)
+	for i := range videos {
+		if !service.IsVideoProcessing(videos[i].Status) {
+			continue
+		}
+		if err := bookingService.CancelVideo(&videos[i], "Booking cancelled via API"); err != nil {
+			log.Printf("bookingSync : Error cancelling video %s of booking %s: %v", videos[i].ID, bookingID, err)
+		}
+	}
+}
+
 // getBookingJSON mengkonversi map ke string JSON
 func getBookingJSON(booking map[string]interface{}) string {
 	jsonBytes, err := json.Marshal(booking)
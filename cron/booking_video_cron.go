@@ -2,6 +2,7 @@ package cron
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"ayo-mwr/api"
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
 	"ayo-mwr/offline"
 	"ayo-mwr/service"
 	"ayo-mwr/storage"
@@ -184,6 +186,11 @@ func cleanRetryWithBackoff(operation func() error, maxRetries int, operationName
 
 		lastErr = err
 
+		// A cancelled video must not be processed again
+		if errors.Is(err, ffmpeg.ErrCancelled) {
+			return err
+		}
+
 		// Log retry attempt - semua error akan di-retry
 		isRetryableError(err) // Ini akan log error yang akan di-retry
 
@@ -255,55 +262,8 @@ func StartBookingVideoCron(cfg *config.Config) {
 			return
 		}
 
-		// Initialize R2 storage client with database configuration
-		r2Config := storage.R2Config{
-			AccessKey: cfg.R2AccessKey,
-			SecretKey: cfg.R2SecretKey,
-			AccountID: cfg.R2AccountID,
-			Bucket:    cfg.R2Bucket,
-			Endpoint:  cfg.R2Endpoint,
-			Region:    cfg.R2Region,
-			BaseURL:   cfg.R2BaseURL,
-		}
-
-		// Load R2 configuration from database if config values are empty
-		if r2Config.AccessKey == "" {
-			if accessKey, err := db.GetSystemConfig(database.ConfigR2AccessKey); err == nil && accessKey.Value != "" {
-				r2Config.AccessKey = accessKey.Value
-			}
-		}
-		if r2Config.SecretKey == "" {
-			if secretKey, err := db.GetSystemConfig(database.ConfigR2SecretKey); err == nil && secretKey.Value != "" {
-				r2Config.SecretKey = secretKey.Value
-			}
-		}
-		if r2Config.AccountID == "" {
-			if accountID, err := db.GetSystemConfig(database.ConfigR2AccountID); err == nil && accountID.Value != "" {
-				r2Config.AccountID = accountID.Value
-			}
-		}
-		if r2Config.Bucket == "" {
-			if bucket, err := db.GetSystemConfig(database.ConfigR2Bucket); err == nil && bucket.Value != "" {
-				r2Config.Bucket = bucket.Value
-			}
-		}
-		if r2Config.Endpoint == "" {
-			if endpoint, err := db.GetSystemConfig(database.ConfigR2Endpoint); err == nil && endpoint.Value != "" {
-				r2Config.Endpoint = endpoint.Value
-			}
-		}
-		if r2Config.Region == "" {
-			if region, err := db.GetSystemConfig(database.ConfigR2Region); err == nil && region.Value != "" {
-				r2Config.Region = region.Value
-			}
-		}
-		if r2Config.BaseURL == "" {
-			if baseURL, err := db.GetSystemConfig(database.ConfigR2BaseURL); err == nil && baseURL.Value != "" {
-				r2Config.BaseURL = baseURL.Value
-			}
-		}
-
-		r2Client, err := storage.NewR2Storage(r2Config)
+		// Initialize object storage selected in system config
+		r2Client, err := storage.NewObjectStore(cfg, db, 1)
 		if err != nil {
 			log.Printf("processBookings : Error initializing R2 storage client: %v", err)
 			return
@@ -315,8 +275,6 @@ func StartBookingVideoCron(cfg *config.Config) {
 		// Initialize queue manager for offline capabilities
 		log.Printf("📦 OFFLINE QUEUE: Initializing offline queue system for booking video cron...")
 		queueManager := offline.NewQueueManager(db, uploadService, r2Client, ayoClient, cfg)
-		queueManager.Start()
-		log.Printf("📦 OFFLINE QUEUE: ✅ Offline queue system started successfully for cron")
 
 		// Initialize booking video service
 		bookingVideoService := service.NewBookingVideoService(db, ayoClient, r2Client, cfg)
@@ -335,6 +293,11 @@ func StartBookingVideoCron(cfg *config.Config) {
 			log.Printf("⚠️ BOOKING-CRON: Failed to create AYO client, hybrid processor will use legacy watermark method: %v", err)
 		}
 
+		// The queue recovers the gaps the duration verifier defers, so start it once the processor is ready
+		queueManager.SetHybridProcessor(hybridProcessor)
+		queueManager.Start()
+		log.Printf("📦 OFFLINE QUEUE: ✅ Offline queue system started successfully for cron")
+
 		// Initialize semaphore dengan konfigurasi dinamis
 		updateBookingConcurrency(cfg.BookingWorkerConcurrency)
 		log.Printf("📊 BOOKING-CRON: Sistem antrian dimulai - maksimal %d proses booking bersamaan", cfg.BookingWorkerConcurrency)
@@ -366,7 +329,7 @@ func StartBookingVideoCron(cfg *config.Config) {
 }
 
 // processBookings handles fetching bookings from database and processing them
-func processBookings(cfg *config.Config, db database.Database, ayoClient *api.AyoIndoClient, r2Client *storage.R2Storage, bookingService *service.BookingVideoService, queueManager *offline.QueueManager, uploadService *service.UploadService, hybridProcessor *service.HybridVideoProcessor) {
+func processBookings(cfg *config.Config, db database.Database, ayoClient *api.AyoIndoClient, r2Client storage.ObjectStore, bookingService *service.BookingVideoService, queueManager *offline.QueueManager, uploadService *service.UploadService, hybridProcessor *service.HybridVideoProcessor) {
 	// Reload configuration from database before processing
 	// This ensures we have the latest venue code and secret key
 	if err := ayoClient.ReloadConfigFromDatabase(); err != nil {
@@ -452,7 +415,7 @@ func processBookings(cfg *config.Config, db database.Database, ayoClient *api.Ay
 			for _, video := range existingVideos {
 				log.Printf("video.Status %s", video.Status)
 
-				if (video.Status == database.StatusReady || video.Status == database.StatusUploading || video.Status == database.StatusInitial) && video.VideoType == "full" {
+				if (video.Status == database.StatusReady || video.Status == database.StatusPartial || video.Status == database.StatusUploading || video.Status == database.StatusInitial) && video.VideoType == "full" {
 					hasReadyVideo = true
 					break
 				}
@@ -609,7 +572,7 @@ func processBookings(cfg *config.Config, db database.Database, ayoClient *api.Ay
 				for _, video := range existingVideos {
 					log.Printf("video.Status %s", video.Status)
 
-					if (video.Status == database.StatusReady || video.Status == database.StatusUploading || video.Status == database.StatusInitial) && video.VideoType == "full" {
+					if (video.Status == database.StatusReady || video.Status == database.StatusPartial || video.Status == database.StatusUploading || video.Status == database.StatusInitial) && video.VideoType == "full" {
 						hasReadyVideo = true
 						break
 					}
@@ -772,6 +735,25 @@ func processBookings(cfg *config.Config, db database.Database, ayoClient *api.Ay
 				}
 				log.Printf("processBookings : uniqueID %s", uniqueID)
 
+				// Make sure the output covers the whole booking window, re-processing any missing parts
+				if videoDurationCheckEnabled(db) {
+					result, err := hybridProcessor.VerifyAndRepairDuration(uniqueID)
+					if err != nil {
+						log.Printf("⚠️ WARNING: Duration verification failed for %s: %v", uniqueID, err)
+					} else {
+						if !result.Complete() {
+							log.Printf("⚠️ PARTIAL: Video %s is missing %d interval(s) of booking %s on camera %s",
+								uniqueID, len(result.MissingIntervals), bookingID, camera.Name)
+						}
+						// Recent gaps may still be recording; recover them later instead of blocking the cron
+						if result.PendingGaps > 0 {
+							if err := service.EnqueueGapRecovery(db, uniqueID); err != nil {
+								log.Printf("⚠️ WARNING: Failed to queue gap recovery for %s: %v", uniqueID, err)
+							}
+						}
+					}
+				}
+
 				// Get the video metadata to find the processed video path
 				video, err := db.GetVideo(uniqueID)
 				if err != nil {
@@ -807,6 +789,10 @@ func processBookings(cfg *config.Config, db database.Database, ayoClient *api.Ay
 						return err
 					}, 5, fmt.Sprintf("File Upload for %s-%s", bookingID, camera.Name))
 
+					if errors.Is(err, ffmpeg.ErrCancelled) {
+						log.Printf("processBookings : Video %s was cancelled, skipping upload", uniqueID)
+						continue
+					}
 					if err != nil {
 						log.Printf("⚠️ WARNING: Direct upload failed for %s-%s: %v", bookingID, camera.Name, err)
 						log.Printf("📦 QUEUE: Menambahkan task upload ke offline queue...")
@@ -817,7 +803,6 @@ func processBookings(cfg *config.Config, db database.Database, ayoClient *api.Ay
 							watermarkedVideoPath,
 							previewPath,
 							thumbnailPath,
-							fmt.Sprintf("mp4/%s.ts", uniqueID),
 							fmt.Sprintf("preview/%s.mp4", uniqueID),
 							fmt.Sprintf("thumbnail/%s.png", uniqueID),
 						)
@@ -844,7 +829,6 @@ func processBookings(cfg *config.Config, db database.Database, ayoClient *api.Ay
 						watermarkedVideoPath,
 						previewPath,
 						thumbnailPath,
-						fmt.Sprintf("mp4/%s.mp4", uniqueID),
 						fmt.Sprintf("preview/%s.mp4", uniqueID),
 						fmt.Sprintf("thumbnail/%s.png", uniqueID),
 					)
@@ -956,3 +940,12 @@ func processBookings(cfg *config.Config, db database.Database, ayoClient *api.Ay
 }
 
 // Semua fungsi helper sudah dipindahkan ke BookingVideoService di service/booking_video.go
+
+// videoDurationCheckEnabled reports whether processed videos should be checked against their
+// booking window. Like the video request cron, the check is on unless explicitly set to "false".
+func videoDurationCheckEnabled(db database.Database) bool {
+	if config, err := db.GetSystemConfig(database.ConfigEnableVideoDurationCheck); err == nil && config.Value == "false" {
+		return false
+	}
+	return true
+}
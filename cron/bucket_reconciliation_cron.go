@@ -0,0 +1,34 @@
+package cron
+
+import (
+	"log"
+	"time"
+
+	"ayo-mwr/database"
+	"ayo-mwr/service"
+	"ayo-mwr/storage"
+)
+
+// StartBucketReconciliationCron compares the bucket with the videos table nightly at 4 AM (after
+// the HLS cleanup at 3 AM), repairing missing uploads from local files. Orphaned objects are only
+// reported; deleting them is left to an admin (POST /api/admin/bucket-reconciliation).
+func StartBucketReconciliationCron(db database.Database, store storage.ObjectStore) {
+	if store == nil {
+		log.Println("Bucket reconciliation cron not started: object storage is not configured")
+		return
+	}
+
+	go func() {
+		for {
+			now := time.Now()
+			next4AM := time.Date(now.Year(), now.Month(), now.Day()+1, 4, 0, 0, 0, now.Location())
+			log.Printf("Bucket reconciliation cron: next run scheduled at %v", next4AM)
+			time.Sleep(next4AM.Sub(now))
+
+			if _, err := service.RunBucketReconciliation(db, store, false); err != nil {
+				log.Printf("Bucket reconciliation failed: %v", err)
+			}
+		}
+	}()
+	log.Println("Bucket reconciliation cron job started - will reconcile the bucket nightly at 4 AM")
+}
@@ -0,0 +1,57 @@
+package cron
+
+import (
+	"log"
+	"time"
+
+	"ayo-mwr/storage"
+)
+
+// diskForecastLogInterval is how often the probe cron logs the disk capacity forecast
+const diskForecastLogInterval = 24 * time.Hour
+
+// StartDiskProbeCron probes every registered disk each minute, so a failing active disk is
+// replaced while recording instead of at the next nightly scan. Once a day it also logs when
+// each disk is forecast to reach the minimum free space threshold.
+func StartDiskProbeCron(diskManager *storage.DiskManager) {
+	if diskManager == nil {
+		log.Println("Disk probe cron not started: disk manager is not available")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(storage.DiskProbeInterval)
+		defer ticker.Stop()
+
+		var lastForecast time.Time
+		for range ticker.C {
+			diskManager.ProbeDisks()
+
+			if time.Since(lastForecast) >= diskForecastLogInterval {
+				lastForecast = time.Now()
+				logDiskForecast(diskManager)
+			}
+		}
+	}()
+	log.Println("Disk probe cron job started - will probe every disk each minute")
+}
+
+// logDiskForecast logs the growth of every disk and how many days it has left until it reaches
+// the minimum free space threshold
+func logDiskForecast(diskManager *storage.DiskManager) {
+	forecast, err := diskManager.ForecastDiskCapacity()
+	if err != nil {
+		log.Printf("Failed to forecast disk capacity: %v", err)
+		return
+	}
+
+	for _, disk := range forecast.Disks {
+		if disk.DaysUntilThreshold == nil {
+			log.Printf("Disk %s: growing %.2f GB/day, stays above the %d GB free space threshold under current retention",
+				disk.DiskID, disk.GBPerDay, disk.ThresholdGB)
+			continue
+		}
+		log.Printf("Disk %s: growing %.2f GB/day, reaches the %d GB free space threshold in %.1f days",
+			disk.DiskID, disk.GBPerDay, disk.ThresholdGB, *disk.DaysUntilThreshold)
+	}
+}
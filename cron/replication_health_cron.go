@@ -0,0 +1,45 @@
+package cron
+
+import (
+	"log"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+)
+
+// primaryHealthCheckInterval is how often the primary object store is probed while failover
+// to replicas is enabled
+const primaryHealthCheckInterval = time.Minute
+
+// StartPrimaryHealthCheckCron probes the primary object store every minute while replication
+// failover is enabled, so media is resolved from a replica when the primary becomes unhealthy
+func StartPrimaryHealthCheckCron(db database.Database, store storage.ObjectStore) {
+	if store == nil {
+		log.Println("Primary health check cron not started: object storage is not configured")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(primaryHealthCheckInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			replicationConfig, _ := config.NewReplicationConfigService(db).GetReplicationConfig()
+			if !replicationConfig.Enabled || !replicationConfig.Failover {
+				continue
+			}
+
+			wasHealthy := storage.PrimaryHealthy()
+			healthy := storage.CheckPrimaryHealth(store)
+			if wasHealthy && !healthy {
+				log.Printf("[Replication] ⚠️ Primary object storage is unhealthy, resolving media from replicas: %v",
+					storage.PrimaryHealthStatus()["lastError"])
+			} else if !wasHealthy && healthy {
+				log.Println("[Replication] ✅ Primary object storage is healthy again")
+			}
+		}
+	}()
+	log.Println("Primary health check cron job started - will probe object storage every minute while failover is enabled")
+}
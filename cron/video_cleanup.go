@@ -20,31 +20,37 @@ type VideoClient interface {
 	MarkVideosUnavailable(uniqueIds []string) (map[string]interface{}, error)
 }
 
-func StartVideoCleanupJob(db *sql.DB, client *api.AyoIndoClient, autoDelete int, venueCode string) {
+// RemoteVideoDeleter deletes the objects of expired videos from object storage
+type RemoteVideoDeleter interface {
+	DeleteExpiredVideos(videoIDs []string)
+}
+
+func StartVideoCleanupJob(db *sql.DB, client *api.AyoIndoClient, remote RemoteVideoDeleter, autoDelete int, venueCode string) {
 	go func() {
 		ticker := time.NewTicker(24 * time.Hour)
 		defer ticker.Stop()
 		for {
-			cleanupExpiredVideosWithClient(db, client, autoDelete, venueCode)
+			cleanupExpiredVideosWithClient(db, client, remote, autoDelete, venueCode)
 			<-ticker.C
 		}
 	}()
 }
 
 // CleanupExpiredVideos is a public wrapper for cleanupExpiredVideos for testing purposes
-func CleanupExpiredVideos(db *sql.DB, client VideoClient, autoDelete int, venueCode string) {
-	cleanupExpiredVideosWithClient(db, client, autoDelete, venueCode)
+func CleanupExpiredVideos(db *sql.DB, client VideoClient, remote RemoteVideoDeleter, autoDelete int, venueCode string) {
+	cleanupExpiredVideosWithClient(db, client, remote, autoDelete, venueCode)
 }
 
 // CleanupExpiredVideosWithSQLiteDB is a wrapper that accepts a SQLiteDB instance
-func CleanupExpiredVideosWithSQLiteDB(sqliteDB *database.SQLiteDB, client VideoClient, autoDelete int, venueCode string) {
+func CleanupExpiredVideosWithSQLiteDB(sqliteDB *database.SQLiteDB, client VideoClient, remote RemoteVideoDeleter, autoDelete int, venueCode string) {
 	// Access the underlying *sql.DB from the SQLiteDB struct
 	db := sqliteDB.GetDB()
-	cleanupExpiredVideosWithClient(db, client, autoDelete, venueCode)
+	cleanupExpiredVideosWithClient(db, client, remote, autoDelete, venueCode)
 }
 
-// cleanupExpiredVideosWithClient is the implementation of cleanupExpiredVideos that accepts the VideoClient interface
-func cleanupExpiredVideosWithClient(db *sql.DB, client VideoClient, autoDelete int, venueCode string) {
+// cleanupExpiredVideosWithClient is the implementation of cleanupExpiredVideos that accepts the VideoClient interface.
+// When remote is set, the objects of expired videos are deleted from object storage too.
+func cleanupExpiredVideosWithClient(db *sql.DB, client VideoClient, remote RemoteVideoDeleter, autoDelete int, venueCode string) {
 	log.Printf("Starting video cleanup process. Auto-delete days: %d", autoDelete)
 
 	expiry := time.Now().AddDate(0, 0, -autoDelete)
@@ -250,6 +256,7 @@ func cleanupExpiredVideosWithClient(db *sql.DB, client VideoClient, autoDelete i
 		if len(batch) >= 10 {
 			markVideosUnavailableWithClient(client, batch)
 			updateVideoStatusInDatabase(db, processedIds)
+			deleteRemoteVideoObjects(remote, processedIds)
 
 			// Reset batch for next group
 			batch = []string{}
@@ -261,6 +268,7 @@ func cleanupExpiredVideosWithClient(db *sql.DB, client VideoClient, autoDelete i
 	if len(batch) > 0 {
 		markVideosUnavailableWithClient(client, batch)
 		updateVideoStatusInDatabase(db, processedIds)
+		deleteRemoteVideoObjects(remote, processedIds)
 	}
 
 	log.Printf("Video cleanup process completed")
@@ -323,6 +331,17 @@ func updateVideoStatusInDatabase(db *sql.DB, ids []string) {
 	log.Printf("Updated %d videos to status 'unavailable' in database", rowsAffected)
 }
 
+// deleteRemoteVideoObjects deletes the MP4, preview, thumbnail and HLS objects of expired videos
+// from object storage
+func deleteRemoteVideoObjects(remote RemoteVideoDeleter, ids []string) {
+	if remote == nil || len(ids) == 0 {
+		return
+	}
+
+	log.Printf("Deleting remote objects of %d expired videos", len(ids))
+	remote.DeleteExpiredVideos(ids)
+}
+
 // updateHLSPlaylist modifies the HLS playlist to remove segments older than the expiry date
 // and deletes the corresponding .ts segment files
 func updateHLSPlaylist(playlistPath string, expiry time.Time) {
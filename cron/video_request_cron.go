@@ -15,7 +15,9 @@ import (
 	"ayo-mwr/api"
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/metrics"
 	"ayo-mwr/recording"
+	"ayo-mwr/service"
 	"ayo-mwr/storage"
 	"ayo-mwr/transcode"
 
@@ -159,55 +161,8 @@ func StartVideoRequestCron(cfg *config.Config) {
 			return
 		}
 
-		// Initialize R2 storage client with database configuration
-		r2Config := storage.R2Config{
-			AccessKey: cfg.R2AccessKey,
-			SecretKey: cfg.R2SecretKey,
-			AccountID: cfg.R2AccountID,
-			Bucket:    cfg.R2Bucket,
-			Endpoint:  cfg.R2Endpoint,
-			Region:    cfg.R2Region,
-			BaseURL:   cfg.R2BaseURL,
-		}
-
-		// Load R2 configuration from database if config values are empty
-		if r2Config.AccessKey == "" {
-			if accessKey, err := db.GetSystemConfig(database.ConfigR2AccessKey); err == nil && accessKey.Value != "" {
-				r2Config.AccessKey = accessKey.Value
-			}
-		}
-		if r2Config.SecretKey == "" {
-			if secretKey, err := db.GetSystemConfig(database.ConfigR2SecretKey); err == nil && secretKey.Value != "" {
-				r2Config.SecretKey = secretKey.Value
-			}
-		}
-		if r2Config.AccountID == "" {
-			if accountID, err := db.GetSystemConfig(database.ConfigR2AccountID); err == nil && accountID.Value != "" {
-				r2Config.AccountID = accountID.Value
-			}
-		}
-		if r2Config.Bucket == "" {
-			if bucket, err := db.GetSystemConfig(database.ConfigR2Bucket); err == nil && bucket.Value != "" {
-				r2Config.Bucket = bucket.Value
-			}
-		}
-		if r2Config.Endpoint == "" {
-			if endpoint, err := db.GetSystemConfig(database.ConfigR2Endpoint); err == nil && endpoint.Value != "" {
-				r2Config.Endpoint = endpoint.Value
-			}
-		}
-		if r2Config.Region == "" {
-			if region, err := db.GetSystemConfig(database.ConfigR2Region); err == nil && region.Value != "" {
-				r2Config.Region = region.Value
-			}
-		}
-		if r2Config.BaseURL == "" {
-			if baseURL, err := db.GetSystemConfig(database.ConfigR2BaseURL); err == nil && baseURL.Value != "" {
-				r2Config.BaseURL = baseURL.Value
-			}
-		}
-
-		r2Client, err := storage.NewR2Storage(r2Config)
+		// Initialize object storage selected in system config
+		r2Client, err := storage.NewObjectStore(cfg, db, 1)
 		if err != nil {
 			log.Printf("Error initializing R2 storage client: %v", err)
 			return
@@ -243,7 +198,7 @@ func StartVideoRequestCron(cfg *config.Config) {
 }
 
 // processVideoRequests handles fetching and processing video requests
-func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *api.AyoIndoClient, r2Client *storage.R2Storage) {
+func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *api.AyoIndoClient, r2Client storage.ObjectStore) {
 	// Reload configuration from database before processing
 	// This ensures we have the latest venue code and secret key
 	if err := ayoClient.ReloadConfigFromDatabase(); err != nil {
@@ -403,8 +358,8 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 				return
 			}
 
-			// Check if video is ready
-			if matchingVideo.Status != database.StatusReady {
+			// Check if video is ready (partial videos are usable, the duration check below decides)
+			if matchingVideo.Status != database.StatusReady && matchingVideo.Status != database.StatusPartial {
 				log.Printf("⏳ VIDEO-REQUEST-CRON-%d: Video for unique_id %s is not ready yet (status: %s)", cronID, uniqueID, matchingVideo.Status)
 				mutex.Lock()
 				videoRequestIDs = append(videoRequestIDs, videoRequestID)
@@ -427,7 +382,7 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 			}
 
 			// Upload video files to R2 if they haven't been uploaded yet
-			var r2HlsURL, r2MP4URL string
+			var r2HlsURL, r2MP4URL, r2DASHURL string
 
 			// Get the video path
 			videoPath := matchingVideo.LocalPath
@@ -592,6 +547,7 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 
 					// Create temporary MP4 file path for conversion
 					convertedMP4Path = filepath.Join(filepath.Dir(matchingVideo.LocalPath), fmt.Sprintf("%s_converted.mp4", uniqueID))
+					videoMetrics := metrics.NewVideoProcessingMetrics(uniqueID)
 
 					// Convert TS to MP4 with or without watermark based on real-time status
 					if !hasRealtimeWatermark && watermarkPath != "" {
@@ -610,11 +566,11 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 							positionStr = "top_right"
 						}
 						
-						if err := transcode.ConvertTSToMP4WithWatermark(matchingVideo.LocalPath, convertedMP4Path, watermarkPath, positionStr, margin); err != nil {
+						if err := transcode.ConvertTSToMP4WithWatermark(matchingVideo.LocalPath, convertedMP4Path, watermarkPath, positionStr, margin, videoMetrics); err != nil {
 							log.Printf("❌ ERROR: Failed to convert TS to MP4 with watermark: %v", err)
 							log.Printf("⚠️ Falling back to conversion without watermark")
 							// Fallback to conversion without watermark
-							if err := transcode.ConvertTSToMP4(matchingVideo.LocalPath, convertedMP4Path); err != nil {
+							if err := transcode.ConvertTSToMP4WithMetrics(matchingVideo.LocalPath, convertedMP4Path, videoMetrics); err != nil {
 								log.Printf("❌ ERROR: Failed to convert TS to MP4: %v", err)
 								db.UpdateVideoRequestID(uniqueID, videoRequestID, true)
 								return
@@ -625,7 +581,7 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 						}
 					} else {
 						// Convert without watermark (either already has real-time watermark or no watermark configured)
-						if err := transcode.ConvertTSToMP4(matchingVideo.LocalPath, convertedMP4Path); err != nil {
+						if err := transcode.ConvertTSToMP4WithMetrics(matchingVideo.LocalPath, convertedMP4Path, videoMetrics); err != nil {
 							log.Printf("❌ ERROR: Failed to convert TS to MP4: %v", err)
 							db.UpdateVideoRequestID(uniqueID, videoRequestID, true)
 							return
@@ -662,7 +618,7 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 				log.Printf("🚀 VIDEO-REQUEST-CRON-%d: HLS generation and MP4 processing completed, queue slot released (aktif: %d/%d)", cronID, newActive, maxConcurrent)
 
 				// Upload HLS ke R2 (tanpa menggunakan slot antrian)
-				_, r2HlsURLTemp, err := r2Client.UploadHLSStream(hlsDir, uniqueID)
+				_, r2HlsURLTemp, err := storage.UploadHLSStream(r2Client, db, hlsDir, uniqueID)
 				if err != nil {
 					log.Printf("❌ VIDEO-REQUEST-CRON-%d: Warning: Failed to upload HLS stream to R2: %v", cronID, err)
 					// Use existing R2 URL if upload fails
@@ -674,6 +630,17 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 					log.Printf("✅ VIDEO-REQUEST-CRON-%d: HLS stream uploaded to R2: %s", cronID, r2HlsURL)
 				}
 
+				// CMAF output also holds a DASH manifest, uploaded together with the HLS playlists
+				if _, err := os.Stat(filepath.Join(hlsDir, transcode.DASHManifestName)); err == nil {
+					dashURL := strings.TrimSuffix(hlsURL, transcode.HLSMasterName) + transcode.DASHManifestName
+					r2DASHURL = fmt.Sprintf("%s/%s/%s", r2Client.GetBaseURL(), r2HLSPath, transcode.DASHManifestName)
+					if err := db.UpdateVideoDASH(matchingVideo.ID, hlsDir, dashURL, r2HLSPath, r2DASHURL); err != nil {
+						log.Printf("⚠️ VIDEO-REQUEST-CRON-%d: Warning: Failed to update DASH manifest in database: %v", cronID, err)
+					} else {
+						log.Printf("✅ VIDEO-REQUEST-CRON-%d: DASH manifest uploaded to R2: %s", cronID, r2DASHURL)
+					}
+				}
+
 				// Update database with HLS path and URL information
 				// First update the R2 paths
 				err = db.UpdateVideoR2Paths(matchingVideo.ID, r2HLSPath, matchingVideo.R2MP4Path)
@@ -705,7 +672,7 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 
 				// Upload the file (either original MP4 or converted MP4) to R2
 				mp4Path := fmt.Sprintf("mp4/%s.mp4", uniqueID)
-				_, err = r2Client.UploadFile(uploadPath, mp4Path)
+				_, err = storage.UploadVideoArtifact(r2Client, db, matchingVideo.ID, uploadPath, mp4Path, nil)
 
 				if err != nil {
 					log.Printf("❌ ERROR: Failed to upload video to R2: %v", err)
@@ -755,7 +722,12 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 			}
 			// Check if r2MP4URL is corrupted or not accessible
 			log.Printf("🔍 VIDEO-REQUEST-CRON-%d: VALIDATION: Checking R2 MP4 URL integrity for %s", cronID, uniqueID)
-			if err := validateR2MP4URL(r2MP4URL); err != nil {
+			// Private buckets are only reachable through a presigned URL
+			validationURL, err := r2Client.PresignGetURL(fmt.Sprintf("mp4/%s.mp4", uniqueID), 5*time.Minute)
+			if err != nil {
+				validationURL = r2MP4URL
+			}
+			if err := validateR2MP4URL(validationURL); err != nil {
 				log.Printf("❌ VIDEO-REQUEST-CRON-%d: ERROR: R2 MP4 URL validation failed for %s: %v", cronID, uniqueID, err)
 
 				// Set database status to failed
@@ -771,8 +743,48 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 			}
 			log.Printf("✅ VIDEO-REQUEST-CRON-%d: VALIDATION: R2 MP4 URL validation passed for %s", cronID, uniqueID)
 
+			// Generate thumbnail sprite sheets + WebVTT scrub track (non-fatal if it fails)
+			spriteURL, spriteVTTURL := matchingVideo.R2SpriteURL, matchingVideo.R2SpriteVTTURL
+			if spriteVTTURL == "" {
+				spriteDir := filepath.Join(BaseDir, "sprites", uniqueID)
+				spriteURL, spriteVTTURL, err = service.GenerateAndUploadSprites(db, r2Client, matchingVideo.ID, videoPath, spriteDir)
+				if err != nil {
+					log.Printf("⚠️ VIDEO-REQUEST-CRON-%d: Warning: Failed to create sprite sheets for %s: %v", cronID, uniqueID, err)
+				} else {
+					log.Printf("✅ VIDEO-REQUEST-CRON-%d: Sprite sheets uploaded, WebVTT track: %s", cronID, spriteVTTURL)
+				}
+			}
+
+			// Upload the separate slow-motion replay of a button clip, if one was produced
+			slowMotionURL := matchingVideo.R2SlowMotionURL
+			if matchingVideo.SlowMotionPath != "" && slowMotionURL == "" {
+				slowMotionURL, err = uploadSlowMotionReplay(db, r2Client, matchingVideo)
+				if err != nil {
+					log.Printf("⚠️ VIDEO-REQUEST-CRON-%d: Warning: Failed to upload slow-motion replay for %s: %v", cronID, uniqueID, err)
+				} else {
+					log.Printf("✅ VIDEO-REQUEST-CRON-%d: Slow-motion replay uploaded: %s", cronID, slowMotionURL)
+				}
+			}
+
+			// The full video is now in the bucket, so copy it to the secondary buckets as well
+			if err := service.EnqueueReplication(db, matchingVideo.ID); err != nil {
+				log.Printf("⚠️ VIDEO-REQUEST-CRON-%d: Warning: Failed to queue replication of %s: %v", cronID, uniqueID, err)
+			}
+
+			// Only report the video once every file it references is verified in object storage
+			if verified, err := db.GetVideo(matchingVideo.ID); err != nil || verified == nil {
+				log.Printf("❌ VIDEO-REQUEST-CRON-%d: ERROR: Failed to reload video %s for verification: %v", cronID, uniqueID, err)
+				db.UpdateVideoRequestID(uniqueID, videoRequestID, true)
+				return
+			} else if unverified := service.UnverifiedArtifacts(r2Client, verified,
+				[]string{r2HlsURL, r2MP4URL, spriteURL, spriteVTTURL, slowMotionURL, r2DASHURL}); len(unverified) > 0 {
+				log.Printf("❌ VIDEO-REQUEST-CRON-%d: ERROR: Artifacts of %s not verified yet: %v", cronID, uniqueID, unverified)
+				db.UpdateVideoRequestID(uniqueID, videoRequestID, true)
+				return
+			}
+
 			// Send video data to AYO API
-			result, err := ayoClient.SaveVideo(
+			result, err := ayoClient.SaveVideoWithAssets(
 				videoRequestID,
 				bookingID,
 				matchingVideo.VideoType, // Assuming "clip" as video type, adjust if needed
@@ -780,6 +792,12 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 				r2MP4URL,
 				startTime,
 				endTime,
+				api.SaveVideoAssets{
+					ThumbnailSpriteURL: spriteURL,
+					ThumbnailVTTURL:    spriteVTTURL,
+					SlowMotionURL:      slowMotionURL,
+					DASHURL:            r2DASHURL,
+				},
 			)
 
 			if err != nil {
@@ -864,6 +882,25 @@ func processVideoRequests(cfg *config.Config, db database.Database, ayoClient *a
 	}
 }
 
+// uploadSlowMotionReplay uploads the separate slow-motion replay of a clip to R2 and stores its URL
+func uploadSlowMotionReplay(db database.Database, r2Client storage.ObjectStore, video *database.VideoMetadata) (string, error) {
+	if _, err := os.Stat(video.SlowMotionPath); err != nil {
+		return "", fmt.Errorf("slow-motion replay not found: %v", err)
+	}
+
+	r2Path := fmt.Sprintf("slowmo/%s.mp4", video.ID)
+	if _, err := storage.UploadVideoArtifact(r2Client, db, video.ID, video.SlowMotionPath, r2Path, nil); err != nil {
+		return "", err
+	}
+	r2URL := fmt.Sprintf("%s/%s", r2Client.GetBaseURL(), r2Path)
+
+	if err := db.UpdateVideoSlowMotion(video.ID, video.SlowMotionPath, r2Path, r2URL); err != nil {
+		log.Printf("⚠️ Warning: Failed to store slow-motion URL for %s: %v", video.ID, err)
+	}
+
+	return r2URL, nil
+}
+
 // validateR2MP4URL validates that the R2 MP4 URL is accessible and not corrupted
 func validateR2MP4URL(url string) error {
 	log.Printf("🔍 VALIDATION: Checking R2 MP4 URL: %s", url)
@@ -978,3 +1015,4 @@ func validateMP4Header(url string, client *http.Client) error {
 
 	return fmt.Errorf("invalid or corrupted MP4 header (read %d bytes)", n)
 }
+
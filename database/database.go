@@ -17,8 +17,24 @@ const (
 	StatusUnavailable VideoStatus = "unavailable" // Video has been auto-deleted
 	StatusCancelled   VideoStatus = "cancelled"   // Video processing cancelled
 	StatusInitial     VideoStatus = "initial"     // Video processing initial
+	StatusPartial     VideoStatus = "partial"     // Video is playable but parts of the booking window are missing
 )
 
+// MissingInterval is a part of a video's requested time range that could not be recovered
+type MissingInterval struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Seconds   float64   `json:"seconds"`
+}
+
+// VideoRendition is an additional version of a video reported to the AYO API (e.g. the vertical social export)
+type VideoRendition struct {
+	Name   string `json:"name"`   // Rendition name, e.g. "vertical"
+	URL    string `json:"url"`    // Public URL of the rendition
+	Width  int    `json:"width"`  // Width in pixels
+	Height int    `json:"height"` // Height in pixels
+}
+
 // VideoMetadata represents the metadata for a recorded video
 type VideoMetadata struct {
 	ID               string      `json:"id"`                  // Unique identifier for the video
@@ -57,6 +73,43 @@ type VideoMetadata struct {
 	StorageDiskID    string      `json:"storageDiskId"`       // ID of the storage disk where this video is stored
 	MP4FullPath      string      `json:"mp4FullPath"`         // Complete path to MP4 file including disk
 	DeprecatedHLS    bool        `json:"deprecatedHls"`       // Whether HLS files have been deprecated/cleaned up
+	R2SpritePath     string      `json:"r2SpritePath"`        // R2 path to thumbnail sprite sheet directory
+	R2SpriteURL      string      `json:"r2SpriteUrl"`         // R2 URL to first thumbnail sprite sheet
+	R2SpriteVTTURL   string      `json:"r2SpriteVttUrl"`      // R2 URL to WebVTT thumbnails track
+	PreviewTemplate  string      `json:"previewTemplate"`     // Name of the preview template that produced the preview
+	MissingIntervals []MissingInterval `json:"missingIntervals,omitempty"` // Unrecovered parts of the booking window (status "partial")
+	IntroSeconds     float64     `json:"introSeconds"`        // Length of the venue intro bumper at the start of the video
+	OutroSeconds     float64     `json:"outroSeconds"`        // Length of the venue outro bumper at the end of the video
+	SlowMotionPath   string      `json:"slowMotionPath"`      // Local path of the separate slow-motion replay (button clips)
+	R2SlowMotionPath string      `json:"r2SlowMotionPath"`    // R2 path of the separate slow-motion replay
+	R2SlowMotionURL  string      `json:"r2SlowMotionUrl"`     // R2 URL of the separate slow-motion replay
+	R2VerticalPath   string      `json:"r2VerticalPath"`      // R2 path of the vertical (9:16) rendition
+	R2VerticalURL    string      `json:"r2VerticalUrl"`       // R2 URL of the vertical (9:16) rendition
+	R2PreviewWebPPath string     `json:"r2PreviewWebpPath"`   // R2 path of the animated WebP preview
+	R2PreviewWebPURL  string     `json:"r2PreviewWebpUrl"`    // R2 URL of the animated WebP preview
+	R2PreviewGIFPath  string     `json:"r2PreviewGifPath"`    // R2 path of the animated GIF preview (fallback)
+	R2PreviewGIFURL   string     `json:"r2PreviewGifUrl"`     // R2 URL of the animated GIF preview (fallback)
+	DASHPath          string     `json:"dashPath"`            // Path to DASH stream directory (the HLS directory for CMAF output)
+	DASHURL           string     `json:"dashUrl"`             // URL to local DASH manifest
+	R2DASHPath        string     `json:"r2DashPath"`          // R2 path to DASH manifest
+	R2DASHURL         string     `json:"r2DashUrl"`           // R2 URL to DASH manifest
+	Checksums         map[string]string `json:"checksums,omitempty"` // Verified MD5 of the uploaded files, by object key
+	Replication       map[string]ReplicationStatus `json:"replication,omitempty"` // Replication to secondary buckets, by destination name
+}
+
+// Replication states of a video on a secondary bucket
+const (
+	ReplicationPending    = "pending"    // Queued, not copied yet
+	ReplicationReplicated = "replicated" // Every object of the video is stored on the destination
+	ReplicationFailed     = "failed"     // The last attempt failed, see Error
+)
+
+// ReplicationStatus is the state of a video on one secondary bucket
+type ReplicationStatus struct {
+	Status    string    `json:"status"`
+	Objects   int       `json:"objects,omitempty"` // Objects stored on the destination
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // CameraConfig represents camera configuration stored in the database
@@ -95,6 +148,26 @@ type StorageDisk struct {
 	CreatedAt        time.Time `json:"createdAt"`        // When this disk was added to the system
 }
 
+// Disk event types
+const (
+	DiskEventUnhealthy      = "unhealthy"       // A probe or FFmpeg found the disk read-only, missing or failing I/O
+	DiskEventRecovered      = "recovered"       // A disk that was unhealthy passes its probe again
+	DiskEventFailover       = "failover"        // Recording switched away from an unhealthy active disk
+	DiskEventFailoverFailed = "failover_failed" // No healthy disk was available to switch to
+)
+
+// DiskEvent records a health change of a storage disk or a failover between disks. Alerts need
+// attention and stay open until acknowledged.
+type DiskEvent struct {
+	ID           int       `json:"id"`
+	DiskID       string    `json:"diskId"`
+	EventType    string    `json:"eventType"`
+	Message      string    `json:"message"`
+	Alert        bool      `json:"alert"`
+	Acknowledged bool      `json:"acknowledged"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
 // ChunkType represents the type of recording segment
 type ChunkType string
 
@@ -131,6 +204,18 @@ type RecordingSegment struct {
 	IsWatermarked        bool             `json:"isWatermarked"`        // Whether this chunk/segment has watermark applied
 }
 
+// RecordingUsage is the total size of the segments or chunks a camera recorded to a disk on one
+// day, counted back from the time the usage was queried
+type RecordingUsage struct {
+	CameraName    string    `json:"cameraName"`
+	StorageDiskID string    `json:"storageDiskId"`
+	ChunkType     ChunkType `json:"chunkType"`
+	AgeDays       int       `json:"ageDays"`       // Whole days between the recordings and the query time
+	OldestAgeDays float64   `json:"oldestAgeDays"` // Age of the oldest recording in this group, in days
+	Bytes         int64     `json:"bytes"`
+	Segments      int       `json:"segments"`
+}
+
 // ChunkInfo represents metadata about a pre-concatenated chunk
 type ChunkInfo struct {
 	ID                   string           `json:"id"`
@@ -149,7 +234,7 @@ type ChunkInfo struct {
 // PendingTask represents a task waiting to be executed
 type PendingTask struct {
 	ID          int       `json:"id"`
-	TaskType    string    `json:"taskType"`    // "upload_r2", "notify_ayo_api"
+	TaskType    string    `json:"taskType"`    // "upload_r2", "notify_ayo_api", "delete_r2", "replicate", "recover_gaps"
 	TaskData    string    `json:"taskData"`    // JSON encoded task-specific data
 	Attempts    int       `json:"attempts"`    // Number of attempts made
 	MaxAttempts int       `json:"maxAttempts"` // Maximum number of attempts
@@ -164,6 +249,9 @@ type PendingTask struct {
 const (
 	TaskUploadR2     = "upload_r2"
 	TaskNotifyAyoAPI = "notify_ayo_api"
+	TaskDeleteR2     = "delete_r2"
+	TaskReplicate    = "replicate"
+	TaskRecoverGaps  = "recover_gaps"
 )
 
 // Task statuses
@@ -180,9 +268,54 @@ type R2UploadTaskData struct {
 	LocalMP4Path       string `json:"localMp4Path"`
 	LocalPreviewPath   string `json:"localPreviewPath"`
 	LocalThumbnailPath string `json:"localThumbnailPath"`
-	R2Key              string `json:"r2Key"`
 	R2PreviewKey       string `json:"r2PreviewKey"`
 	R2ThumbnailKey     string `json:"r2ThumbnailKey"`
+	LocalWebPPath      string `json:"localWebpPath,omitempty"` // Animated WebP preview
+	LocalGIFPath       string `json:"localGifPath,omitempty"`  // Animated GIF preview (fallback)
+}
+
+// R2DeleteTaskData represents data for the task deleting the objects of expired videos
+type R2DeleteTaskData struct {
+	VideoIDs []string `json:"videoIds"`
+}
+
+// ReplicationTaskData represents data for the task copying a delivered video to secondary buckets
+type ReplicationTaskData struct {
+	VideoID      string   `json:"videoId"`
+	Destinations []string `json:"destinations"`
+}
+
+// GapRecoveryTaskData represents data for the task retrying the recovery of recent gaps in a video
+type GapRecoveryTaskData struct {
+	VideoID string `json:"videoId"`
+}
+
+// RemoteDeletion records an object deleted from object storage when its video expired
+type RemoteDeletion struct {
+	ID        int       `json:"id"`
+	VideoID   string    `json:"videoId"`
+	ObjectKey string    `json:"objectKey"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// MultipartUpload is the persisted state of a resumable multipart upload to object storage
+type MultipartUpload struct {
+	UploadID    string    `json:"uploadId"`    // Upload ID issued by the object store
+	ObjectKey   string    `json:"objectKey"`   // Destination key, e.g. mp4/<video id>.mp4
+	LocalPath   string    `json:"localPath"`   // File being uploaded
+	FileSize    int64     `json:"fileSize"`    // Size of the file when the upload started
+	FileModTime time.Time `json:"fileModTime"` // Modification time of the file when the upload started
+	PartSize    int64     `json:"partSize"`    // Size of every part except the last
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"` // Last time a part completed
+}
+
+// MultipartUploadPart is a completed part of a multipart upload
+type MultipartUploadPart struct {
+	UploadID   string `json:"uploadId"`
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
 }
 
 // AyoAPINotifyTaskData represents data for AYO API notification task
@@ -249,6 +382,7 @@ const (
 	
 	// Video Processing Configuration
 	ConfigEnableVideoDurationCheck = "enable_video_duration_check"
+	ConfigSpriteIntervalSeconds    = "sprite_interval_seconds"
 	
 	// Disk Manager Configuration
 	ConfigMinimumFreeSpaceGB     = "minimum_free_space_gb"
@@ -326,6 +460,7 @@ type Database interface {
 	UpdateVideo(metadata VideoMetadata) error
 	UpdateLocalPathVideo(metadata VideoMetadata) error
 	ListVideos(limit, offset int) ([]VideoMetadata, error)
+	ListVideoStatuses() (map[string]VideoStatus, error)
 	DeleteVideo(id string) error
 
 	// Status operations
@@ -354,12 +489,16 @@ type Database interface {
 	UpdateDiskPath(id string, path string) error
 	SetActiveDisk(id string) error
 	GetStorageDisk(id string) (*StorageDisk, error)
+	CreateDiskEvent(event DiskEvent) error
+	GetDiskEvents(limit int, openAlertsOnly bool) ([]DiskEvent, error)
+	AcknowledgeDiskEvent(id int) error
 
 	// Recording segment operations
 	CreateRecordingSegment(segment RecordingSegment) error
 	GetRecordingSegments(cameraName string, start, end time.Time) ([]RecordingSegment, error)
 	DeleteRecordingSegment(id string) error
 	GetRecordingSegmentsByDisk(diskID string) ([]RecordingSegment, error)
+	GetRecordingUsage(now time.Time, maxAgeDays int) ([]RecordingUsage, error)
 
 	// Chunk operations
 	CreateChunk(chunk RecordingSegment) error
@@ -373,6 +512,16 @@ type Database interface {
 	// R2 storage operations
 	UpdateVideoR2Paths(id, hlsPath, mp4Path string) error
 	UpdateVideoR2URLs(id, hlsURL, mp4URL string) error
+	UpdateVideoSpriteURLs(id, spritePath, spriteURL, vttURL string) error
+	UpdateVideoPreviewTemplate(id, templateName string) error
+	UpdateVideoMissingIntervals(id string, intervals []MissingInterval) error
+	UpdateVideoBumpers(id string, introSeconds, outroSeconds float64) error
+	UpdateVideoSlowMotion(id, localPath, r2Path, r2URL string) error
+	UpdateVideoVerticalURL(id, r2Path, r2URL string) error
+	UpdateVideoAnimatedPreviews(id, webpPath, webpURL, gifPath, gifURL string) error
+	UpdateVideoDASH(id, dashPath, dashURL, r2DASHPath, r2DASHURL string) error
+	UpdateVideoChecksum(id, objectKey, checksum string) error
+	UpdateVideoReplication(id, destination string, status ReplicationStatus) error
 	UpdateVideoRequestID(id, requestId string, remove bool) error
 
 	// Offline queue operations
@@ -383,6 +532,18 @@ type Database interface {
 	DeleteCompletedTasks(olderThan time.Time) error
 	GetTaskByID(taskID int) (*PendingTask, error)
 
+	// Multipart upload operations
+	CreateMultipartUpload(upload MultipartUpload) error
+	GetMultipartUploadByKey(objectKey string) (*MultipartUpload, error)
+	GetMultipartUploadsUpdatedBefore(before time.Time) ([]MultipartUpload, error)
+	SaveMultipartUploadPart(part MultipartUploadPart) error
+	GetMultipartUploadParts(uploadID string) ([]MultipartUploadPart, error)
+	DeleteMultipartUpload(uploadID string) error
+
+	// Remote deletion operations
+	RecordRemoteDeletions(videoID string, objectKeys []string) error
+	GetRemoteDeletions(videoID string) ([]RemoteDeletion, error)
+
 	// Booking operations
 	CreateOrUpdateBooking(booking BookingData) error
 	GetBookingByID(bookingID string) (*BookingData, error)
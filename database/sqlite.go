@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -424,6 +425,165 @@ func initTables(db *sql.DB) error {
 		log.Printf("Success: Added end_time column to videos table")
 	}
 
+	// Add sprite sheet columns for thumbnail scrubbing
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_sprite_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_sprite_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_sprite_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_sprite_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_sprite_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_sprite_url column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_sprite_vtt_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_sprite_vtt_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_sprite_vtt_url column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN preview_template TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for preview_template: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added preview_template column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN missing_intervals TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for missing_intervals: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added missing_intervals column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN intro_seconds REAL")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for intro_seconds: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added intro_seconds column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN outro_seconds REAL")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for outro_seconds: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added outro_seconds column to videos table")
+	}
+
+	// Add slow-motion replay columns for button clips produced as a separate video
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN slow_motion_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for slow_motion_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added slow_motion_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_slow_motion_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_slow_motion_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_slow_motion_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_slow_motion_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_slow_motion_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_slow_motion_url column to videos table")
+	}
+
+	// Add vertical (9:16) rendition columns
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_vertical_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_vertical_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_vertical_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_vertical_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_vertical_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_vertical_url column to videos table")
+	}
+
+	// Add animated preview (WebP/GIF) columns
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_preview_webp_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_preview_webp_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_preview_webp_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_preview_webp_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_preview_webp_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_preview_webp_url column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_preview_gif_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_preview_gif_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_preview_gif_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_preview_gif_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_preview_gif_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_preview_gif_url column to videos table")
+	}
+
+	// Add DASH (CMAF) manifest columns
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN dash_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for dash_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added dash_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN dash_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for dash_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added dash_url column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_dash_path TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_dash_path: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_dash_path column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN r2_dash_url TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for r2_dash_url: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added r2_dash_url column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN checksums TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for checksums: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added checksums column to videos table")
+	}
+
+	_, migrationErr = db.Exec("ALTER TABLE videos ADD COLUMN replication TEXT")
+	if migrationErr != nil {
+		log.Printf("Info: Migration for replication: %v (ignore if column exists)", migrationErr)
+	} else {
+		log.Printf("Success: Added replication column to videos table")
+	}
+
 	// Create indexes
 	_, err = db.Exec(`
 		CREATE INDEX IF NOT EXISTS idx_videos_status ON videos (status)
@@ -554,6 +714,79 @@ func initTables(db *sql.DB) error {
 		return err
 	}
 
+	// Create multipart upload tables so interrupted uploads can resume from the last completed part
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS multipart_uploads (
+			upload_id TEXT PRIMARY KEY,
+			object_key TEXT NOT NULL,
+			local_path TEXT NOT NULL,
+			file_size INTEGER NOT NULL,
+			file_mod_time DATETIME NOT NULL,
+			part_size INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_multipart_uploads_object_key ON multipart_uploads (object_key)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS multipart_upload_parts (
+			upload_id TEXT NOT NULL,
+			part_number INTEGER NOT NULL,
+			etag TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			PRIMARY KEY (upload_id, part_number)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create remote deletions table recording the objects removed from object storage on expiry
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS remote_deletions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			video_id TEXT NOT NULL,
+			object_key TEXT NOT NULL,
+			deleted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_remote_deletions_video_id ON remote_deletions (video_id)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create disk events table recording disk health changes, failovers and alerts
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS disk_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			disk_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			message TEXT,
+			alert BOOLEAN DEFAULT 0,
+			acknowledged BOOLEAN DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create users table for authentication
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS users (
@@ -580,6 +813,7 @@ func initTables(db *sql.DB) error {
 		
 		// Video Duration Check Configuration
 		{"enable_video_duration_check", "false", "boolean"},
+		{"sprite_interval_seconds", "10", "int"},
 		
 		// Disk Manager Configuration
 		{"minimum_free_space_gb", "100", "int"},
@@ -827,6 +1061,12 @@ func (s *SQLiteDB) GetVideo(id string) (*VideoMetadata, error) {
 	var video VideoMetadata
 	var finishedAt, uploadedAt, lastCheckFile, startTime, endTime sql.NullTime
 	var cameraName, uniqueID, orderDetailID, bookingID, rawJSON, videoType, requestID, storageDiskID, mp4FullPath sql.NullString
+	var spritePath, spriteURL, spriteVTTURL, previewTemplate, missingIntervals sql.NullString
+	var introSeconds, outroSeconds sql.NullFloat64
+	var slowMotionPath, r2SlowMotionPath, r2SlowMotionURL sql.NullString
+	var r2VerticalPath, r2VerticalURL sql.NullString
+	var webpPath, webpURL, gifPath, gifURL sql.NullString
+	var dashPath, dashURL, r2DASHPath, r2DASHURL, checksums, replication sql.NullString
 	var deprecatedHLS sql.NullBool
 
 	err := s.db.QueryRow(`
@@ -834,7 +1074,11 @@ func (s *SQLiteDB) GetVideo(id string) (*VideoMetadata, error) {
 			r2_hls_path, r2_mp4_path, r2_hls_url, r2_mp4_url,
 			r2_preview_mp4_path, r2_preview_mp4_url, r2_preview_png_path, r2_preview_png_url,
 			unique_id, order_detail_id, booking_id, raw_json, status, error, created_at, finished_at, uploaded_at,
-			size, duration, resolution, has_request, last_check_file, video_type, request_id, storage_disk_id, mp4_full_path, deprecated_hls, start_time, end_time
+			size, duration, resolution, has_request, last_check_file, video_type, request_id, storage_disk_id, mp4_full_path, deprecated_hls, start_time, end_time,
+			r2_sprite_path, r2_sprite_url, r2_sprite_vtt_url, preview_template, missing_intervals, intro_seconds, outro_seconds,
+			slow_motion_path, r2_slow_motion_path, r2_slow_motion_url, r2_vertical_path, r2_vertical_url,
+			r2_preview_webp_path, r2_preview_webp_url, r2_preview_gif_path, r2_preview_gif_url,
+			dash_path, dash_url, r2_dash_path, r2_dash_url, checksums, replication
 		FROM videos WHERE id = ?`, id).Scan(
 		&video.ID,
 		&cameraName,
@@ -870,6 +1114,28 @@ func (s *SQLiteDB) GetVideo(id string) (*VideoMetadata, error) {
 		&deprecatedHLS,
 		&startTime,
 		&endTime,
+		&spritePath,
+		&spriteURL,
+		&spriteVTTURL,
+		&previewTemplate,
+		&missingIntervals,
+		&introSeconds,
+		&outroSeconds,
+		&slowMotionPath,
+		&r2SlowMotionPath,
+		&r2SlowMotionURL,
+		&r2VerticalPath,
+		&r2VerticalURL,
+		&webpPath,
+		&webpURL,
+		&gifPath,
+		&gifURL,
+		&dashPath,
+		&dashURL,
+		&r2DASHPath,
+		&r2DASHURL,
+		&checksums,
+		&replication,
 	)
 
 	if err == sql.ErrNoRows {
@@ -946,17 +1212,94 @@ func (s *SQLiteDB) GetVideo(id string) (*VideoMetadata, error) {
 		video.EndTime = &endTime.Time
 	}
 
+	// Set sprite sheet fields if valid
+	if spritePath.Valid {
+		video.R2SpritePath = spritePath.String
+	}
+	if spriteURL.Valid {
+		video.R2SpriteURL = spriteURL.String
+	}
+	if spriteVTTURL.Valid {
+		video.R2SpriteVTTURL = spriteVTTURL.String
+	}
+	if previewTemplate.Valid {
+		video.PreviewTemplate = previewTemplate.String
+	}
+	if missingIntervals.Valid && missingIntervals.String != "" {
+		if err := json.Unmarshal([]byte(missingIntervals.String), &video.MissingIntervals); err != nil {
+			log.Printf("Warning: Failed to parse missing intervals for video %s: %v", video.ID, err)
+		}
+	}
+	if introSeconds.Valid {
+		video.IntroSeconds = introSeconds.Float64
+	}
+	if outroSeconds.Valid {
+		video.OutroSeconds = outroSeconds.Float64
+	}
+	if slowMotionPath.Valid {
+		video.SlowMotionPath = slowMotionPath.String
+	}
+	if r2SlowMotionPath.Valid {
+		video.R2SlowMotionPath = r2SlowMotionPath.String
+	}
+	if r2SlowMotionURL.Valid {
+		video.R2SlowMotionURL = r2SlowMotionURL.String
+	}
+	if r2VerticalPath.Valid {
+		video.R2VerticalPath = r2VerticalPath.String
+	}
+	if r2VerticalURL.Valid {
+		video.R2VerticalURL = r2VerticalURL.String
+	}
+	if webpPath.Valid {
+		video.R2PreviewWebPPath = webpPath.String
+	}
+	if webpURL.Valid {
+		video.R2PreviewWebPURL = webpURL.String
+	}
+	if gifPath.Valid {
+		video.R2PreviewGIFPath = gifPath.String
+	}
+	if gifURL.Valid {
+		video.R2PreviewGIFURL = gifURL.String
+	}
+	if dashPath.Valid {
+		video.DASHPath = dashPath.String
+	}
+	if dashURL.Valid {
+		video.DASHURL = dashURL.String
+	}
+	if r2DASHPath.Valid {
+		video.R2DASHPath = r2DASHPath.String
+	}
+	if r2DASHURL.Valid {
+		video.R2DASHURL = r2DASHURL.String
+	}
+	if checksums.Valid && checksums.String != "" {
+		if err := json.Unmarshal([]byte(checksums.String), &video.Checksums); err != nil {
+			log.Printf("Warning: Failed to parse checksums for video %s: %v", video.ID, err)
+		}
+	}
+	if replication.Valid && replication.String != "" {
+		if err := json.Unmarshal([]byte(replication.String), &video.Replication); err != nil {
+			log.Printf("Warning: Failed to parse replication status for video %s: %v", video.ID, err)
+		}
+	}
+
 	return &video, nil
 }
 
+// UpdateLocalPathVideo updates the local path and status of a video. A cancelled video keeps
+// its status, so processing that finishes after a cancellation cannot revive it.
 func (s *SQLiteDB) UpdateLocalPathVideo(metadata VideoMetadata) error {
 	log.Printf("UpdateLocalPathVideo : Updating database entry for uniqueID: %s %s %s", metadata.LocalPath, metadata.Status, metadata.ID)
 	_, err := s.db.Exec(`
 		UPDATE videos SET
 			local_path = ?,
-			status = ?
+			status = CASE WHEN status = ? THEN status ELSE ? END
 		WHERE id = ?`,
 		metadata.LocalPath,
+		StatusCancelled,
 		metadata.Status,
 		metadata.ID,
 	)
@@ -1064,6 +1407,165 @@ func (s *SQLiteDB) UpdateVideoR2URLs(id, hlsURL, mp4URL string) error {
 	return s.UpdateVideo(*currentVideo)
 }
 
+// UpdateVideoSpriteURLs updates the thumbnail sprite sheet and WebVTT track locations for a video
+func (s *SQLiteDB) UpdateVideoSpriteURLs(id, spritePath, spriteURL, vttURL string) error {
+	_, err := s.db.Exec(`
+		UPDATE videos SET
+			r2_sprite_path = ?,
+			r2_sprite_url = ?,
+			r2_sprite_vtt_url = ?
+		WHERE id = ?`,
+		spritePath, spriteURL, vttURL, id,
+	)
+	return err
+}
+
+// UpdateVideoPreviewTemplate records which preview template produced the preview of a video
+func (s *SQLiteDB) UpdateVideoPreviewTemplate(id, templateName string) error {
+	_, err := s.db.Exec(`UPDATE videos SET preview_template = ? WHERE id = ?`, templateName, id)
+	return err
+}
+
+// UpdateVideoMissingIntervals stores the unrecovered parts of a video's time range.
+// An empty list clears them.
+func (s *SQLiteDB) UpdateVideoMissingIntervals(id string, intervals []MissingInterval) error {
+	var value interface{}
+	if len(intervals) > 0 {
+		intervalsJSON, err := json.Marshal(intervals)
+		if err != nil {
+			return fmt.Errorf("failed to encode missing intervals: %v", err)
+		}
+		value = string(intervalsJSON)
+	}
+
+	_, err := s.db.Exec(`UPDATE videos SET missing_intervals = ? WHERE id = ?`, value, id)
+	return err
+}
+
+// UpdateVideoBumpers records the length of the intro and outro bumpers attached to a video
+func (s *SQLiteDB) UpdateVideoBumpers(id string, introSeconds, outroSeconds float64) error {
+	_, err := s.db.Exec(`UPDATE videos SET intro_seconds = ?, outro_seconds = ? WHERE id = ?`, introSeconds, outroSeconds, id)
+	return err
+}
+
+// UpdateVideoSlowMotion records the local path and R2 location of a separately stored slow-motion replay
+func (s *SQLiteDB) UpdateVideoSlowMotion(id, localPath, r2Path, r2URL string) error {
+	_, err := s.db.Exec(`
+		UPDATE videos SET
+			slow_motion_path = ?,
+			r2_slow_motion_path = ?,
+			r2_slow_motion_url = ?
+		WHERE id = ?`,
+		localPath, r2Path, r2URL, id,
+	)
+	return err
+}
+
+// UpdateVideoVerticalURL records the R2 location of the vertical (9:16) rendition of a video
+func (s *SQLiteDB) UpdateVideoVerticalURL(id, r2Path, r2URL string) error {
+	_, err := s.db.Exec(`UPDATE videos SET r2_vertical_path = ?, r2_vertical_url = ? WHERE id = ?`, r2Path, r2URL, id)
+	return err
+}
+
+// UpdateVideoAnimatedPreviews records the R2 locations of the animated WebP and GIF previews of a video
+func (s *SQLiteDB) UpdateVideoAnimatedPreviews(id, webpPath, webpURL, gifPath, gifURL string) error {
+	_, err := s.db.Exec(`
+		UPDATE videos SET
+			r2_preview_webp_path = ?,
+			r2_preview_webp_url = ?,
+			r2_preview_gif_path = ?,
+			r2_preview_gif_url = ?
+		WHERE id = ?`,
+		webpPath, webpURL, gifPath, gifURL, id,
+	)
+	return err
+}
+
+// UpdateVideoDASH records the local and R2 locations of the DASH manifest of a video
+func (s *SQLiteDB) UpdateVideoDASH(id, dashPath, dashURL, r2DASHPath, r2DASHURL string) error {
+	_, err := s.db.Exec(`
+		UPDATE videos SET
+			dash_path = ?,
+			dash_url = ?,
+			r2_dash_path = ?,
+			r2_dash_url = ?
+		WHERE id = ?`,
+		dashPath, dashURL, r2DASHPath, r2DASHURL, id,
+	)
+	return err
+}
+
+// UpdateVideoChecksum records the verified MD5 of a file of a video uploaded to objectKey
+func (s *SQLiteDB) UpdateVideoChecksum(id, objectKey, checksum string) error {
+	var existing sql.NullString
+	if err := s.db.QueryRow(`SELECT checksums FROM videos WHERE id = ?`, id).Scan(&existing); err != nil {
+		return err
+	}
+
+	checksums := map[string]string{}
+	if existing.Valid && existing.String != "" {
+		if err := json.Unmarshal([]byte(existing.String), &checksums); err != nil {
+			log.Printf("Warning: Failed to parse checksums for video %s, starting over: %v", id, err)
+			checksums = map[string]string{}
+		}
+	}
+	checksums[objectKey] = checksum
+
+	checksumsJSON, err := json.Marshal(checksums)
+	if err != nil {
+		return fmt.Errorf("failed to encode checksums: %v", err)
+	}
+
+	_, err = s.db.Exec(`UPDATE videos SET checksums = ? WHERE id = ?`, string(checksumsJSON), id)
+	return err
+}
+
+// UpdateVideoReplication records the replication status of a video on a secondary bucket
+func (s *SQLiteDB) UpdateVideoReplication(id, destination string, status ReplicationStatus) error {
+	var existing sql.NullString
+	if err := s.db.QueryRow(`SELECT replication FROM videos WHERE id = ?`, id).Scan(&existing); err != nil {
+		return err
+	}
+
+	replication := map[string]ReplicationStatus{}
+	if existing.Valid && existing.String != "" {
+		if err := json.Unmarshal([]byte(existing.String), &replication); err != nil {
+			log.Printf("Warning: Failed to parse replication status for video %s, starting over: %v", id, err)
+			replication = map[string]ReplicationStatus{}
+		}
+	}
+	replication[destination] = status
+
+	replicationJSON, err := json.Marshal(replication)
+	if err != nil {
+		return fmt.Errorf("failed to encode replication status: %v", err)
+	}
+
+	_, err = s.db.Exec(`UPDATE videos SET replication = ? WHERE id = ?`, string(replicationJSON), id)
+	return err
+}
+
+// ListVideoStatuses returns the status of every video by ID, read in a single query so the
+// result is a consistent snapshot
+func (s *SQLiteDB) ListVideoStatuses() (map[string]VideoStatus, error) {
+	rows, err := s.db.Query(`SELECT id, status FROM videos`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list video statuses: %v", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]VideoStatus)
+	for rows.Next() {
+		var id string
+		var status VideoStatus
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, fmt.Errorf("failed to scan video status: %v", err)
+		}
+		statuses[id] = status
+	}
+	return statuses, rows.Err()
+}
+
 // ListVideos retrieves a list of videos with pagination
 func (s *SQLiteDB) ListVideos(limit, offset int) ([]VideoMetadata, error) {
 	rows, err := s.db.Query(`
@@ -1277,8 +1779,17 @@ func (s *SQLiteDB) UpdateVideoStatus(id string, status VideoStatus, errorMsg str
 	var updateSQL string
 	var args []interface{}
 
-	// If status is ready or failed, also update the finished_at time
-	if status == StatusReady || status == StatusFailed {
+	// A video with unrecovered missing intervals is never reported as fully ready
+	if status == StatusReady {
+		var missingIntervals sql.NullString
+		if err := s.db.QueryRow(`SELECT missing_intervals FROM videos WHERE id = ?`, id).Scan(&missingIntervals); err == nil &&
+			missingIntervals.Valid && missingIntervals.String != "" {
+			status = StatusPartial
+		}
+	}
+
+	// If status is ready, partial or failed, also update the finished_at time
+	if status == StatusReady || status == StatusPartial || status == StatusFailed {
 		now := time.Now()
 		updateSQL = `
 			UPDATE videos 
@@ -1337,7 +1848,7 @@ func (s *SQLiteDB) GetVideosByBookingID(bookingID string) ([]VideoMetadata, erro
 			r2_hls_path, r2_mp4_path, r2_hls_url, r2_mp4_url, 
 			r2_preview_mp4_path, r2_preview_mp4_url, r2_preview_png_path, r2_preview_png_url,
 			unique_id, order_detail_id, booking_id, raw_json, status, error, created_at, finished_at, uploaded_at,
-			size, duration, resolution, has_request, last_check_file, video_type
+			size, duration, resolution, has_request, last_check_file, video_type, start_time, end_time
 		FROM videos 
 		WHERE booking_id = ?
 		ORDER BY created_at DESC
@@ -1351,7 +1862,7 @@ func (s *SQLiteDB) GetVideosByBookingID(bookingID string) ([]VideoMetadata, erro
 	var videos []VideoMetadata
 	for rows.Next() {
 		var video VideoMetadata
-		var createdAt, finishedAt, uploadedAt, lastCheckFile sql.NullTime
+		var createdAt, finishedAt, uploadedAt, lastCheckFile, startTime, endTime sql.NullTime
 		var status string
 		var orderDetailID, resolution, videoType sql.NullString
 		var hasRequest sql.NullBool
@@ -1363,10 +1874,17 @@ func (s *SQLiteDB) GetVideosByBookingID(bookingID string) ([]VideoMetadata, erro
 			&video.UniqueID, &orderDetailID, &video.BookingID, &video.RawJSON, &status, &video.ErrorMessage,
 			&createdAt, &finishedAt, &uploadedAt,
 			&video.Size, &video.Duration, &resolution, &hasRequest, &lastCheckFile, &videoType,
+			&startTime, &endTime,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if startTime.Valid {
+			video.StartTime = &startTime.Time
+		}
+		if endTime.Valid {
+			video.EndTime = &endTime.Time
+		}
 
 		// Convert string status to VideoStatus enum
 		switch status {
@@ -1382,6 +1900,8 @@ func (s *SQLiteDB) GetVideosByBookingID(bookingID string) ([]VideoMetadata, erro
 			video.Status = StatusFailed
 		case "initial":
 			video.Status = StatusInitial
+		case "partial":
+			video.Status = StatusPartial
 		default:
 			video.Status = StatusPending
 		}
@@ -1438,6 +1958,12 @@ func (s *SQLiteDB) GetVideoByUniqueID(uniqueID string) (*VideoMetadata, error) {
 	var createdAt, finishedAt, uploadedAt, lastCheckFile sql.NullTime
 	var status string
 	var orderDetailID, resolution, videoType, requestID sql.NullString
+	var spritePath, spriteURL, spriteVTTURL, previewTemplate, missingIntervals sql.NullString
+	var introSeconds, outroSeconds sql.NullFloat64
+	var slowMotionPath, r2SlowMotionPath, r2SlowMotionURL sql.NullString
+	var r2VerticalPath, r2VerticalURL sql.NullString
+	var webpPath, webpURL, gifPath, gifURL sql.NullString
+	var dashPath, dashURL, r2DASHPath, r2DASHURL, checksums, replication sql.NullString
 	var hasRequest sql.NullBool
 
 	err := s.db.QueryRow(`
@@ -1446,7 +1972,11 @@ func (s *SQLiteDB) GetVideoByUniqueID(uniqueID string) (*VideoMetadata, error) {
 			r2_hls_path, r2_mp4_path, r2_hls_url, r2_mp4_url, 
 			r2_preview_mp4_path, r2_preview_mp4_url, r2_preview_png_path, r2_preview_png_url,
 			unique_id, order_detail_id, booking_id, raw_json, status, error, created_at, finished_at, uploaded_at,
-			size, duration, resolution, has_request, last_check_file, video_type, request_id, start_time, end_time
+			size, duration, resolution, has_request, last_check_file, video_type, request_id, start_time, end_time,
+			r2_sprite_path, r2_sprite_url, r2_sprite_vtt_url, preview_template, missing_intervals, intro_seconds, outro_seconds,
+			slow_motion_path, r2_slow_motion_path, r2_slow_motion_url, r2_vertical_path, r2_vertical_url,
+			r2_preview_webp_path, r2_preview_webp_url, r2_preview_gif_path, r2_preview_gif_url,
+			dash_path, dash_url, r2_dash_path, r2_dash_url, checksums, replication
 		FROM videos 
 		WHERE unique_id = ?
 	`, uniqueID).Scan(
@@ -1457,6 +1987,11 @@ func (s *SQLiteDB) GetVideoByUniqueID(uniqueID string) (*VideoMetadata, error) {
 		&createdAt, &finishedAt, &uploadedAt,
 		&video.Size, &video.Duration, &resolution, &hasRequest, &lastCheckFile, &videoType,
 		&requestID, &video.StartTime, &video.EndTime,
+		&spritePath, &spriteURL, &spriteVTTURL, &previewTemplate, &missingIntervals,
+		&introSeconds, &outroSeconds,
+		&slowMotionPath, &r2SlowMotionPath, &r2SlowMotionURL, &r2VerticalPath, &r2VerticalURL,
+		&webpPath, &webpURL, &gifPath, &gifURL,
+		&dashPath, &dashURL, &r2DASHPath, &r2DASHURL, &checksums, &replication,
 	)
 
 	if err != nil {
@@ -1478,6 +2013,8 @@ func (s *SQLiteDB) GetVideoByUniqueID(uniqueID string) (*VideoMetadata, error) {
 		video.Status = StatusReady
 	case "failed":
 		video.Status = StatusFailed
+	case "partial":
+		video.Status = StatusPartial
 	default:
 		video.Status = StatusPending
 	}
@@ -1515,6 +2052,79 @@ func (s *SQLiteDB) GetVideoByUniqueID(uniqueID string) (*VideoMetadata, error) {
 		video.RequestID = "" // Set default empty value for NULL request_id
 	}
 
+	if spritePath.Valid {
+		video.R2SpritePath = spritePath.String
+	}
+	if spriteURL.Valid {
+		video.R2SpriteURL = spriteURL.String
+	}
+	if spriteVTTURL.Valid {
+		video.R2SpriteVTTURL = spriteVTTURL.String
+	}
+	if previewTemplate.Valid {
+		video.PreviewTemplate = previewTemplate.String
+	}
+	if missingIntervals.Valid && missingIntervals.String != "" {
+		if err := json.Unmarshal([]byte(missingIntervals.String), &video.MissingIntervals); err != nil {
+			log.Printf("Warning: Failed to parse missing intervals for video %s: %v", video.ID, err)
+		}
+	}
+	if introSeconds.Valid {
+		video.IntroSeconds = introSeconds.Float64
+	}
+	if outroSeconds.Valid {
+		video.OutroSeconds = outroSeconds.Float64
+	}
+	if slowMotionPath.Valid {
+		video.SlowMotionPath = slowMotionPath.String
+	}
+	if r2SlowMotionPath.Valid {
+		video.R2SlowMotionPath = r2SlowMotionPath.String
+	}
+	if r2SlowMotionURL.Valid {
+		video.R2SlowMotionURL = r2SlowMotionURL.String
+	}
+	if r2VerticalPath.Valid {
+		video.R2VerticalPath = r2VerticalPath.String
+	}
+	if r2VerticalURL.Valid {
+		video.R2VerticalURL = r2VerticalURL.String
+	}
+	if webpPath.Valid {
+		video.R2PreviewWebPPath = webpPath.String
+	}
+	if webpURL.Valid {
+		video.R2PreviewWebPURL = webpURL.String
+	}
+	if gifPath.Valid {
+		video.R2PreviewGIFPath = gifPath.String
+	}
+	if gifURL.Valid {
+		video.R2PreviewGIFURL = gifURL.String
+	}
+	if dashPath.Valid {
+		video.DASHPath = dashPath.String
+	}
+	if dashURL.Valid {
+		video.DASHURL = dashURL.String
+	}
+	if r2DASHPath.Valid {
+		video.R2DASHPath = r2DASHPath.String
+	}
+	if r2DASHURL.Valid {
+		video.R2DASHURL = r2DASHURL.String
+	}
+	if checksums.Valid && checksums.String != "" {
+		if err := json.Unmarshal([]byte(checksums.String), &video.Checksums); err != nil {
+			log.Printf("Warning: Failed to parse checksums for video %s: %v", video.ID, err)
+		}
+	}
+	if replication.Valid && replication.String != "" {
+		if err := json.Unmarshal([]byte(replication.String), &video.Replication); err != nil {
+			log.Printf("Warning: Failed to parse replication status for video %s: %v", video.ID, err)
+		}
+	}
+
 	return &video, nil
 }
 
@@ -1708,6 +2318,60 @@ func (s *SQLiteDB) GetStorageDisk(id string) (*StorageDisk, error) {
 
 // Recording segment operations
 
+// CreateDiskEvent records a disk health change or failover
+func (s *SQLiteDB) CreateDiskEvent(event DiskEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO disk_events (disk_id, event_type, message, alert, acknowledged, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		event.DiskID, event.EventType, event.Message, event.Alert, event.Acknowledged, event.CreatedAt)
+	return err
+}
+
+// GetDiskEvents returns the most recent disk events, newest first; with openAlertsOnly only the
+// alerts that have not been acknowledged
+func (s *SQLiteDB) GetDiskEvents(limit int, openAlertsOnly bool) ([]DiskEvent, error) {
+	query := `
+		SELECT id, disk_id, event_type, message, alert, acknowledged, created_at
+		FROM disk_events`
+	if openAlertsOnly {
+		query += ` WHERE alert = 1 AND acknowledged = 0`
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []DiskEvent
+	for rows.Next() {
+		var event DiskEvent
+		var message sql.NullString
+		if err := rows.Scan(&event.ID, &event.DiskID, &event.EventType, &message, &event.Alert, &event.Acknowledged, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Message = message.String
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// AcknowledgeDiskEvent closes a disk alert
+func (s *SQLiteDB) AcknowledgeDiskEvent(id int) error {
+	result, err := s.db.Exec(`UPDATE disk_events SET acknowledged = 1 WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // CreateRecordingSegment creates a new recording segment record
 func (s *SQLiteDB) CreateRecordingSegment(segment RecordingSegment) error {
 	_, err := s.db.Exec(`
@@ -1772,6 +2436,34 @@ func (s *SQLiteDB) DeleteRecordingSegment(id string) error {
 	return err
 }
 
+// GetRecordingUsage sums the sizes of the segments and chunks recorded in the last maxAgeDays
+// days before now, per camera, disk, type and day of age
+func (s *SQLiteDB) GetRecordingUsage(now time.Time, maxAgeDays int) ([]RecordingUsage, error) {
+	rows, err := s.db.Query(`
+		SELECT camera_name, COALESCE(storage_disk_id, ''), COALESCE(chunk_type, 'segment'),
+			   CAST(julianday(?) - julianday(segment_start) AS INTEGER) AS age_days,
+			   MAX(julianday(?) - julianday(segment_start)),
+			   COALESCE(SUM(file_size_bytes), 0), COUNT(*)
+		FROM recording_segments
+		WHERE segment_start >= ?
+		GROUP BY camera_name, storage_disk_id, chunk_type, age_days
+	`, now, now, now.AddDate(0, 0, -maxAgeDays))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []RecordingUsage
+	for rows.Next() {
+		var u RecordingUsage
+		if err := rows.Scan(&u.CameraName, &u.StorageDiskID, &u.ChunkType, &u.AgeDays, &u.OldestAgeDays, &u.Bytes, &u.Segments); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
 // GetRecordingSegmentsByDisk retrieves all recording segments for a specific disk
 func (s *SQLiteDB) GetRecordingSegmentsByDisk(diskID string) ([]RecordingSegment, error) {
 	rows, err := s.db.Query(`
@@ -1953,6 +2645,162 @@ func (s *SQLiteDB) GetTaskByID(taskID int) (*PendingTask, error) {
 	return &task, nil
 }
 
+// CreateMultipartUpload records a started multipart upload
+func (s *SQLiteDB) CreateMultipartUpload(upload MultipartUpload) error {
+	_, err := s.db.Exec(`
+		INSERT INTO multipart_uploads (upload_id, object_key, local_path, file_size, file_mod_time, part_size, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		upload.UploadID, upload.ObjectKey, upload.LocalPath, upload.FileSize, upload.FileModTime.UTC(),
+		upload.PartSize, time.Now(), time.Now())
+	return err
+}
+
+// GetMultipartUploadByKey returns the latest unfinished multipart upload to an object key, nil if none
+func (s *SQLiteDB) GetMultipartUploadByKey(objectKey string) (*MultipartUpload, error) {
+	var upload MultipartUpload
+	err := s.db.QueryRow(`
+		SELECT upload_id, object_key, local_path, file_size, file_mod_time, part_size, created_at, updated_at
+		FROM multipart_uploads WHERE object_key = ?
+		ORDER BY created_at DESC LIMIT 1`, objectKey).Scan(
+		&upload.UploadID,
+		&upload.ObjectKey,
+		&upload.LocalPath,
+		&upload.FileSize,
+		&upload.FileModTime,
+		&upload.PartSize,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// GetMultipartUploadsUpdatedBefore returns the unfinished multipart uploads without progress since before
+func (s *SQLiteDB) GetMultipartUploadsUpdatedBefore(before time.Time) ([]MultipartUpload, error) {
+	rows, err := s.db.Query(`
+		SELECT upload_id, object_key, local_path, file_size, file_mod_time, part_size, created_at, updated_at
+		FROM multipart_uploads WHERE updated_at < ?
+		ORDER BY updated_at`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uploads []MultipartUpload
+	for rows.Next() {
+		var upload MultipartUpload
+		if err := rows.Scan(
+			&upload.UploadID,
+			&upload.ObjectKey,
+			&upload.LocalPath,
+			&upload.FileSize,
+			&upload.FileModTime,
+			&upload.PartSize,
+			&upload.CreatedAt,
+			&upload.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, rows.Err()
+}
+
+// SaveMultipartUploadPart records a completed part and marks its upload as progressing
+func (s *SQLiteDB) SaveMultipartUploadPart(part MultipartUploadPart) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO multipart_upload_parts (upload_id, part_number, etag, size)
+		VALUES (?, ?, ?, ?)`,
+		part.UploadID, part.PartNumber, part.ETag, part.Size)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`UPDATE multipart_uploads SET updated_at = ? WHERE upload_id = ?`, time.Now(), part.UploadID)
+	return err
+}
+
+// GetMultipartUploadParts returns the completed parts of a multipart upload ordered by part number
+func (s *SQLiteDB) GetMultipartUploadParts(uploadID string) ([]MultipartUploadPart, error) {
+	rows, err := s.db.Query(`
+		SELECT upload_id, part_number, etag, size
+		FROM multipart_upload_parts WHERE upload_id = ?
+		ORDER BY part_number`, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parts []MultipartUploadPart
+	for rows.Next() {
+		var part MultipartUploadPart
+		if err := rows.Scan(&part.UploadID, &part.PartNumber, &part.ETag, &part.Size); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, rows.Err()
+}
+
+// DeleteMultipartUpload removes a finished or aborted multipart upload and its parts
+func (s *SQLiteDB) DeleteMultipartUpload(uploadID string) error {
+	if _, err := s.db.Exec(`DELETE FROM multipart_upload_parts WHERE upload_id = ?`, uploadID); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM multipart_uploads WHERE upload_id = ?`, uploadID)
+	return err
+}
+
+// RecordRemoteDeletions records the objects of a video deleted from object storage
+func (s *SQLiteDB) RecordRemoteDeletions(videoID string, objectKeys []string) error {
+	if len(objectKeys) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, key := range objectKeys {
+		if _, err := tx.Exec(`INSERT INTO remote_deletions (video_id, object_key, deleted_at) VALUES (?, ?, ?)`,
+			videoID, key, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetRemoteDeletions returns the objects of a video deleted from object storage
+func (s *SQLiteDB) GetRemoteDeletions(videoID string) ([]RemoteDeletion, error) {
+	rows, err := s.db.Query(`
+		SELECT id, video_id, object_key, deleted_at
+		FROM remote_deletions WHERE video_id = ?
+		ORDER BY id`, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []RemoteDeletion
+	for rows.Next() {
+		var deletion RemoteDeletion
+		if err := rows.Scan(&deletion.ID, &deletion.VideoID, &deletion.ObjectKey, &deletion.DeletedAt); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, rows.Err()
+}
+
 // CreateOrUpdateBooking creates a new booking or updates existing one
 func (s *SQLiteDB) CreateOrUpdateBooking(booking BookingData) error {
 	// Check if booking already exists
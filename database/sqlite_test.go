@@ -303,12 +303,19 @@ func testUpdateVideoR2Paths(t *testing.T, db *SQLiteDB) {
 
 	// Update R2 paths
 	hlsPath := "hls/r2-path-test"
-	dashPath := "dash/r2-path-test"
-	err = db.UpdateVideoR2Paths("r2-path-test", hlsPath, dashPath)
+	mp4Path := "mp4/r2-path-test.mp4"
+	err = db.UpdateVideoR2Paths("r2-path-test", hlsPath, mp4Path)
 	if err != nil {
 		t.Fatalf("Failed to update R2 paths: %v", err)
 	}
 
+	// DASH locations are stored separately
+	dashPath := "hls/r2-path-test/manifest.mpd"
+	err = db.UpdateVideoDASH("r2-path-test", "/tmp/hls/r2-path-test", "", dashPath, "")
+	if err != nil {
+		t.Fatalf("Failed to update DASH paths: %v", err)
+	}
+
 	// Verify the update
 	video, err := db.GetVideo("r2-path-test")
 	if err != nil {
@@ -317,6 +324,9 @@ func testUpdateVideoR2Paths(t *testing.T, db *SQLiteDB) {
 	if video.R2HLSPath != hlsPath {
 		t.Errorf("Expected R2 HLS path %s, got %s", hlsPath, video.R2HLSPath)
 	}
+	if video.R2MP4Path != mp4Path {
+		t.Errorf("Expected R2 MP4 path %s, got %s", mp4Path, video.R2MP4Path)
+	}
 	if video.R2DASHPath != dashPath {
 		t.Errorf("Expected R2 DASH path %s, got %s", dashPath, video.R2DASHPath)
 	}
@@ -339,12 +349,19 @@ func testUpdateVideoR2URLs(t *testing.T, db *SQLiteDB) {
 
 	// Update R2 URLs
 	hlsURL := "https://example.r2.dev/hls/r2-url-test/playlist.m3u8"
-	dashURL := "https://example.r2.dev/dash/r2-url-test/manifest.mpd"
-	err = db.UpdateVideoR2URLs("r2-url-test", hlsURL, dashURL)
+	mp4URL := "https://example.r2.dev/mp4/r2-url-test.mp4"
+	err = db.UpdateVideoR2URLs("r2-url-test", hlsURL, mp4URL)
 	if err != nil {
 		t.Fatalf("Failed to update R2 URLs: %v", err)
 	}
 
+	// DASH locations are stored separately
+	dashURL := "https://example.r2.dev/hls/r2-url-test/manifest.mpd"
+	err = db.UpdateVideoDASH("r2-url-test", "", "", "", dashURL)
+	if err != nil {
+		t.Fatalf("Failed to update DASH URLs: %v", err)
+	}
+
 	// Verify the update
 	video, err := db.GetVideo("r2-url-test")
 	if err != nil {
@@ -353,6 +370,9 @@ func testUpdateVideoR2URLs(t *testing.T, db *SQLiteDB) {
 	if video.R2HLSURL != hlsURL {
 		t.Errorf("Expected R2 HLS URL %s, got %s", hlsURL, video.R2HLSURL)
 	}
+	if video.R2MP4URL != mp4URL {
+		t.Errorf("Expected R2 MP4 URL %s, got %s", mp4URL, video.R2MP4URL)
+	}
 	if video.R2DASHURL != dashURL {
 		t.Errorf("Expected R2 DASH URL %s, got %s", dashURL, video.R2DASHURL)
 	}
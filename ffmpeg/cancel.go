@@ -0,0 +1,100 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCancelled is returned for FFmpeg jobs of a video whose processing was cancelled
+var ErrCancelled = errors.New("video processing cancelled")
+
+// videoContext is the processing context shared by the FFmpeg jobs of one video
+type videoContext struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	cancelled bool
+	running   int // FFmpeg jobs currently using the context
+	updatedAt time.Time
+}
+
+var (
+	contextMutex  sync.Mutex
+	baseContext   = context.Background()
+	videoContexts = make(map[string]*videoContext)
+)
+
+// SetBaseContext makes every FFmpeg job a child of ctx, so cancelling it (e.g. on shutdown)
+// stops all running jobs
+func SetBaseContext(ctx context.Context) {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+	baseContext = ctx
+}
+
+// Cancel stops the running FFmpeg jobs of a video and makes later jobs for it fail with
+// ErrCancelled. Returns whether any job was running.
+func Cancel(videoID string) bool {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+
+	vc := videoContextLocked(videoID)
+	vc.cancelled = true
+	vc.updatedAt = time.Now()
+	vc.cancel()
+	return vc.running > 0
+}
+
+// IsCancelled reports whether the processing of a video was cancelled
+func IsCancelled(videoID string) bool {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+
+	vc, ok := videoContexts[videoID]
+	return ok && vc.cancelled
+}
+
+// acquireContext returns the context an FFmpeg job runs under and a function to call when the
+// job has finished. Jobs without a video ID only stop with the base context.
+func acquireContext(videoID string) (context.Context, func(), error) {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+
+	if videoID == "" {
+		return baseContext, func() {}, nil
+	}
+
+	vc := videoContextLocked(videoID)
+	if vc.cancelled {
+		return nil, nil, ErrCancelled
+	}
+	vc.running++
+
+	release := func() {
+		contextMutex.Lock()
+		defer contextMutex.Unlock()
+		vc.running--
+		vc.updatedAt = time.Now()
+	}
+	return vc.ctx, release, nil
+}
+
+// videoContextLocked returns the processing context of a video, creating it if needed, and drops
+// idle contexts older than finishedRetention. contextMutex must be held.
+func videoContextLocked(videoID string) *videoContext {
+	for id, vc := range videoContexts {
+		if vc.running == 0 && time.Since(vc.updatedAt) > finishedRetention {
+			vc.cancel()
+			delete(videoContexts, id)
+		}
+	}
+
+	vc, ok := videoContexts[videoID]
+	if !ok {
+		ctx, cancel := context.WithCancel(baseContext)
+		vc = &videoContext{ctx: ctx, cancel: cancel, updatedAt: time.Now()}
+		videoContexts[videoID] = vc
+	}
+	return vc
+}
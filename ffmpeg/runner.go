@@ -0,0 +1,277 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ayo-mwr/metrics"
+)
+
+// Processing stages reported by the runner
+const (
+	StageMerge      = "merge"
+	StageWatermark  = "watermark"
+	StagePreview    = "preview"
+	StageTranscode  = "transcode"
+	StageHLS        = "hls"
+	StageThumbnail  = "thumbnail"
+	StageSprite     = "sprite"
+	StageBumper     = "bumper"
+	StageSlowMotion = "slow_motion"
+	StageVertical   = "vertical"
+)
+
+// finishedRetention is how long the progress of a finished job stays queryable
+const finishedRetention = time.Hour
+
+// Job identifies an FFmpeg run for progress reporting
+type Job struct {
+	VideoID  string                          // Video the progress is reported under (defaults to Metrics.VideoID)
+	Stage    string                          // Processing stage, e.g. StageMerge
+	Duration float64                         // Expected output length in seconds; read from FFmpeg's input info when 0
+	Dir      string                          // Working directory of the command (current directory when empty)
+	Metrics  *metrics.VideoProcessingMetrics // Optional metrics fed with the same progress
+}
+
+// Progress is the live state of the FFmpeg job running for a video
+type Progress struct {
+	VideoID         string    `json:"videoId"`
+	Stage           string    `json:"stage"`
+	Percent         float64   `json:"percent"`         // 0-100, 0 while the output length is unknown
+	ETASeconds      float64   `json:"etaSeconds"`      // Estimated seconds until the stage finishes
+	Speed           float64   `json:"speed"`           // Encoding speed as a multiple of realtime
+	OutTimeSeconds  float64   `json:"outTimeSeconds"`  // Output written so far
+	DurationSeconds float64   `json:"durationSeconds"` // Expected output length
+	StartedAt       time.Time `json:"startedAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	Done            bool      `json:"done"`
+	Error           string    `json:"error,omitempty"`
+}
+
+var (
+	progressMutex sync.RWMutex
+	progressByID  = make(map[string]*Progress)
+
+	durationRegexp = regexp.MustCompile(`Duration: (\d+):(\d+):(\d+(?:\.\d+)?)`)
+)
+
+// Run executes ffmpeg with args, reporting progress for the job while it runs.
+// Returns FFmpeg's log output, like exec.Cmd.CombinedOutput. The process is killed when the
+// processing of the job's video is cancelled, in which case the error wraps ErrCancelled.
+func Run(job Job, args ...string) ([]byte, error) {
+	if job.VideoID == "" && job.Metrics != nil {
+		job.VideoID = job.Metrics.VideoID
+	}
+
+	ctx, release, err := acquireContext(job.VideoID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", append([]string{"-progress", "pipe:1", "-nostats"}, args...)...)
+	cmd.Dir = job.Dir
+
+	logOutput := &logBuffer{}
+	cmd.Stderr = logOutput
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &Progress{
+		VideoID:         job.VideoID,
+		Stage:           job.Stage,
+		DurationSeconds: job.Duration,
+		StartedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	setProgress(progress)
+
+	if err := cmd.Start(); err != nil {
+		finishProgress(job, progress, err)
+		return nil, err
+	}
+
+	readProgress(stdout, job, progress, logOutput)
+
+	err = cmd.Wait()
+	if err != nil && job.VideoID != "" && IsCancelled(job.VideoID) {
+		err = fmt.Errorf("%w: %v", ErrCancelled, err)
+	}
+	finishProgress(job, progress, err)
+	return logOutput.Bytes(), err
+}
+
+// readProgress parses the key=value blocks FFmpeg writes with -progress until the output closes
+func readProgress(r io.Reader, job Job, progress *Progress, logOutput *logBuffer) {
+	var outTime, speed float64
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "out_time_us", "out_time_ms": // Both are in microseconds
+			if us, err := strconv.ParseFloat(value, 64); err == nil && us > 0 {
+				outTime = us / 1e6
+			}
+		case "speed":
+			if s, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64); err == nil {
+				speed = s
+			}
+		case "progress":
+			// A block is complete; publish it
+			duration := job.Duration
+			if duration <= 0 {
+				duration = logOutput.inputDuration()
+			}
+			updateProgress(job, progress, outTime, duration, speed)
+		}
+	}
+}
+
+func updateProgress(job Job, progress *Progress, outTime, duration, speed float64) {
+	progressMutex.Lock()
+	progress.OutTimeSeconds = outTime
+	progress.DurationSeconds = duration
+	progress.Speed = speed
+	progress.UpdatedAt = time.Now()
+	if duration > 0 {
+		progress.Percent = outTime / duration * 100
+		if progress.Percent > 100 {
+			progress.Percent = 100
+		}
+
+		remaining := duration - outTime
+		if remaining < 0 {
+			remaining = 0
+		}
+		if speed > 0 {
+			progress.ETASeconds = remaining / speed
+		} else if outTime > 0 {
+			progress.ETASeconds = remaining * time.Since(progress.StartedAt).Seconds() / outTime
+		}
+	}
+	percent := progress.Percent
+	progressMutex.Unlock()
+
+	if job.Metrics != nil {
+		job.Metrics.RecordFFmpegProgress(job.Stage, percent, speed)
+	}
+}
+
+func finishProgress(job Job, progress *Progress, err error) {
+	progressMutex.Lock()
+	progress.Done = true
+	progress.UpdatedAt = time.Now()
+	progress.ETASeconds = 0
+	if err != nil {
+		progress.Error = err.Error()
+	} else {
+		progress.Percent = 100
+	}
+	percent, speed := progress.Percent, progress.Speed
+	progressMutex.Unlock()
+
+	if job.Metrics != nil {
+		job.Metrics.RecordFFmpegProgress(job.Stage, percent, speed)
+	}
+}
+
+// setProgress makes progress the current job of its video and drops old finished jobs
+func setProgress(progress *Progress) {
+	if progress.VideoID == "" {
+		return
+	}
+
+	progressMutex.Lock()
+	defer progressMutex.Unlock()
+
+	for id, p := range progressByID {
+		if p.Done && time.Since(p.UpdatedAt) > finishedRetention {
+			delete(progressByID, id)
+		}
+	}
+	progressByID[progress.VideoID] = progress
+}
+
+// GetProgress returns the progress of the latest FFmpeg job of a video
+func GetProgress(videoID string) (Progress, bool) {
+	progressMutex.RLock()
+	defer progressMutex.RUnlock()
+
+	progress, ok := progressByID[videoID]
+	if !ok {
+		return Progress{}, false
+	}
+	return *progress, true
+}
+
+// ActiveJobs returns the progress of every FFmpeg job still running, oldest first
+func ActiveJobs() []Progress {
+	progressMutex.RLock()
+	defer progressMutex.RUnlock()
+
+	jobs := []Progress{}
+	for _, progress := range progressByID {
+		if !progress.Done {
+			jobs = append(jobs, *progress)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.Before(jobs[j].StartedAt) })
+	return jobs
+}
+
+// logBuffer collects FFmpeg's log output and picks the input duration out of it
+type logBuffer struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	duration float64
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n, err := b.buf.Write(p)
+	if b.duration == 0 {
+		if match := durationRegexp.FindSubmatch(b.buf.Bytes()); match != nil {
+			b.duration = parseClock(string(match[1]), string(match[2]), string(match[3]))
+		}
+	}
+	return n, err
+}
+
+func (b *logBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
+
+// inputDuration returns the length of the first input FFmpeg reported, 0 until it is known
+func (b *logBuffer) inputDuration() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.duration
+}
+
+// parseClock converts an HH:MM:SS.ss clock to seconds
+func parseClock(hours, minutes, seconds string) float64 {
+	h, _ := strconv.ParseFloat(hours, 64)
+	m, _ := strconv.ParseFloat(minutes, 64)
+	s, _ := strconv.ParseFloat(seconds, 64)
+	return h*3600 + m*60 + s
+}
@@ -0,0 +1,54 @@
+package ffmpeg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ayo-mwr/metrics"
+)
+
+func TestReadProgress(t *testing.T) {
+	logOutput := &logBuffer{}
+	logOutput.Write([]byte("Input #0, mpegts, from 'in.ts':\n  Duration: 00:01:40.00, start: 1.400000, bitrate: 2000 kb/s\n"))
+
+	videoMetrics := metrics.NewVideoProcessingMetrics("video_1")
+	job := Job{VideoID: "video_1", Stage: StageWatermark, Metrics: videoMetrics}
+	progress := &Progress{VideoID: job.VideoID, Stage: job.Stage}
+	setProgress(progress)
+
+	readProgress(strings.NewReader("frame=750\nout_time_us=25000000\nspeed=2.5x\nprogress=continue\n"), job, progress, logOutput)
+
+	got, ok := GetProgress("video_1")
+	if !ok {
+		t.Fatal("expected progress for video_1")
+	}
+	if got.DurationSeconds != 100 {
+		t.Errorf("duration = %v, want 100", got.DurationSeconds)
+	}
+	if got.Percent != 25 {
+		t.Errorf("percent = %v, want 25", got.Percent)
+	}
+	if got.ETASeconds != 30 {
+		t.Errorf("eta = %v, want 30", got.ETASeconds)
+	}
+	if videoMetrics.StageSpeeds[StageWatermark] != 2.5 {
+		t.Errorf("metrics speed = %v, want 2.5", videoMetrics.StageSpeeds[StageWatermark])
+	}
+	if len(ActiveJobs()) != 1 {
+		t.Errorf("expected 1 active job, got %d", len(ActiveJobs()))
+	}
+}
+
+func TestRunAfterCancel(t *testing.T) {
+	if running := Cancel("video_2"); running {
+		t.Error("expected no running jobs for video_2")
+	}
+	if !IsCancelled("video_2") {
+		t.Fatal("expected video_2 to be cancelled")
+	}
+
+	if _, err := Run(Job{VideoID: "video_2", Stage: StageMerge}, "-version"); !errors.Is(err, ErrCancelled) {
+		t.Errorf("expected ErrCancelled, got %v", err)
+	}
+}
@@ -18,7 +18,9 @@ import (
 	"ayo-mwr/config"
 	"ayo-mwr/cron"
 	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
 	"ayo-mwr/monitoring"
+	"ayo-mwr/offline"
 	"ayo-mwr/recording"
 	"ayo-mwr/service"
 	"ayo-mwr/signaling"
@@ -210,36 +212,42 @@ func main() {
 		// Set AYO client for chunk processor watermarking
 		chunkCron.SetAyoClient(apiClient)
 		log.Println("Set AYO client for chunk processor watermarking")
-		// Start video cleanup cron job (every 24 hours)
-		// delay 10 seconds before first run
-		// time.Sleep(15 * time.Second)
-		log.Println("Starting video cleanup job...")
-		// Use the underlying *sql.DB for the scheduled job
-		cron.StartVideoCleanupJob(db.GetDB(), apiClient, cfg.AutoDelete, cfg.VenueCode)
-
-		// For testing: Immediately run the video cleanup function once
-		log.Println("Running immediate test of video cleanup function...")
-		go cron.CleanupExpiredVideosWithSQLiteDB(db, apiClient, cfg.AutoDelete, cfg.VenueCode)
 	}
 
-	// Initialize R2 storage with config
-	r2Config := storage.R2Config{
-		AccessKey: cfg.R2AccessKey,
-		SecretKey: cfg.R2SecretKey,
-		AccountID: cfg.R2AccountID,
-		Bucket:    cfg.R2Bucket,
-		Region:    cfg.R2Region,
-		Endpoint:  cfg.R2Endpoint,
-		BaseURL:   cfg.R2BaseURL, // Menggunakan R2_BASE_URL dari environment
+	// Apply the upload bandwidth schedule before any upload starts
+	if bandwidthConfig, err := config.NewUploadBandwidthConfigService(db).GetUploadBandwidthConfig(); err == nil {
+		storage.SetUploadBandwidth(bandwidthConfig)
 	}
-	r2Storage, err := storage.NewR2StorageWithConcurrency(r2Config, cfg.UploadWorkerConcurrency)
+
+	// Initialize object storage (R2 or local, selected in system config)
+	r2Storage, err := storage.NewObjectStore(&cfg, db, cfg.UploadWorkerConcurrency)
 	if err != nil {
-		log.Printf("Warning: Failed to initialize R2 storage: %v", err)
+		log.Printf("Warning: Failed to initialize object storage: %v", err)
 	}
 
 	// Initialize upload service with AYO API client
 	uploadService := service.NewUploadService(db, r2Storage, &cfg, apiClient)
 
+	// Start bucket reconciliation cron job (nightly at 4 AM)
+	cron.StartBucketReconciliationCron(db, r2Storage)
+
+	// Probe object storage so media is resolved from replicas while it is down (replication failover)
+	cron.StartPrimaryHealthCheckCron(db, r2Storage)
+
+	if apiClient != nil {
+		// Expired videos are deleted from object storage too, through the offline queue while offline
+		remoteDeleter := offline.NewQueueManager(db, uploadService, r2Storage, apiClient, &cfg)
+
+		// Start video cleanup cron job (every 24 hours)
+		log.Println("Starting video cleanup job...")
+		// Use the underlying *sql.DB for the scheduled job
+		cron.StartVideoCleanupJob(db.GetDB(), apiClient, remoteDeleter, cfg.AutoDelete, cfg.VenueCode)
+
+		// For testing: Immediately run the video cleanup function once
+		log.Println("Running immediate test of video cleanup function...")
+		go cron.CleanupExpiredVideosWithSQLiteDB(db, apiClient, remoteDeleter, cfg.AutoDelete, cfg.VenueCode)
+	}
+
 	// Initialize and start API server with chunk optimization
 	apiServer := api.NewServer(&cfg, db, r2Storage, uploadService, embeddedDashboardFS, diskManager)
 	go apiServer.Start()
@@ -256,18 +264,33 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Running FFmpeg processing jobs are stopped on shutdown
+	ffmpeg.SetBaseContext(ctx)
+
 	// Setup graceful shutdown handling
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	var wg sync.WaitGroup
 
+	// Benchmark the available encoders so each workload uses the fastest one.
+	// The stored results stay selected if no encoder completes the benchmark.
+	if _, err := recording.LoadEncoderBenchmark(db); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if _, err := recording.RunEncoderBenchmark(db); err != nil {
+		log.Printf("Warning: Encoder benchmark failed: %v", err)
+	}
+
 	// Initialize recording manager with disk change capabilities
 	fmt.Println("[MAIN] Starting recording manager")
 	recordingManager := recording.NewRecordingManager(&cfg, db, diskManager)
 	
 	// Register recording manager with disk manager for restart notifications
 	diskManager.SetRecordingManager(recordingManager)
+
+	// Probe disks every minute so a failing recording disk is replaced while recording
+	cron.StartDiskProbeCron(diskManager)
 	
 	// Start all cameras with recording manager
 	if err := recordingManager.StartAllCameras(); err != nil {
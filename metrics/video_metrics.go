@@ -24,6 +24,9 @@ type VideoProcessingMetrics struct {
 	UploadEndTime     *time.Time
 	UploadDuration    time.Duration
 	TotalDuration     time.Duration
+	CurrentStage      string             // FFmpeg stage running for the video
+	StageProgress     float64            // Percent complete of CurrentStage
+	StageSpeeds       map[string]float64 // Last encoding speed (x realtime) reported per FFmpeg stage
 	mu                sync.Mutex
 }
 
@@ -119,6 +122,20 @@ func (m *VideoProcessingMetrics) EndUpload() {
 	}
 }
 
+// RecordFFmpegProgress stores the latest progress reported by the FFmpeg runner for a stage
+func (m *VideoProcessingMetrics) RecordFFmpegProgress(stage string, percent, speed float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.CurrentStage = stage
+	m.StageProgress = percent
+	if speed > 0 {
+		if m.StageSpeeds == nil {
+			m.StageSpeeds = make(map[string]float64)
+		}
+		m.StageSpeeds[stage] = speed
+	}
+}
+
 // Finalize calculates total duration and logs summary
 func (m *VideoProcessingMetrics) Finalize() {
 	m.mu.Lock()
@@ -156,6 +173,10 @@ func (m *VideoProcessingMetrics) GetSummary() string {
 	if m.UploadDuration > 0 {
 		summary += fmt.Sprintf("  Upload to R2: %v\n", m.UploadDuration)
 	}
+
+	for stage, speed := range m.StageSpeeds {
+		summary += fmt.Sprintf("  FFmpeg %s speed: %.2fx\n", stage, speed)
+	}
 	
 	return summary
 }
@@ -18,8 +18,9 @@ type QueueManager struct {
 	db                 database.Database
 	connectivityChecker *ConnectivityChecker
 	uploadService      *service.UploadService
-	r2Storage          *storage.R2Storage
+	r2Storage          storage.ObjectStore
 	ayoClient          service.AyoAPIClient
+	hybridProcessor    *service.HybridVideoProcessor // Recovers deferred gaps, set by SetHybridProcessor
 	config             *config.Config
 	isRunning          bool
 	stopChan           chan struct{}
@@ -36,8 +37,13 @@ type QueueManager struct {
 }
 
 // NewQueueManager creates a new queue manager
-func NewQueueManager(db database.Database, uploadService *service.UploadService, r2Storage *storage.R2Storage, ayoClient service.AyoAPIClient, cfg *config.Config) *QueueManager {
+func NewQueueManager(db database.Database, uploadService *service.UploadService, r2Storage storage.ObjectStore, ayoClient service.AyoAPIClient, cfg *config.Config) *QueueManager {
 	maxConcurrency := cfg.PendingTaskWorkerConcurrency // Process max N tasks concurrently (configurable)
+
+	// Retried upload tasks resume from the last completed part instead of starting over
+	if r2, ok := r2Storage.(*storage.R2Storage); ok {
+		r2.EnableResumableUploads(db)
+	}
 	
 	return &QueueManager{
 		db:                  db,
@@ -78,6 +84,11 @@ func (qm *QueueManager) Start() {
 	go qm.cleanupLoop()
 }
 
+// SetHybridProcessor sets the processor used to recover deferred gaps of processed videos
+func (qm *QueueManager) SetHybridProcessor(hybridProcessor *service.HybridVideoProcessor) {
+	qm.hybridProcessor = hybridProcessor
+}
+
 // Stop stops the queue processing
 func (qm *QueueManager) Stop() {
 	if !qm.isRunning {
@@ -129,7 +140,7 @@ func (qm *QueueManager) processingLoop() {
 	}
 }
 
-// cleanupLoop removes old completed tasks
+// cleanupLoop removes old completed tasks and stale multipart uploads
 func (qm *QueueManager) cleanupLoop() {
 	ticker := time.NewTicker(24 * time.Hour) // Cleanup daily
 	defer ticker.Stop()
@@ -243,8 +254,8 @@ func (qm *QueueManager) processTask(task database.PendingTask) {
 				log.Printf("📦 QUEUE: ❌ Error getting video data: %v", err)
 				return
 			}
-			if video.Status == database.StatusFailed {
-				err := qm.db.UpdateTaskStatus(task.ID, database.TaskStatusFailed, fmt.Sprintf("video status failed: %s", taskData.VideoID))
+			if video.Status == database.StatusFailed || video.Status == database.StatusCancelled {
+				err := qm.db.UpdateTaskStatus(task.ID, database.TaskStatusFailed, fmt.Sprintf("video status %s: %s", video.Status, taskData.VideoID))
 				if err != nil {
 					log.Printf("📦 QUEUE: ❌ Error updating task status: %v", err)
 				}
@@ -268,6 +279,12 @@ func (qm *QueueManager) processTask(task database.PendingTask) {
 		processErr = qm.processR2UploadTask(task)
 	case database.TaskNotifyAyoAPI:
 		processErr = qm.processAyoAPINotifyTask(task)
+	case database.TaskDeleteR2:
+		processErr = qm.processR2DeleteTask(task)
+	case database.TaskReplicate:
+		processErr = qm.processReplicationTask(task)
+	case database.TaskRecoverGaps:
+		processErr = qm.processGapRecoveryTask(task)
 	default:
 		processErr = fmt.Errorf("unknown task type: %s", task.TaskType)
 	}
@@ -279,7 +296,9 @@ func (qm *QueueManager) processTask(task database.PendingTask) {
 	}
 }
 
-// processR2UploadTask processes R2 upload task using existing UploadProcessedVideo function
+// processR2UploadTask processes R2 upload task using existing UploadProcessedVideo function.
+// Uploads go through the resumable path, so a retry after a link drop or restart continues
+// from the last uploaded part.
 func (qm *QueueManager) processR2UploadTask(task database.PendingTask) error {
 	var taskData database.R2UploadTaskData
 	err := json.Unmarshal([]byte(task.TaskData), &taskData)
@@ -298,12 +317,12 @@ func (qm *QueueManager) processR2UploadTask(task database.PendingTask) error {
 	if video == nil {
 		return fmt.Errorf("video not found: %s", taskData.VideoID)
 	}
-	if video.Status == database.StatusFailed {
-		err := qm.db.UpdateTaskStatus(task.ID, database.TaskStatusFailed, fmt.Sprintf("video status failed: %s", taskData.VideoID))
+	if video.Status == database.StatusFailed || video.Status == database.StatusCancelled {
+		err := qm.db.UpdateTaskStatus(task.ID, database.TaskStatusFailed, fmt.Sprintf("video status %s: %s", video.Status, taskData.VideoID))
 		if err != nil {
 			log.Printf("📦 QUEUE: ❌ Error updating task status: %v", err)
 		}
-		return fmt.Errorf("video status failed: %s", taskData.VideoID)
+		return fmt.Errorf("video status %s: %s", video.Status, taskData.VideoID)
 	}
 
 	// Create BookingVideoService instance dengan dependencies yang diperlukan
@@ -321,6 +340,11 @@ func (qm *QueueManager) processR2UploadTask(task database.PendingTask) error {
 		return fmt.Errorf("error in UploadProcessedVideo: %v", err)
 	}
 
+	// Fall back to the animated previews stored with the task if they could not be regenerated
+	if updated, err := qm.db.GetVideo(taskData.VideoID); err == nil && updated != nil && updated.R2PreviewWebPURL == "" {
+		bookingVideoService.UploadAnimatedPreviews(taskData.VideoID, taskData.LocalWebPPath, taskData.LocalGIFPath, nil)
+	}
+
 	log.Printf("📦 QUEUE: ✅ Upload R2 berhasil untuk video %s", taskData.VideoID)
 	log.Printf("📦 QUEUE: - Preview URL: %s", previewURL)
 	log.Printf("📦 QUEUE: - Thumbnail URL: %s", thumbnailURL)
@@ -328,6 +352,125 @@ func (qm *QueueManager) processR2UploadTask(task database.PendingTask) error {
 	return nil
 }
 
+// processR2DeleteTask deletes the objects of expired videos that could not be deleted when they expired
+func (qm *QueueManager) processR2DeleteTask(task database.PendingTask) error {
+	var taskData database.R2DeleteTaskData
+	err := json.Unmarshal([]byte(task.TaskData), &taskData)
+	if err != nil {
+		return fmt.Errorf("error parsing R2 delete task data: %v", err)
+	}
+
+	log.Printf("📦 QUEUE: 🗑️ Hapus objek R2 untuk %d video kedaluwarsa...", len(taskData.VideoIDs))
+
+	// Videos deleted in an earlier attempt have no objects left, so retrying all of them is safe
+	if failed := qm.deleteVideoObjects(taskData.VideoIDs); len(failed) > 0 {
+		return fmt.Errorf("failed to delete objects of %d video(s): %v", len(failed), failed)
+	}
+
+	log.Printf("📦 QUEUE: ✅ Objek R2 berhasil dihapus untuk %d video", len(taskData.VideoIDs))
+	return nil
+}
+
+// processReplicationTask copies a delivered video to the secondary buckets. Objects copied in an
+// earlier attempt are not copied again.
+func (qm *QueueManager) processReplicationTask(task database.PendingTask) error {
+	var taskData database.ReplicationTaskData
+	err := json.Unmarshal([]byte(task.TaskData), &taskData)
+	if err != nil {
+		return fmt.Errorf("error parsing replication task data: %v", err)
+	}
+
+	log.Printf("📦 QUEUE: 🪞 Replikasi video %s ke %d bucket sekunder...", taskData.VideoID, len(taskData.Destinations))
+
+	if err := service.ReplicateVideo(qm.db, qm.r2Storage, taskData.VideoID, taskData.Destinations); err != nil {
+		return err
+	}
+
+	log.Printf("📦 QUEUE: ✅ Replikasi video %s selesai", taskData.VideoID)
+	return nil
+}
+
+// processGapRecoveryTask retries recovering the recent missing parts of a video that the
+// duration verifier left for a later pass
+func (qm *QueueManager) processGapRecoveryTask(task database.PendingTask) error {
+	var taskData database.GapRecoveryTaskData
+	err := json.Unmarshal([]byte(task.TaskData), &taskData)
+	if err != nil {
+		return fmt.Errorf("error parsing gap recovery task data: %v", err)
+	}
+	if qm.hybridProcessor == nil {
+		return fmt.Errorf("no video processor available to recover gaps")
+	}
+
+	log.Printf("📦 QUEUE: 🧩 Memulihkan bagian yang hilang dari video %s...", taskData.VideoID)
+
+	if err := qm.hybridProcessor.RecoverPendingGaps(taskData.VideoID); err != nil {
+		return err
+	}
+
+	log.Printf("📦 QUEUE: ✅ Pemulihan bagian video %s selesai", taskData.VideoID)
+	return nil
+}
+
+// DeleteExpiredVideos deletes the objects of expired videos from object storage and records what
+// was deleted. Videos that cannot be deleted now, e.g. while offline, are retried through the queue.
+func (qm *QueueManager) DeleteExpiredVideos(videoIDs []string) {
+	if len(videoIDs) == 0 {
+		return
+	}
+
+	failed := videoIDs
+	if qm.r2Storage == nil {
+		log.Printf("📦 QUEUE: ⚠️ Object storage tidak tersedia - hapus objek %d video ditunda", len(videoIDs))
+	} else if !qm.connectivityChecker.IsOnline() {
+		log.Printf("📦 QUEUE: 🌐 Offline - hapus objek %d video ditunda", len(videoIDs))
+	} else {
+		failed = qm.deleteVideoObjects(videoIDs)
+	}
+
+	if len(failed) > 0 {
+		if err := qm.EnqueueR2Delete(failed); err != nil {
+			log.Printf("📦 QUEUE: ❌ Error enqueue R2 delete: %v", err)
+		}
+	}
+}
+
+// deleteVideoObjects deletes and records the objects of videos, including their copies on the
+// replication destinations, returning the videos whose objects could not all be deleted
+func (qm *QueueManager) deleteVideoObjects(videoIDs []string) []string {
+	if qm.r2Storage == nil {
+		return videoIDs
+	}
+
+	replicas := service.Replicas(qm.db)
+	var failed []string
+	for _, videoID := range videoIDs {
+		replicaErr := false
+		for _, replica := range replicas {
+			if _, err := storage.DeleteObjectsWithPrefixes(replica.Store, storage.VideoObjectPrefixes(videoID)); err != nil {
+				log.Printf("📦 QUEUE: ❌ Error hapus objek video %s di replika %s: %v", videoID, replica.Name, err)
+				replicaErr = true
+			}
+		}
+		if replicaErr {
+			failed = append(failed, videoID)
+			continue
+		}
+
+		deleted, err := storage.DeleteObjectsWithPrefixes(qm.r2Storage, storage.VideoObjectPrefixes(videoID))
+		if recordErr := qm.db.RecordRemoteDeletions(videoID, deleted); recordErr != nil {
+			log.Printf("📦 QUEUE: ❌ Error mencatat objek terhapus untuk video %s: %v", videoID, recordErr)
+		}
+		if err != nil {
+			log.Printf("📦 QUEUE: ❌ Error hapus objek video %s: %v", videoID, err)
+			failed = append(failed, videoID)
+			continue
+		}
+		log.Printf("📦 QUEUE: 🗑️ %d objek dihapus untuk video %s", len(deleted), videoID)
+	}
+	return failed
+}
+
 // canProcessNotifyTask checks if notify task can be processed (upload must be completed first)
 func (qm *QueueManager) canProcessNotifyTask(notifyTask database.PendingTask) (bool, error) {
 	var taskData database.AyoAPINotifyTaskData
@@ -475,7 +618,7 @@ func (qm *QueueManager) handleTaskFailure(task database.PendingTask, taskErr err
 	}
 }
 
-// cleanupCompletedTasks removes old completed tasks
+// cleanupCompletedTasks removes old completed tasks and aborts stale multipart uploads
 func (qm *QueueManager) cleanupCompletedTasks() {
 	olderThan := time.Now().Add(-7 * 24 * time.Hour) // Remove tasks older than 7 days
 	
@@ -485,20 +628,35 @@ func (qm *QueueManager) cleanupCompletedTasks() {
 	} else {
 		log.Printf("📦 QUEUE: 🧹 Cleanup completed tasks selesai")
 	}
+
+	// Abort multipart uploads that stopped making progress so their parts don't linger in the bucket
+	if qm.r2Storage != nil {
+		aborted, err := storage.AbortStaleMultipartUploads(qm.r2Storage, qm.db, storage.StaleMultipartUploadAge)
+		if err != nil {
+			log.Printf("📦 QUEUE: ❌ Error abort stale multipart uploads: %v", err)
+		} else if aborted > 0 {
+			log.Printf("📦 QUEUE: 🧹 %d stale multipart upload dibatalkan", aborted)
+		}
+	}
 }
 
-// EnqueueR2Upload adds an R2 upload task to the queue
-func (qm *QueueManager) EnqueueR2Upload(videoID, localMP4Path, localPreviewPath, localThumbnailPath, r2Key, r2PreviewKey, r2ThumbnailKey string) error {
+// EnqueueR2Upload adds an R2 upload task to the queue. The video's object keys are chosen by
+// UploadProcessedVideo when the task runs.
+func (qm *QueueManager) EnqueueR2Upload(videoID, localMP4Path, localPreviewPath, localThumbnailPath, r2PreviewKey, r2ThumbnailKey string) error {
 	taskData := database.R2UploadTaskData{
 		VideoID:            videoID,
 		LocalMP4Path:       localMP4Path,
 		LocalPreviewPath:   localPreviewPath,
 		LocalThumbnailPath: localThumbnailPath,
-		R2Key:              r2Key,
 		R2PreviewKey:       r2PreviewKey,
 		R2ThumbnailKey:     r2ThumbnailKey,
 	}
 
+	// Animated previews live next to the preview MP4 so they survive until the queue uploads them
+	if localPreviewPath != "" {
+		taskData.LocalWebPPath, taskData.LocalGIFPath = service.AnimatedPreviewPaths(localPreviewPath)
+	}
+
 	taskDataJSON, err := json.Marshal(taskData)
 	if err != nil {
 		return fmt.Errorf("error marshaling R2 upload task data: %v", err)
@@ -560,6 +718,33 @@ func (qm *QueueManager) EnqueueAyoAPINotify(videoID, uniqueID, mp4URL, previewUR
 	return nil
 }
 
+// EnqueueR2Delete adds a task deleting the objects of expired videos to the queue
+func (qm *QueueManager) EnqueueR2Delete(videoIDs []string) error {
+	taskDataJSON, err := json.Marshal(database.R2DeleteTaskData{VideoIDs: videoIDs})
+	if err != nil {
+		return fmt.Errorf("error marshaling R2 delete task data: %v", err)
+	}
+
+	task := database.PendingTask{
+		TaskType:    database.TaskDeleteR2,
+		TaskData:    string(taskDataJSON),
+		Attempts:    0,
+		MaxAttempts: 5,
+		NextRetryAt: time.Now(),
+		Status:      database.TaskStatusPending,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	err = qm.db.CreatePendingTask(task)
+	if err != nil {
+		return fmt.Errorf("error creating R2 delete task: %v", err)
+	}
+
+	log.Printf("📦 QUEUE: ➕ Task hapus R2 ditambahkan untuk %d video", len(videoIDs))
+	return nil
+}
+
 // GetQueueStats returns statistics about the queue
 func (qm *QueueManager) GetQueueStats() (map[string]interface{}, error) {
 	qm.tasksMutex.RLock()
@@ -585,6 +770,7 @@ func (qm *QueueManager) GetQueueStats() (map[string]interface{}, error) {
 		"last_process_time":  lastProcessTime.Format("2006-01-02 15:04:05"),
 		"is_running":         qm.isRunning,
 		"processing_mode":    "async",
+		"upload_bandwidth":   storage.UploadBandwidthStatus(),
 	}
 	
 	return stats, nil
@@ -0,0 +1,354 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ayo-mwr/ffmpeg"
+)
+
+// Bumper files are stored in ./{storage}/bumper/{venue_code}. A bumper uploaded through the
+// admin API ({kind}_custom.mp4) takes precedence over the one downloaded from the AYO API
+// ({kind}.mp4). Normalised copies for each output format are cached in the "normalized" subfolder.
+
+// BumperFolder returns the folder holding the bumpers of a venue
+func BumperFolder(storagePath, venueCode string) string {
+	return filepath.Join(storagePath, "bumper", venueCode)
+}
+
+// BumperDownloadPath returns where a bumper downloaded from the AYO API is stored
+func BumperDownloadPath(storagePath, venueCode, kind string) string {
+	return filepath.Join(BumperFolder(storagePath, venueCode), kind+".mp4")
+}
+
+// BumperCustomPath returns where a bumper uploaded through the admin API is stored
+func BumperCustomPath(storagePath, venueCode, kind string) string {
+	return filepath.Join(BumperFolder(storagePath, venueCode), kind+"_custom.mp4")
+}
+
+// SaveCustomBumper stores an uploaded bumper video after checking that ffprobe can read it
+func SaveCustomBumper(storagePath, venueCode, kind string, src io.Reader) (string, error) {
+	folder := BumperFolder(storagePath, venueCode)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", folder, err)
+	}
+
+	tmpPath := filepath.Join(folder, kind+"_upload.tmp")
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create bumper file: %w", err)
+	}
+	_, err = io.Copy(f, src)
+	f.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to save bumper file: %w", err)
+	}
+
+	format, err := ProbeVideoFormat(tmpPath)
+	if err != nil || format.Width == 0 {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("uploaded file is not a readable video: %v", err)
+	}
+
+	path := BumperCustomPath(storagePath, venueCode, kind)
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to store bumper file: %w", err)
+	}
+
+	log.Printf("SaveCustomBumper : Stored %s bumper for venue %s (%dx%d, %.2fs)", kind, venueCode, format.Width, format.Height, format.Duration)
+	return path, nil
+}
+
+// VideoFormat describes the stream parameters a bumper must match to be concatenated without re-encoding
+type VideoFormat struct {
+	Width         int     `json:"width"`
+	Height        int     `json:"height"`
+	FrameRate     string  `json:"frameRate"`  // As reported by ffprobe, e.g. "25/1"
+	VideoCodec    string  `json:"videoCodec"` // ffprobe codec name, e.g. "h264" or "hevc"
+	Profile       string  `json:"profile"`    // ffprobe profile name, e.g. "High"
+	PixelFormat   string  `json:"pixelFormat"`
+	HasAudio      bool    `json:"hasAudio"`
+	AudioCodec    string  `json:"audioCodec"`
+	SampleRate    int     `json:"sampleRate"`
+	ChannelLayout string  `json:"channelLayout"`
+	Duration      float64 `json:"duration"`
+}
+
+// ProbeVideoFormat reads the video and audio stream parameters of a file with ffprobe
+func ProbeVideoFormat(path string) (*VideoFormat, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "stream=codec_type,codec_name,profile,pix_fmt,width,height,r_frame_rate,sample_rate,channel_layout:format=duration",
+		"-of", "json",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed for %s: %v", path, err)
+	}
+	return parseVideoFormat(output, path)
+}
+
+// parseVideoFormat reads the ffprobe JSON output of ProbeVideoFormat
+func parseVideoFormat(output []byte, path string) (*VideoFormat, error) {
+	var probe struct {
+		Streams []struct {
+			CodecType     string `json:"codec_type"`
+			CodecName     string `json:"codec_name"`
+			Profile       string `json:"profile"`
+			PixFmt        string `json:"pix_fmt"`
+			Width         int    `json:"width"`
+			Height        int    `json:"height"`
+			RFrameRate    string `json:"r_frame_rate"`
+			SampleRate    string `json:"sample_rate"`
+			ChannelLayout string `json:"channel_layout"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	format := &VideoFormat{}
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if format.Width == 0 {
+				format.Width = stream.Width
+				format.Height = stream.Height
+				format.FrameRate = stream.RFrameRate
+				format.VideoCodec = stream.CodecName
+				format.Profile = stream.Profile
+				format.PixelFormat = stream.PixFmt
+			}
+		case "audio":
+			if !format.HasAudio {
+				format.HasAudio = true
+				format.AudioCodec = stream.CodecName
+				format.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+				format.ChannelLayout = stream.ChannelLayout
+			}
+		}
+	}
+	format.Duration, _ = strconv.ParseFloat(strings.TrimSpace(probe.Format.Duration), 64)
+
+	if format.Width == 0 || format.Height == 0 {
+		return format, fmt.Errorf("no video stream found in %s", path)
+	}
+	return format, nil
+}
+
+// videoEncoders maps ffprobe codec names to the encoder producing that codec
+var videoEncoders = map[string]string{
+	"h264": "libx264",
+	"hevc": "libx265",
+}
+
+// audioEncoders maps ffprobe codec names to the encoder producing that codec
+var audioEncoders = map[string]string{
+	"aac":  "aac",
+	"mp3":  "libmp3lame",
+	"opus": "libopus",
+}
+
+// encoderProfiles maps ffprobe profile names to the profile names of each encoder
+var encoderProfiles = map[string]map[string]string{
+	"libx264": {
+		"Constrained Baseline": "baseline",
+		"Baseline":             "baseline",
+		"Main":                 "main",
+		"High":                 "high",
+		"High 10":              "high10",
+		"High 4:2:2":           "high422",
+	},
+	"libx265": {
+		"Main":    "main",
+		"Main 10": "main10",
+	},
+}
+
+// EncoderArgs returns the FFmpeg output arguments that encode video and audio with the same codec,
+// profile and pixel format as this format, so the result can be stream-copied next to it.
+// MPEG-TS output always uses a 90kHz time base, so time bases match as well.
+func (f VideoFormat) EncoderArgs() []string {
+	encoder, ok := videoEncoders[f.VideoCodec]
+	if !ok {
+		encoder = "libx264"
+	}
+	pixelFormat := f.PixelFormat
+	if pixelFormat == "" {
+		pixelFormat = "yuv420p"
+	}
+
+	args := []string{"-c:v", encoder, "-preset", "veryfast", "-crf", "23"}
+	if profile, ok := encoderProfiles[encoder][f.Profile]; ok {
+		args = append(args, "-profile:v", profile)
+	}
+	args = append(args, "-pix_fmt", pixelFormat)
+
+	if f.HasAudio {
+		audioEncoder, ok := audioEncoders[f.AudioCodec]
+		if !ok {
+			audioEncoder = "aac"
+		}
+		args = append(args, "-c:a", audioEncoder)
+		if f.SampleRate > 0 {
+			args = append(args, "-ar", strconv.Itoa(f.SampleRate))
+		}
+		if f.ChannelLayout != "" {
+			args = append(args, "-channel_layout", f.ChannelLayout)
+		}
+	}
+	return args
+}
+
+// cacheKey identifies the output format in normalised bumper file names
+func (f VideoFormat) cacheKey() string {
+	frameRate := f.FrameRate
+	if frameRate == "" || frameRate == "0/0" {
+		frameRate = "25"
+	}
+	key := fmt.Sprintf("%s_%s_%s_%dx%d_%s", f.VideoCodec, f.Profile, f.PixelFormat, f.Width, f.Height, frameRate)
+	if f.HasAudio {
+		key += fmt.Sprintf("_%s%d_%s", f.AudioCodec, f.SampleRate, f.ChannelLayout)
+	}
+	return strings.NewReplacer("/", "-", " ", "", ".", "", ":", "").Replace(key)
+}
+
+// NormalizeBumper re-encodes a bumper to the resolution, frame rate and audio layout of the main
+// video so the two can be concatenated with stream copy. The result is cached next to the bumper
+// and reused until the bumper file changes.
+func NormalizeBumper(videoID, bumperPath string, target VideoFormat) (string, error) {
+	source, err := os.Stat(bumperPath)
+	if err != nil {
+		return "", fmt.Errorf("bumper not found: %w", err)
+	}
+
+	frameRate := target.FrameRate
+	if frameRate == "" || frameRate == "0/0" {
+		frameRate = "25"
+	}
+
+	cacheDir := filepath.Join(filepath.Dir(bumperPath), "normalized")
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", cacheDir, err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(bumperPath), filepath.Ext(bumperPath))
+	outputPath := filepath.Join(cacheDir, base+"_"+target.cacheKey()+".ts")
+
+	if cached, err := os.Stat(outputPath); err == nil && cached.ModTime().After(source.ModTime()) {
+		return outputPath, nil
+	}
+
+	bumperFormat, err := ProbeVideoFormat(bumperPath)
+	if err != nil {
+		return "", err
+	}
+
+	tmpPath := outputPath + ".tmp"
+	args := normalizeBumperArgs(bumperPath, tmpPath, *bumperFormat, target)
+
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StageBumper,
+		Duration: bumperFormat.Duration,
+	}, args...)
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to normalise bumper: %v, output: %s", err, string(output))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return "", fmt.Errorf("failed to store normalised bumper: %w", err)
+	}
+
+	log.Printf("NormalizeBumper : Normalised %s to %dx%d@%s -> %s", bumperPath, target.Width, target.Height, frameRate, outputPath)
+	return outputPath, nil
+}
+
+// normalizeBumperArgs builds the FFmpeg arguments that re-encode a bumper to the target format
+func normalizeBumperArgs(bumperPath, outputPath string, bumperFormat, target VideoFormat) []string {
+	frameRate := target.FrameRate
+	if frameRate == "" || frameRate == "0/0" {
+		frameRate = "25"
+	}
+
+	filter := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%s",
+		target.Width, target.Height, target.Width, target.Height, frameRate)
+
+	args := []string{"-y", "-v", "warning", "-i", bumperPath}
+	if target.HasAudio && !bumperFormat.HasAudio {
+		// Add silence so the bumper has the same streams as the main video
+		layout := target.ChannelLayout
+		if layout == "" {
+			layout = "stereo"
+		}
+		sampleRate := target.SampleRate
+		if sampleRate <= 0 {
+			sampleRate = 48000
+		}
+		args = append(args, "-f", "lavfi", "-i", fmt.Sprintf("anullsrc=r=%d:cl=%s", sampleRate, layout))
+	}
+	args = append(args, "-vf", filter, "-map", "0:v:0")
+
+	if target.HasAudio {
+		if bumperFormat.HasAudio {
+			args = append(args, "-map", "0:a:0")
+		} else {
+			args = append(args, "-map", "1:a:0", "-shortest")
+		}
+	} else {
+		args = append(args, "-an")
+	}
+
+	args = append(args, target.EncoderArgs()...)
+	return append(args, "-f", "mpegts", outputPath)
+}
+
+// AttachBumpers writes introPath + videoPath + outroPath to outputPath using stream copy.
+// Either bumper may be empty. The bumpers must already be normalised to the video's format.
+func AttachBumpers(videoID, videoPath, introPath, outroPath, outputPath string) error {
+	var list strings.Builder
+	for _, path := range []string{introPath, videoPath, outroPath} {
+		if path == "" {
+			continue
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(absPath, "'", "'\\''"))
+	}
+
+	listPath := outputPath + ".txt"
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write bumper concat list: %w", err)
+	}
+	defer os.Remove(listPath)
+
+	output, err := ffmpeg.Run(ffmpeg.Job{VideoID: videoID, Stage: ffmpeg.StageBumper},
+		"-y",
+		"-v", "warning",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listPath,
+		"-c", "copy",
+		"-f", "mpegts",
+		outputPath,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to attach bumpers: %v, output: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package recording
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const probeOutput = `{
+	"streams": [
+		{"codec_type": "video", "codec_name": "h264", "profile": "High", "pix_fmt": "yuvj420p", "width": 1920, "height": 1080, "r_frame_rate": "30/1"},
+		{"codec_type": "audio", "codec_name": "aac", "sample_rate": "44100", "channel_layout": "mono"}
+	],
+	"format": {"duration": "62.500000"}
+}`
+
+func TestParseVideoFormat(t *testing.T) {
+	format, err := parseVideoFormat([]byte(probeOutput), "video.ts")
+	if err != nil {
+		t.Fatalf("parseVideoFormat failed: %v", err)
+	}
+
+	want := &VideoFormat{
+		Width: 1920, Height: 1080, FrameRate: "30/1",
+		VideoCodec: "h264", Profile: "High", PixelFormat: "yuvj420p",
+		HasAudio: true, AudioCodec: "aac", SampleRate: 44100, ChannelLayout: "mono",
+		Duration: 62.5,
+	}
+	if !reflect.DeepEqual(format, want) {
+		t.Errorf("parseVideoFormat = %+v, want %+v", format, want)
+	}
+
+	if _, err := parseVideoFormat([]byte(`{"streams": [{"codec_type": "audio"}]}`), "audio.aac"); err == nil {
+		t.Errorf("expected error for a file without video")
+	}
+}
+
+func TestVideoFormatEncoderArgs(t *testing.T) {
+	cases := []struct {
+		name   string
+		format VideoFormat
+		want   string
+	}{
+		{
+			name:   "h264 high with audio",
+			format: VideoFormat{VideoCodec: "h264", Profile: "High", PixelFormat: "yuvj420p", HasAudio: true, AudioCodec: "aac", SampleRate: 44100, ChannelLayout: "mono"},
+			want:   "-c:v libx264 -preset veryfast -crf 23 -profile:v high -pix_fmt yuvj420p -c:a aac -ar 44100 -channel_layout mono",
+		},
+		{
+			name:   "hevc main 10",
+			format: VideoFormat{VideoCodec: "hevc", Profile: "Main 10", PixelFormat: "yuv420p10le"},
+			want:   "-c:v libx265 -preset veryfast -crf 23 -profile:v main10 -pix_fmt yuv420p10le",
+		},
+		{
+			name:   "unknown codec falls back to h264",
+			format: VideoFormat{VideoCodec: "mpeg2video", Profile: "Main"},
+			want:   "-c:v libx264 -preset veryfast -crf 23 -profile:v main -pix_fmt yuv420p",
+		},
+		{
+			name:   "unknown profile and audio codec",
+			format: VideoFormat{VideoCodec: "h264", Profile: "Stereo High", HasAudio: true, AudioCodec: "pcm_s16le"},
+			want:   "-c:v libx264 -preset veryfast -crf 23 -pix_fmt yuv420p -c:a aac",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := strings.Join(tc.format.EncoderArgs(), " "); got != tc.want {
+				t.Errorf("EncoderArgs = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeBumperArgs(t *testing.T) {
+	target := VideoFormat{Width: 1280, Height: 720, FrameRate: "25/1", VideoCodec: "h264", Profile: "Main", PixelFormat: "yuv420p",
+		HasAudio: true, AudioCodec: "aac", SampleRate: 48000, ChannelLayout: "stereo"}
+
+	silent := strings.Join(normalizeBumperArgs("intro.mp4", "out.ts", VideoFormat{}, target), " ")
+	for _, want := range []string{
+		"-f lavfi -i anullsrc=r=48000:cl=stereo",
+		"-vf scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=25/1",
+		"-map 0:v:0 -map 1:a:0 -shortest",
+		"-c:v libx264 -preset veryfast -crf 23 -profile:v main -pix_fmt yuv420p -c:a aac -ar 48000 -channel_layout stereo",
+		"-f mpegts out.ts",
+	} {
+		if !strings.Contains(silent, want) {
+			t.Errorf("args %q missing %q", silent, want)
+		}
+	}
+
+	withAudio := strings.Join(normalizeBumperArgs("intro.mp4", "out.ts", VideoFormat{HasAudio: true}, target), " ")
+	if strings.Contains(withAudio, "anullsrc") || !strings.Contains(withAudio, "-map 0:a:0") {
+		t.Errorf("bumper with audio should keep its own audio: %q", withAudio)
+	}
+
+	target.HasAudio = false
+	noAudio := strings.Join(normalizeBumperArgs("intro.mp4", "out.ts", VideoFormat{HasAudio: true}, target), " ")
+	if !strings.Contains(noAudio, "-an") || strings.Contains(noAudio, "-c:a") {
+		t.Errorf("video without audio should drop bumper audio: %q", noAudio)
+	}
+}
+
+func TestVideoFormatCacheKey(t *testing.T) {
+	a := VideoFormat{Width: 1280, Height: 720, FrameRate: "25/1", VideoCodec: "h264", Profile: "High", PixelFormat: "yuv420p"}
+	b := a
+	b.Profile = "Main"
+	if a.cacheKey() == b.cacheKey() {
+		t.Errorf("formats with different profiles must not share a cache key")
+	}
+	if strings.ContainsAny(a.cacheKey(), "/ ") {
+		t.Errorf("cache key %q is not a safe file name", a.cacheKey())
+	}
+}
@@ -0,0 +1,91 @@
+package recording
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"sync"
+
+	"ayo-mwr/storage"
+)
+
+// maxPendingStderr bounds the partial FFmpeg output line kept between writes
+const maxPendingStderr = 4096
+
+// diskErrorWriter watches FFmpeg output for disk failures and reports the first one to the disk
+// manager, which switches recording to another disk
+type diskErrorWriter struct {
+	diskManager *storage.DiskManager
+	path        string // Directory FFmpeg writes to
+	cameraName  string
+
+	mu       sync.Mutex
+	pending  []byte
+	reported bool
+}
+
+// newDiskErrorWriter returns a writer reporting disk failures of FFmpeg writing to path, or nil
+// without a disk manager
+func newDiskErrorWriter(diskManager *storage.DiskManager, path, cameraName string) *diskErrorWriter {
+	if diskManager == nil {
+		return nil
+	}
+	return &diskErrorWriter{diskManager: diskManager, path: path, cameraName: cameraName}
+}
+
+// ffmpegStderr returns the writer for FFmpeg's stderr: the log file, and the disk error writer
+// when there is one. The log file usually lives on the disk being recorded to, so its write
+// errors are ignored; otherwise the first failed write would keep the disk error from being seen.
+func ffmpegStderr(logFile io.Writer, diskErrors *diskErrorWriter) io.Writer {
+	if diskErrors == nil {
+		return logFile
+	}
+	return io.MultiWriter(diskErrors, ignoreWriteErrors{logFile})
+}
+
+// ignoreWriteErrors is a writer that drops the write errors of w
+type ignoreWriteErrors struct {
+	w io.Writer
+}
+
+func (i ignoreWriteErrors) Write(p []byte) (int, error) {
+	i.w.Write(p)
+	return len(p), nil
+}
+
+// Write scans complete lines of FFmpeg output for disk errors
+func (w *diskErrorWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.reported {
+		return len(p), nil
+	}
+
+	w.pending = append(w.pending, p...)
+	for {
+		end := bytes.IndexAny(w.pending, "\r\n")
+		if end < 0 {
+			break
+		}
+		line := string(w.pending[:end])
+		w.pending = w.pending[end+1:]
+		if storage.IsDiskIOError(line) {
+			w.report(line)
+			return len(p), nil
+		}
+	}
+	if len(w.pending) > maxPendingStderr {
+		w.pending = w.pending[len(w.pending)-maxPendingStderr:]
+	}
+	return len(p), nil
+}
+
+// report hands a disk error to the disk manager. The failover restarts recordings, so it runs
+// in the background instead of blocking FFmpeg's output. w.mu must be held.
+func (w *diskErrorWriter) report(line string) {
+	w.reported = true
+	w.pending = nil
+	log.Printf("[%s] 💾 DISK-ERROR: FFmpeg reported a disk failure writing to %s: %s", w.cameraName, w.path, line)
+	go w.diskManager.ReportDiskIOError(w.path, line)
+}
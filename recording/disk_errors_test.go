@@ -0,0 +1,81 @@
+package recording
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+)
+
+// failingWriter fails every write, like a log file on a disk that went read-only
+type failingWriter struct {
+	writes int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	f.writes++
+	return 0, errors.New("write /disk1/logs/camera.log: read-only file system")
+}
+
+func TestFFmpegStderrReportsDiskErrorWhenLogFails(t *testing.T) {
+	db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	diskPath := t.TempDir()
+	// Inactive, so the report does not start a failover
+	if err := db.CreateStorageDisk(database.StorageDisk{ID: "disk1", Path: diskPath, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to create storage disk: %v", err)
+	}
+	diskManager := storage.NewDiskManager(db)
+
+	logFile := &failingWriter{}
+	diskErrors := newDiskErrorWriter(diskManager, filepath.Join(diskPath, "camera_1", "hls"), "camera_1")
+	stderr := ffmpegStderr(logFile, diskErrors)
+
+	lines := []string{
+		"frame= 100 fps= 25 q=23.0 size=N/A time=00:00:04.00\n",
+		"[hls @ 0x55d0] failed to open segment_20260101_100004.ts: Read-only file system\n",
+	}
+	for _, line := range lines {
+		if n, err := stderr.Write([]byte(line)); err != nil || n != len(line) {
+			t.Fatalf("expected write of %d bytes to succeed, got %d, %v", len(line), n, err)
+		}
+	}
+	if logFile.writes != len(lines) {
+		t.Errorf("expected every line to be offered to the log file, got %d writes", logFile.writes)
+	}
+
+	// The report runs in the background and ends with the unhealthy event
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		events, err := db.GetDiskEvents(10, false)
+		if err != nil {
+			t.Fatalf("Failed to get disk events: %v", err)
+		}
+		if len(events) == 1 && events[0].DiskID == "disk1" && events[0].EventType == database.DiskEventUnhealthy {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected an unhealthy event for disk1, got %+v", events)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	health := diskManager.DiskHealthStatus()
+	if len(health) != 1 || health[0].DiskID != "disk1" || health[0].Healthy {
+		t.Errorf("expected disk1 to be unhealthy, got %+v", health)
+	}
+}
+
+func TestFFmpegStderrWithoutDiskManager(t *testing.T) {
+	logFile := &failingWriter{}
+	if stderr := ffmpegStderr(logFile, newDiskErrorWriter(nil, "/disk1", "camera_1")); stderr != logFile {
+		t.Errorf("expected the log file itself without a disk manager")
+	}
+}
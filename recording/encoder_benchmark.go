@@ -0,0 +1,252 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"ayo-mwr/database"
+)
+
+// Encoding workloads that pick their encoder from the benchmark
+const (
+	WorkloadRealtimeWatermark = "realtime_watermark" // Watermark burned into the live camera stream
+	WorkloadClipReencode      = "clip_reencode"      // Watermark/scale pass over a merged booking clip
+	WorkloadPreview           = "preview"            // Preview snippet encode
+)
+
+// benchmarkFrames is the length of each benchmark encode; short enough to run at every startup
+const benchmarkFrames = 150
+
+// benchmarkWorkloads describes the synthetic test pattern each workload is measured with
+var benchmarkWorkloads = []struct {
+	name string
+	size string
+}{
+	{WorkloadRealtimeWatermark, "1920x1080"},
+	{WorkloadClipReencode, "1920x1080"},
+	{WorkloadPreview, "1280x720"},
+}
+
+// EncoderBenchmarkResult is the measured speed of one encoder for one workload
+type EncoderBenchmarkResult struct {
+	Workload string      `json:"workload"`
+	Type     HWAccelType `json:"type"`
+	Encoder  string      `json:"encoder"`
+	FPS      float64     `json:"fps"`             // Frames encoded per second (0 if the encode failed)
+	Error    string      `json:"error,omitempty"` // Why the encode failed
+}
+
+// EncoderBenchmark holds the results of a benchmark run and the encoder picked per workload
+type EncoderBenchmark struct {
+	RanAt    time.Time                `json:"ranAt"`
+	Results  []EncoderBenchmarkResult `json:"results"`
+	Selected map[string]HWAccelType   `json:"selected"` // Workload -> fastest encoder type
+}
+
+var (
+	benchmarkMutex   sync.RWMutex
+	currentBenchmark *EncoderBenchmark
+)
+
+// usableEncoders returns software encoding plus every hardware encoder that passes its test encode.
+// VA-API is left out because it needs frames uploaded to the GPU, which the workload filters don't do.
+func usableEncoders() []HWAccelConfig {
+	encoders := []HWAccelConfig{DetectHardwareAcceleration()}
+	for _, detect := range []func() HWAccelConfig{detectNVIDIA, detectIntelQSV, detectAMD} {
+		if hw := detect(); hw.Available {
+			encoders = append(encoders, hw)
+		}
+	}
+	return encoders
+}
+
+// RunEncoderBenchmark encodes a synthetic test pattern with each usable encoder for each workload,
+// selects the fastest encoder per workload, stores the results in system config and makes them
+// the active selection. If no encoder completes a test encode, an error is returned and the
+// current selection and stored results are kept.
+func RunEncoderBenchmark(db database.Database) (*EncoderBenchmark, error) {
+	log.Println("[hwaccel] ⏱️ Running encoder benchmark...")
+
+	benchmark := &EncoderBenchmark{
+		RanAt:    time.Now(),
+		Selected: make(map[string]HWAccelType),
+	}
+
+	for _, hw := range usableEncoders() {
+		for _, workload := range benchmarkWorkloads {
+			result := benchmarkEncoder(hw, workload.name, workload.size)
+			benchmark.Results = append(benchmark.Results, result)
+
+			if result.Error != "" {
+				log.Printf("[hwaccel] ❌ %s (%s): %s", result.Encoder, workload.name, result.Error)
+				continue
+			}
+			log.Printf("[hwaccel] 📊 %s (%s): %.1f fps", result.Encoder, workload.name, result.FPS)
+
+			if best, ok := benchmark.Selected[workload.name]; !ok || result.FPS > benchmark.fps(workload.name, best) {
+				benchmark.Selected[workload.name] = hw.Type
+			}
+		}
+	}
+
+	if len(benchmark.Selected) == 0 {
+		return benchmark, fmt.Errorf("no encoder completed the benchmark, keeping the previous selection")
+	}
+
+	for _, workload := range benchmarkWorkloads {
+		if _, ok := benchmark.Selected[workload.name]; !ok {
+			benchmark.Selected[workload.name] = HWAccelNone
+		}
+		log.Printf("[hwaccel] ✅ Selected %s for %s", benchmark.Selected[workload.name], workload.name)
+	}
+
+	setEncoderBenchmark(benchmark)
+
+	if db == nil {
+		return benchmark, nil
+	}
+	value, err := json.Marshal(benchmark)
+	if err != nil {
+		return benchmark, err
+	}
+	return benchmark, db.SetSystemConfig(database.SystemConfig{
+		Key:       "encoder_benchmark",
+		Value:     string(value),
+		Type:      "json",
+		UpdatedBy: "system",
+	})
+}
+
+// LoadEncoderBenchmark makes the benchmark stored in system config the active selection.
+// Returns nil if no benchmark has been stored yet.
+func LoadEncoderBenchmark(db database.Database) (*EncoderBenchmark, error) {
+	config, err := db.GetSystemConfig("encoder_benchmark")
+	if err != nil {
+		return nil, nil
+	}
+
+	var benchmark EncoderBenchmark
+	if err := json.Unmarshal([]byte(config.Value), &benchmark); err != nil {
+		return nil, fmt.Errorf("failed to parse stored encoder benchmark: %v", err)
+	}
+
+	setEncoderBenchmark(&benchmark)
+	return &benchmark, nil
+}
+
+// GetEncoderBenchmark returns the active benchmark, or nil if none has run
+func GetEncoderBenchmark() *EncoderBenchmark {
+	benchmarkMutex.RLock()
+	defer benchmarkMutex.RUnlock()
+	return currentBenchmark
+}
+
+func setEncoderBenchmark(benchmark *EncoderBenchmark) {
+	benchmarkMutex.Lock()
+	currentBenchmark = benchmark
+	benchmarkMutex.Unlock()
+}
+
+// EncoderForWorkload returns the encoder selected by the benchmark for a workload.
+// Software encoding is used until a benchmark has run.
+func EncoderForWorkload(workload string) HWAccelConfig {
+	benchmark := GetEncoderBenchmark()
+	if benchmark == nil {
+		return DetectHardwareAcceleration()
+	}
+
+	switch benchmark.Selected[workload] {
+	case HWAccelNVIDIA:
+		return HWAccelConfig{Type: HWAccelNVIDIA, Available: true, EncoderH264: "h264_nvenc", EncoderHEVC: "hevc_nvenc"}
+	case HWAccelIntel:
+		return HWAccelConfig{Type: HWAccelIntel, Available: true, Device: "auto", EncoderH264: "h264_qsv", EncoderHEVC: "hevc_qsv"}
+	case HWAccelAMD:
+		return HWAccelConfig{Type: HWAccelAMD, Available: true, EncoderH264: "h264_amf", EncoderHEVC: "hevc_amf"}
+	default:
+		return DetectHardwareAcceleration()
+	}
+}
+
+// fps returns the measured speed of an encoder type for a workload
+func (b *EncoderBenchmark) fps(workload string, hwType HWAccelType) float64 {
+	for _, result := range b.Results {
+		if result.Workload == workload && result.Type == hwType {
+			return result.FPS
+		}
+	}
+	return 0
+}
+
+// benchmarkEncoder encodes benchmarkFrames frames of a test pattern with the workload settings
+func benchmarkEncoder(hw HWAccelConfig, workload, size string) EncoderBenchmarkResult {
+	result := EncoderBenchmarkResult{Workload: workload, Type: hw.Type, Encoder: hw.EncoderH264}
+
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("testsrc2=size=%s:rate=30", size),
+		"-frames:v", fmt.Sprintf("%d", benchmarkFrames),
+	}
+	args = append(args, hw.WorkloadEncoderArgs(workload)...)
+	args = append(args, "-f", "null", "-")
+
+	start := time.Now()
+	if output, err := exec.Command("ffmpeg", args...).CombinedOutput(); err != nil {
+		result.Error = fmt.Sprintf("%v: %s", err, strings.TrimSpace(string(output)))
+		return result
+	}
+
+	result.FPS = float64(benchmarkFrames) / time.Since(start).Seconds()
+	return result
+}
+
+// WorkloadEncoderArgs returns the H.264 encoder arguments for a workload. rateArgs (e.g. -b:v)
+// replace the constant quality setting when given.
+func (hw HWAccelConfig) WorkloadEncoderArgs(workload string, rateArgs ...string) []string {
+	quality := "23"
+	switch workload {
+	case WorkloadRealtimeWatermark:
+		quality = "30"
+	case WorkloadPreview:
+		quality = "22"
+	}
+
+	var args, qualityArgs []string
+	switch hw.Type {
+	case HWAccelNVIDIA:
+		args = []string{"-c:v", hw.EncoderH264, "-preset", "p1", "-pix_fmt", "yuv420p"}
+		if workload == WorkloadRealtimeWatermark {
+			args = append(args, "-tune", "ll", "-zerolatency", "1", "-profile:v", "baseline")
+		}
+		qualityArgs = []string{"-rc", "vbr", "-cq", quality}
+	case HWAccelIntel:
+		args = []string{"-c:v", hw.EncoderH264, "-preset", "veryfast", "-pix_fmt", "nv12"}
+		if workload == WorkloadRealtimeWatermark {
+			args = append(args, "-profile:v", "baseline")
+		}
+		qualityArgs = []string{"-global_quality", quality}
+	case HWAccelAMD:
+		args = []string{"-c:v", hw.EncoderH264, "-quality", "speed", "-pix_fmt", "nv12"}
+		if workload == WorkloadRealtimeWatermark {
+			args = append(args, "-usage", "lowlatency", "-profile:v", "main")
+		}
+		qualityArgs = []string{"-rc", "cqp", "-qp_i", quality, "-qp_p", quality}
+	default:
+		args = []string{"-c:v", "libx264", "-preset", "ultrafast"}
+		if workload == WorkloadRealtimeWatermark {
+			args = append(args, "-tune", "zerolatency", "-profile:v", "baseline", "-level", "3.1")
+		}
+		qualityArgs = []string{"-crf", quality}
+	}
+
+	if len(rateArgs) > 0 {
+		return append(args, rateArgs...)
+	}
+	return append(args, qualityArgs...)
+}
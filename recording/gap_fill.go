@@ -0,0 +1,112 @@
+package recording
+
+import (
+	"fmt"
+
+	"ayo-mwr/ffmpeg"
+)
+
+// GapFill describes a recovered part of a video that is re-rendered before being stitched into it
+type GapFill struct {
+	VideoID       string      // Video the FFmpeg job is reported under
+	InputPath     string      // Concatenated raw recordings of the part
+	Start         float64     // Offset of the part in InputPath, in seconds
+	Duration      float64     // Length of the part in seconds
+	Target        VideoFormat // Format of the video the part is stitched into
+	WatermarkPath string      // Empty when the recordings already carry the watermark
+	Position      WatermarkPosition
+	Margin        int
+	Opacity       float64
+	Overlay       *TextOverlay // Booking text overlay; ClockStart must be the capture time of the part's first frame
+}
+
+// RenderGapFill cuts a recovered part from its recordings and renders it the way the video it
+// fills was rendered: scaled to the video's resolution and frame rate, watermarked, with the
+// booking text overlay, and encoded with the video's codec parameters as MPEG-TS so it can be
+// stream-copied between the parts of the original output.
+func RenderGapFill(fill GapFill, outputPath string) error {
+	source, err := ProbeVideoFormat(fill.InputPath)
+	if err != nil {
+		return err
+	}
+
+	textFilter, cleanup, err := fill.Overlay.prepare(outputPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  fill.VideoID,
+		Stage:    ffmpeg.StageWatermark,
+		Duration: fill.Duration,
+	}, gapFillArgs(fill, *source, textFilter, outputPath)...)
+	if err != nil {
+		return fmt.Errorf("ffmpeg gap fill render failed: %v\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// gapFillArgs builds the FFmpeg arguments for RenderGapFill. source is the format of the raw
+// recordings and textFilter the prepared drawtext chain, empty for no text overlay.
+func gapFillArgs(fill GapFill, source VideoFormat, textFilter, outputPath string) []string {
+	target := fill.Target
+	frameRate := target.FrameRate
+	if frameRate == "" || frameRate == "0/0" {
+		frameRate = "25"
+	}
+	opacity := fill.Opacity
+	if opacity < 0.0 {
+		opacity = 0.0
+	} else if opacity > 1.0 {
+		opacity = 1.0
+	}
+
+	args := []string{"-y", "-v", "warning",
+		"-ss", fmt.Sprintf("%.3f", fill.Start),
+		"-t", fmt.Sprintf("%.3f", fill.Duration),
+		"-i", fill.InputPath,
+	}
+	nextInput := 1
+
+	filter := fmt.Sprintf("[0:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%s",
+		target.Width, target.Height, target.Width, target.Height, frameRate)
+	if fill.WatermarkPath != "" {
+		args = append(args, "-i", fill.WatermarkPath)
+		filter += fmt.Sprintf("[base];[%d:v]colorchannelmixer=aa=%.1f[wm];[base][wm]%s",
+			nextInput, opacity, getOverlayExpression(fill.Position, fill.Margin))
+		nextInput++
+	}
+	if textFilter != "" {
+		filter += "," + textFilter
+	}
+	filter += "[v]"
+
+	silenceInput := -1
+	if target.HasAudio && !source.HasAudio {
+		// Add silence so the fill has the same streams as the video
+		layout := target.ChannelLayout
+		if layout == "" {
+			layout = "stereo"
+		}
+		sampleRate := target.SampleRate
+		if sampleRate <= 0 {
+			sampleRate = 48000
+		}
+		args = append(args, "-f", "lavfi", "-i", fmt.Sprintf("anullsrc=r=%d:cl=%s", sampleRate, layout))
+		silenceInput = nextInput
+	}
+
+	args = append(args, "-filter_complex", filter, "-map", "[v]")
+	switch {
+	case !target.HasAudio:
+		args = append(args, "-an")
+	case silenceInput >= 0:
+		args = append(args, "-map", fmt.Sprintf("%d:a:0", silenceInput), "-shortest")
+	default:
+		args = append(args, "-map", "0:a:0")
+	}
+
+	args = append(args, target.EncoderArgs()...)
+	return append(args, "-f", "mpegts", outputPath)
+}
@@ -0,0 +1,80 @@
+package recording
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGapFillArgs(t *testing.T) {
+	target := VideoFormat{Width: 1280, Height: 720, FrameRate: "25/1", VideoCodec: "h264", Profile: "High", PixelFormat: "yuv420p",
+		HasAudio: true, AudioCodec: "aac", SampleRate: 44100, ChannelLayout: "mono"}
+	silentTarget := target
+	silentTarget.HasAudio = false
+	recordings := VideoFormat{Width: 1920, Height: 1080, FrameRate: "30/1", VideoCodec: "h264", HasAudio: true}
+	silentRecordings := recordings
+	silentRecordings.HasAudio = false
+
+	scale := "[0:v]scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=25/1"
+	encoder := "-c:v libx264 -preset veryfast -crf 23 -profile:v high -pix_fmt yuv420p"
+
+	cases := []struct {
+		name       string
+		fill       GapFill
+		source     VideoFormat
+		textFilter string
+		contains   []string
+		excludes   []string
+	}{
+		{
+			name:       "watermark and text overlay",
+			fill:       GapFill{Target: target, WatermarkPath: "wm.png", Position: BottomRight, Margin: 10, Opacity: 0.6},
+			source:     recordings,
+			textFilter: "drawtext=text='x'",
+			contains: []string{
+				"-ss 12.500 -t 60.000 -i raw.ts -i wm.png",
+				"-filter_complex " + scale + "[base];[1:v]colorchannelmixer=aa=0.6[wm];[base][wm]overlay=main_w-overlay_w-10:main_h-overlay_h-10,drawtext=text='x'[v]",
+				"-map [v] -map 0:a:0",
+				encoder + " -c:a aac -ar 44100 -channel_layout mono",
+				"-f mpegts fill.ts",
+			},
+		},
+		{
+			name:     "real-time watermarked recordings get only the scaling",
+			fill:     GapFill{Target: target},
+			source:   recordings,
+			contains: []string{"-filter_complex " + scale + "[v] -map [v] -map 0:a:0"},
+			excludes: []string{"wm.png", "colorchannelmixer", "anullsrc"},
+		},
+		{
+			name:     "silence is added when the recordings have no audio",
+			fill:     GapFill{Target: target, WatermarkPath: "wm.png", Opacity: 3},
+			source:   silentRecordings,
+			contains: []string{"-i wm.png -f lavfi -i anullsrc=r=44100:cl=mono", "aa=1.0", "-map [v] -map 2:a:0 -shortest"},
+		},
+		{
+			name:     "silent video drops audio",
+			fill:     GapFill{Target: silentTarget},
+			source:   recordings,
+			contains: []string{"-map [v] -an " + encoder + " -f mpegts"},
+			excludes: []string{"-c:a", "0:a:0"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fill := c.fill
+			fill.InputPath, fill.Start, fill.Duration = "raw.ts", 12.5, 60
+			args := strings.Join(gapFillArgs(fill, c.source, c.textFilter, "fill.ts"), " ")
+			for _, want := range c.contains {
+				if !strings.Contains(args, want) {
+					t.Errorf("expected args to contain %q, got %s", want, args)
+				}
+			}
+			for _, unwanted := range c.excludes {
+				if strings.Contains(args, unwanted) {
+					t.Errorf("expected args not to contain %q, got %s", unwanted, args)
+				}
+			}
+		})
+	}
+}
@@ -442,9 +442,27 @@ func (hw HWAccelConfig) BuildDecoderArgs() []string {
 	return args
 }
 
-// GetBenchmarkInfo returns performance information about the hardware acceleration
+// GetBenchmarkInfo returns performance information about the hardware acceleration,
+// including the speeds measured by the last encoder benchmark
 func (hw HWAccelConfig) GetBenchmarkInfo() map[string]interface{} {
+	measuredFPS := make(map[string]float64)
+	var selectedFor []string
+	if benchmark := GetEncoderBenchmark(); benchmark != nil {
+		for _, result := range benchmark.Results {
+			if result.Type == hw.Type && result.Error == "" {
+				measuredFPS[result.Workload] = result.FPS
+			}
+		}
+		for _, workload := range benchmarkWorkloads {
+			if benchmark.Selected[workload.name] == hw.Type {
+				selectedFor = append(selectedFor, workload.name)
+			}
+		}
+	}
+
 	return map[string]interface{}{
+		"measured_fps": measuredFPS,
+		"selected_for": selectedFor,
 		"type":         string(hw.Type),
 		"available":    hw.Available,
 		"device":       hw.Device,
@@ -118,7 +118,7 @@ func (rm *RecordingManager) startSingleCamera(camera config.CameraConfig, camera
 		}()
 
 		log.Printf("[RecordingManager] Starting recording for camera: %s", camName)
-		captureRTSPStreamForCameraWithGracefulShutdown(ctx, rm.config, cam, camID)
+		captureRTSPStreamForCameraWithGracefulShutdown(ctx, rm.config, cam, camID, rm.diskManager)
 	}(camera, cameraID, cameraName)
 
 	// Track the recording
@@ -53,7 +53,7 @@ func TestMergeSessionVideos(t *testing.T) {
 	endTime, _ := time.ParseInLocation("20060102_150405", "20250414_120540", time.Local)
 
 	// Call the function under test (to be implemented)
-	err := MergeSessionVideos(inputPath, startTime, endTime, outputPath)
+	err := MergeSessionVideos(inputPath, startTime, endTime, outputPath, "")
 	if err != nil {
 		t.Fatalf("MergeSessionVideos failed: %v", err)
 	}
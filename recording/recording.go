@@ -17,6 +17,8 @@ import (
 
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
+	"ayo-mwr/metrics"
 	"ayo-mwr/storage"
 )
 
@@ -75,8 +77,9 @@ func getVenueCode() string {
 	return ""
 }
 
-// startQualityStream starts and manages a single quality stream
-func startQualityStream(ctx context.Context, stream *QualityStream, cameraName, cameraLogsDir string) {
+// startQualityStream starts and manages a single quality stream. Disk failures in FFmpeg's output
+// are reported to diskManager, if any, so recording moves to a healthy disk.
+func startQualityStream(ctx context.Context, stream *QualityStream, cameraName, cameraLogsDir string, diskManager *storage.DiskManager) {
 	hlsPlaylistPath := filepath.Join(stream.HLSDir, "playlist.m3u8")
 
 	for {
@@ -88,6 +91,9 @@ func startQualityStream(ctx context.Context, stream *QualityStream, cameraName,
 			logFile, err := os.Create(filepath.Join(cameraLogsDir, fmt.Sprintf("ffmpeg_%s_%s.log", stream.Quality, time.Now().Format("20060102_150405"))))
 			if err != nil {
 				log.Printf("[%s-%s] Error creating FFmpeg log file: %v", cameraName, stream.Quality, err)
+				if diskManager != nil && storage.IsDiskIOError(err.Error()) {
+					go diskManager.ReportDiskIOError(cameraLogsDir, err.Error())
+				}
 				time.Sleep(5 * time.Second)
 				continue
 			}
@@ -158,15 +164,8 @@ func startQualityStream(ctx context.Context, stream *QualityStream, cameraName,
 				log.Printf("[%s-%s] 🎬 WATERMARK-FILTER: %s", cameraName, stream.Quality, filter)
 				log.Printf("[%s-%s] 🎬 WATERMARK-OVERLAY: %s", cameraName, stream.Quality, overlayExpr)
 
-				ffmpegArgs = append(ffmpegArgs,
-					"-filter_complex", filter,
-					"-c:v", "libx264",
-					"-preset", "ultrafast",
-					"-tune", "zerolatency",
-					"-crf", "30",
-					"-profile:v", "baseline",
-					"-level", "3.1",
-				)
+				ffmpegArgs = append(ffmpegArgs, "-filter_complex", filter)
+				ffmpegArgs = append(ffmpegArgs, EncoderForWorkload(WorkloadRealtimeWatermark).WorkloadEncoderArgs(WorkloadRealtimeWatermark)...)
 				log.Printf("[%s-%s] ✅ WATERMARK-ENCODING: Using real-time watermark encoding with re-encode", cameraName, stream.Quality)
 			} else {
 				// Stream copy for zero CPU encoding when no watermark
@@ -208,7 +207,7 @@ func startQualityStream(ctx context.Context, stream *QualityStream, cameraName,
 
 			stream.Cmd = exec.CommandContext(ctx, "ffmpeg", ffmpegArgs...)
 			stream.Cmd.Stdout = logFile
-			stream.Cmd.Stderr = logFile
+			stream.Cmd.Stderr = ffmpegStderr(logFile, newDiskErrorWriter(diskManager, stream.HLSDir, cameraName))
 
 			err = stream.Cmd.Start()
 			if err != nil {
@@ -548,6 +547,21 @@ func CaptureRTSPSegments(cfg *config.Config) error {
 
 // MergeSessionVideos merges MP4 segments in inputPath between startTime and endTime into outputPath with hardware acceleration.
 func MergeSessionVideos(inputPath string, startTime, endTime time.Time, outputPath string, resolution string) error {
+	return MergeSessionVideosWithMetrics(inputPath, startTime, endTime, outputPath, resolution, nil)
+}
+
+// MergeSessionVideosWithMetrics works like MergeSessionVideos and reports the merge progress
+// under the video of videoMetrics
+func MergeSessionVideosWithMetrics(inputPath string, startTime, endTime time.Time, outputPath string, resolution string,
+	videoMetrics *metrics.VideoProcessingMetrics) error {
+	return MergeSessionVideosWithOverlay(inputPath, startTime, endTime, outputPath, resolution, nil, videoMetrics)
+}
+
+// MergeSessionVideosWithOverlay works like MergeSessionVideosWithMetrics and additionally draws the
+// booking text overlay in the merge encode, for videos whose segments already carry the watermark.
+// The overlay clock starts at the capture time of the first segment. A nil overlay draws no text.
+func MergeSessionVideosWithOverlay(inputPath string, startTime, endTime time.Time, outputPath string, resolution string,
+	overlay *TextOverlay, videoMetrics *metrics.VideoProcessingMetrics) error {
 
 	log.Printf("MergeSessionVideos: Merging video segments with hardware acceleration")
 	// find segment in range of the startTime and endTime
@@ -593,6 +607,18 @@ func MergeSessionVideos(inputPath string, startTime, endTime time.Time, outputPa
 		return fmt.Errorf("failed to get project root: %w", err)
 	}
 
+	// Prepare the booking text overlay, clocked from the first segment actually captured
+	if overlay != nil {
+		if segmentTime, err := parseTimestampFromFilename(filepath.Base(segments[0])); err == nil {
+			overlay.ClockStart = segmentTime
+		}
+	}
+	textFilter, cleanupOverlay, err := overlay.prepare(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to prepare text overlay: %w", err)
+	}
+	defer cleanupOverlay()
+
 	// Define supported resolutions
 	resolutions := map[string]struct {
 		width  string
@@ -616,24 +642,41 @@ func MergeSessionVideos(inputPath string, startTime, endTime time.Time, outputPa
 
 	// Add resolution parameters with software encoding
 	if res, found := resolutions[resolution]; found {
-		// Software scaling and encoding
+		// Software scaling and encoding, with the text overlay drawn after scaling
+		videoFilter := fmt.Sprintf("scale=%s:%s", res.width, res.height)
+		if textFilter != "" {
+			videoFilter += "," + textFilter
+		}
 		ffmpegArgs = append(ffmpegArgs,
 			"-c:v", "libx264",
 			"-preset", "ultrafast",
 			"-crf", "23",
-			"-vf", fmt.Sprintf("scale=%s:%s", res.width, res.height),
+			"-vf", videoFilter,
 			"-c:a", "aac",
 			outputPath,
 		)
+	} else if textFilter != "" {
+		// No resolution specified, but the text overlay needs an encode
+		ffmpegArgs = append(ffmpegArgs,
+			"-c:v", "libx264",
+			"-preset", "ultrafast",
+			"-crf", "23",
+			"-vf", textFilter,
+			"-c:a", "copy",
+			outputPath,
+		)
 	} else {
 		// No resolution specified - use copy codec (no transcoding)
 		ffmpegArgs = append(ffmpegArgs, "-c", "copy", outputPath)
 	}
 
 	log.Printf("MergeSessionVideos: Executing ffmpeg with software encoding")
-	cmd := exec.Command("ffmpeg", ffmpegArgs...)
-	cmd.Dir = projectRoot
-	output, err := cmd.CombinedOutput()
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		Stage:    ffmpeg.StageMerge,
+		Duration: endTime.Sub(startTime).Seconds(),
+		Dir:      projectRoot,
+		Metrics:  videoMetrics,
+	}, ffmpegArgs...)
 	if err != nil {
 		return fmt.Errorf("ffmpeg concat failed: %v\nOutput: %s", err, string(output))
 	}
@@ -648,6 +691,17 @@ func MergeSessionVideos(inputPath string, startTime, endTime time.Time, outputPa
 // This approach is typically 2-3x faster than single-step complex filter operations
 func MergeAndWatermark(inputPath string, startTime, endTime time.Time, outputPath, watermarkPath string,
 	position WatermarkPosition, margin int, opacity float64, resolution string) error {
+	return MergeAndWatermarkWithOverlay(inputPath, startTime, endTime, outputPath, watermarkPath,
+		position, margin, opacity, resolution, nil, nil)
+}
+
+// MergeAndWatermarkWithOverlay works like MergeAndWatermark and additionally burns the booking
+// text overlay into the video in the watermark encode, so the overlay costs no extra pass.
+// The overlay clock starts at the capture time of the first segment. A nil overlay draws no text.
+// FFmpeg progress is reported under the video of videoMetrics when it is not nil.
+func MergeAndWatermarkWithOverlay(inputPath string, startTime, endTime time.Time, outputPath, watermarkPath string,
+	position WatermarkPosition, margin int, opacity float64, resolution string, overlay *TextOverlay,
+	videoMetrics *metrics.VideoProcessingMetrics) error {
 
 	// Generate unique ID to prevent race conditions
 	uniqueID := fmt.Sprintf("%d_%d", time.Now().Unix(), rand.Intn(100000))
@@ -684,14 +738,27 @@ func MergeAndWatermark(inputPath string, startTime, endTime time.Time, outputPat
 
 	// STEP 1: Fast concatenation with copy codec (no transcoding)
 	log.Printf("MergeAndWatermark: Step 1 - Fast concatenation with copy codec (ID: %s)", uniqueID)
-	err = fastConcatSegments(segments, tempConcatPath, outDir, uniqueID, startTime, endTime)
+	err = fastConcatSegments(segments, tempConcatPath, outDir, uniqueID, startTime, endTime, videoMetrics)
 	if err != nil {
 		return fmt.Errorf("failed to concatenate segments: %w", err)
 	}
 
+	// Prepare the booking text overlay, clocked from the first segment actually captured
+	if overlay != nil {
+		if segmentTime, err := parseTimestampFromFilename(filepath.Base(segments[0])); err == nil {
+			overlay.ClockStart = segmentTime
+		}
+	}
+	textFilter, cleanupOverlay, err := overlay.prepare(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to prepare text overlay: %w", err)
+	}
+	defer cleanupOverlay()
+
 	// STEP 2: Apply watermark and encoding to the concatenated file
 	log.Printf("MergeAndWatermark: Step 2 - Applying watermark and encoding (ID: %s)", uniqueID)
-	err = applyWatermarkWithPosition(tempConcatPath, watermarkPath, outputPath, position, margin, opacity, resolution)
+	err = applyWatermarkWithPosition(tempConcatPath, watermarkPath, outputPath, position, margin, opacity, resolution, textFilter,
+		endTime.Sub(startTime).Seconds(), videoMetrics)
 	if err != nil {
 		return fmt.Errorf("failed to apply watermark: %w", err)
 	}
@@ -701,7 +768,8 @@ func MergeAndWatermark(inputPath string, startTime, endTime time.Time, outputPat
 }
 
 // fastConcatSegments performs fast concatenation using copy codec (no transcoding)
-func fastConcatSegments(segments []string, outputPath, workingDir, uniqueID string, startTime, endTime time.Time) error {
+func fastConcatSegments(segments []string, outputPath, workingDir, uniqueID string, startTime, endTime time.Time,
+	videoMetrics *metrics.VideoProcessingMetrics) error {
 	// Create concat list file with unique ID to prevent race conditions
 	concatListPath := filepath.Join(workingDir, fmt.Sprintf("segments_concat_list_%s.txt", uniqueID))
 	tmpFile, err := os.Create(concatListPath)
@@ -747,9 +815,12 @@ func fastConcatSegments(segments []string, outputPath, workingDir, uniqueID stri
 	}
 
 	log.Printf("fastConcatSegments: Executing fast concat with copy codec (ID: %s)", uniqueID)
-	cmd := exec.Command("ffmpeg", ffmpegArgs...)
-	cmd.Dir = projectRoot
-	output, err := cmd.CombinedOutput()
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		Stage:    ffmpeg.StageMerge,
+		Duration: bookingDuration.Seconds(),
+		Dir:      projectRoot,
+		Metrics:  videoMetrics,
+	}, ffmpegArgs...)
 	if err != nil {
 		return fmt.Errorf("ffmpeg fast concat failed: %v\nOutput: %s", err, string(output))
 	}
@@ -758,8 +829,11 @@ func fastConcatSegments(segments []string, outputPath, workingDir, uniqueID stri
 	return nil
 }
 
-// applyWatermarkWithPosition applies watermark to a single video file with optional resolution scaling
-func applyWatermarkWithPosition(inputVideo, watermarkPath, outputPath string, position WatermarkPosition, margin int, opacity float64, resolution string) error {
+// applyWatermarkWithPosition applies watermark to a single video file with optional resolution scaling.
+// A non-empty textFilter (drawtext chain) is applied after the watermark in the same filter graph.
+// duration is the expected length of the output in seconds, used for progress reporting.
+func applyWatermarkWithPosition(inputVideo, watermarkPath, outputPath string, position WatermarkPosition, margin int, opacity float64, resolution string, textFilter string,
+	duration float64, videoMetrics *metrics.VideoProcessingMetrics) error {
 	// Validate opacity value
 	if opacity < 0.0 {
 		opacity = 0.0
@@ -781,6 +855,9 @@ func applyWatermarkWithPosition(inputVideo, watermarkPath, outputPath string, po
 	default:
 		overlayExpr = fmt.Sprintf("overlay=%d:%d", margin, margin)
 	}
+	if textFilter != "" {
+		overlayExpr += "," + textFilter
+	}
 
 	// Define supported resolutions
 	resolutions := map[string]struct {
@@ -805,37 +882,37 @@ func applyWatermarkWithPosition(inputVideo, watermarkPath, outputPath string, po
 		"-i", inputVideo,
 		"-i", watermarkPath,
 	}
+	encoderArgs := EncoderForWorkload(WorkloadClipReencode).WorkloadEncoderArgs(WorkloadClipReencode)
 
 	// Add resolution and watermark filters
 	if res, found := resolutions[resolution]; found {
 		// Scale video and apply watermark
 		filter := fmt.Sprintf("[0:v]scale=%s:%s[scaled];[1:v]colorchannelmixer=aa=%.1f[wm];[scaled][wm]%s",
 			res.width, res.height, opacity, overlayExpr)
+		ffmpegArgs = append(ffmpegArgs, "-filter_complex", filter)
+		ffmpegArgs = append(ffmpegArgs, encoderArgs...)
 		ffmpegArgs = append(ffmpegArgs,
-			"-filter_complex", filter,
-			"-c:v", "libx264",
-			"-preset", "ultrafast",
-			"-crf", "23",
 			"-c:a", "aac",
 			outputPath,
 		)
 	} else {
 		// No resolution specified - apply watermark only
 		filter := fmt.Sprintf("[1:v]colorchannelmixer=aa=%.1f[wm];[0:v][wm]%s", opacity, overlayExpr)
+		ffmpegArgs = append(ffmpegArgs, "-filter_complex", filter)
+		ffmpegArgs = append(ffmpegArgs, encoderArgs...)
 		ffmpegArgs = append(ffmpegArgs,
-			"-filter_complex", filter,
-			"-c:v", "libx264",
-			"-preset", "ultrafast",
-			"-crf", "23",
 			"-c:a", "copy",
 			outputPath,
 		)
 	}
 
 	log.Printf("applyWatermarkWithPosition: Executing watermark operation")
-	cmd := exec.Command("ffmpeg", ffmpegArgs...)
-	cmd.Dir = projectRoot
-	output, err := cmd.CombinedOutput()
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		Stage:    ffmpeg.StageWatermark,
+		Duration: duration,
+		Dir:      projectRoot,
+		Metrics:  videoMetrics,
+	}, ffmpegArgs...)
 	if err != nil {
 		return fmt.Errorf("ffmpeg watermark failed: %v\nOutput: %s", err, string(output))
 	}
@@ -1156,8 +1233,9 @@ type QualityStream struct {
 	Cmd     *exec.Cmd
 }
 
-// captureRTSPStreamForCameraWithGracefulShutdown handles graceful shutdown for FFmpeg processes
-func captureRTSPStreamForCameraWithGracefulShutdown(ctx context.Context, cfg *config.Config, camera config.CameraConfig, cameraID int) {
+// captureRTSPStreamForCameraWithGracefulShutdown handles graceful shutdown for FFmpeg processes.
+// diskManager may be nil; with it, disk failures while recording switch to another disk.
+func captureRTSPStreamForCameraWithGracefulShutdown(ctx context.Context, cfg *config.Config, camera config.CameraConfig, cameraID int, diskManager *storage.DiskManager) {
 	cameraName := camera.Name
 	if cameraName == "" {
 		cameraName = fmt.Sprintf("camera_%d", cameraID)
@@ -1265,7 +1343,7 @@ func captureRTSPStreamForCameraWithGracefulShutdown(ctx context.Context, cfg *co
 		wg.Add(1)
 		go func(stream *QualityStream) {
 			defer wg.Done()
-			startQualityStream(ctx, stream, cameraName, cameraLogsDir)
+			startQualityStream(ctx, stream, cameraName, cameraLogsDir, diskManager)
 		}(&qualityStreams[i])
 	}
 
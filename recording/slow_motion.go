@@ -0,0 +1,96 @@
+package recording
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"ayo-mwr/ffmpeg"
+)
+
+// CreateSlowMotionReplay encodes the last replaySeconds of videoPath slowed down to speed (0.5 = half speed).
+// With interpolate the missing frames are motion-interpolated, otherwise frames are repeated.
+// When forConcat is true the replay is written as MPEG-TS with the same codec, profile, pixel format,
+// resolution, frame rate and audio layout as the source so it can be appended with AttachBumpers;
+// otherwise a standalone H.264 MP4 is written.
+func CreateSlowMotionReplay(videoID, videoPath, outputPath string, replaySeconds, speed float64, interpolate, forConcat bool) error {
+	if speed <= 0 || speed >= 1 {
+		return fmt.Errorf("invalid slow-motion speed %.2f", speed)
+	}
+
+	format, err := ProbeVideoFormat(videoPath)
+	if err != nil {
+		return err
+	}
+	if format.Duration <= 0 {
+		return fmt.Errorf("could not determine duration of %s", videoPath)
+	}
+
+	start := format.Duration - replaySeconds
+	if start < 0 {
+		start = 0
+	}
+
+	tmpPath := outputPath + ".tmp"
+	args := slowMotionArgs(videoPath, tmpPath, *format, start, speed, interpolate, forConcat)
+
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StageSlowMotion,
+		Duration: (format.Duration - start) / speed,
+	}, args...)
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to create slow-motion replay: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to store slow-motion replay: %w", err)
+	}
+
+	log.Printf("CreateSlowMotionReplay : Created %.1fs replay at %.2fx (interpolate: %v) -> %s", format.Duration-start, speed, interpolate, outputPath)
+	return nil
+}
+
+// slowMotionArgs builds the FFmpeg arguments for a slow-motion replay of source starting at start seconds
+func slowMotionArgs(videoPath, outputPath string, source VideoFormat, start, speed float64, interpolate, forConcat bool) []string {
+	frameRate := source.FrameRate
+	if frameRate == "" || frameRate == "0/0" {
+		frameRate = "25"
+	}
+	speedStr := strconv.FormatFloat(speed, 'f', -1, 64)
+
+	videoFilter := fmt.Sprintf("setpts=PTS/%s", speedStr)
+	if interpolate {
+		videoFilter += fmt.Sprintf(",minterpolate=fps=%s:mi_mode=mci:mc_mode=aobmc:me_mode=bidir", frameRate)
+	} else {
+		videoFilter += fmt.Sprintf(",fps=%s", frameRate)
+	}
+	videoFilter += ",setsar=1"
+
+	args := []string{
+		"-y",
+		"-v", "warning",
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-i", videoPath,
+		"-vf", videoFilter,
+	}
+	if source.HasAudio {
+		args = append(args, "-af", fmt.Sprintf("atempo=%s", speedStr))
+	} else {
+		args = append(args, "-an")
+	}
+
+	if forConcat {
+		// Same codec parameters as the clip so the replay can be stream-copied onto it
+		args = append(args, source.EncoderArgs()...)
+		return append(args, "-f", "mpegts", outputPath)
+	}
+
+	standalone := VideoFormat{VideoCodec: "h264", PixelFormat: "yuv420p", HasAudio: source.HasAudio, AudioCodec: "aac",
+		SampleRate: source.SampleRate, ChannelLayout: source.ChannelLayout}
+	args = append(args, standalone.EncoderArgs()...)
+	return append(args, "-movflags", "+faststart", "-f", "mp4", outputPath)
+}
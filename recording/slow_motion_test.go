@@ -0,0 +1,83 @@
+package recording
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlowMotionArgs(t *testing.T) {
+	clip := VideoFormat{Width: 1920, Height: 1080, FrameRate: "30/1", VideoCodec: "hevc", Profile: "Main", PixelFormat: "yuv420p",
+		HasAudio: true, AudioCodec: "aac", SampleRate: 48000, ChannelLayout: "stereo"}
+	silentClip := clip
+	silentClip.HasAudio = false
+	noRate := clip
+	noRate.FrameRate = "0/0"
+
+	cases := []struct {
+		name        string
+		format      VideoFormat
+		speed       float64
+		interpolate bool
+		forConcat   bool
+		contains    []string
+		excludes    []string
+	}{
+		{
+			name:      "append replay matches the clip",
+			format:    clip,
+			speed:     0.5,
+			forConcat: true,
+			contains: []string{
+				"-ss 55.000 -i clip.ts",
+				"-vf setpts=PTS/0.5,fps=30/1,setsar=1",
+				"-af atempo=0.5",
+				"-c:v libx265 -preset veryfast -crf 23 -profile:v main -pix_fmt yuv420p -c:a aac -ar 48000 -channel_layout stereo",
+				"-f mpegts out.ts",
+			},
+			excludes: []string{"-movflags"},
+		},
+		{
+			name:        "interpolated separate replay is a standalone H.264 MP4",
+			format:      clip,
+			speed:       0.75,
+			interpolate: true,
+			contains: []string{
+				"-vf setpts=PTS/0.75,minterpolate=fps=30/1:mi_mode=mci:mc_mode=aobmc:me_mode=bidir,setsar=1",
+				"-af atempo=0.75",
+				"-c:v libx264 -preset veryfast -crf 23 -pix_fmt yuv420p -c:a aac -ar 48000 -channel_layout stereo",
+				"-movflags +faststart -f mp4 out.ts",
+			},
+		},
+		{
+			name:      "clip without audio",
+			format:    silentClip,
+			speed:     0.5,
+			forConcat: true,
+			contains:  []string{"-an"},
+			excludes:  []string{"atempo", "-c:a"},
+		},
+		{
+			name:      "unknown frame rate defaults to 25",
+			format:    noRate,
+			speed:     0.5,
+			forConcat: true,
+			contains:  []string{"fps=25,"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := strings.Join(slowMotionArgs("clip.ts", "out.ts", tc.format, 55, tc.speed, tc.interpolate, tc.forConcat), " ")
+			for _, want := range tc.contains {
+				if !strings.Contains(args, want) {
+					t.Errorf("args %q missing %q", args, want)
+				}
+			}
+			for _, unwanted := range tc.excludes {
+				if strings.Contains(args, unwanted) {
+					t.Errorf("args %q should not contain %q", args, unwanted)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/ffmpeg"
+	"ayo-mwr/metrics"
+)
+
+// TextOverlay is burned-in booking information drawn in the same FFmpeg pass as the watermark
+type TextOverlay struct {
+	Layout     config.TextOverlayLayout
+	Lines      []string  // Static lines (field name, booking date/time)
+	ClockStart time.Time // Capture time of the first frame; set by the segment merges from the first segment
+}
+
+// NewBookingTextOverlay builds the overlay text for a booking from its raw API JSON
+func NewBookingTextOverlay(layout config.TextOverlayLayout, rawJSON string, clockStart time.Time) *TextOverlay {
+	var booking map[string]interface{}
+	if rawJSON != "" {
+		json.Unmarshal([]byte(rawJSON), &booking)
+	}
+
+	overlay := &TextOverlay{Layout: layout, ClockStart: clockStart}
+
+	if layout.ShowFieldName {
+		if fieldName, _ := booking["field_name"].(string); fieldName != "" {
+			overlay.Lines = append(overlay.Lines, fieldName)
+		} else if fieldID, ok := booking["field_id"].(float64); ok {
+			overlay.Lines = append(overlay.Lines, fmt.Sprintf("Field %d", int(fieldID)))
+		}
+	}
+
+	if layout.ShowBookingTime {
+		date, _ := booking["date"].(string)
+		startTime, _ := booking["start_time"].(string)
+		endTime, _ := booking["end_time"].(string)
+
+		var parts []string
+		if date != "" {
+			parts = append(parts, formatOverlayDate(date, layout.DateFormat))
+		}
+		if startTime != "" && endTime != "" {
+			parts = append(parts, fmt.Sprintf("%s - %s", shortClock(startTime), shortClock(endTime)))
+		}
+		if len(parts) > 0 {
+			overlay.Lines = append(overlay.Lines, strings.Join(parts, " "))
+		}
+	}
+
+	return overlay
+}
+
+// HasText reports whether the overlay draws anything; a nil overlay draws nothing
+func (o *TextOverlay) HasText() bool {
+	return o != nil && (len(o.Lines) > 0 || (o.Layout.ShowClock && !o.ClockStart.IsZero()))
+}
+
+// BurnTextOverlay re-encodes inputPath into outputPath with only the booking text overlay drawn.
+// It is used where the watermark is already in the video (real-time watermark, hybrid chunks),
+// so the overlay is still drawn without watermarking twice.
+func BurnTextOverlay(videoID, inputPath, outputPath string, overlay *TextOverlay, duration float64, videoMetrics *metrics.VideoProcessingMetrics) error {
+	textFilter, cleanup, err := overlay.prepare(outputPath)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if textFilter == "" {
+		return fmt.Errorf("text overlay has nothing to draw")
+	}
+
+	ffmpegArgs := []string{"-y",
+		"-i", inputPath,
+		"-vf", textFilter,
+	}
+	ffmpegArgs = append(ffmpegArgs, EncoderForWorkload(WorkloadClipReencode).WorkloadEncoderArgs(WorkloadClipReencode)...)
+	ffmpegArgs = append(ffmpegArgs,
+		"-c:a", "copy",
+		outputPath,
+	)
+
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StageWatermark,
+		Duration: duration,
+		Metrics:  videoMetrics,
+	}, ffmpegArgs...)
+	if err != nil {
+		return fmt.Errorf("ffmpeg text overlay failed: %v\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// formatOverlayDate reformats a booking date ("2006-01-02") with the layout's date format
+func formatOverlayDate(date, format string) string {
+	if format == "" {
+		return date
+	}
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		return t.Format(format)
+	}
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t.Format(format)
+	}
+	return date
+}
+
+// shortClock trims seconds from a booking time ("19:00:00" -> "19:00")
+func shortClock(clock string) string {
+	if len(clock) == len("15:04:05") && strings.Count(clock, ":") == 2 {
+		return clock[:5]
+	}
+	return clock
+}
+
+// prepare writes the static text to a file next to outputPath and returns the drawtext filter chain.
+// The static text is read through textfile= so booking data never has to be escaped for the filtergraph.
+// The returned cleanup removes the text file; the filter is empty when there is nothing to draw.
+func (o *TextOverlay) prepare(outputPath string) (string, func(), error) {
+	cleanup := func() {}
+	if o == nil {
+		return "", cleanup, nil
+	}
+
+	var filters []string
+
+	if len(o.Lines) > 0 {
+		textPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_overlay.txt"
+		if err := os.WriteFile(textPath, []byte(strings.Join(o.Lines, "\n")), 0644); err != nil {
+			return "", cleanup, fmt.Errorf("failed to write overlay text: %w", err)
+		}
+		cleanup = func() { os.Remove(textPath) }
+
+		filters = append(filters, o.drawtext(o.Layout.Position,
+			fmt.Sprintf("textfile=%s:expansion=none", filterPath(textPath))))
+	}
+
+	if o.Layout.ShowClock && !o.ClockStart.IsZero() {
+		// pts is relative to the first frame, localtime adds it to the capture start (default format "%Y-%m-%d %H:%M:%S")
+		filters = append(filters, o.drawtext(o.Layout.ClockPosition,
+			fmt.Sprintf("text='%%{pts\\:localtime\\:%d}'", o.ClockStart.Unix())))
+	}
+
+	return strings.Join(filters, ","), cleanup, nil
+}
+
+// drawtext builds one drawtext filter for the given text source at a position
+func (o *TextOverlay) drawtext(position, source string) string {
+	layout := o.Layout
+	x, y := overlayCoordinates(position, layout.Margin)
+
+	opts := []string{source}
+	if layout.FontFile != "" {
+		opts = append(opts, "fontfile="+filterPath(layout.FontFile))
+	}
+	opts = append(opts,
+		fmt.Sprintf("fontsize=%d", layout.FontSize),
+		"fontcolor="+layout.FontColor,
+		"line_spacing=6",
+	)
+	if layout.BoxColor != "" {
+		opts = append(opts, "box=1", "boxcolor="+layout.BoxColor, "boxborderw=8")
+	}
+	opts = append(opts, "x="+x, "y="+y)
+
+	return "drawtext=" + strings.Join(opts, ":")
+}
+
+// overlayCoordinates returns the drawtext x/y expressions for a position
+func overlayCoordinates(position string, margin int) (string, string) {
+	left := fmt.Sprintf("%d", margin)
+	center := "(w-text_w)/2"
+	right := fmt.Sprintf("w-text_w-%d", margin)
+	top := fmt.Sprintf("%d", margin)
+	bottom := fmt.Sprintf("h-text_h-%d", margin)
+
+	switch position {
+	case config.OverlayTopLeft:
+		return left, top
+	case config.OverlayTopCenter:
+		return center, top
+	case config.OverlayTopRight:
+		return right, top
+	case config.OverlayBottomCenter:
+		return center, bottom
+	case config.OverlayBottomRight:
+		return right, bottom
+	default:
+		return left, bottom
+	}
+}
+
+// filterPath quotes a file path for use as a filter option value (paths must not contain quotes)
+func filterPath(path string) string {
+	return "'" + strings.ReplaceAll(path, ":", `\:`) + "'"
+}
@@ -0,0 +1,168 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ayo-mwr/config"
+)
+
+const overlayBookingJSON = `{"field_name":"Court 1","field_id":7,"date":"2026-03-14","start_time":"19:00:00","end_time":"20:00:00"}`
+
+func TestNewBookingTextOverlay(t *testing.T) {
+	cases := []struct {
+		name    string
+		layout  config.TextOverlayLayout
+		rawJSON string
+		want    []string
+	}{
+		{
+			name:    "field name and booking time",
+			layout:  config.TextOverlayLayout{ShowFieldName: true, ShowBookingTime: true, DateFormat: "02 Jan 2006"},
+			rawJSON: overlayBookingJSON,
+			want:    []string{"Court 1", "14 Mar 2026 19:00 - 20:00"},
+		},
+		{
+			name:    "raw date without format",
+			layout:  config.TextOverlayLayout{ShowBookingTime: true},
+			rawJSON: overlayBookingJSON,
+			want:    []string{"2026-03-14 19:00 - 20:00"},
+		},
+		{
+			name:    "field id fallback",
+			layout:  config.TextOverlayLayout{ShowFieldName: true},
+			rawJSON: `{"field_id":7}`,
+			want:    []string{"Field 7"},
+		},
+		{
+			name:    "nothing enabled",
+			layout:  config.TextOverlayLayout{},
+			rawJSON: overlayBookingJSON,
+			want:    nil,
+		},
+		{
+			name:    "invalid json",
+			layout:  config.TextOverlayLayout{ShowFieldName: true, ShowBookingTime: true},
+			rawJSON: `{not json`,
+			want:    nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			overlay := NewBookingTextOverlay(tc.layout, tc.rawJSON, time.Time{})
+			if !reflect.DeepEqual(overlay.Lines, tc.want) {
+				t.Errorf("Lines = %q, want %q", overlay.Lines, tc.want)
+			}
+		})
+	}
+}
+
+func TestTextOverlayHasText(t *testing.T) {
+	var nilOverlay *TextOverlay
+	if nilOverlay.HasText() {
+		t.Errorf("nil overlay should have no text")
+	}
+	if (&TextOverlay{Layout: config.TextOverlayLayout{ShowClock: true}}).HasText() {
+		t.Errorf("clock without a start time should have no text")
+	}
+	if !(&TextOverlay{Layout: config.TextOverlayLayout{ShowClock: true}, ClockStart: time.Unix(1700000000, 0)}).HasText() {
+		t.Errorf("clock with a start time should have text")
+	}
+	if !(&TextOverlay{Lines: []string{"Court 1"}}).HasText() {
+		t.Errorf("overlay with lines should have text")
+	}
+}
+
+func TestTextOverlayPrepare(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "video.ts")
+	overlay := &TextOverlay{
+		Layout: config.TextOverlayLayout{
+			FontSize:      24,
+			FontColor:     "white",
+			BoxColor:      "black@0.5",
+			Position:      config.OverlayTopLeft,
+			ClockPosition: config.OverlayBottomRight,
+			Margin:        16,
+			ShowClock:     true,
+		},
+		Lines:      []string{"Court 1: 'A'", "14 Mar 2026 19:00 - 20:00"},
+		ClockStart: time.Unix(1700000000, 0),
+	}
+
+	filter, cleanup, err := overlay.prepare(outputPath)
+	if err != nil {
+		t.Fatalf("prepare failed: %v", err)
+	}
+
+	textPath := strings.TrimSuffix(outputPath, ".ts") + "_overlay.txt"
+	content, err := os.ReadFile(textPath)
+	if err != nil {
+		t.Fatalf("overlay text file not written: %v", err)
+	}
+	if string(content) != "Court 1: 'A'\n14 Mar 2026 19:00 - 20:00" {
+		t.Errorf("unexpected overlay text %q", content)
+	}
+
+	wantText := "drawtext=textfile=" + filterPath(textPath) + ":expansion=none:fontsize=24:fontcolor=white:line_spacing=6:box=1:boxcolor=black@0.5:boxborderw=8:x=16:y=16"
+	wantClock := `drawtext=text='%{pts\:localtime\:1700000000}':fontsize=24:fontcolor=white:line_spacing=6:box=1:boxcolor=black@0.5:boxborderw=8:x=w-text_w-16:y=h-text_h-16`
+	if filter != wantText+","+wantClock {
+		t.Errorf("filter = %q\nwant %q", filter, wantText+","+wantClock)
+	}
+
+	cleanup()
+	if _, err := os.Stat(textPath); !os.IsNotExist(err) {
+		t.Errorf("cleanup should remove the overlay text file")
+	}
+}
+
+func TestTextOverlayPrepareEmpty(t *testing.T) {
+	var nilOverlay *TextOverlay
+	filter, cleanup, err := nilOverlay.prepare("video.ts")
+	cleanup()
+	if err != nil || filter != "" {
+		t.Errorf("nil overlay: filter = %q, err = %v", filter, err)
+	}
+
+	filter, cleanup, err = (&TextOverlay{}).prepare(filepath.Join(t.TempDir(), "video.ts"))
+	cleanup()
+	if err != nil || filter != "" {
+		t.Errorf("empty overlay: filter = %q, err = %v", filter, err)
+	}
+}
+
+func TestOverlayHelpers(t *testing.T) {
+	if got := filterPath("C:/fonts/Inter.ttf"); got != `'C\:/fonts/Inter.ttf'` {
+		t.Errorf("filterPath = %s", got)
+	}
+
+	positions := map[string][2]string{
+		config.OverlayTopCenter:    {"(w-text_w)/2", "10"},
+		config.OverlayTopRight:     {"w-text_w-10", "10"},
+		config.OverlayBottomCenter: {"(w-text_w)/2", "h-text_h-10"},
+		"unknown":                  {"10", "h-text_h-10"},
+	}
+	for position, want := range positions {
+		if x, y := overlayCoordinates(position, 10); x != want[0] || y != want[1] {
+			t.Errorf("overlayCoordinates(%s) = %s, %s, want %s, %s", position, x, y, want[0], want[1])
+		}
+	}
+
+	clocks := map[string]string{"19:00:00": "19:00", "19:00": "19:00", "7pm": "7pm"}
+	for in, want := range clocks {
+		if got := shortClock(in); got != want {
+			t.Errorf("shortClock(%s) = %s, want %s", in, got, want)
+		}
+	}
+
+	if got := formatOverlayDate("2026-03-14T00:00:00Z", "Jan 2"); got != "Mar 14" {
+		t.Errorf("formatOverlayDate RFC3339 = %s", got)
+	}
+	if got := formatOverlayDate("14/03/2026", "Jan 2"); got != "14/03/2026" {
+		t.Errorf("formatOverlayDate unparseable = %s", got)
+	}
+}
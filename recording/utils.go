@@ -197,6 +197,11 @@ func FindSegmentsInRangeMultiDisk(cameraName string, startTime, endTime time.Tim
 	return result, nil
 }
 
+// ParseSegmentTimestamp extracts the recording start time from a segment filename
+func ParseSegmentTimestamp(filename string) (time.Time, error) {
+	return parseTimestampFromFilename(filename)
+}
+
 // parseTimestampFromFilename extracts timestamp from video filename (any extension)
 func parseTimestampFromFilename(filename string) (time.Time, error) {
 	filenameLower := strings.ToLower(filename)
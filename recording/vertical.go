@@ -0,0 +1,178 @@
+package recording
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+
+	"ayo-mwr/ffmpeg"
+)
+
+// Motion analysis samples the video on a small grayscale grid and measures the difference
+// between consecutive samples; the column with the most change is where the play is.
+const (
+	motionSampleFPS   = 2    // Samples per second
+	motionGridWidth   = 64   // Analysis grid width
+	motionGridHeight  = 36   // Analysis grid height
+	motionNoiseFloor  = 16   // Per-pixel difference below this is treated as sensor noise
+	motionMinActivity = 40   // Minimum changed pixels in a sample to count as activity
+	motionSmoothing   = 0.35 // Fraction of the way the centre moves towards new activity each second
+)
+
+// ActivityCenters returns, for each second of the video, the horizontal centre of the motion as a
+// fraction of the width (0.0 - 1.0). Seconds without noticeable motion keep the previous centre,
+// starting at fallback. The result is smoothed so the crop window pans instead of jumping.
+func ActivityCenters(videoID, videoPath string, fallback float64) ([]float64, error) {
+	filter := fmt.Sprintf("fps=%d,scale=%d:%d,format=gray,tblend=all_mode=difference",
+		motionSampleFPS, motionGridWidth, motionGridHeight)
+
+	// The samples are written to a file, since the runner reads FFmpeg's progress from stdout
+	samples, err := os.CreateTemp("", "motion-*.gray")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create motion sample file: %w", err)
+	}
+	samples.Close()
+	defer os.Remove(samples.Name())
+
+	output, err := ffmpeg.Run(ffmpeg.Job{VideoID: videoID, Stage: ffmpeg.StageVertical},
+		"-y",
+		"-v", "error",
+		"-i", videoPath,
+		"-an",
+		"-vf", filter,
+		"-f", "rawvideo",
+		samples.Name(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("motion analysis failed: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	frameSize := motionGridWidth * motionGridHeight
+	data, err := os.ReadFile(samples.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read motion samples: %w", err)
+	}
+	frames := len(data) / frameSize
+	if frames == 0 {
+		return nil, fmt.Errorf("motion analysis produced no frames for %s", videoPath)
+	}
+
+	seconds := (frames + motionSampleFPS - 1) / motionSampleFPS
+	centers := make([]float64, seconds)
+	current := fallback
+
+	for second := 0; second < seconds; second++ {
+		var weighted, total float64
+		for f := second * motionSampleFPS; f < (second+1)*motionSampleFPS && f < frames; f++ {
+			frame := data[f*frameSize : (f+1)*frameSize]
+			for i, value := range frame {
+				if value < motionNoiseFloor {
+					continue
+				}
+				column := i % motionGridWidth
+				weighted += (float64(column) + 0.5) * float64(value)
+				total += float64(value)
+			}
+		}
+
+		// total is a sum of differences, so compare against the noise floor scaled by the pixel count
+		if total >= motionMinActivity*motionNoiseFloor {
+			target := weighted / total / motionGridWidth
+			current += (target - current) * motionSmoothing
+		}
+		centers[second] = current
+	}
+
+	return centers, nil
+}
+
+// VerticalCropFilter returns the crop + scale filter that turns a landscape video into a
+// width x height portrait video. With one centre the crop window is fixed; with one centre per
+// second (see ActivityCenters) the window pans linearly from one second's centre to the next.
+func VerticalCropFilter(format VideoFormat, width, height int, centers []float64) string {
+	cropWidth := int(math.Round(float64(format.Height)*float64(width)/float64(height))) &^ 1
+	if cropWidth > format.Width {
+		cropWidth = format.Width &^ 1
+	}
+	maxX := format.Width - cropWidth
+
+	offset := func(center float64) int {
+		x := int(math.Round(center*float64(format.Width))) - cropWidth/2
+		if x < 0 {
+			return 0
+		}
+		if x > maxX {
+			return maxX
+		}
+		return x
+	}
+
+	if len(centers) == 0 {
+		centers = []float64{0.5}
+	}
+
+	// Flat sum of ramps instead of nested if() so long clips stay within FFmpeg's expression depth
+	var expr strings.Builder
+	previous := offset(centers[0])
+	fmt.Fprintf(&expr, "%d", previous)
+	for second := 1; second < len(centers); second++ {
+		x := offset(centers[second])
+		if delta := x - previous; delta > -8 && delta < 8 {
+			continue // Ignore jitter of a few pixels
+		}
+		fmt.Fprintf(&expr, "+(%d)*clip(t-%d,0,1)", x-previous, second-1)
+		previous = x
+	}
+
+	return fmt.Sprintf("crop=w=%d:h=%d:x='%s':y=0,scale=%d:%d,setsar=1",
+		cropWidth, format.Height, expr.String(), width, height)
+}
+
+// CreateVerticalExport renders a portrait version of videoPath to outputPath (MP4) using the crop
+// centres from ActivityCenters, or a single fixed centre.
+func CreateVerticalExport(videoID, videoPath, outputPath string, width, height int, centers []float64) error {
+	format, err := ProbeVideoFormat(videoPath)
+	if err != nil {
+		return err
+	}
+
+	filter := VerticalCropFilter(*format, width, height, centers)
+
+	args := []string{
+		"-y",
+		"-v", "warning",
+		"-i", videoPath,
+		"-vf", filter,
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "23",
+		"-pix_fmt", "yuv420p",
+	}
+	if format.HasAudio {
+		args = append(args, "-c:a", "aac")
+	} else {
+		args = append(args, "-an")
+	}
+
+	tmpPath := outputPath + ".tmp"
+	args = append(args, "-movflags", "+faststart", "-f", "mp4", tmpPath)
+
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StageVertical,
+		Duration: format.Duration,
+	}, args...)
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to create vertical export: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to store vertical export: %w", err)
+	}
+
+	log.Printf("CreateVerticalExport : Created %dx%d export of %s (%d crop points) -> %s", width, height, videoPath, len(centers), outputPath)
+	return nil
+}
@@ -256,6 +256,12 @@ func AddWatermark(inputVideo, watermarkImg, outputVideo string, x, y int) error
 // Opacity should be between 0.0 (fully transparent) and 1.0 (fully opaque).
 // This implementation preserves the original video quality as much as possible.
 func AddWatermarkWithPosition(inputVideo, watermarkImg, outputVideo string, position WatermarkPosition, margin int, opacity float64, resolution string) error {
+	return AddWatermarkWithOverlay(inputVideo, watermarkImg, outputVideo, position, margin, opacity, resolution, nil)
+}
+
+// AddWatermarkWithOverlay works like AddWatermarkWithPosition and also draws the booking text
+// overlay in the same encode. A nil overlay draws no text.
+func AddWatermarkWithOverlay(inputVideo, watermarkImg, outputVideo string, position WatermarkPosition, margin int, opacity float64, resolution string, overlay *TextOverlay) error {
 	log.Printf("AddWatermarkWithPosition : Adding watermark to video: %s", inputVideo)
 	if opacity < 0.0 {
 		opacity = 0.0
@@ -278,6 +284,15 @@ func AddWatermarkWithPosition(inputVideo, watermarkImg, outputVideo string, posi
 
 	// Use a simplified but more reliable filter chain
 	filter := fmt.Sprintf("overlay=%s:enable='between(t,0,999999)'", overlayExpr[8:])
+
+	textFilter, cleanupOverlay, err := overlay.prepare(outputVideo)
+	if err != nil {
+		return fmt.Errorf("AddWatermarkWithPosition : %v", err)
+	}
+	defer cleanupOverlay()
+	if textFilter != "" {
+		filter += "," + textFilter
+	}
 	log.Printf("AddWatermarkWithPosition : Filter: %s", filter)
 	// Verify watermark file exists and is readable
 	if _, err := os.Stat(watermarkImg); err != nil {
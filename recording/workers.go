@@ -175,5 +175,5 @@ func startCameraWithContext(ctx context.Context, cfg *config.Config, cam config.
     log.Printf("[workers] Starting camera %s with graceful shutdown support", cameraName)
     
     // Start the camera capture with context
-    captureRTSPStreamForCameraWithGracefulShutdown(cameraCtx, cfg, cam, idx)
+    captureRTSPStreamForCameraWithGracefulShutdown(cameraCtx, cfg, cam, idx, nil)
 }
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ayo-mwr/config"
+	"ayo-mwr/metrics"
+	"ayo-mwr/storage"
+	"ayo-mwr/transcode"
+)
+
+// AnimatedPreviewPaths returns where the animated WebP and GIF previews of a preview MP4 are written
+func AnimatedPreviewPaths(previewPath string) (string, string) {
+	base := strings.TrimSuffix(previewPath, filepath.Ext(previewPath))
+	return base + ".webp", base + ".gif"
+}
+
+// AnimatedPreviewKeys returns the R2 keys of the animated WebP and GIF previews of a video
+func AnimatedPreviewKeys(uniqueID string) (string, string) {
+	return fmt.Sprintf("mp4/%s_preview.webp", uniqueID), fmt.Sprintf("mp4/%s_preview.gif", uniqueID)
+}
+
+// CreateAnimatedPreviews generates the animated WebP preview (and GIF fallback if enabled) from a
+// preview MP4. Returns the created file paths; a path is empty when that format was not produced.
+func (s *BookingVideoService) CreateAnimatedPreviews(uniqueID, previewPath string) (string, string, error) {
+	previewConfig, err := config.NewAnimatedPreviewConfigService(s.db).GetAnimatedPreviewConfig()
+	if err != nil || !previewConfig.Enabled {
+		return "", "", err
+	}
+
+	webpPath, gifPath := AnimatedPreviewPaths(previewPath)
+
+	if err := transcode.GenerateAnimatedPreview(uniqueID, previewPath, webpPath, transcode.AnimatedWebP, previewConfig); err != nil {
+		os.Remove(webpPath)
+		return "", "", err
+	}
+
+	if !previewConfig.GIFEnabled {
+		return webpPath, "", nil
+	}
+	if err := transcode.GenerateAnimatedPreview(uniqueID, previewPath, gifPath, transcode.AnimatedGIF, previewConfig); err != nil {
+		os.Remove(gifPath)
+		log.Printf("Warning: Failed to create GIF preview: %v", err)
+		return webpPath, "", nil
+	}
+
+	return webpPath, gifPath, nil
+}
+
+// UploadAnimatedPreviews uploads existing animated preview files to R2 and stores their URLs.
+// Empty or missing paths are skipped.
+func (s *BookingVideoService) UploadAnimatedPreviews(uniqueID, webpPath, gifPath string, videoMetrics *metrics.VideoProcessingMetrics) (string, string) {
+	webpKey, gifKey := AnimatedPreviewKeys(uniqueID)
+
+	upload := func(localPath, key string) string {
+		if localPath == "" {
+			return ""
+		}
+		if _, err := os.Stat(localPath); err != nil {
+			return ""
+		}
+		if _, err := storage.UploadVideoArtifact(s.r2Client, s.db, uniqueID, localPath, key, videoMetrics); err != nil {
+			log.Printf("Warning: Failed to upload animated preview %s: %v", localPath, err)
+			return ""
+		}
+		url := fmt.Sprintf("%s/%s", s.r2Client.GetBaseURL(), key)
+		log.Printf("Animated preview uploaded to custom URL: %s", url)
+		return url
+	}
+
+	webpURL := upload(webpPath, webpKey)
+	gifURL := upload(gifPath, gifKey)
+	if webpURL == "" && gifURL == "" {
+		return "", ""
+	}
+
+	if webpURL == "" {
+		webpKey = ""
+	}
+	if gifURL == "" {
+		gifKey = ""
+	}
+	if err := s.db.UpdateVideoAnimatedPreviews(uniqueID, webpKey, webpURL, gifKey, gifURL); err != nil {
+		log.Printf("Warning: Failed to store animated preview URLs for %s: %v", uniqueID, err)
+	}
+
+	return webpURL, gifURL
+}
@@ -15,6 +15,7 @@ import (
 
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
 	"ayo-mwr/metrics"
 	"ayo-mwr/recording"
 	"ayo-mwr/storage"
@@ -25,25 +26,30 @@ import (
 type AyoAPIClient interface {
 	GetBookings(date string) (map[string]interface{}, error)
 	SaveVideoAvailable(bookingID, videoType, previewURL, thumbnailURL, uniqueID string, startTime, endTime time.Time, duration int) (map[string]interface{}, error)
+	SaveVideoAvailableWithRenditions(bookingID, videoType, previewURL, thumbnailURL, uniqueID string, startTime, endTime time.Time, duration int, renditions []database.VideoRendition) (map[string]interface{}, error)
 	GetWatermark(resolution string) (string, error)
+	GetBumper(kind string) (string, error)
 }
 
 // BookingVideoService handles all operations related to booking videos
 type BookingVideoService struct {
 	db        database.Database
 	ayoClient AyoAPIClient
-	r2Client  *storage.R2Storage
+	r2Client  storage.ObjectStore
 	config    *config.Config
 	metricsCollector *metrics.MetricsCollector
 }
 
 // Tipe file sementara yang disimpan
 const (
-	TmpTypeHLS       = "hls"
-	TmpTypeMerged    = "merged"
-	TmpTypeWatermark = "watermark"
-	TmpTypePreview   = "preview"
-	TmpTypeThumbnail = "thumbnail"
+	TmpTypeHLS        = "hls"
+	TmpTypeMerged     = "merged"
+	TmpTypeWatermark  = "watermark"
+	TmpTypePreview    = "preview"
+	TmpTypeThumbnail  = "thumbnail"
+	TmpTypeSlowMotion = "slowmo"
+	TmpTypeVertical   = "vertical"
+	TmpTypeSprites    = "sprites"
 )
 
 // getTempPath mengembalikan path file sementara berdasarkan tipe dan uniqueID
@@ -65,7 +71,7 @@ func sanitizeID(id string) string {
 }
 
 // NewBookingVideoService creates a new booking video service
-func NewBookingVideoService(db database.Database, ayoClient AyoAPIClient, r2Client *storage.R2Storage, cfg *config.Config) *BookingVideoService {
+func NewBookingVideoService(db database.Database, ayoClient AyoAPIClient, r2Client storage.ObjectStore, cfg *config.Config) *BookingVideoService {
 	return &BookingVideoService{
 		db:        db,
 		ayoClient: ayoClient,
@@ -137,31 +143,24 @@ func (s *BookingVideoService) ProcessVideoSegments(
 
 	log.Printf("ProcessVideoSegments: Created initial entry for video %s with status Initial", uniqueID)
 
+	// Start metrics tracking here so merge progress is reported under the video ID;
+	// UploadProcessedVideo picks the same metrics up
+	videoMetrics := s.metricsCollector.StartVideo(uniqueID)
+
 	// Tentukan direktori tempat segment berada (ambil dari segment pertama)
 	segmentDir := filepath.Dir(segments[0])
 
 	// Check if video already has real-time watermark applied during recording
-	hasRealtimeWatermark := false
-	
-	// Check if real-time watermarking is enabled
-	enableRealtimeWatermark := true // Default to enabled
-	if realtimeConfig, err := s.db.GetSystemConfig(database.ConfigEnableRealtimeWatermark); err == nil {
-		if realtimeConfig.Value == "false" {
-			enableRealtimeWatermark = false
-		}
-	}
-	
-	if enableRealtimeWatermark {
-		if venueConfig, err := s.db.GetSystemConfig(database.ConfigVenueCode); err == nil && venueConfig.Value != "" {
-			if watermarkPath, err := recording.GetWatermark(venueConfig.Value); err == nil && watermarkPath != "" {
-				hasRealtimeWatermark = true
-				log.Printf("✅ ProcessVideoSegments: Video has real-time watermark, skipping post-processing watermark")
-			}
-		}
+	hasRealtimeWatermark := realtimeWatermarkApplied(s.db)
+	if hasRealtimeWatermark {
+		log.Printf("✅ ProcessVideoSegments: Video has real-time watermark, skipping post-processing watermark")
 	}
 
 	watermarkedVideoPath := s.getTempPath(TmpTypeWatermark, uniqueID, ".ts", camera.Name)
 
+	// Booking info text overlay, drawn in the same encode as the merge and watermark
+	overlay := s.bookingTextOverlay(rawJSON, startTime)
+
 	// Only apply post-processing watermark if not already applied during recording
 	if !hasRealtimeWatermark {
 		log.Printf("ProcessVideoSegments : Merging video segments and adding watermark in one FFmpeg operation, output to: %s", watermarkedVideoPath)
@@ -171,38 +170,59 @@ func (s *BookingVideoService) ProcessVideoSegments(
 		if watermarkErr != nil {
 			log.Printf("ProcessVideoSegments : Warning: Failed to get watermark: %v, continuing with merge only", watermarkErr)
 			// Jika gagal mendapatkan watermark, lakukan merge saja
-			err := recording.MergeSessionVideos(segmentDir, startTime, endTime, watermarkedVideoPath, camera.Resolution)
+			err := recording.MergeSessionVideosWithOverlay(segmentDir, startTime, endTime, watermarkedVideoPath, camera.Resolution, overlay, videoMetrics)
 			if err != nil {
-				s.db.UpdateVideoStatus(uniqueID, database.StatusFailed, err.Error())
-				return "", fmt.Errorf("failed to merge video segments: %v", err)
+				return "", s.processingError(uniqueID, fmt.Errorf("failed to merge video segments: %v", err))
 			}
 		} else {
 			// Dapatkan pengaturan watermark
 			pos, margin, opacity := recording.GetWatermarkSettings()
 
 			// Lakukan merge dan tambahkan watermark dalam satu operasi
-			err := recording.MergeAndWatermark(segmentDir, startTime, endTime, watermarkedVideoPath,
-				watermarkPath, pos, margin, opacity, camera.Resolution)
+			err := recording.MergeAndWatermarkWithOverlay(segmentDir, startTime, endTime, watermarkedVideoPath,
+				watermarkPath, pos, margin, opacity, camera.Resolution, overlay, videoMetrics)
+			if err != nil && ffmpeg.IsCancelled(uniqueID) {
+				return "", s.processingError(uniqueID, err)
+			}
 			if err != nil {
 				log.Printf("ProcessVideoSegments : Warning: Failed to merge and add watermark: %v, falling back to merge only", err)
 				// Jika gagal, coba lakukan hanya merge saja
-				err := recording.MergeSessionVideos(segmentDir, startTime, endTime, watermarkedVideoPath, camera.Resolution)
+				err := recording.MergeSessionVideosWithOverlay(segmentDir, startTime, endTime, watermarkedVideoPath, camera.Resolution, overlay, videoMetrics)
 				if err != nil {
-					s.db.UpdateVideoStatus(uniqueID, database.StatusFailed, err.Error())
-					return "", fmt.Errorf("failed to merge video segments in fallback mode: %v", err)
+					return "", s.processingError(uniqueID, fmt.Errorf("failed to merge video segments in fallback mode: %v", err))
 				}
 			}
 		}
 	} else {
 		log.Printf("ProcessVideoSegments : Real-time watermark detected, performing merge only, output to: %s", watermarkedVideoPath)
 		// Only merge segments without adding watermark
-		err := recording.MergeSessionVideos(segmentDir, startTime, endTime, watermarkedVideoPath, camera.Resolution)
+		err := recording.MergeSessionVideosWithOverlay(segmentDir, startTime, endTime, watermarkedVideoPath, camera.Resolution, overlay, videoMetrics)
 		if err != nil {
-			s.db.UpdateVideoStatus(uniqueID, database.StatusFailed, err.Error())
-			return "", fmt.Errorf("failed to merge video segments: %v", err)
+			return "", s.processingError(uniqueID, fmt.Errorf("failed to merge video segments: %v", err))
 		}
 	}
 
+	// Stop here if the video was cancelled while merging
+	if ffmpeg.IsCancelled(uniqueID) {
+		return "", s.processingError(uniqueID, nil)
+	}
+
+	// Button clips can get a slow-motion replay of their final seconds (before any outro bumper)
+	if videoType == "clip" {
+		replayPath := s.getTempPath(TmpTypeSlowMotion, uniqueID, ".mp4", camera.Name)
+		if err := applySlowMotionReplay(s.db, uniqueID, watermarkedVideoPath, replayPath, rawJSON); err != nil {
+			log.Printf("ProcessVideoSegments : Warning: Failed to create slow-motion replay: %v, continuing without replay", err)
+		}
+	}
+
+	// Attach the venue intro/outro bumpers selected for this video type
+	introSeconds, outroSeconds, err := attachVenueBumpers(s.db, s.ayoClient, uniqueID, watermarkedVideoPath, videoType)
+	if err != nil {
+		log.Printf("ProcessVideoSegments : Warning: Failed to attach bumpers: %v, continuing without bumpers", err)
+	} else if introSeconds > 0 || outroSeconds > 0 {
+		s.db.UpdateVideoBumpers(uniqueID, introSeconds, outroSeconds)
+	}
+
 	videoPathForNextStep := watermarkedVideoPath
 	log.Printf("ProcessVideoSegments : videoPathForNextStep %s", videoPathForNextStep)
 	log.Printf("ProcessVideoSegments : camera.Resolution %s", camera.Resolution)
@@ -214,6 +234,10 @@ func (s *BookingVideoService) ProcessVideoSegments(
 		// Continue without storage info rather than failing
 	}
 
+	if ffmpeg.IsCancelled(uniqueID) {
+		return "", s.processingError(uniqueID, nil)
+	}
+
 	// Step 3: update video metadata
 	videoMeta := database.VideoMetadata{
 		ID:            uniqueID,
@@ -228,6 +252,9 @@ func (s *BookingVideoService) ProcessVideoSegments(
 		s.db.UpdateVideoStatus(uniqueID, database.StatusFailed, err.Error())
 		return "", fmt.Errorf("ProcessVideoSegments: error updating database entry: %v", err)
 	}
+	if ffmpeg.IsCancelled(uniqueID) {
+		return "", s.processingError(uniqueID, nil)
+	}
 	log.Printf("ProcessVideoSegments : Database entry created for uniqueID: %s", uniqueID)
 	return uniqueID, nil
 }
@@ -239,17 +266,24 @@ func (s *BookingVideoService) UploadProcessedVideo(
 	bookingID string,
 	cameraName string,
 ) (string, string, error) {
-	// Start metrics tracking for this video
-	videoMetrics := s.metricsCollector.StartVideo(uniqueID)
+	// Continue the metrics started by ProcessVideoSegments, or start tracking this video
+	videoMetrics := s.metricsCollector.GetMetrics(uniqueID)
+	if videoMetrics == nil {
+		videoMetrics = s.metricsCollector.StartVideo(uniqueID)
+	}
 	defer videoMetrics.Finalize()
 
+	if ffmpeg.IsCancelled(uniqueID) {
+		return "", "", s.processingError(uniqueID, nil)
+	}
+
 	// UniqueID sudah berisi booking ID yang aman
 	// getVideoMeta := s.db.GetVideo(uniqueID)
 
 	// Create preview video (di folder preview)
 	previewVideoPath := s.getTempPath(TmpTypePreview, uniqueID, ".mp4", cameraName)
 	log.Printf("Creating preview video at: %s", previewVideoPath)
-	err := s.CreateVideoPreviewWithMetrics(videoPath, previewVideoPath, videoMetrics)
+	err := s.CreateVideoPreviewWithMetrics(uniqueID, videoPath, previewVideoPath, videoMetrics)
 	if err != nil {
 		log.Printf("Warning: Failed to create preview video: %v", err)
 		previewVideoPath = "" // Don't use preview if creation failed
@@ -258,12 +292,17 @@ func (s *BookingVideoService) UploadProcessedVideo(
 	// Create thumbnail (di folder thumbnail)
 	thumbnailPath := s.getTempPath(TmpTypeThumbnail, uniqueID, ".jpg", cameraName)
 	log.Printf("Creating thumbnail at: %s", thumbnailPath)
-	err = s.CreateThumbnail(videoPath, thumbnailPath)
+	err = s.CreateThumbnailWithMetrics(uniqueID, videoPath, thumbnailPath, videoMetrics)
 	if err != nil {
 		log.Printf("Warning: Failed to create thumbnail: %v", err)
 		thumbnailPath = "" // Don't use thumbnail if creation failed
 	}
 
+	// Don't upload anything for a video cancelled while its preview was being made
+	if ffmpeg.IsCancelled(uniqueID) {
+		return "", "", s.processingError(uniqueID, nil)
+	}
+
 	// Buat direktori HLS untuk video ini di folder hls, bukan di tmp/hls
 	// Sesuai dengan konfigurasi server di api/server.go
 	// hlsParentDir := filepath.Join(s.config.StoragePath, "hls")
@@ -284,7 +323,7 @@ func (s *BookingVideoService) UploadProcessedVideo(
 	// 	log.Printf("HLS stream can be accessed at: %s", hlsURL)
 
 	// 	// Upload HLS ke R2
-	// 	// _, r2HlsURL, err := s.r2Client.UploadHLSStream(hlsDir, uniqueID)
+	// 	// _, r2HlsURL, err := storage.UploadHLSStream(s.r2Client, hlsDir, uniqueID)
 	// 	// if err != nil {
 	// 	// 	log.Printf("Warning: Failed to upload HLS stream to R2: %v", err)
 	// 	// } else {
@@ -308,7 +347,7 @@ func (s *BookingVideoService) UploadProcessedVideo(
 	previewPath := fmt.Sprintf("mp4/%s_preview.mp4", uniqueID)
 	previewURL := ""
 	if previewVideoPath != "" {
-		_, err = s.r2Client.UploadFileWithMetrics(previewVideoPath, previewPath, videoMetrics)
+		_, err = storage.UploadVideoArtifact(s.r2Client, s.db, uniqueID, previewVideoPath, previewPath, videoMetrics)
 		if err != nil {
 			log.Printf("Warning: Failed to upload preview video: %v", err)
 		} else {
@@ -318,11 +357,20 @@ func (s *BookingVideoService) UploadProcessedVideo(
 		}
 	}
 
+	// Create and upload the animated WebP/GIF previews from the preview MP4
+	if previewVideoPath != "" {
+		webpPath, gifPath, err := s.CreateAnimatedPreviews(uniqueID, previewVideoPath)
+		if err != nil {
+			log.Printf("Warning: Failed to create animated preview: %v", err)
+		}
+		s.UploadAnimatedPreviews(uniqueID, webpPath, gifPath, videoMetrics)
+	}
+
 	// Upload thumbnail if available
 	thumbnailR2Path := fmt.Sprintf("mp4/%s_thumbnail.jpg", uniqueID)
 	thumbnailURL := ""
 	if thumbnailPath != "" {
-		_, err = s.r2Client.UploadFileWithMetrics(thumbnailPath, thumbnailR2Path, videoMetrics)
+		_, err = storage.UploadVideoArtifact(s.r2Client, s.db, uniqueID, thumbnailPath, thumbnailR2Path, videoMetrics)
 		if err != nil {
 			log.Printf("Warning: Failed to upload thumbnail: %v", err)
 		} else {
@@ -332,6 +380,22 @@ func (s *BookingVideoService) UploadProcessedVideo(
 		}
 	}
 
+	// Render and upload the vertical (9:16) social rendition if the export profile covers this video
+	if video, err := s.db.GetVideo(uniqueID); err == nil && video != nil && video.R2VerticalURL == "" {
+		verticalPath := s.getTempPath(TmpTypeVertical, uniqueID, ".mp4", cameraName)
+		if _, err := createVerticalRendition(s.db, s.r2Client, video, videoPath, verticalPath); err != nil {
+			log.Printf("Warning: Failed to create vertical rendition: %v", err)
+		}
+	}
+
+	// Generate thumbnail sprite sheets + WebVTT scrub track for every delivered video
+	if video, err := s.db.GetVideo(uniqueID); err == nil && video != nil && video.R2SpriteVTTURL == "" {
+		spriteDir := s.getTempPath(TmpTypeSprites, uniqueID, "", cameraName)
+		if _, _, err := GenerateAndUploadSprites(s.db, s.r2Client, uniqueID, videoPath, spriteDir); err != nil {
+			log.Printf("Warning: Failed to create sprite sheets: %v", err)
+		}
+	}
+
 	// Get video info (size and duration)
 	fileInfo, err := os.Stat(videoPath)
 	var fileSize int64
@@ -502,8 +566,9 @@ func (s *BookingVideoService) CleanupTemporaryFiles(mergedPath, watermarkedPath,
 	}
 }
 
-// addWatermarkToVideo adds a watermark to a video
-func (s *BookingVideoService) addWatermarkToVideo(inputPath, outputPath string, resolution string) error {
+// addWatermarkToVideo adds a watermark and the booking info text overlay to a video.
+// captureStart is the capture time of the video's first frame, used for the running clock.
+func (s *BookingVideoService) addWatermarkToVideo(inputPath, outputPath string, resolution string, rawJSON string, captureStart time.Time) error {
 	// Attempt to get watermark from AYO API
 	log.Printf("addWatermarkToVideo : Attempting to get watermark from AYO API")
 	watermarkPath, err := s.ayoClient.GetWatermark(resolution)
@@ -514,10 +579,11 @@ func (s *BookingVideoService) addWatermarkToVideo(inputPath, outputPath string,
 
 	// Get watermark position settings
 	pos, margin, opacity := recording.GetWatermarkSettings()
-	log.Printf("addWatermarkToVideo : Watermark position: %s, margin: %d, opacity: %f", pos, margin, opacity)
+	log.Printf("addWatermarkToVideo : Watermark position: %v, margin: %d, opacity: %f", pos, margin, opacity)
 
-	// Add watermark to video
-	err = recording.AddWatermarkWithPosition(inputPath, watermarkPath, outputPath, pos, margin, opacity, resolution)
+	// Add watermark and text overlay to video in one encode
+	overlay := s.bookingTextOverlay(rawJSON, captureStart)
+	err = recording.AddWatermarkWithOverlay(inputPath, watermarkPath, outputPath, pos, margin, opacity, resolution, overlay)
 	if err != nil {
 		return fmt.Errorf("addWatermarkToVideo : failed to add watermark: %v", err)
 	}
@@ -526,31 +592,83 @@ func (s *BookingVideoService) addWatermarkToVideo(inputPath, outputPath string,
 	return nil
 }
 
-// CreateVideoPreview creates a preview video using interval-based clipping based on video duration
+// bookingTextOverlay returns the booking info text overlay for this venue, or nil when disabled
+func (s *BookingVideoService) bookingTextOverlay(rawJSON string, captureStart time.Time) *recording.TextOverlay {
+	return bookingTextOverlay(s.db, rawJSON, captureStart)
+}
+
+// realtimeWatermarkApplied reports whether segments are watermarked while recording, which is the
+// case when real-time watermarking is enabled (the default) and the venue has a watermark
+func realtimeWatermarkApplied(db database.Database) bool {
+	if realtimeConfig, err := db.GetSystemConfig(database.ConfigEnableRealtimeWatermark); err == nil && realtimeConfig.Value == "false" {
+		return false
+	}
+	venueConfig, err := db.GetSystemConfig(database.ConfigVenueCode)
+	if err != nil || venueConfig.Value == "" {
+		return false
+	}
+	watermarkPath, err := recording.GetWatermark(venueConfig.Value)
+	return err == nil && watermarkPath != ""
+}
+
+// bookingTextOverlay returns the booking info text overlay configured for the venue, or nil when disabled
+func bookingTextOverlay(db database.Database, rawJSON string, captureStart time.Time) *recording.TextOverlay {
+	venueCode := ""
+	if venueConfig, err := db.GetSystemConfig(database.ConfigVenueCode); err == nil {
+		venueCode = venueConfig.Value
+	}
+
+	layout, ok := config.NewTextOverlayConfigService(db).LayoutFor(venueCode)
+	if !ok {
+		return nil
+	}
+	return recording.NewBookingTextOverlay(layout, rawJSON, captureStart)
+}
+
+// CreateVideoPreview creates a preview video for a file that is not tracked in the database,
+// using the active preview template
 func (s *BookingVideoService) CreateVideoPreview(inputPath, outputPath string) error {
-	return s.CreateVideoPreviewWithMetrics(inputPath, outputPath, nil)
+	return s.CreateVideoPreviewWithMetrics("", inputPath, outputPath, nil)
 }
 
-// CreateVideoPreviewWithMetrics creates a preview video with metrics tracking
-func (s *BookingVideoService) CreateVideoPreviewWithMetrics(inputPath, outputPath string, videoMetrics *metrics.VideoProcessingMetrics) error {
+// CreateVideoPreviewWithMetrics creates a preview video with metrics tracking (videoMetrics may be nil).
+// Snippet placement, length, resolution and size follow the active preview template; the
+// template name is recorded on the video when videoID is set.
+func (s *BookingVideoService) CreateVideoPreviewWithMetrics(videoID, inputPath, outputPath string, videoMetrics *metrics.VideoProcessingMetrics) error {
 	// Start preview metrics if provided
 	if videoMetrics != nil {
 		videoMetrics.StartPreview()
 		defer videoMetrics.EndPreview()
 	}
-	// Get video duration to determine which interval pattern to use
+	// Get video duration to place the snippets
 	duration, err := s.GetVideoDuration(inputPath)
 	if err != nil {
 		return fmt.Errorf("failed to get video duration: %v", err)
 	}
 
-	// Using duration directly in seconds for more accurate interval selection
-	log.Printf("Creating preview for video with duration: %.2f minutes (%v seconds)", duration/60, duration)
+	template := s.activePreviewTemplate()
+	log.Printf("Creating preview for video with duration: %.2f minutes (%v seconds) using template %q (%s, %d x %.1fs)",
+		duration/60, duration, template.Name, template.Placement, template.SnippetCount, template.SnippetLengthSeconds)
+
+	// Gather placement hints required by the template
+	var activity, presses []float64
+	switch template.Placement {
+	case config.PreviewPlacementActivity:
+		activity, err = detectSceneChanges(videoID, inputPath)
+		if err != nil {
+			log.Printf("Warning: %v, falling back to even placement", err)
+		}
+	case config.PreviewPlacementButtonPress:
+		if videoID != "" && s.db != nil {
+			if video, err := s.db.GetVideo(videoID); err == nil {
+				presses = buttonPressOffsets(s.db, video)
+			}
+		}
+	}
+
+	starts := planPreviewSnippets(template, duration, activity, presses)
+	log.Printf("preview snippet starts (seconds): %v", starts)
 
-	// Define intervals based on video duration directly in seconds
-	// Each interval is a time range to extract (start_time, end_time)
-	intervals := determineIntervals(int(duration))
-	log.Printf("intervals: %v", intervals)
 	// Create a temporary directory for clip segments
 	tmpDir := filepath.Join(os.TempDir(), fmt.Sprintf("preview_clips_%d", time.Now().UnixNano()))
 	if err := os.MkdirAll(tmpDir, 0755); err != nil {
@@ -566,29 +684,36 @@ func (s *BookingVideoService) CreateVideoPreviewWithMetrics(inputPath, outputPat
 	}
 	defer clipListFile.Close()
 
-	// Extract each interval segment
-	for i, interval := range intervals {
+	// Extract each snippet
+	for i, start := range starts {
 		clipPath := filepath.Join(tmpDir, fmt.Sprintf("clip_%d.mp4", i))
 
 		// Extract the clip using ffmpeg with software encoding
 		ffmpegArgs := []string{"-y",
+			"-ss", strconv.FormatFloat(start, 'f', 3, 64), // Start time
 			"-i", inputPath,
-			"-ss", interval.start, // Start time
-			"-to", interval.end, // End time
+			"-t", strconv.FormatFloat(template.SnippetLengthSeconds, 'f', 3, 64), // Snippet length
 		}
 
-		// Add software encoder
+		// Scale to the template resolution if configured
+		if template.Width > 0 && template.Height > 0 {
+			ffmpegArgs = append(ffmpegArgs, "-vf", fmt.Sprintf(
+				"scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
+				template.Width, template.Height, template.Width, template.Height))
+		}
+
+		// Add the encoder picked for previews by the encoder benchmark
+		ffmpegArgs = append(ffmpegArgs, recording.EncoderForWorkload(recording.WorkloadPreview).WorkloadEncoderArgs(recording.WorkloadPreview)...)
 		ffmpegArgs = append(ffmpegArgs,
-			"-c:v", "libx264",
-			"-crf", "22", // Good quality (lower number = better quality)
-			"-preset", "ultrafast", // Faster encoding
 			"-c:a", "aac", // Use consistent audio codec
 			clipPath,
 		)
 
-		cmd := exec.Command("ffmpeg", ffmpegArgs...)
-
-		out, err := cmd.CombinedOutput()
+		out, err := ffmpeg.Run(ffmpeg.Job{
+			Stage:    ffmpeg.StagePreview,
+			Duration: template.SnippetLengthSeconds,
+			Metrics:  videoMetrics,
+		}, ffmpegArgs...)
 		if err != nil {
 			return fmt.Errorf("failed to extract clip %d: %v, output: %s", i, err, string(out))
 		}
@@ -601,29 +726,61 @@ func (s *BookingVideoService) CreateVideoPreviewWithMetrics(inputPath, outputPat
 	clipListFile.Close()
 
 	// Concatenate all clips into the final preview video with software encoding
-	ffmpegArgs := []string{"-y"}
+	previewSeconds := float64(len(starts)) * template.SnippetLengthSeconds
+	if err := concatPreviewClips(clipListPath, outputPath, nil, previewSeconds, videoMetrics); err != nil {
+		return err
+	}
 
-	ffmpegArgs = append(ffmpegArgs,
+	// Enforce the template size budget by re-encoding at a bitrate that fits
+	if template.MaxSizeMB > 0 {
+		if info, err := os.Stat(outputPath); err == nil && float64(info.Size()) > template.MaxSizeMB*1024*1024 {
+			// Leave 10% headroom for container overhead and 64k for audio
+			videoKbps := int(template.MaxSizeMB*1024*8*0.9/previewSeconds) - 64
+			if videoKbps < 100 {
+				videoKbps = 100
+			}
+			log.Printf("Preview is %.2f MB, above the %.2f MB budget of template %q, re-encoding at %dk",
+				float64(info.Size())/1024/1024, template.MaxSizeMB, template.Name, videoKbps)
+
+			bitrate := fmt.Sprintf("%dk", videoKbps)
+			if err := concatPreviewClips(clipListPath, outputPath, []string{
+				"-b:v", bitrate, "-maxrate", bitrate, "-bufsize", fmt.Sprintf("%dk", videoKbps*2), "-b:a", "64k",
+			}, previewSeconds, videoMetrics); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Record which template produced this preview
+	if videoID != "" && s.db != nil {
+		if err := s.db.UpdateVideoPreviewTemplate(videoID, template.Name); err != nil {
+			log.Printf("Warning: Failed to record preview template for %s: %v", videoID, err)
+		}
+	}
+
+	return nil
+}
+
+// concatPreviewClips concatenates the extracted clips into the preview file.
+// rateArgs replaces the default constant quality setting when a bitrate is required.
+func concatPreviewClips(clipListPath, outputPath string, rateArgs []string, previewSeconds float64, videoMetrics *metrics.VideoProcessingMetrics) error {
+	ffmpegArgs := []string{"-y",
 		"-f", "concat",
 		"-safe", "0",
 		"-i", clipListPath,
-	)
-
-	// Add software encoder
-	ffmpegArgs = append(ffmpegArgs,
-		"-c:v", "libx264",
-		"-crf", "22", // Good quality
-		"-preset", "ultrafast",
-	)
+	}
+	ffmpegArgs = append(ffmpegArgs, recording.EncoderForWorkload(recording.WorkloadPreview).WorkloadEncoderArgs(recording.WorkloadPreview, rateArgs...)...)
 
 	ffmpegArgs = append(ffmpegArgs,
 		"-c:a", "aac",
 		outputPath,
 	)
 
-	cmd := exec.Command("ffmpeg", ffmpegArgs...)
-
-	out, err := cmd.CombinedOutput()
+	out, err := ffmpeg.Run(ffmpeg.Job{
+		Stage:    ffmpeg.StagePreview,
+		Duration: previewSeconds,
+		Metrics:  videoMetrics,
+	}, ffmpegArgs...)
 	if err != nil {
 		return fmt.Errorf("failed to concatenate clips: %v, output: %s", err, string(out))
 	}
@@ -631,167 +788,18 @@ func (s *BookingVideoService) CreateVideoPreviewWithMetrics(inputPath, outputPat
 	return nil
 }
 
-// timeInterval represents a start and end time for video clipping
-type timeInterval struct {
-	start string
-	end   string
-}
-
-// determineIntervals returns the appropriate time intervals for clipping based on video duration in seconds
-func determineIntervals(durationSeconds int) []timeInterval {
-	// Konstanta durasi dalam detik
-	const (
-		fiveMinutesInSeconds   = 5 * 60      // 5 menit dalam detik
-		thirtyMinutesInSeconds = 30 * 60     // 30 menit dalam detik
-		oneHourInSeconds       = 60 * 60     // 1 jam dalam detik
-		twoHoursInSeconds      = 2 * 60 * 60 // 2 jam dalam detik
-		threeHoursInSeconds    = 3 * 60 * 60 // 3 jam dalam detik
-		fourHoursInSeconds     = 4 * 60 * 60 // 4 jam dalam detik
-		fiveHoursInSeconds     = 5 * 60 * 60 // 5 jam dalam detik
-		sixHoursInSeconds      = 6 * 60 * 60 // 6 jam dalam detik
-		sevenHoursInSeconds    = 7 * 60 * 60 // 7 jam dalam detik
-	)
-
-	// Helper function to create an interval
-	makeInterval := func(timeStr string) timeInterval {
-		// Parse the time string (format: H:MM:SS)
-		parts := strings.Split(timeStr, ":")
-
-		// Create the end time (add 2 seconds)
-		minuteStr := parts[1]
-		secondStr := parts[2]
-
-		// Calculate seconds for end time
-		second, _ := strconv.Atoi(secondStr)
-		second += 2
-
-		// Handle overflow
-		if second >= 60 {
-			second -= 60
-			minute, _ := strconv.Atoi(minuteStr)
-			minute++
-			minuteStr = fmt.Sprintf("%02d", minute)
-		}
-
-		endTime := fmt.Sprintf("%s:%s:%02d", parts[0], minuteStr, second)
-
-		return timeInterval{start: timeStr, end: endTime}
-	}
-
-	// Determine intervals based on video duration
-	intervals := []timeInterval{}
-
-	// Always include the first interval at 0:00:00
-	intervals = append(intervals, makeInterval("0:00:00"))
-	log.Printf("Duration in seconds: %d", durationSeconds)
-	log.Printf("Duration in minutes: %.2f", float64(durationSeconds)/60)
-	if durationSeconds < 20 {
-		// Videos less than 20 seconds
-		return []timeInterval{makeInterval("0:00:00")}
-	} else if durationSeconds < 30 {
-		// Videos between 20-30 seconds
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:00:20"),
-		}
-	} else if durationSeconds < 60 {
-		// Videos between 30-60 seconds
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:00:20"),
-			makeInterval("0:00:40"),
-		}
-	} else if durationSeconds >= 60 && durationSeconds < 120 {
-		// 1 minute
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:00:20"),
-			makeInterval("0:00:40"),
-		}
-	} else if durationSeconds <= thirtyMinutesInSeconds+fiveMinutesInSeconds {
-		// 30 menit + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:10:00"),
-			makeInterval("0:20:00"),
-		}
-	} else if durationSeconds <= oneHourInSeconds+fiveMinutesInSeconds {
-		// 1 jam + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:12:00"),
-			makeInterval("0:24:00"),
-			makeInterval("0:36:00"),
-			makeInterval("0:48:00"),
-		}
-	} else if durationSeconds <= twoHoursInSeconds+fiveMinutesInSeconds {
-		// 2 jam + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:24:00"),
-			makeInterval("0:48:00"),
-			makeInterval("1:12:00"),
-			makeInterval("1:36:00"),
-		}
-	} else if durationSeconds <= threeHoursInSeconds+fiveMinutesInSeconds {
-		// 3 jam + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:36:00"),
-			makeInterval("1:12:00"),
-			makeInterval("1:48:00"),
-			makeInterval("2:24:00"),
-		}
-	} else if durationSeconds <= fourHoursInSeconds+fiveMinutesInSeconds {
-		// 4 jam + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("0:48:00"),
-			makeInterval("1:36:00"),
-			makeInterval("2:24:00"),
-			makeInterval("3:12:00"),
-		}
-	} else if durationSeconds <= fiveHoursInSeconds+fiveMinutesInSeconds {
-		// 5 jam + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("1:00:00"),
-			makeInterval("2:00:00"),
-			makeInterval("3:00:00"),
-			makeInterval("4:00:00"),
-		}
-	} else if durationSeconds <= sixHoursInSeconds+fiveMinutesInSeconds {
-		// 6 jam + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("1:12:00"),
-			makeInterval("2:24:00"),
-			makeInterval("3:36:00"),
-			makeInterval("4:48:00"),
-		}
-	} else if durationSeconds <= sevenHoursInSeconds+fiveMinutesInSeconds {
-		// 7 jam + 5 menit toleransi
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("1:24:00"),
-			makeInterval("2:48:00"),
-			makeInterval("4:12:00"),
-			makeInterval("5:36:00"),
-		}
-	} else {
-		// 8 hours or more
-		return []timeInterval{
-			makeInterval("0:00:00"),
-			makeInterval("1:36:00"),
-			makeInterval("3:12:00"),
-			makeInterval("4:48:00"),
-			makeInterval("6:24:00"),
-		}
-	}
+// activePreviewTemplate returns the preview template configured in the database
+func (s *BookingVideoService) activePreviewTemplate() config.PreviewTemplate {
+	return config.NewPreviewTemplateService(s.db).GetActiveTemplate()
 }
 
 // CreateThumbnail extracts a frame from the middle of the video as a thumbnail
 func (s *BookingVideoService) CreateThumbnail(inputPath, outputPath string) error {
+	return s.CreateThumbnailWithMetrics("", inputPath, outputPath, nil)
+}
+
+// CreateThumbnailWithMetrics works like CreateThumbnail and reports the FFmpeg job under videoID
+func (s *BookingVideoService) CreateThumbnailWithMetrics(videoID, inputPath, outputPath string, videoMetrics *metrics.VideoProcessingMetrics) error {
 	// Use ffmpeg to extract a thumbnail from the middle of the video with software encoding
 
 	// Build FFmpeg arguments for thumbnail generation with software encoding
@@ -804,9 +812,11 @@ func (s *BookingVideoService) CreateThumbnail(inputPath, outputPath string) erro
 		outputPath,
 	}
 
-	cmd := exec.Command("ffmpeg", ffmpegArgs...)
-
-	out, err := cmd.CombinedOutput()
+	out, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID: videoID,
+		Stage:   ffmpeg.StageThumbnail,
+		Metrics: videoMetrics,
+	}, ffmpegArgs...)
 	if err != nil {
 		return fmt.Errorf("ffmpeg thumbnail creation failed: %v, output: %s", err, string(out))
 	}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/recording"
+	"ayo-mwr/transcode"
+)
+
+// BumperSource provides the venue's intro and outro bumper videos (implemented by the AYO API client)
+type BumperSource interface {
+	GetBumper(kind string) (string, error)
+}
+
+// attachVenueBumpers prepends and/or appends the venue bumpers configured for videoType to the
+// video at videoPath, replacing it in place. Bumpers are normalised to the video's codec, profile,
+// pixel format, resolution, frame rate and audio layout first so no re-encode of the main video is needed.
+// Returns the lengths of the attached intro and outro; a missing bumper is simply skipped.
+func attachVenueBumpers(db database.Database, source BumperSource, videoID, videoPath, videoType string) (float64, float64, error) {
+	bumperConfigService := config.NewBumperConfigService(db)
+	wantIntro, wantOutro := bumperConfigService.BumpersFor(videoType)
+	if !wantIntro && !wantOutro {
+		return 0, 0, nil
+	}
+	maxSeconds := 0.0
+	if bumperConfig, err := bumperConfigService.GetBumperConfig(); err == nil {
+		maxSeconds = bumperConfig.MaxSeconds
+	}
+	if source == nil {
+		return 0, 0, fmt.Errorf("no bumper source available")
+	}
+
+	format, err := recording.ProbeVideoFormat(videoPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var introPath, outroPath string
+	var introSeconds, outroSeconds float64
+	if wantIntro {
+		introPath, introSeconds = prepareBumper(source, videoID, config.BumperIntro, *format, maxSeconds)
+	}
+	if wantOutro {
+		outroPath, outroSeconds = prepareBumper(source, videoID, config.BumperOutro, *format, maxSeconds)
+	}
+	if introPath == "" && outroPath == "" {
+		return 0, 0, nil
+	}
+
+	ext := filepath.Ext(videoPath)
+	outputPath := strings.TrimSuffix(videoPath, ext) + "_bumpers" + ext
+	if err := recording.AttachBumpers(videoID, videoPath, introPath, outroPath, outputPath); err != nil {
+		os.Remove(outputPath)
+		return 0, 0, err
+	}
+	if err := os.Rename(outputPath, videoPath); err != nil {
+		os.Remove(outputPath)
+		return 0, 0, fmt.Errorf("failed to replace video with bumpered version: %v", err)
+	}
+
+	log.Printf("[Bumper] ✅ Attached bumpers to %s (intro: %.2fs, outro: %.2fs)", videoPath, introSeconds, outroSeconds)
+	return introSeconds, outroSeconds, nil
+}
+
+// prepareBumper fetches and normalises one bumper, returning its path and length.
+// An empty path means the bumper is unavailable or longer than maxSeconds and should be skipped.
+func prepareBumper(source BumperSource, videoID, kind string, format recording.VideoFormat, maxSeconds float64) (string, float64) {
+	bumperPath, err := source.GetBumper(kind)
+	if err != nil {
+		log.Printf("[Bumper] Skipping %s bumper: %v", kind, err)
+		return "", 0
+	}
+
+	normalizedPath, err := recording.NormalizeBumper(videoID, bumperPath, format)
+	if err != nil {
+		log.Printf("[Bumper] Skipping %s bumper: %v", kind, err)
+		return "", 0
+	}
+
+	duration, err := transcode.GetVideoDuration(normalizedPath)
+	if err != nil {
+		log.Printf("[Bumper] Skipping %s bumper: %v", kind, err)
+		return "", 0
+	}
+	if maxSeconds > 0 && duration > maxSeconds {
+		log.Printf("[Bumper] Skipping %s bumper: %.2fs is longer than the %.2fs limit", kind, duration, maxSeconds)
+		return "", 0
+	}
+
+	return normalizedPath, duration
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -363,3 +364,79 @@ func (cds *ChunkDiscoveryService) GetSegmentDiscoveryStats(cameraName string, st
 
 	return stats, nil
 }
+
+// CoverageGap represents a part of a requested time range with no recorded video
+type CoverageGap struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Seconds   float64   `json:"seconds"`
+}
+
+// AnalyzeCoverage reports how much of the requested time range is covered by the given sources.
+// It returns the covered percentage (0-100) and the uncovered gaps, using the same 30 second
+// tolerance as chunk coverage detection.
+func (cds *ChunkDiscoveryService) AnalyzeCoverage(sources []SegmentSource, startTime, endTime time.Time) (float64, []CoverageGap) {
+	return analyzeCoverageWithTolerance(sources, startTime, endTime, 30*time.Second)
+}
+
+// analyzeCoverageWithTolerance is AnalyzeCoverage with a custom tolerance; gaps no longer than
+// tolerance still reduce the covered percentage but are not reported
+func analyzeCoverageWithTolerance(sources []SegmentSource, startTime, endTime time.Time, tolerance time.Duration) (float64, []CoverageGap) {
+	totalDuration := endTime.Sub(startTime)
+	if totalDuration <= 0 {
+		return 0, nil
+	}
+
+	if len(sources) == 0 {
+		return 0, []CoverageGap{{StartTime: startTime, EndTime: endTime, Seconds: totalDuration.Seconds()}}
+	}
+
+	// Sort a copy so callers keep their original ordering
+	sorted := make([]SegmentSource, len(sources))
+	copy(sorted, sources)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	var gaps []CoverageGap
+	currentTime := startTime
+	uncovered := time.Duration(0)
+
+	addGap := func(gapStart, gapEnd time.Time) {
+		gap := gapEnd.Sub(gapStart)
+		if gap <= 0 {
+			return
+		}
+		uncovered += gap
+		if gap > tolerance {
+			gaps = append(gaps, CoverageGap{StartTime: gapStart, EndTime: gapEnd, Seconds: gap.Seconds()})
+		}
+	}
+
+	for _, source := range sorted {
+		if !source.EndTime.After(currentTime) {
+			continue
+		}
+		if !source.StartTime.Before(endTime) {
+			break
+		}
+		if source.StartTime.After(currentTime) {
+			addGap(currentTime, source.StartTime)
+		}
+		currentTime = source.EndTime
+		if !currentTime.Before(endTime) {
+			break
+		}
+	}
+
+	if currentTime.Before(endTime) {
+		addGap(currentTime, endTime)
+	}
+
+	coveredPercent := float64(totalDuration-uncovered) / float64(totalDuration) * 100
+	if coveredPercent < 0 {
+		coveredPercent = 0
+	}
+
+	return coveredPercent, gaps
+}
@@ -0,0 +1,573 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
+	"ayo-mwr/recording"
+	"ayo-mwr/transcode"
+)
+
+// Duration verification settings
+const (
+	minMissingGap        = 5 * time.Second  // Shorter gaps are normal segment boundary jitter
+	maxDurationTolerance = 30 * time.Second // Upper bound for the allowed duration shortfall
+	gapWaitAttempts      = 3                // How often the offline queue retries recovering recent gaps
+	gapWaitInterval      = 30 * time.Second // Delay before the first retry of recent gaps
+	recentGapWindow      = 5 * time.Minute  // Gaps ending this close to now may still be recording or indexing
+)
+
+// errGapPending is returned for a missing part whose recordings may still be written or indexed
+var errGapPending = errors.New("recordings of the missing part may not be written yet")
+
+// DurationVerificationResult describes how well a processed video covers its requested time range
+type DurationVerificationResult struct {
+	VideoID          string                     `json:"videoId"`
+	ExpectedSeconds  float64                    `json:"expectedSeconds"`
+	ActualSeconds    float64                    `json:"actualSeconds"`
+	RecoveredGaps    int                        `json:"recoveredGaps"`
+	PendingGaps      int                        `json:"pendingGaps"` // Recent missing parts left for a later pass
+	MissingIntervals []database.MissingInterval `json:"missingIntervals,omitempty"`
+}
+
+// Complete reports whether the video covers its whole time range
+func (r *DurationVerificationResult) Complete() bool {
+	return len(r.MissingIntervals) == 0
+}
+
+// timelinePart is a piece of the requested time range that is either present in the
+// processed output (starting at outputOffset seconds) or missing from it
+type timelinePart struct {
+	startTime    time.Time
+	endTime      time.Time
+	present      bool
+	outputOffset float64
+	fillPath     string // Re-extracted clip for a missing part, empty if not recovered
+}
+
+// VerifyAndRepairDuration compares the duration of a processed video with its requested time
+// range. When the video is short it locates the missing sub-ranges, recovers their segments
+// (including from other storage disks), re-extracts only those parts and stitches them into the
+// output. Parts that cannot be recovered are stored on the video and its status is set to partial.
+// Recent parts whose segments may still be written are counted as pending; EnqueueGapRecovery
+// retries them later instead of waiting here.
+func (hvp *HybridVideoProcessor) VerifyAndRepairDuration(uniqueID string) (*DurationVerificationResult, error) {
+	video, err := hvp.db.GetVideo(uniqueID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting video %s: %v", uniqueID, err)
+	}
+	if video == nil {
+		return nil, fmt.Errorf("video %s not found", uniqueID)
+	}
+	if video.StartTime == nil || video.EndTime == nil {
+		return nil, fmt.Errorf("video %s has no start or end time", uniqueID)
+	}
+	if video.LocalPath == "" {
+		return nil, fmt.Errorf("video %s has no local path", uniqueID)
+	}
+
+	startTime, endTime := *video.StartTime, *video.EndTime
+	result := &DurationVerificationResult{
+		VideoID:         uniqueID,
+		ExpectedSeconds: endTime.Sub(startTime).Seconds(),
+	}
+
+	actual, err := transcode.GetVideoDuration(video.LocalPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting duration of %s: %v", video.LocalPath, err)
+	}
+	// Venue bumpers are not part of the booking window
+	actual -= video.IntroSeconds + video.OutroSeconds
+	result.ActualSeconds = actual
+
+	tolerance := durationTolerance(result.ExpectedSeconds)
+	if actual >= result.ExpectedSeconds-tolerance {
+		log.Printf("[DurationVerifier] ✅ Video %s duration OK: %.2fs of %.2fs", uniqueID, actual, result.ExpectedSeconds)
+		if len(video.MissingIntervals) > 0 {
+			hvp.db.UpdateVideoMissingIntervals(uniqueID, nil)
+		}
+		return result, nil
+	}
+
+	log.Printf("[DurationVerifier] ⚠️ Video %s is short: %.2fs of %.2fs (tolerance %.2fs)",
+		uniqueID, actual, result.ExpectedSeconds, tolerance)
+
+	sources, err := hvp.chunkDiscovery.FindOptimalSegmentSources(video.CameraName, startTime, endTime)
+	if err != nil {
+		log.Printf("[DurationVerifier] Warning: Could not get segment sources for %s: %v", video.CameraName, err)
+	}
+	parts := locateMissingParts(sources, startTime, endTime, actual)
+	for i := range parts {
+		parts[i].outputOffset += video.IntroSeconds
+	}
+
+	// Recovered parts are rendered like the output so they can be stitched into it
+	finish, err := hvp.gapFillFinishFor(video)
+	if err != nil {
+		log.Printf("[DurationVerifier] Warning: Cannot render recovered parts for video %s: %v", uniqueID, err)
+	}
+
+	tmpDir := filepath.Join(filepath.Dir(video.LocalPath), "gapfill", uniqueID)
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating gap-fill directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i := range parts {
+		if parts[i].present || finish == nil {
+			continue
+		}
+		log.Printf("[DurationVerifier] Missing %s - %s (%.1fs) for video %s",
+			parts[i].startTime.Format("15:04:05"), parts[i].endTime.Format("15:04:05"),
+			parts[i].endTime.Sub(parts[i].startTime).Seconds(), uniqueID)
+
+		fillPath, err := hvp.recoverMissingPart(video.CameraName, parts[i], finish, fmt.Sprintf("%s_gap_%d", uniqueID, i), tmpDir)
+		if errors.Is(err, errGapPending) {
+			log.Printf("[DurationVerifier] ⏳ Missing part %d of video %s is recent, leaving it for a later pass", i, uniqueID)
+			result.PendingGaps++
+			continue
+		}
+		if err != nil {
+			log.Printf("[DurationVerifier] Could not recover missing part %d of video %s: %v", i, uniqueID, err)
+			continue
+		}
+		parts[i].fillPath = fillPath
+		result.RecoveredGaps++
+	}
+
+	if result.RecoveredGaps > 0 {
+		if err := stitchTimeline(video.LocalPath, withBumperParts(parts, video), finish.format, uniqueID, tmpDir); err != nil {
+			// Keep the original output; everything missing stays missing
+			log.Printf("[DurationVerifier] ❌ Failed to stitch recovered parts into %s: %v", video.LocalPath, err)
+			for i := range parts {
+				parts[i].fillPath = ""
+			}
+			result.RecoveredGaps = 0
+		} else if duration, err := transcode.GetVideoDuration(video.LocalPath); err == nil {
+			result.ActualSeconds = duration - video.IntroSeconds - video.OutroSeconds
+			log.Printf("[DurationVerifier] ✅ Stitched %d recovered part(s) into video %s, duration now %.2fs",
+				result.RecoveredGaps, uniqueID, duration)
+		}
+	}
+
+	missingSeconds := 0.0
+	for _, part := range parts {
+		if part.present || part.fillPath != "" {
+			continue
+		}
+		seconds := part.endTime.Sub(part.startTime).Seconds()
+		missingSeconds += seconds
+		result.MissingIntervals = append(result.MissingIntervals, database.MissingInterval{
+			StartTime: part.startTime,
+			EndTime:   part.endTime,
+			Seconds:   seconds,
+		})
+	}
+
+	if err := hvp.db.UpdateVideoMissingIntervals(uniqueID, result.MissingIntervals); err != nil {
+		log.Printf("[DurationVerifier] Warning: Failed to store missing intervals for video %s: %v", uniqueID, err)
+	}
+
+	if !result.Complete() {
+		hvp.db.UpdateVideoStatus(uniqueID, database.StatusPartial,
+			fmt.Sprintf("Missing %.0fs of %.0fs in %d interval(s)", missingSeconds, result.ExpectedSeconds, len(result.MissingIntervals)))
+		log.Printf("[DurationVerifier] ⚠️ Video %s marked partial: %d interval(s) missing (%.0fs)",
+			uniqueID, len(result.MissingIntervals), missingSeconds)
+	}
+
+	return result, nil
+}
+
+// EnqueueGapRecovery queues another attempt at recovering the recent missing parts of a video,
+// so their recordings get time to be written without holding up the caller. The offline queue
+// runs the task.
+func EnqueueGapRecovery(db database.Database, videoID string) error {
+	taskDataJSON, err := json.Marshal(database.GapRecoveryTaskData{VideoID: videoID})
+	if err != nil {
+		return fmt.Errorf("error marshaling gap recovery task data: %v", err)
+	}
+
+	task := database.PendingTask{
+		TaskType:    database.TaskRecoverGaps,
+		TaskData:    string(taskDataJSON),
+		Attempts:    0,
+		MaxAttempts: gapWaitAttempts,
+		NextRetryAt: time.Now().Add(gapWaitInterval),
+		Status:      database.TaskStatusPending,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := db.CreatePendingTask(task); err != nil {
+		return fmt.Errorf("error creating gap recovery task: %v", err)
+	}
+
+	log.Printf("[DurationVerifier] ➕ Video %s queued for recovery of its recent gaps", videoID)
+	return nil
+}
+
+// RecoverPendingGaps verifies a video again to recover the recent missing parts left by an
+// earlier pass. A video that is complete afterwards is no longer partial. An error is returned
+// while parts are still pending, so the queue retries later.
+func (hvp *HybridVideoProcessor) RecoverPendingGaps(videoID string) error {
+	video, err := hvp.db.GetVideo(videoID)
+	if err != nil {
+		return fmt.Errorf("error getting video %s: %v", videoID, err)
+	}
+	if video == nil || video.Status == database.StatusCancelled || video.Status == database.StatusFailed {
+		return nil
+	}
+
+	result, err := hvp.VerifyAndRepairDuration(videoID)
+	if err != nil {
+		return err
+	}
+	if result.PendingGaps > 0 {
+		return fmt.Errorf("%d recent part(s) of video %s are still missing", result.PendingGaps, videoID)
+	}
+	if result.Complete() && video.Status == database.StatusPartial {
+		hvp.db.UpdateVideoStatus(videoID, database.StatusReady, "")
+	}
+	return nil
+}
+
+// withBumperParts wraps the timeline with the video's intro and outro bumpers so stitching keeps them
+func withBumperParts(parts []timelinePart, video *database.VideoMetadata) []timelinePart {
+	if video.IntroSeconds <= 0 && video.OutroSeconds <= 0 {
+		return parts
+	}
+
+	var wrapped []timelinePart
+	if video.IntroSeconds > 0 {
+		wrapped = append(wrapped, timelinePart{
+			endTime:      time.Time{}.Add(time.Duration(video.IntroSeconds * float64(time.Second))),
+			present:      true,
+			outputOffset: 0,
+		})
+	}
+	wrapped = append(wrapped, parts...)
+	if video.OutroSeconds > 0 {
+		// The outro follows the last part that was present in the original output
+		outroOffset := video.IntroSeconds
+		for _, part := range parts {
+			if part.present {
+				outroOffset = part.outputOffset + part.endTime.Sub(part.startTime).Seconds()
+			}
+		}
+		wrapped = append(wrapped, timelinePart{
+			endTime:      time.Time{}.Add(time.Duration(video.OutroSeconds * float64(time.Second))),
+			present:      true,
+			outputOffset: outroOffset,
+		})
+	}
+	return wrapped
+}
+
+// gapFillFinish is the finishing a processed video got after merging, which recovered parts
+// need as well to look like the rest of the video
+type gapFillFinish struct {
+	videoID       string // Video the FFmpeg jobs are reported under
+	format        recording.VideoFormat
+	watermarkPath string // Empty when the segments already carry the real-time watermark
+	position      recording.WatermarkPosition
+	margin        int
+	opacity       float64
+	rawJSON       string // Booking JSON for the text overlay
+}
+
+// gapFillFinishFor returns how recovered parts of video must be rendered: in the output's format,
+// with the watermark ProcessVideoSegments applies when segments are not watermarked while
+// recording, and with the booking text overlay
+func (hvp *HybridVideoProcessor) gapFillFinishFor(video *database.VideoMetadata) (*gapFillFinish, error) {
+	format, err := recording.ProbeVideoFormat(video.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	finish := &gapFillFinish{videoID: video.ID, format: *format, rawJSON: video.RawJSON}
+	if realtimeWatermarkApplied(hvp.db) {
+		return finish, nil
+	}
+
+	client, ok := hvp.ayoClient.(interface{ GetWatermark(string) (string, error) })
+	if !ok {
+		return nil, fmt.Errorf("no watermark source available")
+	}
+	watermarkPath, err := client.GetWatermark(video.Resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermark: %v", err)
+	}
+	finish.watermarkPath = watermarkPath
+	finish.position, finish.margin, finish.opacity = recording.GetWatermarkSettings()
+	return finish, nil
+}
+
+// durationTolerance returns the allowed shortfall for a video: 1% of its length,
+// at least 2 seconds and at most maxDurationTolerance
+func durationTolerance(expectedSeconds float64) float64 {
+	return math.Min(maxDurationTolerance.Seconds(), math.Max(2, expectedSeconds*0.01))
+}
+
+// locateMissingParts splits the requested time range into parts present in the output and
+// missing parts. Ranges without any recording are missing; the remaining recorded ranges are
+// assumed to appear in the output in order until the output runs out, and whatever is left
+// after that (typically segments that were not written yet when the video was processed)
+// is missing as well.
+func locateMissingParts(sources []SegmentSource, startTime, endTime time.Time, actualSeconds float64) []timelinePart {
+	_, gaps := analyzeCoverageWithTolerance(sources, startTime, endTime, minMissingGap)
+
+	var parts []timelinePart
+	remaining := actualSeconds
+	offset := 0.0
+
+	addRecorded := func(from, to time.Time) {
+		if !to.After(from) {
+			return
+		}
+		length := to.Sub(from).Seconds()
+		if remaining <= 0 {
+			parts = append(parts, timelinePart{startTime: from, endTime: to})
+			return
+		}
+		if length > remaining {
+			split := from.Add(time.Duration(remaining * float64(time.Second)))
+			if to.Sub(split) >= minMissingGap {
+				parts = append(parts, timelinePart{startTime: from, endTime: split, present: true, outputOffset: offset})
+				parts = append(parts, timelinePart{startTime: split, endTime: to})
+				offset += remaining
+				remaining = 0
+				return
+			}
+		}
+		parts = append(parts, timelinePart{startTime: from, endTime: to, present: true, outputOffset: offset})
+		offset += length
+		remaining -= length
+	}
+
+	cursor := startTime
+	for _, gap := range gaps {
+		addRecorded(cursor, gap.StartTime)
+		parts = append(parts, timelinePart{startTime: gap.StartTime, endTime: gap.EndTime})
+		cursor = gap.EndTime
+	}
+	addRecorded(cursor, endTime)
+
+	return parts
+}
+
+// recoverMissingPart finds recordings for a missing part and re-extracts just that range.
+// A recent part without full recordings fails with errGapPending, since its segments may
+// still be written or indexed.
+func (hvp *HybridVideoProcessor) recoverMissingPart(cameraName string, part timelinePart, finish *gapFillFinish, fillID, tmpDir string) (string, error) {
+	sources := hvp.findRecoverySources(cameraName, part.startTime, part.endTime)
+	if coverage, gaps := analyzeCoverageWithTolerance(sources, part.startTime, part.endTime, minMissingGap); len(sources) == 0 || len(gaps) > 0 {
+		if time.Since(part.endTime) <= recentGapWindow {
+			return "", errGapPending
+		}
+		return "", fmt.Errorf("recordings cover only %.1f%% of the missing range", coverage)
+	}
+
+	return hvp.extractGapFill(cameraName, sources, part, finish, fillID, tmpDir)
+}
+
+// findRecoverySources looks up recordings for a time range in the database first, then scans
+// every storage disk for segment files, which also finds segments that were never indexed.
+// Whichever covers more of the range is returned.
+func (hvp *HybridVideoProcessor) findRecoverySources(cameraName string, startTime, endTime time.Time) []SegmentSource {
+	sources, err := hvp.chunkDiscovery.FindOptimalSegmentSources(cameraName, startTime, endTime)
+	if err != nil {
+		log.Printf("[DurationVerifier] Warning: Database segment lookup failed: %v", err)
+	}
+	coverage, gaps := analyzeCoverageWithTolerance(sources, startTime, endTime, minMissingGap)
+	if len(sources) > 0 && len(gaps) == 0 {
+		return sources
+	}
+
+	disks, err := hvp.db.GetStorageDisks()
+	if err != nil {
+		log.Printf("[DurationVerifier] Warning: Could not list storage disks: %v", err)
+		return sources
+	}
+	var diskPaths []string
+	for _, disk := range disks {
+		diskPaths = append(diskPaths, disk.Path)
+	}
+
+	segmentLength := time.Duration(hvp.config.SegmentDuration) * time.Second
+	if segmentLength <= 0 {
+		segmentLength = 30 * time.Second
+	}
+
+	// Start one segment early so the segment containing startTime is included
+	files, err := recording.FindSegmentsInRangeMultiDisk(cameraName, startTime.Add(-segmentLength), endTime, diskPaths)
+	if err != nil {
+		log.Printf("[DurationVerifier] Warning: Disk segment scan failed: %v", err)
+		return sources
+	}
+
+	diskSources := segmentSourcesFromFiles(files, segmentLength)
+	if diskCoverage, _ := analyzeCoverageWithTolerance(diskSources, startTime, endTime, minMissingGap); diskCoverage > coverage {
+		log.Printf("[DurationVerifier] Recovered %d segment file(s) from storage disks (%.1f%% coverage)", len(diskSources), diskCoverage)
+		return diskSources
+	}
+	return sources
+}
+
+// segmentSourcesFromFiles builds segment sources from segment files sorted by time. A segment
+// ends where the next one starts, capped at segmentLength. Copies of the same segment on
+// several disks are only used once.
+func segmentSourcesFromFiles(files []string, segmentLength time.Duration) []SegmentSource {
+	var sources []SegmentSource
+	for _, file := range files {
+		ts, err := recording.ParseSegmentTimestamp(filepath.Base(file))
+		if err != nil {
+			continue
+		}
+		// Segments are concatenated from a list in another directory, so use absolute paths
+		if absFile, err := filepath.Abs(file); err == nil {
+			file = absFile
+		}
+		if n := len(sources); n > 0 {
+			if sources[n-1].StartTime.Equal(ts) {
+				continue
+			}
+			if ts.Before(sources[n-1].EndTime) {
+				sources[n-1].EndTime = ts
+				sources[n-1].Duration = ts.Sub(sources[n-1].StartTime)
+			}
+		}
+		sources = append(sources, SegmentSource{
+			ID:          filepath.Base(file),
+			Type:        "segment",
+			FilePath:    file,
+			StartTime:   ts,
+			EndTime:     ts.Add(segmentLength),
+			Duration:    segmentLength,
+			SourceCount: 1,
+		})
+	}
+	return sources
+}
+
+// extractGapFill concatenates the sources of a missing part and renders the part's range with
+// the output's finishing, so it matches the parts around it
+func (hvp *HybridVideoProcessor) extractGapFill(cameraName string, sources []SegmentSource, part timelinePart, finish *gapFillFinish, fillID, tmpDir string) (string, error) {
+	var relevant []SegmentSource
+	for _, source := range sources {
+		if source.EndTime.After(part.startTime) && source.StartTime.Before(part.endTime) {
+			relevant = append(relevant, source)
+		}
+	}
+	if len(relevant) == 0 {
+		return "", fmt.Errorf("no recordings overlap the missing range")
+	}
+	sort.Slice(relevant, func(i, j int) bool {
+		return relevant[i].StartTime.Before(relevant[j].StartTime)
+	})
+
+	concatenatedPath, err := hvp.processMultipleSources(relevant, finish.videoID, fillID, config.CameraConfig{Name: cameraName}, part.startTime, part.endTime, tmpDir)
+	if err != nil {
+		return "", err
+	}
+
+	// Chunks are already cut to the missing range, segments are used whole
+	fileStart := relevant[0].StartTime
+	if relevant[0].Type == "chunk" && part.startTime.After(fileStart) {
+		fileStart = part.startTime
+	}
+	fillStart := part.startTime
+	if fileStart.After(fillStart) {
+		fillStart = fileStart
+	}
+
+	fillPath := filepath.Join(tmpDir, fillID+".ts")
+	err = recording.RenderGapFill(recording.GapFill{
+		VideoID:       finish.videoID,
+		InputPath:     concatenatedPath,
+		Start:         fillStart.Sub(fileStart).Seconds(),
+		Duration:      part.endTime.Sub(fillStart).Seconds(),
+		Target:        finish.format,
+		WatermarkPath: finish.watermarkPath,
+		Position:      finish.position,
+		Margin:        finish.margin,
+		Opacity:       finish.opacity,
+		Overlay:       bookingTextOverlay(hvp.db, finish.rawJSON, fillStart),
+	}, fillPath)
+	if err != nil {
+		return "", fmt.Errorf("error rendering gap fill: %v", err)
+	}
+
+	return fillPath, nil
+}
+
+// stitchTimeline rebuilds outputPath from its present parts and the recovered fills, in time order.
+// Present parts are re-encoded in format, like the fills, so they are cut on the exact frame
+// instead of the nearest keyframe and the pieces join without overlaps or holes.
+func stitchTimeline(outputPath string, parts []timelinePart, format recording.VideoFormat, uniqueID, tmpDir string) error {
+	var list strings.Builder
+	for i, part := range parts {
+		var piecePath string
+		switch {
+		case part.present:
+			piecePath = filepath.Join(tmpDir, fmt.Sprintf("%s_part_%d.ts", uniqueID, i))
+			args := []string{
+				"-ss", fmt.Sprintf("%.3f", part.outputOffset),
+				"-i", outputPath,
+				"-t", fmt.Sprintf("%.3f", part.endTime.Sub(part.startTime).Seconds()),
+			}
+			if !format.HasAudio {
+				args = append(args, "-an")
+			}
+			args = append(args, format.EncoderArgs()...)
+			args = append(args, "-f", "mpegts", "-y", piecePath)
+			output, err := ffmpeg.Run(ffmpeg.Job{
+				VideoID:  uniqueID,
+				Stage:    ffmpeg.StageMerge,
+				Duration: part.endTime.Sub(part.startTime).Seconds(),
+			}, args...)
+			if err != nil {
+				return fmt.Errorf("error cutting part %d from output: %v\nFFmpeg output: %s", i, err, string(output))
+			}
+		case part.fillPath != "":
+			piecePath = part.fillPath
+		default:
+			continue
+		}
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(piecePath, "'", "'\\''"))
+	}
+
+	listPath := filepath.Join(tmpDir, uniqueID+"_stitch_list.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("error writing stitch list: %v", err)
+	}
+
+	// Keep the output's container; the parts are MPEG-TS
+	stitchedPath := filepath.Join(tmpDir, uniqueID+"_stitched"+filepath.Ext(outputPath))
+	output, err := ffmpeg.Run(ffmpeg.Job{VideoID: uniqueID, Stage: ffmpeg.StageMerge},
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listPath,
+		"-c", "copy",
+		"-y",
+		stitchedPath,
+	)
+	if err != nil {
+		return fmt.Errorf("error stitching parts: %v\nFFmpeg output: %s", err, string(output))
+	}
+
+	// tmpDir lives next to the output, so this is a same-disk rename
+	if err := os.Rename(stitchedPath, outputPath); err != nil {
+		return fmt.Errorf("error replacing output: %v", err)
+	}
+	return nil
+}
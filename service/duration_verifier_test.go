@@ -0,0 +1,140 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDurationTolerance(t *testing.T) {
+	cases := []struct {
+		expected float64
+		want     float64
+	}{
+		{expected: 60, want: 2},    // 1% is below the 2s minimum
+		{expected: 1200, want: 12}, // 1% of a 20 minute video
+		{expected: 3600, want: 30}, // 1% is 36s, capped
+		{expected: 7200, want: 30}, // Always capped for long videos
+		{expected: 0, want: 2},     // Unknown length still gets the minimum
+	}
+	for _, c := range cases {
+		if got := durationTolerance(c.expected); got != c.want {
+			t.Errorf("durationTolerance(%.0f) = %.2f, want %.2f", c.expected, got, c.want)
+		}
+	}
+}
+
+func TestLocateMissingParts(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	at := func(minutes float64) time.Time {
+		return start.Add(time.Duration(minutes * float64(time.Minute)))
+	}
+
+	type part struct {
+		from, to float64 // Minutes after start
+		present  bool
+		offset   float64 // Seconds into the output
+	}
+	cases := []struct {
+		name    string
+		sources []SegmentSource
+		actual  float64
+		want    []part
+	}{
+		{
+			name:    "recording gap in the middle",
+			sources: []SegmentSource{{StartTime: start, EndTime: at(4)}, {StartTime: at(5), EndTime: end}},
+			actual:  540,
+			want:    []part{{0, 4, true, 0}, {4, 5, false, 0}, {5, 10, true, 240}},
+		},
+		{
+			name:    "output ran out before the last recordings",
+			sources: []SegmentSource{{StartTime: start, EndTime: end}},
+			actual:  480,
+			want:    []part{{0, 8, true, 0}, {8, 10, false, 0}},
+		},
+		{
+			name:    "short tail shortfall is not split off",
+			sources: []SegmentSource{{StartTime: start, EndTime: end}},
+			actual:  597,
+			want:    []part{{0, 10, true, 0}},
+		},
+		{
+			name:   "nothing recorded",
+			actual: 0,
+			want:   []part{{0, 10, false, 0}},
+		},
+		{
+			name:    "boundary jitter is not a gap",
+			sources: []SegmentSource{{StartTime: start, EndTime: at(5)}, {StartTime: at(5).Add(3 * time.Second), EndTime: end}},
+			actual:  597,
+			want:    []part{{0, 10, true, 0}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			parts := locateMissingParts(c.sources, start, end, c.actual)
+			if len(parts) != len(c.want) {
+				t.Fatalf("expected %d parts, got %d: %+v", len(c.want), len(parts), parts)
+			}
+			for i, want := range c.want {
+				got := parts[i]
+				if !got.startTime.Equal(at(want.from)) || !got.endTime.Equal(at(want.to)) {
+					t.Errorf("part %d: expected %v - %v, got %v - %v", i, at(want.from), at(want.to), got.startTime, got.endTime)
+				}
+				if got.present != want.present {
+					t.Errorf("part %d: expected present %v, got %v", i, want.present, got.present)
+				}
+				if want.present && got.outputOffset != want.offset {
+					t.Errorf("part %d: expected output offset %.0f, got %.0f", i, want.offset, got.outputOffset)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentSourcesFromFiles(t *testing.T) {
+	ts := func(clock string) time.Time {
+		parsed, err := time.ParseInLocation("20060102_150405", "20260101_"+clock, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	files := []string{
+		"/disk1/cam/hls/segment_20260101_100000.ts",
+		"/disk2/cam/hls/segment_20260101_100000.ts", // Same segment on another disk
+		"/disk1/cam/hls/segment_20260101_100020.ts", // Starts before the previous one would end
+		"/disk1/cam/hls/playlist.m3u8",              // Not a segment
+		"/disk1/cam/hls/segment_20260101_100200.ts", // After a gap
+	}
+
+	sources := segmentSourcesFromFiles(files, 30*time.Second)
+	if len(sources) != 3 {
+		t.Fatalf("expected 3 sources, got %d: %+v", len(sources), sources)
+	}
+
+	want := []struct {
+		path       string
+		start, end time.Time
+	}{
+		{"/disk1/cam/hls/segment_20260101_100000.ts", ts("100000"), ts("100020")},
+		{"/disk1/cam/hls/segment_20260101_100020.ts", ts("100020"), ts("100050")},
+		{"/disk1/cam/hls/segment_20260101_100200.ts", ts("100200"), ts("100230")},
+	}
+	for i, w := range want {
+		source := sources[i]
+		if source.FilePath != filepath.Clean(w.path) || source.ID != filepath.Base(w.path) || source.Type != "segment" {
+			t.Errorf("source %d: unexpected file %+v", i, source)
+		}
+		if !source.StartTime.Equal(w.start) || !source.EndTime.Equal(w.end) {
+			t.Errorf("source %d: expected %v - %v, got %v - %v", i, w.start, w.end, source.StartTime, source.EndTime)
+		}
+		if source.Duration != w.end.Sub(w.start) {
+			t.Errorf("source %d: expected duration %v, got %v", i, w.end.Sub(w.start), source.Duration)
+		}
+	}
+}
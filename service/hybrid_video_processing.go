@@ -4,13 +4,14 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
+	"ayo-mwr/metrics"
 	"ayo-mwr/recording"
 	"ayo-mwr/storage"
 )
@@ -182,11 +183,30 @@ func (hvp *HybridVideoProcessor) ProcessVideoSegmentsOptimized(
 	processingStart2 := time.Now()
 	processedVideoPath, err := hvp.processVideoSources(segmentSources, uniqueID, camera, startTime, endTime)
 	if err != nil {
-		hvp.db.UpdateVideoStatus(uniqueID, database.StatusFailed, err.Error())
-		return "", fmt.Errorf("error processing video sources: %v", err)
+		return "", hvp.processingError(uniqueID, fmt.Errorf("error processing video sources: %v", err))
 	}
 	processingTime := time.Since(processingStart2)
 
+	// Sources are only stream-copied above, so the booking text overlay is drawn in its own pass
+	overlay := bookingTextOverlay(hvp.db, rawJSON, sourcesClockStart(segmentSources, startTime))
+	if err := burnTextOverlay(uniqueID, processedVideoPath, overlay, endTime.Sub(startTime).Seconds(), nil); err != nil {
+		log.Printf("[HybridProcessor] Warning: Failed to draw text overlay: %v, continuing without overlay", err)
+	}
+
+	// Attach the venue intro/outro bumpers selected for this video type
+	var introSeconds, outroSeconds float64
+	if source, ok := hvp.ayoClient.(BumperSource); ok {
+		introSeconds, outroSeconds, err = attachVenueBumpers(hvp.db, source, uniqueID, processedVideoPath, videoType)
+		if err != nil {
+			log.Printf("[HybridProcessor] Warning: Failed to attach bumpers: %v, continuing without bumpers", err)
+		}
+	}
+
+	if ffmpeg.IsCancelled(uniqueID) {
+		os.Remove(processedVideoPath)
+		return "", hvp.processingError(uniqueID, nil)
+	}
+
 	// Update database with processed video info
 	storageDiskID, mp4FullPath, err := hvp.determineStorageInfo(processedVideoPath)
 	if err != nil {
@@ -203,6 +223,14 @@ func (hvp *HybridVideoProcessor) ProcessVideoSegmentsOptimized(
 	if err := hvp.db.UpdateVideo(videoMeta); err != nil {
 		return "", fmt.Errorf("error updating database entry: %v", err)
 	}
+	if ffmpeg.IsCancelled(uniqueID) {
+		// Cancelled while the entry was being updated, which may have overwritten the cancelled status
+		hvp.db.UpdateVideoStatus(uniqueID, database.StatusCancelled, "cancelled during processing")
+		return "", hvp.processingError(uniqueID, nil)
+	}
+	if introSeconds > 0 || outroSeconds > 0 {
+		hvp.db.UpdateVideoBumpers(uniqueID, introSeconds, outroSeconds)
+	}
 
 	totalTime := time.Since(processingStart)
 	log.Printf("[HybridProcessor] ✅ Optimized processing completed in %v (discovery: %v, processing: %v)", 
@@ -218,6 +246,16 @@ func (hvp *HybridVideoProcessor) ProcessVideoSegmentsOptimized(
 	return uniqueID, nil
 }
 
+// processingError returns the error a processing step fails with. A cancelled video keeps its
+// cancelled status and fails with ffmpeg.ErrCancelled; otherwise the video is marked failed.
+func (hvp *HybridVideoProcessor) processingError(uniqueID string, err error) error {
+	if ffmpeg.IsCancelled(uniqueID) {
+		return fmt.Errorf("%w: video %s", ffmpeg.ErrCancelled, uniqueID)
+	}
+	hvp.db.UpdateVideoStatus(uniqueID, database.StatusFailed, err.Error())
+	return err
+}
+
 // processVideoSources processes the optimal combination of chunks and segments
 func (hvp *HybridVideoProcessor) processVideoSources(sources []SegmentSource, uniqueID string, camera config.CameraConfig, startTime, endTime time.Time) (string, error) {
 	// Get current active disk path (don't rely on potentially stale config)
@@ -255,7 +293,37 @@ func (hvp *HybridVideoProcessor) processVideoSources(sources []SegmentSource, un
 	}
 
 	// Multiple sources - need to extract and concatenate
-	return hvp.processMultipleSources(sources, uniqueID, camera, startTime, endTime, tmpDir)
+	return hvp.processMultipleSources(sources, uniqueID, uniqueID, camera, startTime, endTime, tmpDir)
+}
+
+// sourcesClockStart returns the capture time of the first frame produced by processVideoSources:
+// chunks are cut to the requested start, segments are used whole
+func sourcesClockStart(sources []SegmentSource, startTime time.Time) time.Time {
+	if len(sources) == 0 {
+		return startTime
+	}
+	first := sources[0]
+	if first.Type == "chunk" && startTime.After(first.StartTime) {
+		return startTime
+	}
+	return first.StartTime
+}
+
+// burnTextOverlay draws the text overlay onto videoPath in place. The hybrid sources are only
+// stream-copied, so this is the one encode the overlay needs. Nothing is done when the overlay
+// has no text.
+func burnTextOverlay(videoID, videoPath string, overlay *recording.TextOverlay, duration float64, videoMetrics *metrics.VideoProcessingMetrics) error {
+	if !overlay.HasText() {
+		return nil
+	}
+
+	ext := filepath.Ext(videoPath)
+	overlaidPath := strings.TrimSuffix(videoPath, ext) + "_text" + ext
+	if err := recording.BurnTextOverlay(videoID, videoPath, overlaidPath, overlay, duration, videoMetrics); err != nil {
+		os.Remove(overlaidPath)
+		return err
+	}
+	return os.Rename(overlaidPath, videoPath)
 }
 
 // sourceCoversRange checks if a single source completely covers the requested time range
@@ -275,7 +343,11 @@ func (hvp *HybridVideoProcessor) processSimpleChunk(source SegmentSource, unique
 	// Extract the specific time range from the chunk
 	extractedPath := filepath.Join(tmpDir, fmt.Sprintf("%s_extracted.ts", uniqueID))
 	
-	cmd := exec.Command("ffmpeg",
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  uniqueID,
+		Stage:    ffmpeg.StageMerge,
+		Duration: extractDuration,
+	},
 		"-ss", fmt.Sprintf("%.3f", extractStart),
 		"-i", source.FilePath,
 		"-t", fmt.Sprintf("%.3f", extractDuration),
@@ -284,8 +356,7 @@ func (hvp *HybridVideoProcessor) processSimpleChunk(source SegmentSource, unique
 		"-y",
 		extractedPath,
 	)
-
-	if output, err := cmd.CombinedOutput(); err != nil {
+	if err != nil {
 		return "", fmt.Errorf("error extracting from chunk: %v\nFFmpeg output: %s", err, string(output))
 	}
 
@@ -293,12 +364,13 @@ func (hvp *HybridVideoProcessor) processSimpleChunk(source SegmentSource, unique
 	return extractedPath, nil
 }
 
-// processMultipleSources processes multiple chunks and segments
-func (hvp *HybridVideoProcessor) processMultipleSources(sources []SegmentSource, uniqueID string, camera config.CameraConfig, startTime, endTime time.Time, tmpDir string) (string, error) {
+// processMultipleSources processes multiple chunks and segments. FFmpeg jobs are reported under
+// videoID; fileID names the temporary files.
+func (hvp *HybridVideoProcessor) processMultipleSources(sources []SegmentSource, videoID, fileID string, camera config.CameraConfig, startTime, endTime time.Time, tmpDir string) (string, error) {
 	log.Printf("[HybridProcessor] 🔀 Processing %d sources for concatenation", len(sources))
 
 	// Create a file list for FFmpeg concat
-	concatListPath := filepath.Join(tmpDir, fmt.Sprintf("%s_concat_list.txt", uniqueID))
+	concatListPath := filepath.Join(tmpDir, fmt.Sprintf("%s_concat_list.txt", fileID))
 	concatFile, err := os.Create(concatListPath)
 	if err != nil {
 		return "", fmt.Errorf("error creating concat list: %v", err)
@@ -315,7 +387,7 @@ func (hvp *HybridVideoProcessor) processMultipleSources(sources []SegmentSource,
 		if source.Type == "chunk" {
 			// Extract relevant portion from chunk
 			var extractErr error
-			sourcePath, extractErr = hvp.extractFromChunk(source, startTime, endTime, uniqueID, i, tmpDir)
+			sourcePath, extractErr = hvp.extractFromChunk(source, startTime, endTime, videoID, fileID, i, tmpDir)
 			if extractErr != nil {
 				log.Printf("[HybridProcessor] Warning: Error processing chunk source %s: %v", source.ID, extractErr)
 				continue
@@ -353,8 +425,12 @@ func (hvp *HybridVideoProcessor) processMultipleSources(sources []SegmentSource,
 	}
 
 	// Concatenate all sources
-	concatenatedPath := filepath.Join(tmpDir, fmt.Sprintf("%s_concatenated.ts", uniqueID))
-	cmd := exec.Command("ffmpeg",
+	concatenatedPath := filepath.Join(tmpDir, fmt.Sprintf("%s_concatenated.ts", fileID))
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StageMerge,
+		Duration: endTime.Sub(startTime).Seconds(),
+	},
 		"-f", "concat",
 		"-safe", "0",
 		"-i", concatListPath,
@@ -362,8 +438,7 @@ func (hvp *HybridVideoProcessor) processMultipleSources(sources []SegmentSource,
 		"-y",
 		concatenatedPath,
 	)
-
-	if output, err := cmd.CombinedOutput(); err != nil {
+	if err != nil {
 		return "", fmt.Errorf("error concatenating sources: %v\nFFmpeg output: %s", err, string(output))
 	}
 
@@ -372,7 +447,7 @@ func (hvp *HybridVideoProcessor) processMultipleSources(sources []SegmentSource,
 }
 
 // extractFromChunk extracts a specific time range from a pre-concatenated chunk
-func (hvp *HybridVideoProcessor) extractFromChunk(source SegmentSource, startTime, endTime time.Time, uniqueID string, index int, tmpDir string) (string, error) {
+func (hvp *HybridVideoProcessor) extractFromChunk(source SegmentSource, startTime, endTime time.Time, videoID, fileID string, index int, tmpDir string) (string, error) {
 	log.Printf("[HybridProcessor] Extracting from chunk %s: file=%s", source.ID, source.FilePath)
 	
 	// Check if chunk file exists first
@@ -404,10 +479,10 @@ func (hvp *HybridVideoProcessor) extractFromChunk(source SegmentSource, startTim
 		return "", fmt.Errorf("invalid extraction duration: %.3fs (start=%.3fs)", extractDuration, extractStart)
 	}
 
-	extractedPath := filepath.Join(tmpDir, fmt.Sprintf("%s_chunk_extract_%d.ts", uniqueID, index))
+	extractedPath := filepath.Join(tmpDir, fmt.Sprintf("%s_chunk_extract_%d.ts", fileID, index))
 	log.Printf("[HybridProcessor] Extract output path: %s (tmpDir: %s)", extractedPath, tmpDir)
 	
-	cmd := exec.Command("ffmpeg",
+	args := []string{
 		"-ss", fmt.Sprintf("%.3f", extractStart),
 		"-i", source.FilePath,
 		"-t", fmt.Sprintf("%.3f", extractDuration),
@@ -415,13 +490,18 @@ func (hvp *HybridVideoProcessor) extractFromChunk(source SegmentSource, startTim
 		"-avoid_negative_ts", "make_zero",
 		"-y",
 		extractedPath,
-	)
+	}
 
-	log.Printf("[HybridProcessor] Running FFmpeg: %s", strings.Join(cmd.Args, " "))
+	log.Printf("[HybridProcessor] Running FFmpeg: ffmpeg %s", strings.Join(args, " "))
 	
-	if output, err := cmd.CombinedOutput(); err != nil {
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StageMerge,
+		Duration: extractDuration,
+	}, args...)
+	if err != nil {
 		log.Printf("[HybridProcessor] FFmpeg failed for chunk %s", source.ID)
-		log.Printf("[HybridProcessor] FFmpeg command: %s", strings.Join(cmd.Args, " "))
+		log.Printf("[HybridProcessor] FFmpeg command: ffmpeg %s", strings.Join(args, " "))
 		log.Printf("[HybridProcessor] FFmpeg output: %s", string(output))
 		return "", fmt.Errorf("error extracting from chunk %s: %v", source.ID, err)
 	}
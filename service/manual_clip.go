@@ -0,0 +1,490 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+	"ayo-mwr/transcode"
+
+	"github.com/google/uuid"
+)
+
+// Status values for manual clip jobs
+const (
+	ClipStatusPending    = "pending"
+	ClipStatusProcessing = "processing"
+	ClipStatusUploading  = "uploading"
+	ClipStatusCompleted  = "completed"
+	ClipStatusPartial    = "completed_with_errors"
+	ClipStatusFailed     = "failed"
+)
+
+// manualClipJobTTL is how long finished jobs are kept in memory for status queries
+const manualClipJobTTL = 24 * time.Hour
+
+// ManualClipRequest describes a clip requested manually by an admin
+type ManualClipRequest struct {
+	Cameras    []string
+	StartTime  time.Time
+	EndTime    time.Time
+	BookingID  string // Optional, used to link the clip to a booking
+	VideoType  string // Defaults to "clip"
+	UploadToR2 bool   // Upload preview and thumbnail to R2
+	UploadMP4  bool   // Also upload the full clip as MP4 to R2
+}
+
+// ManualClipCameraResult holds the progress and output of a manual clip for a single camera
+type ManualClipCameraResult struct {
+	CameraName      string        `json:"cameraName"`
+	UniqueID        string        `json:"uniqueId,omitempty"`
+	Status          string        `json:"status"`
+	Progress        int           `json:"progress"` // 0-100
+	SourceCount     int           `json:"sourceCount"`
+	ChunkCount      int           `json:"chunkCount"`
+	CoveragePercent float64       `json:"coveragePercent"`
+	Gaps            []CoverageGap `json:"gaps,omitempty"`
+	LocalPath       string        `json:"localPath,omitempty"`
+	LocalURL        string        `json:"localUrl,omitempty"`
+	PreviewURL      string        `json:"previewUrl,omitempty"`
+	ThumbnailURL    string        `json:"thumbnailUrl,omitempty"`
+	R2MP4URL        string        `json:"r2Mp4Url,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// ManualClipJob tracks a manual clip request across all requested cameras
+type ManualClipJob struct {
+	ID         string                    `json:"id"`
+	Status     string                    `json:"status"`
+	Progress   int                       `json:"progress"` // 0-100, averaged across cameras
+	BookingID  string                    `json:"bookingId,omitempty"`
+	VideoType  string                    `json:"videoType"`
+	StartTime  time.Time                 `json:"startTime"`
+	EndTime    time.Time                 `json:"endTime"`
+	UploadToR2 bool                      `json:"uploadToR2"`
+	UploadMP4  bool                      `json:"uploadMp4"`
+	CreatedAt  time.Time                 `json:"createdAt"`
+	UpdatedAt  time.Time                 `json:"updatedAt"`
+	FinishedAt *time.Time                `json:"finishedAt,omitempty"`
+	Cameras    []*ManualClipCameraResult `json:"cameras"`
+	Error      string                    `json:"error,omitempty"`
+}
+
+// NoCoverageError is returned when a requested camera has no recordings in the requested range
+type NoCoverageError struct {
+	Cameras []string
+}
+
+func (e *NoCoverageError) Error() string {
+	return fmt.Sprintf("no recordings found for camera(s) %s in the requested time range", strings.Join(e.Cameras, ", "))
+}
+
+// InvalidClipRequestError is returned when a clip request cannot be accepted as given
+type InvalidClipRequestError struct {
+	Reason string
+}
+
+func (e *InvalidClipRequestError) Error() string {
+	return e.Reason
+}
+
+// ManualClipService creates clips for arbitrary cameras and time ranges on demand
+type ManualClipService struct {
+	db              database.Database
+	config          *config.Config
+	r2Client        storage.ObjectStore
+	storageManager  *storage.DiskManager
+	hybridProcessor *HybridVideoProcessor
+
+	mutex sync.RWMutex
+	jobs  map[string]*ManualClipJob
+}
+
+// NewManualClipService creates a new manual clip service
+func NewManualClipService(db database.Database, cfg *config.Config, r2Client storage.ObjectStore, storageManager *storage.DiskManager) *ManualClipService {
+	return &ManualClipService{
+		db:              db,
+		config:          cfg,
+		r2Client:        r2Client,
+		storageManager:  storageManager,
+		hybridProcessor: NewHybridVideoProcessor(db, cfg, storageManager),
+		jobs:            make(map[string]*ManualClipJob),
+	}
+}
+
+// CreateClip validates the request, checks recording coverage and starts processing in the background.
+// Invalid requests are refused with an *InvalidClipRequestError and ranges without any coverage with
+// a *NoCoverageError; partial coverage is reported per camera.
+func (s *ManualClipService) CreateClip(req ManualClipRequest, ayoClient AyoAPIClient) (*ManualClipJob, error) {
+	if len(req.Cameras) == 0 {
+		return nil, &InvalidClipRequestError{Reason: "at least one camera is required"}
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return nil, &InvalidClipRequestError{Reason: "end time must be after start time"}
+	}
+	if req.StartTime.After(time.Now()) {
+		return nil, &InvalidClipRequestError{Reason: "start time is in the future"}
+	}
+	if (req.UploadToR2 || req.UploadMP4) && s.r2Client == nil {
+		return nil, fmt.Errorf("R2 storage is not configured")
+	}
+	if req.VideoType == "" {
+		req.VideoType = "clip"
+	}
+
+	// Resolve requested cameras against the current configuration
+	cameras := make([]config.CameraConfig, 0, len(req.Cameras))
+	seen := make(map[string]bool)
+	for _, name := range req.Cameras {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		found := false
+		for _, camera := range s.config.Cameras {
+			if camera.Name == name {
+				cameras = append(cameras, camera)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, &InvalidClipRequestError{Reason: fmt.Sprintf("camera not found: %s", name)}
+		}
+	}
+
+	// Check coverage for every camera before accepting the job
+	discovery := s.hybridProcessor.chunkDiscovery
+	var uncovered []string
+	results := make([]*ManualClipCameraResult, 0, len(cameras))
+	for _, camera := range cameras {
+		sources, err := s.hybridProcessor.GetSegmentSources(camera.Name, req.StartTime, req.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("error discovering recordings for camera %s: %v", camera.Name, err)
+		}
+
+		coverage, gaps := discovery.AnalyzeCoverage(sources, req.StartTime, req.EndTime)
+		if len(sources) == 0 || coverage <= 0 {
+			uncovered = append(uncovered, camera.Name)
+			continue
+		}
+
+		results = append(results, &ManualClipCameraResult{
+			CameraName:      camera.Name,
+			Status:          ClipStatusPending,
+			SourceCount:     len(sources),
+			ChunkCount:      countSourcesOfType(sources, "chunk"),
+			CoveragePercent: coverage,
+			Gaps:            gaps,
+		})
+	}
+
+	if len(uncovered) > 0 {
+		return nil, &NoCoverageError{Cameras: uncovered}
+	}
+
+	now := time.Now()
+	job := &ManualClipJob{
+		ID:         "clip_" + uuid.NewString(),
+		Status:     ClipStatusPending,
+		BookingID:  req.BookingID,
+		VideoType:  req.VideoType,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		UploadToR2: req.UploadToR2,
+		UploadMP4:  req.UploadMP4,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Cameras:    results,
+	}
+
+	s.mutex.Lock()
+	s.pruneJobsLocked(now)
+	s.jobs[job.ID] = job
+	s.mutex.Unlock()
+
+	log.Printf("[ManualClip] 🎬 Job %s accepted: %d camera(s) from %s to %s",
+		job.ID, len(cameras), req.StartTime.Format("2006-01-02 15:04:05"), req.EndTime.Format("15:04:05"))
+
+	go s.runJob(job, cameras, ayoClient)
+
+	return s.snapshot(job), nil
+}
+
+// GetJob returns a copy of the job with the given ID
+func (s *ManualClipService) GetJob(jobID string) (*ManualClipJob, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok || jobExpired(job, time.Now()) {
+		return nil, false
+	}
+	return s.snapshotLocked(job), true
+}
+
+// ListJobs returns copies of all known jobs, newest first
+func (s *ManualClipService) ListJobs() []*ManualClipJob {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pruneJobsLocked(time.Now())
+
+	jobs := make([]*ManualClipJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, s.snapshotLocked(job))
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// runJob processes every camera of a job sequentially
+func (s *ManualClipService) runJob(job *ManualClipJob, cameras []config.CameraConfig, ayoClient AyoAPIClient) {
+	bookingService := NewBookingVideoService(s.db, ayoClient, s.r2Client, s.config)
+	// Jobs run concurrently with their own AYO client, so each gets its own processor
+	hybridProcessor := NewHybridVideoProcessor(s.db, s.config, s.storageManager)
+	hybridProcessor.SetAyoClient(ayoClient)
+
+	bookingID := job.BookingID
+	if bookingID == "" {
+		bookingID = job.ID
+	}
+
+	s.updateJob(job, func() { job.Status = ClipStatusProcessing })
+
+	for i, camera := range cameras {
+		result := job.Cameras[i]
+		if err := s.processCamera(job, result, camera, bookingID, bookingService, hybridProcessor); err != nil {
+			log.Printf("[ManualClip] ❌ Job %s camera %s failed: %v", job.ID, camera.Name, err)
+			s.updateJob(job, func() {
+				result.Status = ClipStatusFailed
+				result.Error = err.Error()
+			})
+			if result.UniqueID != "" {
+				s.db.UpdateVideoStatus(result.UniqueID, database.StatusFailed, fmt.Sprintf("Manual clip failed: %v", err))
+			}
+		}
+	}
+
+	s.updateJob(job, func() {
+		failed := 0
+		for _, result := range job.Cameras {
+			if result.Status == ClipStatusFailed {
+				failed++
+			}
+		}
+
+		switch {
+		case failed == 0:
+			job.Status = ClipStatusCompleted
+		case failed == len(job.Cameras):
+			job.Status = ClipStatusFailed
+			job.Error = "all cameras failed"
+		default:
+			job.Status = ClipStatusPartial
+		}
+		finishedAt := time.Now()
+		job.FinishedAt = &finishedAt
+	})
+
+	log.Printf("[ManualClip] ✅ Job %s finished with status %s", job.ID, job.Status)
+}
+
+// processCamera runs a single camera through the hybrid chunk/segment pipeline and uploads the result
+func (s *ManualClipService) processCamera(job *ManualClipJob, result *ManualClipCameraResult, camera config.CameraConfig, bookingID string, bookingService *BookingVideoService, hybridProcessor *HybridVideoProcessor) error {
+	s.updateJob(job, func() {
+		result.Status = ClipStatusProcessing
+		result.Progress = 10
+	})
+
+	var uniqueID string
+	var err error
+	if result.ChunkCount > 0 {
+		log.Printf("[ManualClip] Job %s: using hybrid processor for camera %s (%d chunks)", job.ID, camera.Name, result.ChunkCount)
+		uniqueID, err = hybridProcessor.ProcessVideoSegmentsOptimized(camera, bookingID, "", job.StartTime, job.EndTime, "", job.VideoType)
+	} else {
+		log.Printf("[ManualClip] Job %s: using segment pipeline for camera %s (%d segments)", job.ID, camera.Name, result.SourceCount)
+		sources, discoverErr := hybridProcessor.GetSegmentSources(camera.Name, job.StartTime, job.EndTime)
+		if discoverErr != nil {
+			return fmt.Errorf("error discovering segments: %v", discoverErr)
+		}
+		var segments []string
+		for _, source := range sources {
+			segments = append(segments, source.FilePath)
+		}
+		uniqueID, err = bookingService.ProcessVideoSegments(camera, bookingID, "", segments, job.StartTime, job.EndTime, "", job.VideoType)
+	}
+	if uniqueID != "" {
+		s.updateJob(job, func() { result.UniqueID = uniqueID })
+	}
+	if err != nil {
+		return fmt.Errorf("error processing video: %v", err)
+	}
+
+	video, err := s.db.GetVideo(uniqueID)
+	if err != nil || video == nil {
+		return fmt.Errorf("error getting video metadata for %s: %v", uniqueID, err)
+	}
+
+	s.updateJob(job, func() {
+		result.LocalPath = video.LocalPath
+		result.LocalURL = s.localURL(video.LocalPath)
+		result.Progress = 60
+	})
+
+	if !job.UploadToR2 && !job.UploadMP4 {
+		s.updateJob(job, func() {
+			result.Status = ClipStatusCompleted
+			result.Progress = 100
+		})
+		return nil
+	}
+
+	s.updateJob(job, func() { result.Status = ClipStatusUploading })
+	s.db.UpdateVideoStatus(uniqueID, database.StatusUploading, "")
+
+	if job.UploadToR2 {
+		previewURL, thumbnailURL, err := bookingService.UploadProcessedVideo(uniqueID, video.LocalPath, bookingID, camera.Name)
+		if err != nil {
+			return fmt.Errorf("error uploading preview and thumbnail: %v", err)
+		}
+		s.updateJob(job, func() {
+			result.PreviewURL = previewURL
+			result.ThumbnailURL = thumbnailURL
+			result.Progress = 80
+		})
+	}
+
+	if job.UploadMP4 {
+		r2MP4URL, err := s.uploadMP4(video)
+		if err != nil {
+			return fmt.Errorf("error uploading MP4: %v", err)
+		}
+		s.updateJob(job, func() { result.R2MP4URL = r2MP4URL })
+	}
+
+	s.db.UpdateVideoStatus(uniqueID, database.StatusReady, "")
+	s.updateJob(job, func() {
+		result.Status = ClipStatusCompleted
+		result.Progress = 100
+	})
+	return nil
+}
+
+// uploadMP4 uploads the full clip to R2 as mp4/<id>.mp4, converting from TS first when needed
+func (s *ManualClipService) uploadMP4(video *database.VideoMetadata) (string, error) {
+	uploadPath := video.LocalPath
+	if transcode.IsTSFile(video.LocalPath) {
+		convertedPath := strings.TrimSuffix(video.LocalPath, filepath.Ext(video.LocalPath)) + ".mp4"
+		if err := transcode.ConvertTSToMP4ForVideo(video.ID, video.LocalPath, convertedPath); err != nil {
+			return "", fmt.Errorf("TS to MP4 conversion failed: %v", err)
+		}
+		defer os.Remove(convertedPath)
+		uploadPath = convertedPath
+	}
+
+	mp4Path := fmt.Sprintf("mp4/%s.mp4", video.ID)
+	if _, err := storage.UploadVideoArtifact(s.r2Client, s.db, video.ID, uploadPath, mp4Path, nil); err != nil {
+		return "", err
+	}
+	r2MP4URL := fmt.Sprintf("%s/%s", s.r2Client.GetBaseURL(), mp4Path)
+
+	if err := s.db.UpdateVideoR2Paths(video.ID, video.R2HLSPath, mp4Path); err != nil {
+		log.Printf("[ManualClip] Warning: failed to update R2 paths for %s: %v", video.ID, err)
+	}
+	if err := s.db.UpdateVideoR2URLs(video.ID, video.R2HLSURL, r2MP4URL); err != nil {
+		log.Printf("[ManualClip] Warning: failed to update R2 URLs for %s: %v", video.ID, err)
+	}
+
+	return r2MP4URL, nil
+}
+
+// localURL returns the URL under which the local file is served by the /hls static route, if any
+func (s *ManualClipService) localURL(localPath string) string {
+	recordingsDir := filepath.Join(s.config.StoragePath, "recordings")
+	rel, err := filepath.Rel(recordingsDir, localPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	baseURL := s.config.BaseURL
+	if baseURL == "" {
+		baseURL = "http://localhost:8080" // Fallback if not configured
+	}
+	return fmt.Sprintf("%s/hls/%s", strings.TrimSuffix(baseURL, "/"), filepath.ToSlash(rel))
+}
+
+// updateJob applies a change to a job under lock and recalculates its overall progress
+func (s *ManualClipService) updateJob(job *ManualClipJob, update func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	update()
+
+	total := 0
+	for _, result := range job.Cameras {
+		if result.Status == ClipStatusFailed {
+			total += 100
+		} else {
+			total += result.Progress
+		}
+	}
+	if len(job.Cameras) > 0 {
+		job.Progress = total / len(job.Cameras)
+	}
+	job.UpdatedAt = time.Now()
+}
+
+// snapshot returns a deep copy of a job that is safe to hand out
+func (s *ManualClipService) snapshot(job *ManualClipJob) *ManualClipJob {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.snapshotLocked(job)
+}
+
+func (s *ManualClipService) snapshotLocked(job *ManualClipJob) *ManualClipJob {
+	jobCopy := *job
+	jobCopy.Cameras = make([]*ManualClipCameraResult, len(job.Cameras))
+	for i, result := range job.Cameras {
+		resultCopy := *result
+		resultCopy.Gaps = append([]CoverageGap(nil), result.Gaps...)
+		jobCopy.Cameras[i] = &resultCopy
+	}
+	return &jobCopy
+}
+
+// pruneJobsLocked drops finished jobs older than manualClipJobTTL so the job map stays bounded
+func (s *ManualClipService) pruneJobsLocked(now time.Time) {
+	for id, job := range s.jobs {
+		if jobExpired(job, now) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// jobExpired reports whether a job finished more than manualClipJobTTL ago
+func jobExpired(job *ManualClipJob, now time.Time) bool {
+	return job.FinishedAt != nil && now.Sub(*job.FinishedAt) > manualClipJobTTL
+}
+
+// countSourcesOfType counts segment sources of the given type ("chunk" or "segment")
+func countSourcesOfType(sources []SegmentSource, sourceType string) int {
+	count := 0
+	for _, source := range sources {
+		if source.Type == sourceType {
+			count++
+		}
+	}
+	return count
+}
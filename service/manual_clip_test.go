@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ayo-mwr/config"
+)
+
+func TestManualClipJobPruning(t *testing.T) {
+	now := time.Now()
+	oldFinish := now.Add(-manualClipJobTTL - time.Minute)
+	recentFinish := now.Add(-time.Minute)
+
+	s := &ManualClipService{jobs: map[string]*ManualClipJob{
+		"expired":  {ID: "expired", CreatedAt: oldFinish, FinishedAt: &oldFinish},
+		"finished": {ID: "finished", CreatedAt: recentFinish, FinishedAt: &recentFinish},
+		"running":  {ID: "running", CreatedAt: oldFinish},
+	}}
+
+	if _, ok := s.GetJob("expired"); ok {
+		t.Errorf("expected expired job to be hidden")
+	}
+
+	jobs := s.ListJobs()
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs after pruning, got %d", len(jobs))
+	}
+	if _, ok := s.jobs["expired"]; ok {
+		t.Errorf("expected expired job to be removed from the map")
+	}
+	if _, ok := s.GetJob("running"); !ok {
+		t.Errorf("expected unfinished job to be kept regardless of age")
+	}
+}
+
+func TestAnalyzeCoverageWithTolerance(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	sources := []SegmentSource{
+		{StartTime: start.Add(5 * time.Minute), EndTime: end},
+		{StartTime: start, EndTime: start.Add(4 * time.Minute)},
+	}
+
+	coverage, gaps := analyzeCoverageWithTolerance(sources, start, end, 30*time.Second)
+	if coverage != 90 {
+		t.Errorf("expected 90%% coverage, got %.2f", coverage)
+	}
+	if len(gaps) != 1 || !gaps[0].StartTime.Equal(start.Add(4*time.Minute)) || gaps[0].Seconds != 60 {
+		t.Errorf("unexpected gaps: %+v", gaps)
+	}
+	if !sources[0].StartTime.Equal(start.Add(5 * time.Minute)) {
+		t.Errorf("expected caller's source order to be preserved")
+	}
+}
+
+func TestCreateClipRejectsInvalidRequests(t *testing.T) {
+	s := NewManualClipService(nil, &config.Config{Cameras: []config.CameraConfig{{Name: "camera_1"}}}, nil, nil)
+	start := time.Now().Add(-time.Hour)
+
+	cases := map[string]ManualClipRequest{
+		"no cameras":       {StartTime: start, EndTime: start.Add(time.Minute)},
+		"end before start": {Cameras: []string{"camera_1"}, StartTime: start, EndTime: start.Add(-time.Minute)},
+		"future start":     {Cameras: []string{"camera_1"}, StartTime: time.Now().Add(time.Hour), EndTime: time.Now().Add(2 * time.Hour)},
+		"unknown camera":   {Cameras: []string{"camera_9"}, StartTime: start, EndTime: start.Add(time.Minute)},
+	}
+	for name, req := range cases {
+		_, err := s.CreateClip(req, nil)
+		var invalid *InvalidClipRequestError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: expected *InvalidClipRequestError, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
+)
+
+// minPreviewSnippetSpacing is the minimum distance between snippet starts in seconds,
+// so short videos get fewer snippets instead of overlapping ones
+const minPreviewSnippetSpacing = 10.0
+
+// planPreviewSnippets returns the start offsets (in seconds) of the preview snippets for a video.
+// activity holds scene change timestamps and presses holds button press offsets; both may be empty,
+// in which case the even placement is used.
+func planPreviewSnippets(template config.PreviewTemplate, duration float64, activity, presses []float64) []float64 {
+	if duration <= 0 {
+		return []float64{0}
+	}
+
+	count := template.SnippetCount
+	if maxCount := int(duration / minPreviewSnippetSpacing); count > maxCount {
+		count = maxCount
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	var starts []float64
+	switch template.Placement {
+	case config.PreviewPlacementActivity:
+		starts = activitySnippetStarts(activity, count, duration, template.SnippetLengthSeconds)
+	case config.PreviewPlacementButtonPress:
+		starts = buttonPressSnippetStarts(presses, count, duration, template.SnippetLengthSeconds)
+	}
+
+	// Fill any remaining slots with evenly spaced snippets that don't overlap the chosen ones
+	if len(starts) < count {
+		for _, candidate := range evenSnippetStarts(count, duration) {
+			if len(starts) >= count {
+				break
+			}
+			if !overlapsSnippet(starts, candidate, minPreviewSnippetSpacing/2) {
+				starts = append(starts, candidate)
+			}
+		}
+	}
+
+	for i := range starts {
+		starts[i] = clampSnippetStart(starts[i], duration, template.SnippetLengthSeconds)
+	}
+	sort.Float64s(starts)
+	return starts
+}
+
+// evenSnippetStarts spreads count snippets evenly, starting at the beginning of the video
+func evenSnippetStarts(count int, duration float64) []float64 {
+	starts := make([]float64, 0, count)
+	for i := 0; i < count; i++ {
+		starts = append(starts, float64(i)*duration/float64(count))
+	}
+	return starts
+}
+
+// activitySnippetStarts divides the video into count windows and, in each window, picks the
+// moment with the most scene changes in the following snippet length
+func activitySnippetStarts(activity []float64, count int, duration, snippetLength float64) []float64 {
+	if len(activity) == 0 {
+		return nil
+	}
+
+	windowLength := duration / float64(count)
+	lookahead := math.Max(snippetLength*3, 5)
+
+	var starts []float64
+	for i := 0; i < count; i++ {
+		windowStart := float64(i) * windowLength
+		windowEnd := windowStart + windowLength
+
+		bestStart, bestScore := -1.0, 0
+		for _, t := range activity {
+			if t < windowStart || t >= windowEnd {
+				continue
+			}
+			score := 0
+			for _, other := range activity {
+				if other >= t && other < t+lookahead {
+					score++
+				}
+			}
+			if score > bestScore {
+				bestStart, bestScore = t, score
+			}
+		}
+
+		if bestStart >= 0 {
+			// Start slightly before the burst of activity
+			starts = append(starts, math.Max(windowStart, bestStart-snippetLength/2))
+		}
+	}
+	return starts
+}
+
+// buttonPressSnippetStarts places snippets so they end at the moment a button was pressed.
+// When there are more presses than snippets, presses are picked evenly across the list.
+func buttonPressSnippetStarts(presses []float64, count int, duration, snippetLength float64) []float64 {
+	var valid []float64
+	for _, press := range presses {
+		if press >= 0 && press <= duration {
+			valid = append(valid, press)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	sort.Float64s(valid)
+
+	selected := valid
+	if len(valid) > count {
+		selected = make([]float64, 0, count)
+		for i := 0; i < count; i++ {
+			selected = append(selected, valid[i*len(valid)/count])
+		}
+	}
+
+	var starts []float64
+	for _, press := range selected {
+		start := press - snippetLength
+		if !overlapsSnippet(starts, start, snippetLength) {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// overlapsSnippet reports whether candidate is closer than minDistance to any existing start
+func overlapsSnippet(starts []float64, candidate, minDistance float64) bool {
+	for _, start := range starts {
+		if math.Abs(start-candidate) < minDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// clampSnippetStart keeps a snippet fully inside the video
+func clampSnippetStart(start, duration, snippetLength float64) float64 {
+	if start+snippetLength > duration {
+		start = duration - snippetLength
+	}
+	if start < 0 {
+		start = 0
+	}
+	return start
+}
+
+var sceneTimeRegex = regexp.MustCompile(`pts_time:([0-9.]+)`)
+
+// detectSceneChanges returns the timestamps (in seconds) of scene changes in a video.
+// Only keyframes of a downscaled copy are analysed to keep this cheap for long videos.
+func detectSceneChanges(videoID, inputPath string) ([]float64, error) {
+	output, err := ffmpeg.Run(ffmpeg.Job{VideoID: videoID, Stage: ffmpeg.StagePreview},
+		"-skip_frame", "nokey",
+		"-i", inputPath,
+		"-vf", "scale=160:-2,select='gt(scene,0.08)',showinfo",
+		"-an",
+		"-f", "null",
+		"-")
+	if err != nil {
+		return nil, fmt.Errorf("scene detection failed: %v", err)
+	}
+
+	var timestamps []float64
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		match := sceneTimeRegex.FindStringSubmatch(scanner.Text())
+		if len(match) < 2 {
+			continue
+		}
+		if t, err := strconv.ParseFloat(match[1], 64); err == nil {
+			timestamps = append(timestamps, t)
+		}
+	}
+
+	return timestamps, nil
+}
+
+// buttonPressOffsets returns offsets (in seconds from the start of the video) of button presses
+// that happened during the video. A button press creates a "clip" video for the same booking and
+// camera that ends at the moment of the press.
+func buttonPressOffsets(db database.Database, video *database.VideoMetadata) []float64 {
+	if db == nil || video == nil || video.BookingID == "" || video.StartTime == nil {
+		return nil
+	}
+
+	clips, err := db.GetVideosByBookingID(video.BookingID)
+	if err != nil {
+		log.Printf("Warning: Failed to get clips for booking %s: %v", video.BookingID, err)
+		return nil
+	}
+
+	var offsets []float64
+	for _, clip := range clips {
+		if clip.ID == video.ID || clip.VideoType != "clip" || clip.CameraName != video.CameraName || clip.EndTime == nil {
+			continue
+		}
+		offsets = append(offsets, clip.EndTime.Sub(*video.StartTime).Seconds())
+	}
+	return offsets
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"ayo-mwr/config"
+)
+
+func TestPlanPreviewSnippets(t *testing.T) {
+	even := config.PreviewTemplate{Name: "even", SnippetCount: 5, SnippetLengthSeconds: 2, Placement: config.PreviewPlacementEven}
+	activity := config.PreviewTemplate{Name: "activity", SnippetCount: 2, SnippetLengthSeconds: 2, Placement: config.PreviewPlacementActivity}
+	presses := config.PreviewTemplate{Name: "presses", SnippetCount: 3, SnippetLengthSeconds: 3, Placement: config.PreviewPlacementButtonPress}
+
+	cases := []struct {
+		name     string
+		template config.PreviewTemplate
+		duration float64
+		activity []float64
+		presses  []float64
+		want     []float64
+	}{
+		{"even spacing", even, 100, nil, nil, []float64{0, 20, 40, 60, 80}},
+		{"short video gets fewer snippets", even, 25, nil, nil, []float64{0, 12.5}},
+		{"very short video gets one snippet", even, 5, nil, nil, []float64{0}},
+		{"unknown duration", even, 0, nil, nil, []float64{0}},
+		{"activity burst", activity, 100, []float64{50, 51, 52, 53}, nil, []float64{0, 50}},
+		{"activity without scene changes falls back to even", activity, 100, nil, nil, []float64{0, 50}},
+		{"snippets end at button presses", presses, 100, nil, []float64{30, 95}, []float64{0, 27, 92}},
+		{"presses are sampled when there are too many", presses, 100, nil, []float64{60, 10, 20, 30, 40, 50}, []float64{7, 27, 47}},
+		{"early press is clamped to the start", presses, 100, nil, []float64{1}, []float64{0, 33.333333333333336, 66.66666666666667}},
+		{"presses outside the video are ignored", presses, 100, nil, []float64{-5, 150}, []float64{0, 33.333333333333336, 66.66666666666667}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := planPreviewSnippets(tc.template, tc.duration, tc.activity, tc.presses)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("planPreviewSnippets() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClampSnippetStart(t *testing.T) {
+	cases := []struct {
+		start, duration, length, want float64
+	}{
+		{10, 100, 2, 10},
+		{99, 100, 2, 98},
+		{-3, 100, 2, 0},
+		{0, 1, 2, 0},
+	}
+	for _, tc := range cases {
+		if got := clampSnippetStart(tc.start, tc.duration, tc.length); got != tc.want {
+			t.Errorf("clampSnippetStart(%v, %v, %v) = %v, want %v", tc.start, tc.duration, tc.length, got, tc.want)
+		}
+	}
+}
+
+func TestOverlapsSnippet(t *testing.T) {
+	starts := []float64{10, 40}
+	cases := map[float64]bool{
+		12: true,
+		15: false,
+		36: true,
+		60: false,
+	}
+	for candidate, want := range cases {
+		if got := overlapsSnippet(starts, candidate, 5); got != want {
+			t.Errorf("overlapsSnippet(%v) = %v, want %v", candidate, got, want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+)
+
+// orphanMinAge keeps objects uploaded shortly before their video row is written from being
+// reported as orphans
+const orphanMinAge = 24 * time.Hour
+
+// reconciliationPrefixes are the bucket prefixes videos are uploaded under
+var reconciliationPrefixes = []string{"mp4/", "preview/", "thumbnail/", "hls/", "sprites/", "slowmo/"}
+
+// reconciliationMutex prevents the cron and the admin API from reconciling at the same time
+var reconciliationMutex sync.Mutex
+
+// ReconciledObject is an object a video references that was missing from the bucket
+type ReconciledObject struct {
+	VideoID string `json:"videoId"`
+	Key     string `json:"key"`
+	Source  string `json:"source,omitempty"` // Local file or directory it was uploaded again from
+	Reason  string `json:"reason,omitempty"` // Why it could not be repaired
+}
+
+// ReconciliationReport is the result of comparing the bucket with the videos table
+type ReconciliationReport struct {
+	RanAt          time.Time          `json:"ranAt"`
+	Duration       string             `json:"duration"`
+	VideosChecked  int                `json:"videosChecked"`
+	ObjectsListed  int                `json:"objectsListed"`
+	Repaired       []ReconciledObject `json:"repaired"`
+	Unrecoverable  []ReconciledObject `json:"unrecoverable"`
+	Orphans        []string           `json:"orphans"`        // Objects no video references
+	OrphansDeleted int                `json:"orphansDeleted"` // Set when orphan deletion was requested
+	Errors         []string           `json:"errors,omitempty"`
+}
+
+// videoArtifact is an object a video references and where it can be uploaded again from
+type videoArtifact struct {
+	url       string
+	sources   []string // Local files, the first existing one is uploaded
+	streamDir string   // Local HLS/DASH directory uploaded as a whole instead
+}
+
+// RunBucketReconciliation compares the objects under the video prefixes of the bucket with the
+// videos table. Missing objects are uploaded again from local files where possible, the others
+// are reported as unrecoverable. Objects no video references are reported as orphans and deleted
+// when deleteOrphans is set. The report is stored and returned.
+func RunBucketReconciliation(db database.Database, store storage.ObjectStore, deleteOrphans bool) (*ReconciliationReport, error) {
+	if store == nil {
+		return nil, fmt.Errorf("object storage is not configured")
+	}
+
+	reconciliationMutex.Lock()
+	defer reconciliationMutex.Unlock()
+
+	started := time.Now()
+	report := &ReconciliationReport{RanAt: started}
+	log.Printf("[Reconciliation] 🔍 Comparing bucket with videos table (delete orphans: %v)...", deleteOrphans)
+
+	objects := make(map[string]storage.ObjectInfo)
+	for _, prefix := range reconciliationPrefixes {
+		listed, err := store.ListObjects(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under %s: %v", prefix, err)
+		}
+		for _, object := range listed {
+			objects[object.Key] = object
+		}
+	}
+	report.ObjectsListed = len(objects)
+
+	// Snapshot the videos before checking them, so videos deleted or added during the run cannot
+	// shift a page and leave their objects unowned
+	statuses, err := db.ListVideoStatuses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list videos: %v", err)
+	}
+	ids := make([]string, 0, len(statuses))
+	for id, status := range statuses {
+		// Expired videos have no objects left; anything still stored for them is an orphan
+		if status == database.StatusUnavailable {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	referenced := make(map[string]bool)
+	videoIDs := make(map[string]bool)
+	for _, id := range ids {
+		// Objects of a video in the snapshot are never orphans in this run, even if the
+		// video is deleted meanwhile
+		videoIDs[id] = true
+
+		video, err := db.GetVideo(id)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to get video %s: %v", id, err))
+			continue
+		}
+		if video == nil {
+			continue // Deleted since the snapshot
+		}
+		report.VideosChecked++
+		reconcileVideo(db, store, video, objects, referenced, report)
+	}
+
+	cutoff := started.Add(-orphanMinAge)
+	for key, object := range objects {
+		if referenced[key] || keyOwnedByVideo(key, videoIDs) {
+			continue
+		}
+		if object.LastModified.Before(cutoff) {
+			report.Orphans = append(report.Orphans, key)
+		}
+	}
+	sort.Strings(report.Orphans)
+
+	if deleteOrphans && len(report.Orphans) > 0 {
+		deleted, err := storage.DeleteObjects(store, report.Orphans)
+		report.OrphansDeleted = len(deleted)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to delete orphans: %v", err))
+		}
+	}
+
+	report.Duration = time.Since(started).Round(time.Millisecond).String()
+	log.Printf("[Reconciliation] ✅ %d videos, %d objects: %d repaired, %d unrecoverable, %d orphans (%d deleted)",
+		report.VideosChecked, report.ObjectsListed, len(report.Repaired), len(report.Unrecoverable), len(report.Orphans), report.OrphansDeleted)
+
+	if err := saveReconciliationReport(db, report); err != nil {
+		return report, fmt.Errorf("failed to store reconciliation report: %v", err)
+	}
+	return report, nil
+}
+
+// reconcileVideo checks the objects a video references and uploads the missing ones again
+func reconcileVideo(db database.Database, store storage.ObjectStore, video *database.VideoMetadata, objects map[string]storage.ObjectInfo, referenced map[string]bool, report *ReconciliationReport) {
+	prefix := store.GetBaseURL() + "/"
+	mp4Sources := []string{video.LocalPath, video.MP4FullPath}
+
+	artifacts := []videoArtifact{
+		{url: video.R2MP4URL, sources: mp4Sources},
+		{url: video.R2HLSURL, streamDir: video.HLSPath},
+		{url: video.R2DASHURL, streamDir: video.HLSPath},
+		{url: video.R2PreviewMP4URL},
+		{url: video.R2PreviewPNGURL},
+		{url: video.R2PreviewWebPURL},
+		{url: video.R2PreviewGIFURL},
+		{url: video.R2SpriteVTTURL},
+		{url: video.R2SlowMotionURL, sources: []string{video.SlowMotionPath}},
+		{url: video.R2VerticalURL},
+	}
+
+	for _, artifact := range artifacts {
+		// URLs outside the current store (e.g. a previous backend) cannot be checked
+		if !strings.HasPrefix(artifact.url, prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(artifact.url, prefix)
+		referenced[key] = true
+		if objectExists(store, objects, key) {
+			continue
+		}
+
+		missing := ReconciledObject{VideoID: video.ID, Key: key}
+		source, err := repairArtifact(db, store, video, key, artifact)
+		if err != nil {
+			missing.Reason = err.Error()
+			report.Unrecoverable = append(report.Unrecoverable, missing)
+			log.Printf("[Reconciliation] ❌ %s of video %s is missing and cannot be repaired: %v", key, video.ID, err)
+			continue
+		}
+		missing.Source = source
+		report.Repaired = append(report.Repaired, missing)
+		log.Printf("[Reconciliation] 🔧 %s of video %s uploaded again from %s", key, video.ID, source)
+	}
+}
+
+// repairArtifact uploads a missing object of a video again and returns the local source used
+func repairArtifact(db database.Database, store storage.ObjectStore, video *database.VideoMetadata, key string, artifact videoArtifact) (string, error) {
+	if artifact.streamDir != "" {
+		if _, err := os.Stat(filepath.Join(artifact.streamDir, filepath.Base(key))); err != nil {
+			return "", fmt.Errorf("local stream %s not found", filepath.Join(artifact.streamDir, filepath.Base(key)))
+		}
+		if _, _, err := storage.UploadHLSStream(store, db, artifact.streamDir, video.ID); err != nil {
+			return "", err
+		}
+		return artifact.streamDir, nil
+	}
+
+	for _, source := range artifact.sources {
+		if source == "" || filepath.Ext(source) != filepath.Ext(key) {
+			continue
+		}
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+		if _, err := storage.UploadVideoArtifact(store, db, video.ID, source, key, nil); err != nil {
+			return "", err
+		}
+		return source, nil
+	}
+	return "", fmt.Errorf("no local copy")
+}
+
+// objectExists reports whether key is stored, checking keys outside the listed prefixes directly
+func objectExists(store storage.ObjectStore, objects map[string]storage.ObjectInfo, key string) bool {
+	if _, ok := objects[key]; ok {
+		return true
+	}
+	for _, prefix := range reconciliationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	_, err := store.HeadObject(key)
+	return err == nil
+}
+
+// keyOwnedByVideo reports whether key is stored under the prefixes of one of videoIDs (see
+// storage.VideoObjectPrefixes): hls/<id>/..., sprites/<id>/..., or <prefix>/<id>.<ext> and
+// <prefix>/<id>_<suffix>.<ext> for single files
+func keyOwnedByVideo(key string, videoIDs map[string]bool) bool {
+	slash := strings.Index(key, "/")
+	if slash < 0 {
+		return false
+	}
+	rest := key[slash+1:]
+
+	if end := strings.Index(rest, "/"); end >= 0 {
+		return videoIDs[rest[:end]]
+	}
+
+	name := strings.TrimSuffix(rest, filepath.Ext(rest))
+	if videoIDs[name] {
+		return true
+	}
+	for i := range name {
+		if name[i] == '_' && videoIDs[name[:i]] {
+			return true
+		}
+	}
+	return false
+}
+
+// saveReconciliationReport stores the report of the last reconciliation
+func saveReconciliationReport(db database.Database, report *ReconciliationReport) error {
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	return db.SetSystemConfig(database.SystemConfig{
+		Key:       "bucket_reconciliation",
+		Value:     string(reportJSON),
+		Type:      "json",
+		UpdatedBy: "system",
+	})
+}
+
+// LoadReconciliationReport returns the report of the last reconciliation, or nil if none has run
+func LoadReconciliationReport(db database.Database) (*ReconciliationReport, error) {
+	config, err := db.GetSystemConfig("bucket_reconciliation")
+	if err != nil {
+		return nil, nil
+	}
+
+	var report ReconciliationReport
+	if err := json.Unmarshal([]byte(config.Value), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse stored reconciliation report: %v", err)
+	}
+	return &report, nil
+}
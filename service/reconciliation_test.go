@@ -0,0 +1,156 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+)
+
+func TestKeyOwnedByVideo(t *testing.T) {
+	videoIDs := map[string]bool{"video_1": true, "booking_cam_1": true}
+
+	cases := map[string]bool{
+		"mp4/video_1.mp4":                    true,
+		"mp4/video_1_preview.mp4":            true,
+		"mp4/booking_cam_1_thumbnail.jpg":    true,
+		"hls/video_1/720p/segment_000.ts":    true,
+		"sprites/booking_cam_1/sprite_1.jpg": true,
+		"mp4/video_10.mp4":                   false,
+		"hls/video_2/master.m3u8":            false,
+		"mp4/booking_cam.mp4":                false,
+		"stray.txt":                          false,
+	}
+	for key, want := range cases {
+		if got := keyOwnedByVideo(key, videoIDs); got != want {
+			t.Errorf("keyOwnedByVideo(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+// agedStore reports the listed objects in aged as uploaded two days ago, past orphanMinAge
+type agedStore struct {
+	*storage.MemoryStorage
+	aged map[string]bool
+}
+
+func (a *agedStore) ListObjects(prefix string) ([]storage.ObjectInfo, error) {
+	objects, err := a.MemoryStorage.ListObjects(prefix)
+	for i := range objects {
+		if a.aged[objects[i].Key] {
+			objects[i].LastModified = objects[i].LastModified.Add(-48 * time.Hour)
+		}
+	}
+	return objects, err
+}
+
+// deletingDB deletes a video the first time another one is read, like a cleanup running
+// alongside the reconciliation
+type deletingDB struct {
+	database.Database
+	deleteID string
+}
+
+func (d *deletingDB) GetVideo(id string) (*database.VideoMetadata, error) {
+	if d.deleteID != "" && id != d.deleteID {
+		d.Database.DeleteVideo(d.deleteID)
+		d.deleteID = ""
+	}
+	return d.Database.GetVideo(id)
+}
+
+func TestRunBucketReconciliation(t *testing.T) {
+	sqlite, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer sqlite.Close()
+	db := &deletingDB{Database: sqlite, deleteID: "video_c"}
+
+	base := "https://media.example.com"
+	store := &agedStore{MemoryStorage: storage.NewMemoryStorage(base), aged: map[string]bool{}}
+	put := func(key string, aged bool) {
+		store.PutObject(key, []byte(key))
+		store.aged[key] = aged
+	}
+
+	localMP4 := filepath.Join(t.TempDir(), "video_a.mp4")
+	if err := os.WriteFile(localMP4, []byte("local copy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	videos := []database.VideoMetadata{
+		// MP4 missing but still on disk, preview present
+		{ID: "video_a", Status: database.StatusReady, LocalPath: localMP4,
+			R2MP4URL: base + "/mp4/video_a.mp4", R2PreviewMP4URL: base + "/preview/video_a.mp4"},
+		// Thumbnail missing without a local copy
+		{ID: "video_b", Status: database.StatusReady, R2PreviewPNGURL: base + "/thumbnail/video_b.png"},
+		// Deleted while the run is in progress
+		{ID: "video_c", Status: database.StatusReady, R2MP4URL: base + "/mp4/video_c.mp4"},
+		// Expired, anything left of it is an orphan
+		{ID: "video_d", Status: database.StatusUnavailable},
+	}
+	for _, video := range videos {
+		video.CreatedAt = time.Now()
+		if err := sqlite.CreateVideo(video); err != nil {
+			t.Fatalf("Failed to create video %s: %v", video.ID, err)
+		}
+	}
+
+	put("preview/video_a.mp4", true)
+	put("mp4/video_c.mp4", true)
+	put("hls/video_c/master.m3u8", true)
+	put("mp4/video_d.mp4", true)
+	put("mp4/ghost.mp4", true)
+	put("mp4/uploading.mp4", false) // Too recent to be an orphan
+
+	report, err := RunBucketReconciliation(db, store, true)
+	if err != nil {
+		t.Fatalf("RunBucketReconciliation: %v", err)
+	}
+
+	if report.VideosChecked != 2 {
+		t.Errorf("expected 2 videos checked, got %d", report.VideosChecked)
+	}
+	if report.ObjectsListed != 6 {
+		t.Errorf("expected 6 objects listed, got %d", report.ObjectsListed)
+	}
+
+	if len(report.Repaired) != 1 || report.Repaired[0].Key != "mp4/video_a.mp4" || report.Repaired[0].Source != localMP4 {
+		t.Errorf("expected mp4/video_a.mp4 to be repaired from %s, got %+v", localMP4, report.Repaired)
+	}
+	if data, err := store.GetObject("mp4/video_a.mp4"); err != nil || string(data) != "local copy" {
+		t.Errorf("expected the local copy to be uploaded again, got %q, %v", data, err)
+	}
+
+	if len(report.Unrecoverable) != 1 || report.Unrecoverable[0].Key != "thumbnail/video_b.png" || report.Unrecoverable[0].Reason == "" {
+		t.Errorf("expected thumbnail/video_b.png to be unrecoverable, got %+v", report.Unrecoverable)
+	}
+
+	wantOrphans := []string{"mp4/ghost.mp4", "mp4/video_d.mp4"}
+	if !reflect.DeepEqual(report.Orphans, wantOrphans) {
+		t.Errorf("expected orphans %v, got %v", wantOrphans, report.Orphans)
+	}
+	if report.OrphansDeleted != len(wantOrphans) {
+		t.Errorf("expected %d orphans deleted, got %d", len(wantOrphans), report.OrphansDeleted)
+	}
+	for _, key := range wantOrphans {
+		if _, err := store.HeadObject(key); err == nil {
+			t.Errorf("expected orphan %s to be deleted", key)
+		}
+	}
+	for _, key := range []string{"mp4/video_c.mp4", "hls/video_c/master.m3u8", "mp4/uploading.mp4", "preview/video_a.mp4"} {
+		if _, err := store.HeadObject(key); err != nil {
+			t.Errorf("expected %s to be kept: %v", key, err)
+		}
+	}
+
+	stored, err := LoadReconciliationReport(sqlite)
+	if err != nil || stored == nil || len(stored.Orphans) != len(wantOrphans) {
+		t.Errorf("expected the report to be stored, got %+v, %v", stored, err)
+	}
+}
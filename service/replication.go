@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+)
+
+// EnqueueReplication queues copying a delivered video to the secondary buckets when replication
+// is enabled, marking it pending on every destination. The offline queue runs the task. It is
+// called again whenever more objects of the video are uploaded.
+func EnqueueReplication(db database.Database, videoID string) error {
+	replicationConfig, _ := config.NewReplicationConfigService(db).GetReplicationConfig()
+	if !replicationConfig.Enabled {
+		return nil
+	}
+
+	var destinations []string
+	for _, destination := range replicationConfig.Destinations {
+		destinations = append(destinations, destination.Name)
+	}
+
+	taskDataJSON, err := json.Marshal(database.ReplicationTaskData{VideoID: videoID, Destinations: destinations})
+	if err != nil {
+		return fmt.Errorf("error marshaling replication task data: %v", err)
+	}
+
+	task := database.PendingTask{
+		TaskType:    database.TaskReplicate,
+		TaskData:    string(taskDataJSON),
+		Attempts:    0,
+		MaxAttempts: 5,
+		NextRetryAt: time.Now(),
+		Status:      database.TaskStatusPending,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := db.CreatePendingTask(task); err != nil {
+		return fmt.Errorf("error creating replication task: %v", err)
+	}
+
+	for _, destination := range destinations {
+		status := database.ReplicationStatus{Status: database.ReplicationPending, UpdatedAt: time.Now()}
+		if err := db.UpdateVideoReplication(videoID, destination, status); err != nil {
+			log.Printf("[Replication] Warning: Failed to mark video %s pending on %s: %v", videoID, destination, err)
+		}
+	}
+
+	log.Printf("[Replication] ➕ Video %s queued for replication to %s", videoID, strings.Join(destinations, ", "))
+	return nil
+}
+
+// ReplicateVideo copies the objects of a video from the primary store to destinations and
+// records the outcome per destination. Videos are queued again when more of their objects are
+// uploaded, so destinations the video is already replicated to are checked again and only get
+// the objects they are missing. Destinations removed from the configuration since the video was
+// queued are skipped. An error is returned if any destination failed, so the queue retries the rest.
+func ReplicateVideo(db database.Database, primary storage.ObjectStore, videoID string, destinations []string) error {
+	if primary == nil {
+		return fmt.Errorf("object storage is not configured")
+	}
+
+	video, err := db.GetVideo(videoID)
+	if err != nil {
+		return fmt.Errorf("error getting video data: %v", err)
+	}
+	if video == nil {
+		return fmt.Errorf("video not found: %s", videoID)
+	}
+
+	replicationConfig, _ := config.NewReplicationConfigService(db).GetReplicationConfig()
+	storageConfig, _ := config.NewObjectStorageConfigService(db).GetObjectStorageConfig()
+
+	var failed []string
+	for _, name := range destinations {
+		destination := replicationConfig.Destination(name)
+		if destination == nil {
+			log.Printf("[Replication] ⚠️ Destination %s is no longer configured, skipping video %s", name, videoID)
+			continue
+		}
+
+		status := database.ReplicationStatus{Status: database.ReplicationReplicated}
+		replica, err := storage.NewReplica(*destination, storageConfig)
+		if err == nil {
+			status.Objects, err = storage.ReplicateObjects(primary, replica.Store, storage.VideoObjectPrefixes(videoID))
+		}
+		if err != nil {
+			status.Status = database.ReplicationFailed
+			status.Error = err.Error()
+			failed = append(failed, name)
+			log.Printf("[Replication] ❌ Video %s to %s failed: %v", videoID, name, err)
+		} else {
+			log.Printf("[Replication] ✅ Video %s replicated to %s (%d objects)", videoID, name, status.Objects)
+		}
+
+		status.UpdatedAt = time.Now()
+		if err := db.UpdateVideoReplication(videoID, name, status); err != nil {
+			log.Printf("[Replication] Warning: Failed to record replication of video %s to %s: %v", videoID, name, err)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("replication of video %s failed for %s", videoID, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Replicas returns the stores of every configured replication destination
+func Replicas(db database.Database) []*storage.Replica {
+	replicationConfig, _ := config.NewReplicationConfigService(db).GetReplicationConfig()
+	storageConfig, _ := config.NewObjectStorageConfigService(db).GetObjectStorageConfig()
+
+	var replicas []*storage.Replica
+	for _, destination := range replicationConfig.Destinations {
+		replica, err := storage.NewReplica(destination, storageConfig)
+		if err != nil {
+			log.Printf("[Replication] Warning: %v", err)
+			continue
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
+// FailoverReplicas returns the replicas media is resolved from while the primary store is
+// unhealthy, or nil when replication or failover is disabled or the bucket is public
+func FailoverReplicas(db database.Database) []*storage.Replica {
+	replicationConfig, _ := config.NewReplicationConfigService(db).GetReplicationConfig()
+	if !replicationConfig.Enabled || !replicationConfig.Failover {
+		return nil
+	}
+	storageConfig, _ := config.NewObjectStorageConfigService(db).GetObjectStorageConfig()
+	if err := replicationConfig.CheckStorage(storageConfig); err != nil {
+		return nil
+	}
+	return Replicas(db)
+}
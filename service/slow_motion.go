@@ -0,0 +1,80 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/recording"
+	"ayo-mwr/transcode"
+)
+
+// applySlowMotionReplay produces the slow-motion replay configured for the clip's venue and field.
+// In append mode the replay is added to the end of videoPath in place. In separate mode it is
+// written to replayPath and recorded on the video so it is uploaded to R2 with its own key.
+func applySlowMotionReplay(db database.Database, videoID, videoPath, replayPath, rawJSON string) error {
+	venueCode := ""
+	if venueConfig, err := db.GetSystemConfig(database.ConfigVenueCode); err == nil {
+		venueCode = venueConfig.Value
+	}
+
+	var booking struct {
+		FieldID int `json:"field_id"`
+	}
+	if rawJSON != "" {
+		json.Unmarshal([]byte(rawJSON), &booking)
+	}
+
+	settings := config.NewSlowMotionConfigService(db).SettingsFor(venueCode, booking.FieldID)
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.MaxClipSeconds > 0 {
+		duration, err := transcode.GetVideoDuration(videoPath)
+		if err != nil {
+			return err
+		}
+		if duration > settings.MaxClipSeconds {
+			log.Printf("[SlowMotion] Skipping replay for %s: clip is %.1fs (limit %.1fs)", videoID, duration, settings.MaxClipSeconds)
+			return nil
+		}
+	}
+
+	if settings.Mode == config.SlowMotionSeparate {
+		if err := recording.CreateSlowMotionReplay(videoID, videoPath, replayPath, settings.ReplaySeconds, settings.Speed, settings.Interpolate, false); err != nil {
+			return err
+		}
+		if err := db.UpdateVideoSlowMotion(videoID, replayPath, "", ""); err != nil {
+			return fmt.Errorf("failed to record slow-motion replay: %v", err)
+		}
+		log.Printf("[SlowMotion] ✅ Created separate replay for %s: %s", videoID, replayPath)
+		return nil
+	}
+
+	ext := filepath.Ext(videoPath)
+	replayTS := strings.TrimSuffix(videoPath, ext) + "_slowmo.ts"
+	defer os.Remove(replayTS)
+	if err := recording.CreateSlowMotionReplay(videoID, videoPath, replayTS, settings.ReplaySeconds, settings.Speed, settings.Interpolate, true); err != nil {
+		return err
+	}
+
+	// The replay has the clip's format, so it is appended the same way as an outro bumper
+	outputPath := strings.TrimSuffix(videoPath, ext) + "_replay" + ext
+	if err := recording.AttachBumpers(videoID, videoPath, "", replayTS, outputPath); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	if err := os.Rename(outputPath, videoPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to replace clip with replay version: %v", err)
+	}
+
+	log.Printf("[SlowMotion] ✅ Appended %.1fs replay at %.2fx to %s", settings.ReplaySeconds, settings.Speed, videoID)
+	return nil
+}
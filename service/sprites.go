@@ -0,0 +1,39 @@
+package service
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"ayo-mwr/database"
+	"ayo-mwr/storage"
+	"ayo-mwr/transcode"
+)
+
+// GenerateAndUploadSprites creates thumbnail sprite sheets and a WebVTT track for a video,
+// uploads them to R2 and stores their URLs on the video record
+func GenerateAndUploadSprites(db database.Database, r2Client storage.ObjectStore, videoID, videoPath, spriteDir string) (string, string, error) {
+	interval := 10 // default: one thumbnail every 10 seconds
+	if config, err := db.GetSystemConfig(database.ConfigSpriteIntervalSeconds); err == nil {
+		if value, err := strconv.Atoi(config.Value); err == nil && value > 0 {
+			interval = value
+		}
+	}
+
+	// GenerateSpriteSheet creates spriteDir, so it is removed even when generation fails partway
+	defer os.RemoveAll(spriteDir)
+	if _, _, err := transcode.GenerateSpriteSheet(videoID, videoPath, spriteDir, interval); err != nil {
+		return "", "", err
+	}
+
+	spritePath, spriteURL, vttURL, err := storage.UploadSpriteSheet(r2Client, db, spriteDir, videoID, transcode.SpriteVTTFileName)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := db.UpdateVideoSpriteURLs(videoID, spritePath, spriteURL, vttURL); err != nil {
+		log.Printf("⚠️ Warning: Failed to store sprite URLs for %s: %v", videoID, err)
+	}
+
+	return spriteURL, vttURL, nil
+}
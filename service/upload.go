@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,7 +29,7 @@ type QueuedVideo struct {
 // UploadService handles uploading videos to R2 storage
 type UploadService struct {
 	db           database.Database
-	r2Storage    *storage.R2Storage
+	r2Storage    storage.ObjectStore
 	config       *config.Config
 	ayoClient    AyoAPIClient
 	uploadQueue  []QueuedVideo
@@ -39,7 +40,7 @@ type UploadService struct {
 
 // NewUploadService creates a new upload service
 // ayoClient can be nil if AYO API is not available
-func NewUploadService(db database.Database, r2Storage *storage.R2Storage, cfg *config.Config, ayoClient AyoAPIClient) *UploadService {
+func NewUploadService(db database.Database, r2Storage storage.ObjectStore, cfg *config.Config, ayoClient AyoAPIClient) *UploadService {
 	if ayoClient == nil {
 		log.Printf("⚠️ WARNING: UploadService initialized without AYO API client - API notifications will be disabled")
 	}
@@ -188,7 +189,7 @@ func (s *UploadService) StartUploadWorker() {
 			}
 
 			// Upload HLS stream - sekarang mengembalikan r2Path, r2URL, error
-			_, hlsURL, err := s.r2Storage.UploadHLSStream(video.HLSPath, video.ID)
+			_, hlsURL, err := storage.UploadHLSStream(s.r2Storage, s.db, video.HLSPath, video.ID)
 			if err != nil {
 				log.Printf("Error uploading HLS stream for video %s: %v", video.ID, err)
 				s.updateQueuedVideo(queuedVideo.VideoID, fmt.Sprintf("HLS upload error: %v", err))
@@ -197,7 +198,7 @@ func (s *UploadService) StartUploadWorker() {
 			}
 
 			// Upload MP4 file
-			mp4URL, err := s.r2Storage.UploadMP4(video.LocalPath, video.ID)
+			mp4URL, err := storage.UploadMP4(s.r2Storage, s.db, video.LocalPath, video.ID)
 			if err != nil {
 				log.Printf("Error uploading MP4 for video %s: %v", video.ID, err)
 				s.updateQueuedVideo(queuedVideo.VideoID, fmt.Sprintf("MP4 upload error: %v", err))
@@ -358,6 +359,13 @@ func removeLocalFiles(video database.VideoMetadata) {
 			log.Printf("Error removing HLS directory %s: %v", video.HLSPath, err)
 		}
 	}
+
+	// Remove DASH directory (CMAF output shares the HLS directory)
+	if video.DASHPath != "" && video.DASHPath != video.HLSPath {
+		if err := os.RemoveAll(video.DASHPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing DASH directory %s: %v", video.DASHPath, err)
+		}
+	}
 }
 
 // UploadVideo manually uploads a specific video to R2
@@ -383,7 +391,7 @@ func (s *UploadService) UploadVideo(videoID string) error {
 	}
 
 	// Upload HLS stream - sekarang dengan 3 nilai return (r2Path, r2URL, error)
-	r2HLSPath, r2HLSURL, err := s.r2Storage.UploadHLSStream(video.HLSPath, video.ID)
+	r2HLSPath, r2HLSURL, err := storage.UploadHLSStream(s.r2Storage, s.db, video.HLSPath, video.ID)
 	if err != nil {
 		s.db.UpdateVideoStatus(video.ID, database.StatusFailed, fmt.Sprintf("HLS upload error: %v", err))
 		return fmt.Errorf("error uploading HLS stream: %v", err)
@@ -528,8 +536,23 @@ func (s *UploadService) NotifyAyoAPI(uniqueID, mp4URL, previewURL, thumbnailURL
 		return fmt.Errorf("AYO API client not initialized")
 	}
 
+	// Additional renditions (e.g. vertical social export) are referenced in the same call
+	renditions := videoRenditions(s.db, video)
+	for _, rendition := range renditions {
+		log.Printf("📡 AYO API: - Rendition %s: %s", rendition.Name, rendition.URL)
+	}
+
+	// Only report the video once every file it references is verified in object storage
+	reported := []string{mp4URL, previewURL, thumbnailURL}
+	for _, rendition := range renditions {
+		reported = append(reported, rendition.URL)
+	}
+	if unverified := UnverifiedArtifacts(s.r2Storage, video, reported); len(unverified) > 0 {
+		return fmt.Errorf("artifacts of video %s not verified yet: %v", uniqueID, unverified)
+	}
+
 	// Call the actual AYO API
-	_, err = s.ayoClient.SaveVideoAvailable(
+	_, err = s.ayoClient.SaveVideoAvailableWithRenditions(
 		video.BookingID, // bookingID
 		videoType,       // videoType
 		previewURL,      // previewPath
@@ -538,6 +561,7 @@ func (s *UploadService) NotifyAyoAPI(uniqueID, mp4URL, previewURL, thumbnailURL
 		startTime,       // startTime
 		endTime,         // endTime
 		int(duration),   // duration
+		renditions,      // additional renditions
 	)
 
 	if err != nil {
@@ -545,6 +569,31 @@ func (s *UploadService) NotifyAyoAPI(uniqueID, mp4URL, previewURL, thumbnailURL
 	}
 
 	log.Printf("📡 AYO API: ✅ Successfully notified AYO API for video %s", uniqueID)
+
+	// Delivered videos are copied to the secondary buckets in the background
+	if err := EnqueueReplication(s.db, video.ID); err != nil {
+		log.Printf("📡 AYO API: ⚠️ Failed to queue replication of video %s: %v", uniqueID, err)
+	}
 	return nil
 }
 
+// UnverifiedArtifacts returns the object keys among urls that have no verified checksum on the
+// video. URLs outside the object store, e.g. from a previous storage backend, are not checked.
+func UnverifiedArtifacts(store storage.ObjectStore, video *database.VideoMetadata, urls []string) []string {
+	if store == nil {
+		return nil
+	}
+
+	prefix := store.GetBaseURL() + "/"
+	var unverified []string
+	for _, url := range urls {
+		if !strings.HasPrefix(url, prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(url, prefix)
+		if video.Checksums[key] == "" {
+			unverified = append(unverified, key)
+		}
+	}
+	return unverified
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/recording"
+	"ayo-mwr/storage"
+)
+
+// createVerticalRendition renders the 9:16 social export of a video when the vertical export profile
+// covers its video type, uploads it to R2 and records its URL. Returns "" when no rendition is needed.
+func createVerticalRendition(db database.Database, r2Client storage.ObjectStore, video *database.VideoMetadata, videoPath, outputPath string) (string, error) {
+	exportConfig, err := config.NewVerticalExportConfigService(db).GetVerticalExportConfig()
+	if err != nil || !exportConfig.EnabledFor(video.VideoType) {
+		return "", err
+	}
+
+	crop := exportConfig.CropFor(video.CameraName)
+	centers := []float64{crop.CenterX}
+	if crop.CropMode == config.VerticalCropActivity {
+		if activity, err := recording.ActivityCenters(video.ID, videoPath, crop.CenterX); err != nil {
+			log.Printf("[VerticalExport] Warning: Motion analysis failed for %s, using fixed centre: %v", video.ID, err)
+		} else {
+			centers = activity
+		}
+	}
+
+	if err := recording.CreateVerticalExport(video.ID, videoPath, outputPath, exportConfig.Width, exportConfig.Height, centers); err != nil {
+		return "", err
+	}
+	defer os.Remove(outputPath)
+
+	r2Path := fmt.Sprintf("mp4/%s_vertical.mp4", video.ID)
+	if _, err := storage.UploadVideoArtifact(r2Client, db, video.ID, outputPath, r2Path, nil); err != nil {
+		return "", fmt.Errorf("failed to upload vertical rendition: %v", err)
+	}
+	r2URL := fmt.Sprintf("%s/%s", r2Client.GetBaseURL(), r2Path)
+
+	if err := db.UpdateVideoVerticalURL(video.ID, r2Path, r2URL); err != nil {
+		log.Printf("[VerticalExport] Warning: Failed to store vertical rendition URL for %s: %v", video.ID, err)
+	}
+
+	log.Printf("[VerticalExport] ✅ Vertical rendition uploaded for %s: %s", video.ID, r2URL)
+	return r2URL, nil
+}
+
+// videoRenditions returns the additional renditions of a video to report to the AYO API
+func videoRenditions(db database.Database, video *database.VideoMetadata) []database.VideoRendition {
+	var renditions []database.VideoRendition
+	if video.R2VerticalURL != "" {
+		exportConfig, _ := config.NewVerticalExportConfigService(db).GetVerticalExportConfig()
+		renditions = append(renditions, database.VideoRendition{
+			Name:   "vertical",
+			URL:    video.R2VerticalURL,
+			Width:  exportConfig.Width,
+			Height: exportConfig.Height,
+		})
+	}
+	return renditions
+}
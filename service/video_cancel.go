@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
+)
+
+// IsVideoProcessing reports whether a video with this status is still being processed and can be cancelled
+func IsVideoProcessing(status database.VideoStatus) bool {
+	switch status {
+	case database.StatusInitial, database.StatusPending, database.StatusProcessing, database.StatusUploading:
+		return true
+	default:
+		return false
+	}
+}
+
+// CancelVideo stops the in-flight FFmpeg processes of a video, removes its temporary files and
+// marks it cancelled. Processing steps still to run for the video fail with ffmpeg.ErrCancelled.
+func (s *BookingVideoService) CancelVideo(video *database.VideoMetadata, reason string) error {
+	if ffmpeg.Cancel(video.ID) {
+		log.Printf("CancelVideo : Stopped running FFmpeg processes of video %s", video.ID)
+	}
+
+	previewPath := s.getTempPath(TmpTypePreview, video.ID, ".mp4", video.CameraName)
+	s.CleanupTemporaryFiles(
+		"", // Segments are merged straight into the watermark file
+		s.getTempPath(TmpTypeWatermark, video.ID, ".ts", video.CameraName),
+		previewPath,
+		s.getTempPath(TmpTypeThumbnail, video.ID, ".jpg", video.CameraName),
+	)
+	webpPath, gifPath := AnimatedPreviewPaths(previewPath)
+	os.Remove(webpPath)
+	os.Remove(gifPath)
+	os.Remove(s.getTempPath(TmpTypeSlowMotion, video.ID, ".mp4", video.CameraName))
+	os.Remove(s.getTempPath(TmpTypeVertical, video.ID, ".mp4", video.CameraName))
+
+	if err := s.db.UpdateVideoStatus(video.ID, database.StatusCancelled, reason); err != nil {
+		return fmt.Errorf("failed to mark video %s cancelled: %v", video.ID, err)
+	}
+	log.Printf("CancelVideo : Video %s cancelled: %s", video.ID, reason)
+	return nil
+}
+
+// processingError returns the error a processing step fails with. A cancelled video keeps the
+// status set by CancelVideo and fails with ffmpeg.ErrCancelled; otherwise the video is marked failed.
+func (s *BookingVideoService) processingError(uniqueID string, err error) error {
+	if ffmpeg.IsCancelled(uniqueID) {
+		return fmt.Errorf("%w: video %s", ffmpeg.ErrCancelled, uniqueID)
+	}
+	s.db.UpdateVideoStatus(uniqueID, database.StatusFailed, err.Error())
+	return err
+}
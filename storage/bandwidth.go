@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"ayo-mwr/config"
+)
+
+const (
+	// throughputWindow is the number of seconds upload throughput is averaged over
+	throughputWindow = 10
+	// limitedReadSize caps a single read from a rate limited body so tokens are spent smoothly
+	limitedReadSize = 32 * 1024
+)
+
+// BandwidthLimiter is a token bucket shared by all uploads, with the rate following a
+// time-of-day schedule. It also measures the throughput of the traffic passing through it.
+type BandwidthLimiter struct {
+	mu       sync.Mutex
+	schedule *config.UploadBandwidthConfig
+	tokens   float64
+	last     time.Time
+
+	buckets    [throughputWindow]int64 // Bytes sent per second, indexed by Unix second
+	bucketSecs [throughputWindow]int64
+}
+
+// uploadLimiter throttles every upload to R2
+var uploadLimiter = &BandwidthLimiter{}
+
+// SetUploadBandwidth applies an upload bandwidth schedule to all uploads, including running ones
+func SetUploadBandwidth(schedule *config.UploadBandwidthConfig) {
+	uploadLimiter.SetSchedule(schedule)
+}
+
+// UploadBandwidthStatus returns the current upload limit and measured throughput
+func UploadBandwidthStatus() map[string]interface{} {
+	return uploadLimiter.Status()
+}
+
+// SetSchedule replaces the schedule the rate follows
+func (l *BandwidthLimiter) SetSchedule(schedule *config.UploadBandwidthConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.schedule = schedule
+}
+
+// bytesPerSecondLocked returns the current rate, 0 meaning unlimited. l.mu must be held.
+func (l *BandwidthLimiter) bytesPerSecondLocked(now time.Time) float64 {
+	if l.schedule == nil {
+		return 0
+	}
+	return l.schedule.LimitAt(now) * 1000 * 1000 / 8
+}
+
+// WaitN blocks until n bytes may be sent, then records them as sent
+func (l *BandwidthLimiter) WaitN(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	rate := l.bytesPerSecondLocked(now)
+	var wait time.Duration
+	if rate > 0 {
+		// Refill the bucket, allowing bursts of up to one second of traffic
+		if !l.last.IsZero() {
+			l.tokens += now.Sub(l.last).Seconds() * rate
+		}
+		if l.tokens > rate {
+			l.tokens = rate
+		}
+		l.tokens -= float64(n)
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / rate * float64(time.Second))
+		}
+	} else {
+		l.tokens = 0
+	}
+	l.last = now
+	l.recordLocked(now.Add(wait), n)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// recordLocked adds n bytes sent at t to the throughput buckets. l.mu must be held.
+func (l *BandwidthLimiter) recordLocked(t time.Time, n int) {
+	second := t.Unix()
+	i := second % throughputWindow
+	if l.bucketSecs[i] != second {
+		l.bucketSecs[i] = second
+		l.buckets[i] = 0
+	}
+	l.buckets[i] += int64(n)
+}
+
+// Throughput returns the bytes per second sent over the last throughputWindow seconds
+func (l *BandwidthLimiter) Throughput() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().Unix()
+	var total int64
+	for i := range l.buckets {
+		// The current second is still filling up, so average over the complete ones
+		if l.bucketSecs[i] < now && l.bucketSecs[i] >= now-throughputWindow {
+			total += l.buckets[i]
+		}
+	}
+	return float64(total) / throughputWindow
+}
+
+// Status returns the current limit and measured throughput
+func (l *BandwidthLimiter) Status() map[string]interface{} {
+	throughput := l.Throughput()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limitMbps := 0.0
+	enabled := false
+	if l.schedule != nil {
+		enabled = l.schedule.Enabled
+		limitMbps = l.schedule.LimitAt(time.Now())
+	}
+	return map[string]interface{}{
+		"enabled":         enabled,
+		"limit_mbps":      limitMbps, // 0 = unlimited
+		"throughput_mbps": throughput * 8 / 1000 / 1000,
+		"throughput_bps":  throughput * 8,
+	}
+}
+
+// limitedReader throttles reads through a BandwidthLimiter
+type limitedReader struct {
+	io.ReadCloser
+	limiter *BandwidthLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > limitedReadSize {
+		p = p[:limitedReadSize]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.limiter.WaitN(n)
+	}
+	return n, err
+}
+
+// throttledTransport sends request bodies through a BandwidthLimiter
+type throttledTransport struct {
+	base    http.RoundTripper
+	limiter *BandwidthLimiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+
+	limited := req.Clone(req.Context())
+	limited.Body = &limitedReader{ReadCloser: req.Body, limiter: t.limiter}
+	return t.base.RoundTrip(limited)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"ayo-mwr/config"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	schedule := &config.UploadBandwidthConfig{
+		Enabled:     true,
+		DefaultMbps: 80,
+		Schedule: []config.UploadBandwidthSchedule{
+			{Start: "17:00", End: "23:00", Mbps: 2},
+			{Start: "23:00", End: "06:00", Mbps: 0},
+		},
+	}
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)
+	for clock, want := range map[string]float64{"18:30": 2, "23:30": 0, "03:00": 0, "12:00": 80} {
+		at, _ := time.Parse("15:04", clock)
+		if got := schedule.LimitAt(day.Add(time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute)); got != want {
+			t.Errorf("LimitAt(%s) = %v, want %v", clock, got, want)
+		}
+	}
+
+	// Only the default window applies all day: 80 Mbps = 10 MB/s
+	limiter := &BandwidthLimiter{}
+	limiter.SetSchedule(&config.UploadBandwidthConfig{Enabled: true, DefaultMbps: 80})
+	body := &limitedReader{ReadCloser: io.NopCloser(bytes.NewReader(make([]byte, 2*1000*1000))), limiter: limiter}
+
+	start := time.Now()
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("2 MB at 10 MB/s took %v, expected about 200ms", elapsed)
+	}
+}
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+)
+
+const (
+	// forecastLookbackDays is how many recent days of recordings the growth rates are measured over
+	forecastLookbackDays = 7
+	// defaultRetentionDays is how long recordings are kept when neither the camera nor the system
+	// config sets auto_delete, matching the config default
+	defaultRetentionDays = 30
+	// minObservedDays keeps a camera that just started recording from being extrapolated from a
+	// few segments
+	minObservedDays = 1.0 / 24
+	bytesPerGB      = 1024 * 1024 * 1024
+)
+
+// forecastBitrateReductions are the lower bitrates, in percent below the current one, the
+// forecast estimates the possible retention for. 0 is the current bitrate.
+var forecastBitrateReductions = []int{0, 25, 50}
+
+// CameraGrowth is how fast a camera fills disk space and how long its recordings are kept
+type CameraGrowth struct {
+	CameraName    string  `json:"cameraName"`
+	RetentionDays int     `json:"retentionDays"`
+	GBPerDay      float64 `json:"gbPerDay"`      // Segments and chunks recorded per day
+	ChunkGBPerDay float64 `json:"chunkGbPerDay"` // Part of GBPerDay that is chunks, kept for the chunk retention
+	ObservedDays  float64 `json:"observedDays"`  // Span the rate was measured over
+}
+
+// DiskCameraUsage is the share of one camera in the recordings on a disk
+type DiskCameraUsage struct {
+	CameraName string  `json:"cameraName"`
+	RetainedGB float64 `json:"retainedGb"` // Recordings on the disk still within retention
+	GBPerDay   float64 `json:"gbPerDay"`   // Recorded to this disk per day over the lookback
+}
+
+// RetentionScenario estimates how many days every camera's retention could be extended by (or
+// must be shortened by, when negative) if cameras recorded at a lower bitrate
+type RetentionScenario struct {
+	BitrateReductionPercent int     `json:"bitrateReductionPercent"`
+	ExtraRetentionDays      float64 `json:"extraRetentionDays"`
+}
+
+// DiskForecast forecasts when a disk reaches the minimum free space threshold
+type DiskForecast struct {
+	DiskID        string  `json:"diskId"`
+	Path          string  `json:"path"`
+	IsActive      bool    `json:"isActive"`
+	AvailableGB   int64   `json:"availableGb"`
+	ThresholdGB   int64   `json:"thresholdGb"`
+	RetainedGB    float64 `json:"retainedGb"`    // Recordings on the disk still within retention
+	GBPerDay      float64 `json:"gbPerDay"`      // Expected growth; only the active disk receives new recordings
+	SteadyStateGB float64 `json:"steadyStateGb"` // Recordings kept once retention deletes as fast as cameras record
+	// DaysUntilThreshold is nil when the disk does not grow or retention deletes recordings
+	// before it reaches the threshold
+	DaysUntilThreshold *float64            `json:"daysUntilThreshold"`
+	Cameras            []DiskCameraUsage   `json:"cameras"`
+	RetentionScenarios []RetentionScenario `json:"retentionScenarios,omitempty"`
+}
+
+// CapacityForecast is the disk capacity forecast of every disk and the growth of every camera
+type CapacityForecast struct {
+	GeneratedAt  time.Time      `json:"generatedAt"`
+	LookbackDays int            `json:"lookbackDays"`
+	Cameras      []CameraGrowth `json:"cameras"`
+	Disks        []DiskForecast `json:"disks"`
+}
+
+// ForecastDiskCapacity measures how fast each camera fills disk space from the sizes of its
+// recent recording segments and forecasts how many days each disk has until it reaches the
+// minimum free space threshold under the current retention
+func (dm *DiskManager) ForecastDiskCapacity() (*CapacityForecast, error) {
+	disks, err := dm.db.GetStorageDisks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage disks: %v", err)
+	}
+
+	minimumFreeSpaceGB, _, _, _, _, _, err := dm.configService.GetDiskManagerConfig()
+	if err != nil {
+		minimumFreeSpaceGB = MinimumFreeSpaceGB
+	}
+
+	defaultRetention := defaultRetentionDays
+	if cfg, err := dm.configService.GetConfig(database.ConfigAutoDelete); err == nil && cfg != nil {
+		if val, parseErr := strconv.Atoi(cfg.Value); parseErr == nil && val > 0 {
+			defaultRetention = val
+		}
+	}
+	retention := make(map[string]int)
+	if cameras, err := dm.db.GetCameras(); err == nil {
+		for _, camera := range cameras {
+			if camera.AutoDelete > 0 {
+				retention[camera.Name] = camera.AutoDelete
+			}
+		}
+	}
+	chunkConfig, _ := config.NewChunkConfigService(dm.db).GetChunkConfig()
+
+	// Live free space is more current than the last scan
+	for i, disk := range disks {
+		if _, availableGB, err := dm.getDiskSpace(disk.Path); err == nil {
+			disks[i].AvailableSpaceGB = availableGB
+		}
+	}
+
+	// Recordings are needed back to the longest retention to know what is still kept
+	maxAgeDays := forecastLookbackDays
+	retentions := []int{defaultRetention, chunkConfig.RetentionDays}
+	for _, days := range retention {
+		retentions = append(retentions, days)
+	}
+	for _, days := range retentions {
+		if days > maxAgeDays {
+			maxAgeDays = days
+		}
+	}
+
+	now := time.Now()
+	usage, err := dm.db.GetRecordingUsage(now, maxAgeDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recording usage: %v", err)
+	}
+
+	forecast := forecastCapacity(disks, usage, retention, defaultRetention, chunkConfig.RetentionDays, int64(minimumFreeSpaceGB))
+	forecast.GeneratedAt = now
+	return forecast, nil
+}
+
+// forecastCapacity computes the forecast from the recording usage of the last days. Segments
+// are kept for the retention of their camera (defaultRetention if unset), chunks for
+// chunkRetention days.
+func forecastCapacity(disks []database.StorageDisk, usage []database.RecordingUsage, retention map[string]int, defaultRetention, chunkRetention int, thresholdGB int64) *CapacityForecast {
+	retentionOf := func(cameraName string, chunkType database.ChunkType) int {
+		if chunkType == database.ChunkTypeChunk {
+			return chunkRetention
+		}
+		if days, ok := retention[cameraName]; ok {
+			return days
+		}
+		return defaultRetention
+	}
+
+	// Growth per camera over the lookback, across disks since recording moves with the active disk
+	type cameraTotals struct {
+		bytes, chunkBytes int64
+		observedDays      float64
+	}
+	cameras := make(map[string]*cameraTotals)
+	for _, u := range usage {
+		if u.AgeDays >= forecastLookbackDays {
+			continue
+		}
+		totals := cameras[u.CameraName]
+		if totals == nil {
+			totals = &cameraTotals{}
+			cameras[u.CameraName] = totals
+		}
+		totals.bytes += u.Bytes
+		if u.ChunkType == database.ChunkTypeChunk {
+			totals.chunkBytes += u.Bytes
+		}
+		totals.observedDays = math.Max(totals.observedDays, math.Min(u.OldestAgeDays, forecastLookbackDays))
+	}
+
+	forecast := &CapacityForecast{LookbackDays: forecastLookbackDays, Cameras: []CameraGrowth{}, Disks: []DiskForecast{}}
+	var gbPerDay, segmentGBPerDay, steadyStateGB float64
+	growth := make(map[string]CameraGrowth)
+	for name, totals := range cameras {
+		observedDays := math.Max(totals.observedDays, minObservedDays)
+		camera := CameraGrowth{
+			CameraName:    name,
+			RetentionDays: retentionOf(name, database.ChunkTypeSegment),
+			GBPerDay:      float64(totals.bytes) / bytesPerGB / observedDays,
+			ChunkGBPerDay: float64(totals.chunkBytes) / bytesPerGB / observedDays,
+			ObservedDays:  observedDays,
+		}
+		growth[name] = camera
+
+		segmentRate := camera.GBPerDay - camera.ChunkGBPerDay
+		gbPerDay += camera.GBPerDay
+		segmentGBPerDay += segmentRate
+		steadyStateGB += segmentRate*float64(camera.RetentionDays) + camera.ChunkGBPerDay*float64(chunkRetention)
+	}
+
+	for _, disk := range disks {
+		diskForecast := DiskForecast{
+			DiskID:      disk.ID,
+			Path:        disk.Path,
+			IsActive:    disk.IsActive,
+			AvailableGB: disk.AvailableSpaceGB,
+			ThresholdGB: thresholdGB,
+			Cameras:     []DiskCameraUsage{},
+		}
+
+		perCamera := make(map[string]*DiskCameraUsage)
+		for _, u := range usage {
+			if u.StorageDiskID != disk.ID {
+				continue
+			}
+			cameraUsage := perCamera[u.CameraName]
+			if cameraUsage == nil {
+				cameraUsage = &DiskCameraUsage{CameraName: u.CameraName}
+				perCamera[u.CameraName] = cameraUsage
+			}
+			gb := float64(u.Bytes) / bytesPerGB
+			if u.AgeDays < retentionOf(u.CameraName, u.ChunkType) {
+				cameraUsage.RetainedGB += gb
+			}
+			if u.AgeDays < forecastLookbackDays {
+				cameraUsage.GBPerDay += gb / math.Max(growth[u.CameraName].ObservedDays, minObservedDays)
+			}
+		}
+		for _, cameraUsage := range perCamera {
+			diskForecast.RetainedGB += cameraUsage.RetainedGB
+			cameraUsage.RetainedGB = round(cameraUsage.RetainedGB, 2)
+			cameraUsage.GBPerDay = round(cameraUsage.GBPerDay, 2)
+			diskForecast.Cameras = append(diskForecast.Cameras, *cameraUsage)
+		}
+		sort.Slice(diskForecast.Cameras, func(i, j int) bool {
+			return diskForecast.Cameras[i].CameraName < diskForecast.Cameras[j].CameraName
+		})
+
+		if disk.IsActive {
+			diskForecast.GBPerDay = gbPerDay
+			diskForecast.SteadyStateGB = steadyStateGB
+			diskForecast.DaysUntilThreshold = daysUntilThreshold(diskForecast)
+			diskForecast.RetentionScenarios = retentionScenarios(diskForecast, segmentGBPerDay)
+		}
+
+		diskForecast.RetainedGB = round(diskForecast.RetainedGB, 2)
+		diskForecast.GBPerDay = round(diskForecast.GBPerDay, 2)
+		diskForecast.SteadyStateGB = round(diskForecast.SteadyStateGB, 2)
+		forecast.Disks = append(forecast.Disks, diskForecast)
+	}
+
+	for _, camera := range growth {
+		camera.GBPerDay = round(camera.GBPerDay, 2)
+		camera.ChunkGBPerDay = round(camera.ChunkGBPerDay, 2)
+		camera.ObservedDays = round(camera.ObservedDays, 1)
+		forecast.Cameras = append(forecast.Cameras, camera)
+	}
+	sort.Slice(forecast.Cameras, func(i, j int) bool {
+		return forecast.Cameras[i].CameraName < forecast.Cameras[j].CameraName
+	})
+	return forecast
+}
+
+// daysUntilThreshold returns the days until the free space of a disk falls to the threshold
+// at its growth rate, or nil if it doesn't grow or retention levels it off before
+func daysUntilThreshold(disk DiskForecast) *float64 {
+	headroomGB := float64(disk.AvailableGB - disk.ThresholdGB)
+	if headroomGB <= 0 {
+		days := 0.0
+		return &days
+	}
+	if disk.GBPerDay <= 0 || disk.SteadyStateGB-disk.RetainedGB <= headroomGB {
+		return nil
+	}
+	days := round(headroomGB/disk.GBPerDay, 1)
+	return &days
+}
+
+// retentionScenarios estimates, for each bitrate reduction, how many days the retention of every
+// camera could grow by until its recordings fill the disk down to the threshold
+func retentionScenarios(disk DiskForecast, segmentGBPerDay float64) []RetentionScenario {
+	if segmentGBPerDay <= 0 {
+		return nil
+	}
+
+	// Space recordings may occupy: what they use now plus the free space above the threshold
+	capacityGB := disk.RetainedGB + float64(disk.AvailableGB-disk.ThresholdGB)
+
+	var scenarios []RetentionScenario
+	for _, reduction := range forecastBitrateReductions {
+		factor := 1 - float64(reduction)/100
+		scenarios = append(scenarios, RetentionScenario{
+			BitrateReductionPercent: reduction,
+			ExtraRetentionDays:      round((capacityGB-factor*disk.SteadyStateGB)/(factor*segmentGBPerDay), 1),
+		})
+	}
+	return scenarios
+}
+
+// round rounds v to the given number of decimal places
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"testing"
+
+	"ayo-mwr/database"
+)
+
+// dailyUsage returns usage of cameraName recording gbPerDay to diskID for each of the last days
+func dailyUsage(cameraName, diskID string, gbPerDay float64, days int) []database.RecordingUsage {
+	var usage []database.RecordingUsage
+	for age := 0; age < days; age++ {
+		usage = append(usage, database.RecordingUsage{
+			CameraName:    cameraName,
+			StorageDiskID: diskID,
+			ChunkType:     database.ChunkTypeSegment,
+			AgeDays:       age,
+			OldestAgeDays: float64(age + 1),
+			Bytes:         int64(gbPerDay * bytesPerGB),
+		})
+	}
+	return usage
+}
+
+func TestForecastCapacity(t *testing.T) {
+	disks := []database.StorageDisk{
+		{ID: "active", Path: "/mnt/a", IsActive: true, AvailableSpaceGB: 200},
+		{ID: "old", Path: "/mnt/b", AvailableSpaceGB: 50},
+	}
+	// Two cameras at 10 GB/day each for 10 days, kept 30 days: the disk needs 600 GB once
+	// retention levels off, holds 200 GB and has 100 GB left above the threshold
+	usage := append(dailyUsage("camera_1", "active", 10, 10), dailyUsage("camera_2", "active", 10, 10)...)
+
+	forecast := forecastCapacity(disks, usage, map[string]int{"camera_1": 30}, 30, 7, 100)
+
+	if len(forecast.Cameras) != 2 || forecast.Cameras[0].GBPerDay != 10 {
+		t.Fatalf("expected 2 cameras at 10 GB/day, got %+v", forecast.Cameras)
+	}
+
+	active := forecast.Disks[0]
+	if active.GBPerDay != 20 || active.RetainedGB != 200 || active.SteadyStateGB != 600 {
+		t.Errorf("unexpected growth of the active disk: %+v", active)
+	}
+	if active.DaysUntilThreshold == nil || *active.DaysUntilThreshold != 5 {
+		t.Errorf("expected the active disk to reach the threshold in 5 days, got %v", active.DaysUntilThreshold)
+	}
+
+	// 300 GB for recordings: 15 days at 20 GB/day, 20 days at 15, 30 days at 10
+	expected := map[int]float64{0: -15, 25: -10, 50: 0}
+	for _, scenario := range active.RetentionScenarios {
+		if scenario.ExtraRetentionDays != expected[scenario.BitrateReductionPercent] {
+			t.Errorf("at %d%% lower bitrate expected %.1f extra days, got %.1f",
+				scenario.BitrateReductionPercent, expected[scenario.BitrateReductionPercent], scenario.ExtraRetentionDays)
+		}
+	}
+
+	if old := forecast.Disks[1]; old.GBPerDay != 0 || old.DaysUntilThreshold != nil || old.RetentionScenarios != nil {
+		t.Errorf("a disk not recorded to must not grow: %+v", old)
+	}
+}
+
+func TestForecastCapacityLevelsOffWithinRetention(t *testing.T) {
+	disks := []database.StorageDisk{{ID: "active", Path: "/mnt/a", IsActive: true, AvailableSpaceGB: 500}}
+	// 5 GB/day kept 7 days needs 35 GB; 7 days are already on disk
+	usage := dailyUsage("camera_1", "active", 5, 10)
+
+	forecast := forecastCapacity(disks, usage, map[string]int{"camera_1": 7}, 30, 7, 100)
+
+	active := forecast.Disks[0]
+	if active.RetainedGB != 35 {
+		t.Errorf("expected only recordings within retention to count, got %.2f GB", active.RetainedGB)
+	}
+	if active.DaysUntilThreshold != nil {
+		t.Errorf("expected retention to keep the disk above the threshold, got %.1f days", *active.DaysUntilThreshold)
+	}
+}
@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"ayo-mwr/database"
+)
+
+const (
+	// DiskProbeInterval is how often every registered disk is probed
+	DiskProbeInterval = time.Minute
+	// diskFailoverCooldown keeps a burst of FFmpeg errors from switching disks repeatedly
+	diskFailoverCooldown = 2 * time.Minute
+	// diskProbeFile is written and read back to probe a disk
+	diskProbeFile = ".ayo-disk-probe"
+	// statfsReadOnly is the read-only mount flag in Statfs_t.Flags (ST_RDONLY / MNT_RDONLY)
+	statfsReadOnly = 0x1
+)
+
+// diskIOErrorPatterns are FFmpeg and OS messages caused by the recording disk rather than the camera
+var diskIOErrorPatterns = []string{
+	"Read-only file system",
+	"Input/output error",
+	"No space left on device",
+	"Transport endpoint is not connected",
+	"Stale file handle",
+}
+
+// IsDiskIOError reports whether a message, e.g. a line of FFmpeg output, is a disk failure
+func IsDiskIOError(message string) bool {
+	for _, pattern := range diskIOErrorPatterns {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiskHealth is the result of the last probe of a disk
+type DiskHealth struct {
+	DiskID    string    `json:"diskId"`
+	Path      string    `json:"path"`
+	Healthy   bool      `json:"healthy"`
+	Problem   string    `json:"problem,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// probeDisk writes a probe file to the disk at path, reads it back and removes it. It also
+// reports whether path is on the root filesystem's device, which is where the path of an
+// unplugged disk ends up.
+func probeDisk(path string) (bool, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return false, fmt.Errorf("disk not accessible: %v", err)
+	}
+	if uint64(stat.Flags)&statfsReadOnly != 0 {
+		return false, fmt.Errorf("filesystem is mounted read-only")
+	}
+
+	onRoot := false
+	var pathStat, rootStat syscall.Stat_t
+	if syscall.Stat(path, &pathStat) == nil && syscall.Stat("/", &rootStat) == nil {
+		onRoot = uint64(pathStat.Dev) == uint64(rootStat.Dev)
+	}
+
+	data := make([]byte, 4096)
+	if _, err := rand.Read(data); err != nil {
+		return onRoot, fmt.Errorf("failed to generate probe data: %v", err)
+	}
+
+	probePath := filepath.Join(path, diskProbeFile)
+	defer os.Remove(probePath)
+
+	file, err := os.OpenFile(probePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return onRoot, fmt.Errorf("write probe failed: %v", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return onRoot, fmt.Errorf("write probe failed: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return onRoot, fmt.Errorf("write probe failed to sync: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return onRoot, fmt.Errorf("write probe failed: %v", err)
+	}
+
+	readBack, err := os.ReadFile(probePath)
+	if err != nil {
+		return onRoot, fmt.Errorf("read probe failed: %v", err)
+	}
+	if !bytes.Equal(readBack, data) {
+		return onRoot, fmt.Errorf("read probe returned different data than written")
+	}
+	return onRoot, nil
+}
+
+// ProbeDisks probes every registered disk, records health changes as disk events and switches
+// recording to the next healthy disk when the active disk fails
+func (dm *DiskManager) ProbeDisks() []DiskHealth {
+	disks, err := dm.db.GetStorageDisks()
+	if err != nil {
+		log.Printf("[DiskManager] Failed to get storage disks for probing: %v", err)
+		return nil
+	}
+
+	var results []DiskHealth
+	for _, disk := range disks {
+		problem := dm.probe(disk)
+		dm.recordDiskHealth(disk, problem)
+		if problem != "" && disk.IsActive {
+			dm.failover(disk, problem)
+		}
+		results = append(results, dm.diskHealth(disk.ID))
+	}
+	return results
+}
+
+// probe probes a disk and returns what is wrong with it, or "" if it is healthy
+func (dm *DiskManager) probe(disk database.StorageDisk) string {
+	onRoot, err := probeDisk(disk.Path)
+
+	dm.healthMu.Lock()
+	defer dm.healthMu.Unlock()
+	if onRoot && dm.mountedDisks[disk.ID] {
+		return "disk is no longer mounted, its path is on the root filesystem"
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if !onRoot {
+		dm.mountedDisks[disk.ID] = true
+	}
+	return ""
+}
+
+// recordDiskHealth stores the probe result of a disk. A disk becoming unhealthy records an
+// event and raises an alert; a disk passing its probe again records a recovery.
+func (dm *DiskManager) recordDiskHealth(disk database.StorageDisk, problem string) {
+	dm.healthMu.Lock()
+	previous, known := dm.health[disk.ID]
+	dm.health[disk.ID] = DiskHealth{
+		DiskID:    disk.ID,
+		Path:      disk.Path,
+		Healthy:   problem == "",
+		Problem:   problem,
+		CheckedAt: time.Now(),
+	}
+	dm.healthMu.Unlock()
+
+	switch {
+	case problem != "" && (!known || previous.Healthy):
+		log.Printf("[DiskManager] 🚨 DISK ALERT: disk %s (%s) is unhealthy: %s", disk.ID, disk.Path, problem)
+		dm.recordDiskEvent(database.DiskEvent{
+			DiskID:    disk.ID,
+			EventType: database.DiskEventUnhealthy,
+			Message:   fmt.Sprintf("Disk %s is unhealthy: %s", disk.Path, problem),
+			Alert:     true,
+		})
+	case problem == "" && known && !previous.Healthy:
+		log.Printf("[DiskManager] ✅ Disk %s (%s) is healthy again", disk.ID, disk.Path)
+		dm.recordDiskEvent(database.DiskEvent{
+			DiskID:    disk.ID,
+			EventType: database.DiskEventRecovered,
+			Message:   fmt.Sprintf("Disk %s passes its probe again (was: %s)", disk.Path, previous.Problem),
+		})
+	}
+}
+
+// ReportDiskIOError handles a disk failure seen outside the probes, e.g. in FFmpeg output while
+// recording to path. The disk holding path is marked unhealthy and, if recording to it, replaced.
+func (dm *DiskManager) ReportDiskIOError(path, message string) {
+	disks, err := dm.db.GetStorageDisks()
+	if err != nil {
+		log.Printf("[DiskManager] Failed to get storage disks for I/O error on %s: %v", path, err)
+		return
+	}
+
+	var failing *database.StorageDisk
+	for i, disk := range disks {
+		if pathOnDisk(path, disk.Path) && (failing == nil || len(disk.Path) > len(failing.Path)) {
+			failing = &disks[i]
+		}
+	}
+	if failing == nil {
+		return
+	}
+
+	problem := fmt.Sprintf("I/O error while recording: %s", strings.TrimSpace(message))
+	dm.recordDiskHealth(*failing, problem)
+	if failing.IsActive {
+		dm.failover(*failing, problem)
+	}
+}
+
+// pathOnDisk reports whether path is diskPath or inside it
+func pathOnDisk(path, diskPath string) bool {
+	rel, err := filepath.Rel(diskPath, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// failover switches recording from the failed active disk to the healthy disk with the highest
+// priority and enough free space, recording the switch as an event and raising an alert
+func (dm *DiskManager) failover(failed database.StorageDisk, reason string) {
+	dm.failoverMu.Lock()
+	defer dm.failoverMu.Unlock()
+
+	if active, err := dm.db.GetActiveDisk(); err != nil || active == nil || active.ID != failed.ID {
+		return // Already switched away
+	}
+	if time.Since(dm.lastFailover) < diskFailoverCooldown {
+		return
+	}
+	dm.lastFailover = time.Now()
+
+	minimumFreeSpaceGB, _, _, _, _, _, err := dm.configService.GetDiskManagerConfig()
+	if err != nil {
+		minimumFreeSpaceGB = MinimumFreeSpaceGB
+	}
+
+	disks, err := dm.db.GetStorageDisks()
+	if err != nil {
+		log.Printf("[DiskManager] Failed to get storage disks for failover: %v", err)
+		return
+	}
+
+	var next *database.StorageDisk
+	for i, disk := range disks {
+		if disk.ID == failed.ID || disk.AvailableSpaceGB < int64(minimumFreeSpaceGB) {
+			continue
+		}
+		problem := dm.probe(disk)
+		dm.recordDiskHealth(disk, problem)
+		if problem == "" {
+			next = &disks[i]
+			break
+		}
+	}
+
+	if next == nil {
+		log.Printf("[DiskManager] 🚨 DISK ALERT: active disk %s (%s) failed and no healthy disk is available", failed.ID, failed.Path)
+		dm.recordDiskEvent(database.DiskEvent{
+			DiskID:    failed.ID,
+			EventType: database.DiskEventFailoverFailed,
+			Message:   fmt.Sprintf("Active disk %s failed (%s) and no healthy disk with %dGB free is available", failed.Path, reason, minimumFreeSpaceGB),
+			Alert:     true,
+		})
+		return
+	}
+
+	log.Printf("[DiskManager] 🚨 DISK ALERT: active disk %s (%s) failed, switching recording to %s (%s)", failed.ID, failed.Path, next.ID, next.Path)
+	message := fmt.Sprintf("Recording switched from %s to %s: %s", failed.Path, next.Path, reason)
+	if err := dm.SetActiveDiskAndRestartRecordings(next.ID); err != nil {
+		message = fmt.Sprintf("%s (restart failed: %v)", message, err)
+		log.Printf("[DiskManager] ❌ Failover to %s failed: %v", next.ID, err)
+	}
+	dm.recordDiskEvent(database.DiskEvent{
+		DiskID:    failed.ID,
+		EventType: database.DiskEventFailover,
+		Message:   message,
+		Alert:     true,
+	})
+}
+
+// recordDiskEvent stores a disk event
+func (dm *DiskManager) recordDiskEvent(event database.DiskEvent) {
+	event.CreatedAt = time.Now()
+	if err := dm.db.CreateDiskEvent(event); err != nil {
+		log.Printf("[DiskManager] Failed to record disk event %s for %s: %v", event.EventType, event.DiskID, err)
+	}
+}
+
+// diskHealth returns the last probe result of a disk
+func (dm *DiskManager) diskHealth(diskID string) DiskHealth {
+	dm.healthMu.Lock()
+	defer dm.healthMu.Unlock()
+	return dm.health[diskID]
+}
+
+// isDiskUnhealthy reports whether the last probe of a disk failed
+func (dm *DiskManager) isDiskUnhealthy(diskID string) bool {
+	health := dm.diskHealth(diskID)
+	return health.DiskID != "" && !health.Healthy
+}
+
+// DiskHealthStatus returns the last probe result of every probed disk
+func (dm *DiskManager) DiskHealthStatus() []DiskHealth {
+	dm.healthMu.Lock()
+	defer dm.healthMu.Unlock()
+
+	results := make([]DiskHealth, 0, len(dm.health))
+	for _, health := range dm.health {
+		results = append(results, health)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results
+}
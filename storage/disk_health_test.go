@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsDiskIOError(t *testing.T) {
+	cases := map[string]bool{
+		"[segment @ 0x55] Failed to open segment 'seg_001.ts': Read-only file system": true,
+		"av_interleaved_write_frame(): Input/output error":                            true,
+		"Error writing trailer: No space left on device":                              true,
+		"[rtsp @ 0x55] method DESCRIBE failed: 401 Unauthorized":                      false,
+		"Connection refused": false,
+	}
+	for message, expected := range cases {
+		if IsDiskIOError(message) != expected {
+			t.Errorf("IsDiskIOError(%q) = %v, expected %v", message, !expected, expected)
+		}
+	}
+}
+
+func TestPathOnDisk(t *testing.T) {
+	if !pathOnDisk("/mnt/disk1/recordings/camera_1/hls", "/mnt/disk1") {
+		t.Error("expected a recording directory to be on its disk")
+	}
+	if !pathOnDisk("/mnt/disk1", "/mnt/disk1") {
+		t.Error("expected the disk path to be on the disk")
+	}
+	if pathOnDisk("/mnt/disk10/recordings", "/mnt/disk1") {
+		t.Error("a sibling path sharing a prefix must not be on the disk")
+	}
+}
+
+func TestProbeDisk(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := probeDisk(dir); err != nil {
+		t.Fatalf("probe of a writable directory failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, diskProbeFile)); !os.IsNotExist(err) {
+		t.Error("probe file must be removed after probing")
+	}
+
+	if _, err := probeDisk(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected probe of a missing directory to fail")
+	}
+}
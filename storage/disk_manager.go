@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -46,6 +47,13 @@ type DiskManager struct {
 	db               database.Database
 	configService    *config.SystemConfigService
 	recordingManager RecordingManager // Embedded dependency, not global
+
+	// Disk health probing and failover (see disk_health.go)
+	healthMu     sync.Mutex
+	health       map[string]DiskHealth // Last probe result by disk ID
+	mountedDisks map[string]bool       // Disks seen on their own device, so falling back to root means unplugged
+	failoverMu   sync.Mutex
+	lastFailover time.Time
 }
 
 // NewDiskManager creates a new disk manager instance
@@ -53,6 +61,8 @@ func NewDiskManager(db database.Database) *DiskManager {
 	return &DiskManager{
 		db:            db,
 		configService: config.NewSystemConfigService(db),
+		health:        make(map[string]DiskHealth),
+		mountedDisks:  make(map[string]bool),
 		// recordingManager will be set later via SetRecordingManager
 	}
 }
@@ -117,9 +127,13 @@ func (dm *DiskManager) SelectActiveDisk() error {
 		return fmt.Errorf("failed to get storage disks: %v", err)
 	}
 
-	// Find the first disk with sufficient free space (sorted by priority)
+	// Find the first healthy disk with sufficient free space (sorted by priority)
 	var selectedDisk *database.StorageDisk
 	for _, disk := range disks {
+		if dm.isDiskUnhealthy(disk.ID) {
+			log.Printf("Skipping unhealthy disk %s (%s): %s", disk.ID, disk.Path, dm.diskHealth(disk.ID).Problem)
+			continue
+		}
 		if disk.AvailableSpaceGB >= int64(minimumFreeSpaceGB) {
 			selectedDisk = &disk
 			break
@@ -247,7 +261,8 @@ func (dm *DiskManager) GetRecordingPath(cameraName string) (string, string, erro
 	return recordingDir, activeDisk.ID, nil
 }
 
-// CheckDiskHealth verifies all disks are accessible and reports issues
+// CheckDiskHealth verifies all disks are accessible, writable and have enough free space, and
+// reports issues
 func (dm *DiskManager) CheckDiskHealth() error {
 	// Get minimum free space from configuration
 	minimumFreeSpaceGB, _, _, _, _, _, err := dm.configService.GetDiskManagerConfig()
@@ -270,6 +285,13 @@ func (dm *DiskManager) CheckDiskHealth() error {
 			continue
 		}
 
+		// Check the disk can still be written and read back (read-only remounts, I/O errors)
+		if problem := dm.probe(disk); problem != "" {
+			dm.recordDiskHealth(disk, problem)
+			issues = append(issues, fmt.Sprintf("Disk %s (%s) failed its probe: %s", disk.ID, disk.Path, problem))
+			continue
+		}
+
 		// Check if disk is nearly full
 		if disk.AvailableSpaceGB < int64(minimumFreeSpaceGB) {
 			issues = append(issues, fmt.Sprintf("Disk %s is nearly full: %dGB available (minimum %dGB)",
@@ -325,6 +347,18 @@ func (dm *DiskManager) GetDiskUsageStats() (map[string]interface{}, error) {
 		"disks":              []map[string]interface{}{},
 	}
 
+	// Forecast of when each disk reaches the minimum free space threshold
+	forecasts := make(map[string]DiskForecast)
+	forecast, err := dm.ForecastDiskCapacity()
+	if err != nil {
+		log.Printf("Warning: Failed to forecast disk capacity: %v", err)
+	} else {
+		for _, diskForecast := range forecast.Disks {
+			forecasts[diskForecast.DiskID] = diskForecast
+		}
+		stats["camera_growth"] = forecast.Cameras
+	}
+
 	var totalSpace, availableSpace int64
 
 	for _, disk := range disks {
@@ -345,6 +379,11 @@ func (dm *DiskManager) GetDiskUsageStats() (map[string]interface{}, error) {
 			"usage_percent":      float64(disk.TotalSpaceGB-disk.AvailableSpaceGB) / float64(disk.TotalSpaceGB) * 100,
 			"last_scan":          disk.LastScan,
 		}
+		if diskForecast, ok := forecasts[disk.ID]; ok {
+			diskStats["growth_gb_per_day"] = diskForecast.GBPerDay
+			diskStats["days_until_threshold"] = diskForecast.DaysUntilThreshold
+			diskStats["retention_scenarios"] = diskForecast.RetentionScenarios
+		}
 
 		stats["disks"] = append(stats["disks"].([]map[string]interface{}), diskStats)
 	}
@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ayo-mwr/metrics"
+)
+
+// localUploadsDir holds in-progress multipart uploads inside the local storage root
+const localUploadsDir = ".uploads"
+
+// LocalStorage is the ObjectStore for a local disk or NAS mount. Objects are files under the
+// root directory, served by our own HTTP server under the base URL.
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStorage creates a LocalStorage rooted at root, served under baseURL
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("local storage requires a base URL")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create local storage directory %s: %v", root, err)
+	}
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// Root returns the directory objects are stored in
+func (l *LocalStorage) Root() string {
+	return l.root
+}
+
+// IsLocalUploadPath reports whether a path below the local storage root lies in the in-progress
+// multipart uploads, which are not objects and must not be served
+func IsLocalUploadPath(name string) bool {
+	cleaned := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	return cleaned == localUploadsDir || strings.HasPrefix(cleaned, localUploadsDir+"/")
+}
+
+// objectPath returns the file an object is stored in, rejecting keys that escape the root
+func (l *LocalStorage) objectPath(key string) (string, error) {
+	cleaned := filepath.Clean("/" + filepath.FromSlash(key))
+	if cleaned == string(filepath.Separator) || strings.HasPrefix(strings.TrimPrefix(cleaned, string(filepath.Separator)), localUploadsDir) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.root, cleaned), nil
+}
+
+// UploadFile copies a file into local storage
+func (l *LocalStorage) UploadFile(localPath, key string) (string, error) {
+	return l.UploadFileWithMetrics(localPath, key, nil)
+}
+
+// UploadFileWithMetrics copies a file into local storage with metrics tracking
+func (l *LocalStorage) UploadFileWithMetrics(localPath, key string, videoMetrics *metrics.VideoProcessingMetrics) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %v", localPath, err)
+	}
+	defer file.Close()
+
+	if videoMetrics != nil {
+		videoMetrics.StartUpload()
+		defer videoMetrics.EndUpload()
+	}
+
+	if err := l.writeObject(key, file); err != nil {
+		return "", err
+	}
+
+	publicURL := l.ObjectURL(key)
+	log.Printf("File stored locally, public URL: %s", publicURL)
+	return publicURL, nil
+}
+
+// writeObject writes an object through a temporary file so readers never see a partial object
+func (l *LocalStorage) writeObject(key string, body io.Reader) error {
+	path, err := l.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %v", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store %s: %v", key, err)
+	}
+	return nil
+}
+
+// uploadDir returns the directory the parts of a multipart upload are kept in
+func (l *LocalStorage) uploadDir(uploadID string) (string, error) {
+	if uploadID == "" || strings.ContainsAny(uploadID, `/\.`) {
+		return "", fmt.Errorf("invalid upload ID %q", uploadID)
+	}
+	return filepath.Join(l.root, localUploadsDir, uploadID), nil
+}
+
+// CreateMultipartUpload starts a multipart upload
+func (l *LocalStorage) CreateMultipartUpload(key, contentType string) (string, error) {
+	if _, err := l.objectPath(key); err != nil {
+		return "", err
+	}
+
+	uploadID := fmt.Sprintf("%d", time.Now().UnixNano())
+	dir, _ := l.uploadDir(uploadID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create multipart upload: %v", err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart stores one part of a multipart upload
+func (l *LocalStorage) UploadPart(key, uploadID string, partNumber int, body io.ReadSeeker, size int64) (string, error) {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUploadNotFound, uploadID)
+	}
+
+	part, err := os.Create(filepath.Join(dir, fmt.Sprintf("%05d", partNumber)))
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	defer part.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(part, hash), io.LimitReader(body, size)); err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CompleteMultipartUpload concatenates the uploaded parts into the object
+func (l *LocalStorage) CompleteMultipartUpload(key, uploadID string, parts []CompletedPart) error {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("%w: %s", ErrUploadNotFound, uploadID)
+	}
+
+	sorted := append([]CompletedPart(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PartNumber < sorted[j].PartNumber })
+
+	readers := make([]io.Reader, 0, len(sorted))
+	for _, part := range sorted {
+		file, err := os.Open(filepath.Join(dir, fmt.Sprintf("%05d", part.PartNumber)))
+		if err != nil {
+			return fmt.Errorf("failed to complete multipart upload: missing part %d", part.PartNumber)
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+
+	if err := l.writeObject(key, io.MultiReader(readers...)); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return os.RemoveAll(dir)
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts
+func (l *LocalStorage) AbortMultipartUpload(key, uploadID string) error {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// ListObjects lists the stored objects whose key starts with prefix
+func (l *LocalStorage) ListObjects(prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.Walk(l.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if relPath == localUploadsDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".tmp-") {
+			return nil
+		}
+
+		key := filepath.ToSlash(relPath)
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, ObjectInfo{
+				Key:          key,
+				Size:         info.Size(),
+				LastModified: info.ModTime(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %v", err)
+	}
+	return objects, nil
+}
+
+// HeadObject returns the info of a stored object
+func (l *LocalStorage) HeadObject(key string) (*ObjectInfo, error) {
+	path, err := l.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %v", err)
+	}
+	return &ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()}, nil
+}
+
+// GetObject returns the content of a stored object
+func (l *LocalStorage) GetObject(key string) ([]byte, error) {
+	path, err := l.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %v", err)
+	}
+	return data, nil
+}
+
+// DownloadFile copies a stored object to localPath
+func (l *LocalStorage) DownloadFile(key, localPath string) error {
+	path, err := l.objectPath(key)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return ErrObjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open object: %v", err)
+	}
+	defer file.Close()
+
+	return writeDownloadedFile(localPath, file)
+}
+
+// DeleteObject deletes a stored object; deleting a missing object is not an error
+func (l *LocalStorage) DeleteObject(key string) error {
+	path, err := l.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete object: %v", err)
+	}
+	return nil
+}
+
+// GetBaseURL returns the URL local objects are served under
+func (l *LocalStorage) GetBaseURL() string {
+	return l.baseURL
+}
+
+// ObjectURL returns the public URL of a stored object
+func (l *LocalStorage) ObjectURL(key string) string {
+	return fmt.Sprintf("%s/%s", l.baseURL, key)
+}
+
+// PresignGetURL returns the public URL of a stored object; local objects are served to the LAN
+// without credentials
+func (l *LocalStorage) PresignGetURL(key string, ttl time.Duration) (string, error) {
+	return l.ObjectURL(key), nil
+}
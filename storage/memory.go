@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ayo-mwr/metrics"
+)
+
+// MemoryStorage is an in-memory ObjectStore for tests
+type MemoryStorage struct {
+	mu      sync.Mutex
+	baseURL string
+	objects map[string]memoryObject
+	uploads map[string]map[int][]byte
+	nextID  int
+}
+
+type memoryObject struct {
+	data         []byte
+	lastModified time.Time
+}
+
+// NewMemoryStorage creates an empty MemoryStorage serving objects under baseURL
+func NewMemoryStorage(baseURL string) *MemoryStorage {
+	return &MemoryStorage{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		objects: make(map[string]memoryObject),
+		uploads: make(map[string]map[int][]byte),
+	}
+}
+
+// PutObject stores data under key
+func (m *MemoryStorage) PutObject(key string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{data: append([]byte(nil), data...), lastModified: time.Now()}
+}
+
+// GetObject returns the data stored under key
+func (m *MemoryStorage) GetObject(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	object, ok := m.objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return object.data, nil
+}
+
+// DownloadFile writes the data stored under key to localPath
+func (m *MemoryStorage) DownloadFile(key, localPath string) error {
+	data, err := m.GetObject(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(localPath, data, 0644)
+}
+
+// UploadFile stores the contents of a local file
+func (m *MemoryStorage) UploadFile(localPath, key string) (string, error) {
+	return m.UploadFileWithMetrics(localPath, key, nil)
+}
+
+// UploadFileWithMetrics stores the contents of a local file with metrics tracking
+func (m *MemoryStorage) UploadFileWithMetrics(localPath, key string, videoMetrics *metrics.VideoProcessingMetrics) (string, error) {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %v", localPath, err)
+	}
+
+	if videoMetrics != nil {
+		videoMetrics.StartUpload()
+		defer videoMetrics.EndUpload()
+	}
+
+	m.PutObject(key, data)
+	return m.ObjectURL(key), nil
+}
+
+// CreateMultipartUpload starts a multipart upload
+func (m *MemoryStorage) CreateMultipartUpload(key, contentType string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	uploadID := fmt.Sprintf("upload-%d", m.nextID)
+	m.uploads[uploadID] = make(map[int][]byte)
+	return uploadID, nil
+}
+
+// UploadPart stores one part of a multipart upload
+func (m *MemoryStorage) UploadPart(key, uploadID string, partNumber int, body io.ReadSeeker, size int64) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(body, size))
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parts, ok := m.uploads[uploadID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUploadNotFound, uploadID)
+	}
+	parts[partNumber] = data
+
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CompleteMultipartUpload concatenates the uploaded parts into the object
+func (m *MemoryStorage) CompleteMultipartUpload(key, uploadID string, parts []CompletedPart) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	uploaded, ok := m.uploads[uploadID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUploadNotFound, uploadID)
+	}
+
+	sorted := append([]CompletedPart(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PartNumber < sorted[j].PartNumber })
+
+	var data []byte
+	for _, part := range sorted {
+		partData, ok := uploaded[part.PartNumber]
+		if !ok {
+			return fmt.Errorf("failed to complete multipart upload: missing part %d", part.PartNumber)
+		}
+		data = append(data, partData...)
+	}
+
+	m.objects[key] = memoryObject{data: data, lastModified: time.Now()}
+	delete(m.uploads, uploadID)
+	return nil
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts
+func (m *MemoryStorage) AbortMultipartUpload(key, uploadID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.uploads, uploadID)
+	return nil
+}
+
+// ListObjects lists the stored objects whose key starts with prefix, sorted by key
+func (m *MemoryStorage) ListObjects(prefix string) ([]ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var objects []ObjectInfo
+	for key, object := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, m.infoLocked(key, object))
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// HeadObject returns the info of a stored object
+func (m *MemoryStorage) HeadObject(key string) (*ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	object, ok := m.objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	info := m.infoLocked(key, object)
+	return &info, nil
+}
+
+func (m *MemoryStorage) infoLocked(key string, object memoryObject) ObjectInfo {
+	sum := md5.Sum(object.data)
+	return ObjectInfo{
+		Key:          key,
+		Size:         int64(len(object.data)),
+		ETag:         hex.EncodeToString(sum[:]),
+		LastModified: object.lastModified,
+	}
+}
+
+// DeleteObject deletes a stored object
+func (m *MemoryStorage) DeleteObject(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// GetBaseURL returns the URL objects are served under
+func (m *MemoryStorage) GetBaseURL() string {
+	return m.baseURL
+}
+
+// ObjectURL returns the public URL of a stored object
+func (m *MemoryStorage) ObjectURL(key string) string {
+	return fmt.Sprintf("%s/%s", m.baseURL, key)
+}
+
+// PresignGetURL returns the URL of a stored object with its expiry, like a presigned URL
+func (m *MemoryStorage) PresignGetURL(key string, ttl time.Duration) (string, error) {
+	return fmt.Sprintf("%s?expires=%d", m.ObjectURL(key), time.Now().Add(ttl).Unix()), nil
+}
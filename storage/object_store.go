@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/database"
+	"ayo-mwr/metrics"
+)
+
+var (
+	// ErrObjectNotFound is returned by HeadObject for a key that does not exist
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrUploadNotFound is returned for a multipart upload the store no longer knows, e.g. after
+	// it expired; the upload has to start over
+	ErrUploadNotFound = errors.New("multipart upload not found")
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string // Backend checksum, empty if the backend has none
+	LastModified time.Time
+}
+
+// CompletedPart is an uploaded part of a multipart upload
+type CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// ObjectStore is the storage videos, previews and streams are delivered from.
+// Keys use forward slashes, e.g. mp4/<video id>.mp4.
+type ObjectStore interface {
+	// UploadFile uploads a local file and returns its public URL
+	UploadFile(localPath, key string) (string, error)
+	// UploadFileWithMetrics uploads a local file with metrics tracking and returns its public URL
+	UploadFileWithMetrics(localPath, key string, videoMetrics *metrics.VideoProcessingMetrics) (string, error)
+
+	// CreateMultipartUpload starts a multipart upload and returns its upload ID
+	CreateMultipartUpload(key, contentType string) (string, error)
+	// UploadPart uploads one part (numbered from 1) and returns its ETag
+	UploadPart(key, uploadID string, partNumber int, body io.ReadSeeker, size int64) (string, error)
+	// CompleteMultipartUpload assembles the uploaded parts into the object
+	CompleteMultipartUpload(key, uploadID string, parts []CompletedPart) error
+	// AbortMultipartUpload discards a multipart upload and its parts
+	AbortMultipartUpload(key, uploadID string) error
+
+	// ListObjects lists the objects whose key starts with prefix
+	ListObjects(prefix string) ([]ObjectInfo, error)
+	// HeadObject returns the object's info, or ErrObjectNotFound
+	HeadObject(key string) (*ObjectInfo, error)
+	// GetObject returns the content of a small object such as a playlist, or ErrObjectNotFound
+	GetObject(key string) ([]byte, error)
+	// DownloadFile writes the content of an object to a local file, or returns ErrObjectNotFound
+	DownloadFile(key, localPath string) error
+	// DeleteObject deletes an object
+	DeleteObject(key string) error
+
+	// GetBaseURL returns the URL objects are served under
+	GetBaseURL() string
+	// ObjectURL returns the URL of an object that is stored in the database and sent to the AYO API.
+	// For private stores this is a reference resolved by our own media endpoint.
+	ObjectURL(key string) string
+	// PresignGetURL returns a URL the object can be downloaded from without credentials for at
+	// least ttl. Public stores return the object's permanent URL.
+	PresignGetURL(key string, ttl time.Duration) (string, error)
+}
+
+// MediaPathPrefix is the path of our media endpoint, which resolves object references of
+// private stores to presigned URLs
+const MediaPathPrefix = "/api/media"
+
+var (
+	_ ObjectStore = (*R2Storage)(nil)
+	_ ObjectStore = (*LocalStorage)(nil)
+	_ ObjectStore = (*MemoryStorage)(nil)
+)
+
+// NewObjectStore creates the object store selected in system config. R2 settings missing from
+// cfg are loaded from system config; concurrency is the number of parallel parts per R2 upload.
+// With a database, large R2 uploads are resumable.
+func NewObjectStore(cfg *config.Config, db database.Database, concurrency int) (ObjectStore, error) {
+	storageConfig := &config.ObjectStorageConfig{Backend: config.ObjectStorageR2}
+	if db != nil {
+		storageConfig, _ = config.NewObjectStorageConfigService(db).GetObjectStorageConfig()
+	}
+
+	switch storageConfig.Backend {
+	case config.ObjectStorageLocal:
+		root := storageConfig.LocalPath
+		if root == "" {
+			root = filepath.Join(cfg.StoragePath, "objects")
+		}
+		store, err := NewLocalStorage(root, storageConfig.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		store, err := NewR2StorageWithConcurrency(r2ConfigFromSystem(cfg, db), concurrency)
+		if err != nil {
+			return nil, err
+		}
+		if db != nil {
+			store.EnableResumableUploads(db)
+		}
+		if storageConfig.Private {
+			store.EnablePrivateAccess(storageConfig.ResolverBaseURL)
+		}
+		return store, nil
+	}
+}
+
+// r2ConfigFromSystem returns the R2 settings of cfg, filling empty values from system config
+func r2ConfigFromSystem(cfg *config.Config, db database.Database) R2Config {
+	r2Config := R2Config{
+		AccessKey: cfg.R2AccessKey,
+		SecretKey: cfg.R2SecretKey,
+		AccountID: cfg.R2AccountID,
+		Bucket:    cfg.R2Bucket,
+		Endpoint:  cfg.R2Endpoint,
+		Region:    cfg.R2Region,
+		BaseURL:   cfg.R2BaseURL,
+	}
+	if db == nil {
+		return r2Config
+	}
+
+	for _, setting := range []struct {
+		value *string
+		key   string
+	}{
+		{&r2Config.AccessKey, database.ConfigR2AccessKey},
+		{&r2Config.SecretKey, database.ConfigR2SecretKey},
+		{&r2Config.AccountID, database.ConfigR2AccountID},
+		{&r2Config.Bucket, database.ConfigR2Bucket},
+		{&r2Config.Endpoint, database.ConfigR2Endpoint},
+		{&r2Config.Region, database.ConfigR2Region},
+		{&r2Config.BaseURL, database.ConfigR2BaseURL},
+	} {
+		if *setting.value != "" {
+			continue
+		}
+		if systemConfig, err := db.GetSystemConfig(setting.key); err == nil && systemConfig.Value != "" {
+			*setting.value = systemConfig.Value
+		}
+	}
+	return r2Config
+}
+
+// writeDownloadedFile writes body to localPath, removing the file again if the download fails
+func writeDownloadedFile(localPath string, body io.Reader) error {
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %v", localPath, err)
+	}
+
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		os.Remove(localPath)
+		return fmt.Errorf("failed to download to %s: %v", localPath, err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(localPath)
+		return fmt.Errorf("failed to write file %s: %v", localPath, err)
+	}
+	return nil
+}
+
+// contentTypeFor returns the content type objects are served with, based on the file extension
+func contentTypeFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4":
+		return "video/mp4"
+	case ".ts":
+		return "video/mp2t"
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".mpd":
+		return "application/dash+xml"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".webp":
+		return "image/webp"
+	case ".gif":
+		return "image/gif"
+	case ".vtt":
+		return "text/vtt"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// UploadDirectory uploads all files in a directory under remotePrefix, recording the verified
+// checksum of each file on the video
+func UploadDirectory(store ObjectStore, db database.Database, videoID, localDir, remotePrefix string) ([]string, error) {
+	var uploadedFiles []string
+
+	err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories
+		if info.IsDir() {
+			return nil
+		}
+
+		// Calculate remote path
+		relPath, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to determine relative path: %v", err)
+		}
+
+		remotePath := filepath.Join(remotePrefix, relPath)
+		// Ensure forward slashes for object keys
+		remotePath = strings.ReplaceAll(remotePath, "\\", "/")
+
+		// Upload and verify file
+		location, err := UploadVideoArtifact(store, db, videoID, path, remotePath, nil)
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %v", path, err)
+		}
+
+		uploadedFiles = append(uploadedFiles, location)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("directory upload failed: %v", err)
+	}
+
+	return uploadedFiles, nil
+}
+
+// UploadHLSStream uploads an HLS stream directory. For CMAF output the directory also holds
+// the DASH manifest, which is uploaded next to the HLS master playlist.
+func UploadHLSStream(store ObjectStore, db database.Database, hlsDir, videoID string) (string, string, error) {
+	return UploadHLSStreamWithMetrics(store, db, hlsDir, videoID, nil)
+}
+
+// UploadHLSStreamWithMetrics uploads HLS stream with metrics tracking
+func UploadHLSStreamWithMetrics(store ObjectStore, db database.Database, hlsDir, videoID string, videoMetrics *metrics.VideoProcessingMetrics) (string, string, error) {
+	// Start upload metrics if provided
+	if videoMetrics != nil {
+		videoMetrics.StartUpload()
+		defer videoMetrics.EndUpload()
+	}
+
+	remotePrefix := fmt.Sprintf("hls/%s", videoID)
+	_, err := UploadDirectory(store, db, videoID, hlsDir, remotePrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to upload HLS stream: %v", err)
+	}
+	// Kembalikan path dan URL
+	r2Path := remotePrefix
+	r2URL := fmt.Sprintf("%s/%s/master.m3u8", store.GetBaseURL(), remotePrefix)
+	return r2Path, r2URL, nil
+}
+
+// UploadSpriteSheet uploads a sprite sheet directory (sprite_*.jpg + WebVTT track).
+// Returns the remote path, the URL of the first sprite sheet and the URL of the WebVTT track.
+func UploadSpriteSheet(store ObjectStore, db database.Database, spriteDir, videoID, vttFileName string) (string, string, string, error) {
+	remotePrefix := fmt.Sprintf("sprites/%s", videoID)
+	_, err := UploadDirectory(store, db, videoID, spriteDir, remotePrefix)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to upload sprite sheet: %v", err)
+	}
+	spriteURL := fmt.Sprintf("%s/%s/sprite_001.jpg", store.GetBaseURL(), remotePrefix)
+	vttURL := fmt.Sprintf("%s/%s/%s", store.GetBaseURL(), remotePrefix, vttFileName)
+	return remotePrefix, spriteURL, vttURL, nil
+}
+
+// UploadMP4 uploads a video file as mp4/<video id><ext> and records its verified checksum on the video
+func UploadMP4(store ObjectStore, db database.Database, mp4Path, videoID string) (string, error) {
+	return UploadMP4WithMetrics(store, db, mp4Path, videoID, nil)
+}
+
+// UploadMP4WithMetrics uploads MP4 file with metrics tracking
+func UploadMP4WithMetrics(store ObjectStore, db database.Database, mp4Path, videoID string, videoMetrics *metrics.VideoProcessingMetrics) (string, error) {
+	remotePrefix := fmt.Sprintf("mp4/%s%s", videoID, filepath.Ext(mp4Path))
+
+	log.Printf("Uploading MP4 %s with key %s", mp4Path, remotePrefix)
+
+	_, err := UploadVideoArtifact(store, db, videoID, mp4Path, remotePrefix, videoMetrics)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload MP4: %v", err)
+	}
+
+	publicURL := store.ObjectURL(remotePrefix)
+	log.Printf("MP4 URL: %s", publicURL)
+	return publicURL, nil
+}
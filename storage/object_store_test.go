@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorageMultipartUpload(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "http://192.168.1.10:3000/objects/")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	testMultipartUpload(t, store)
+
+	if _, err := store.UploadFile(filepath.Join(t.TempDir(), "missing.mp4"), "mp4/missing.mp4"); err == nil {
+		t.Error("expected error uploading a missing file")
+	}
+	if _, err := store.objectPath("../../etc/passwd"); err != nil {
+		t.Errorf("expected key to be confined to the root, got %v", err)
+	}
+	if _, err := store.objectPath(".uploads/1/00001"); err == nil {
+		t.Error("expected keys inside the multipart upload directory to be rejected")
+	}
+}
+
+func TestIsLocalUploadPath(t *testing.T) {
+	for name, want := range map[string]bool{
+		"/.uploads":                 true,
+		"/.uploads/1/00001":         true,
+		"/mp4/../.uploads/1/00001":  true,
+		"mp4/video_1.mp4":           false,
+		"/hls/video_1/.uploadsfile": false,
+	} {
+		if got := IsLocalUploadPath(name); got != want {
+			t.Errorf("IsLocalUploadPath(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestMemoryStorageMultipartUpload(t *testing.T) {
+	testMultipartUpload(t, NewMemoryStorage("http://192.168.1.10:3000/objects"))
+}
+
+func testMultipartUpload(t *testing.T, store ObjectStore) {
+	t.Helper()
+
+	uploadID, err := store.CreateMultipartUpload("mp4/video_1.mp4", "video/mp4")
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+
+	var parts []CompletedPart
+	for i, data := range []string{"first-", "second"} {
+		etag, err := store.UploadPart("mp4/video_1.mp4", uploadID, i+1, bytes.NewReader([]byte(data)), int64(len(data)))
+		if err != nil {
+			t.Fatalf("UploadPart %d: %v", i+1, err)
+		}
+		parts = append(parts, CompletedPart{PartNumber: i + 1, ETag: etag})
+	}
+	// Parts may be completed in any order
+	parts[0], parts[1] = parts[1], parts[0]
+	if err := store.CompleteMultipartUpload("mp4/video_1.mp4", uploadID, parts); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+
+	info, err := store.HeadObject("mp4/video_1.mp4")
+	if err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+	if info.Size != int64(len("first-second")) {
+		t.Errorf("size = %d, want %d", info.Size, len("first-second"))
+	}
+
+	localPath := filepath.Join(t.TempDir(), "thumb.jpg")
+	if err := os.WriteFile(localPath, []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	url, err := store.UploadFile(localPath, "thumbnails/video_1.jpg")
+	if err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if url != "http://192.168.1.10:3000/objects/thumbnails/video_1.jpg" {
+		t.Errorf("url = %s", url)
+	}
+
+	objects, err := store.ListObjects("mp4/")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "mp4/video_1.mp4" {
+		t.Errorf("ListObjects(mp4/) = %+v", objects)
+	}
+
+	if err := store.DeleteObject("mp4/video_1.mp4"); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := store.HeadObject("mp4/video_1.mp4"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected ErrObjectNotFound after delete, got %v", err)
+	}
+}
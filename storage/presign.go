@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// playlistURIAttr matches URI="..." attributes of HLS tags such as EXT-X-MAP and EXT-X-MEDIA
+var playlistURIAttr = regexp.MustCompile(`URI="([^"]*)"`)
+
+// manifestURLAttr matches the attributes of DASH manifests that reference segments: media and
+// initialization of SegmentTemplate, sourceURL of Initialization, and media of SegmentURL
+var manifestURLAttr = regexp.MustCompile(`\b(media|initialization|sourceURL)="([^"]*)"`)
+
+// SignMediaKey returns the signature of a media endpoint request for key, valid until the Unix
+// time expires: the hex HMAC-SHA512 of "expires=<expires>&key=<url-encoded key>" with the venue
+// secret key, the same scheme the AYO API uses for our requests
+func SignMediaKey(secret, key string, expires int64) string {
+	values := url.Values{}
+	values.Set("expires", fmt.Sprintf("%d", expires))
+	values.Set("key", key)
+
+	h := hmac.New(sha512.New, []byte(secret))
+	h.Write([]byte(values.Encode()))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyMediaSignature reports whether signature authorizes a media endpoint request for key
+// that has not expired yet
+func VerifyMediaSignature(secret, key string, expires int64, signature string) bool {
+	if secret == "" || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignMediaKey(secret, key, expires)))
+}
+
+// VerifyMediaScopeSignature reports whether signature authorizes media endpoint requests for
+// every key under scope, a key prefix ending in "/", and key is one of them
+func VerifyMediaScopeSignature(secret, scope, key string, expires int64, signature string) bool {
+	if !strings.HasSuffix(scope, "/") || !strings.HasPrefix(key, scope) || path.Clean(key) != key {
+		return false
+	}
+	return VerifyMediaSignature(secret, scope, expires, signature)
+}
+
+// SignedMediaScopeURL returns the media endpoint URL of name under scope, signed until expires
+// for every key under scope. name may be a DASH segment template, whose keys are only known
+// to the player.
+func SignedMediaScopeURL(store ObjectStore, secret, scope, name string, expires time.Time) string {
+	return fmt.Sprintf("%s?expires=%d&scope=%s&signature=%s", store.ObjectURL(scope+name), expires.Unix(),
+		url.QueryEscape(scope), SignMediaKey(secret, scope, expires.Unix()))
+}
+
+// SignedMediaURL returns the media endpoint URL of key with a signature valid until expires,
+// so it can be fetched without other credentials
+func SignedMediaURL(store ObjectStore, secret, key string, expires time.Time) string {
+	return fmt.Sprintf("%s?expires=%d&signature=%s", store.ObjectURL(key), expires.Unix(), SignMediaKey(secret, key, expires.Unix()))
+}
+
+// PresignPlaylist returns the HLS playlist stored under key, rewritten so a player can follow
+// it in a private bucket: nested playlists point to signed media endpoint URLs and segments and
+// init sections to presigned URLs, all valid for ttl
+func PresignPlaylist(store ObjectStore, secret, key string, ttl time.Duration) ([]byte, error) {
+	playlist, err := store.GetObject(key)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(ttl)
+	var rewriteErr error
+	resolve := func(uri string) string {
+		if uri == "" || strings.Contains(uri, "://") || rewriteErr != nil {
+			return uri
+		}
+		target := path.Join(path.Dir(key), strings.SplitN(uri, "?", 2)[0])
+		if strings.HasSuffix(target, ".m3u8") {
+			return SignedMediaURL(store, secret, target, expires)
+		}
+		presigned, err := store.PresignGetURL(target, ttl)
+		if err != nil {
+			rewriteErr = err
+			return uri
+		}
+		return presigned
+	}
+
+	lines := strings.Split(string(playlist), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			lines[i] = playlistURIAttr.ReplaceAllStringFunc(line, func(attr string) string {
+				return fmt.Sprintf(`URI="%s"`, resolve(playlistURIAttr.FindStringSubmatch(attr)[1]))
+			})
+		default:
+			lines[i] = resolve(trimmed)
+		}
+	}
+	if rewriteErr != nil {
+		return nil, rewriteErr
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// PresignDASHManifest returns the DASH manifest stored under key, rewritten so a player can
+// follow it in a private bucket. Segment templates cannot be presigned per segment, so segment
+// and init references point to media endpoint URLs signed for the manifest's directory, valid
+// for ttl.
+func PresignDASHManifest(store ObjectStore, secret, key string, ttl time.Duration) ([]byte, error) {
+	manifest, err := store.GetObject(key)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(ttl)
+	scope := path.Dir(key) + "/"
+	rewritten := manifestURLAttr.ReplaceAllStringFunc(string(manifest), func(attr string) string {
+		match := manifestURLAttr.FindStringSubmatch(attr)
+		uri := html.UnescapeString(match[2])
+		if uri == "" || strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") || strings.Contains(uri, "..") {
+			return attr
+		}
+		signed := SignedMediaScopeURL(store, secret, scope, uri, expires)
+		return fmt.Sprintf(`%s="%s"`, match[1], html.EscapeString(signed))
+	})
+	return []byte(rewritten), nil
+}
+
+// PresignSpriteTrack returns the WebVTT thumbnail track stored under key with its sprite sheet
+// references presigned for ttl, keeping their #xywh= fragments
+func PresignSpriteTrack(store ObjectStore, key string, ttl time.Duration) ([]byte, error) {
+	track, err := store.GetObject(key)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(track), "\n")
+	inCue := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			inCue = false
+		case strings.Contains(trimmed, "-->"):
+			inCue = true
+		case inCue && !strings.Contains(trimmed, "://"):
+			// Cue payload: a sprite sheet reference relative to the track
+			reference, fragment := trimmed, ""
+			if hash := strings.Index(trimmed, "#"); hash >= 0 {
+				reference, fragment = trimmed[:hash], trimmed[hash:]
+			}
+			presigned, err := store.PresignGetURL(path.Join(path.Dir(key), reference), ttl)
+			if err != nil {
+				return nil, err
+			}
+			lines[i] = presigned + fragment
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
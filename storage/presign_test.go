@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresignPlaylist(t *testing.T) {
+	store := NewMemoryStorage("https://venue.example.com/api/media")
+	store.PutObject("hls/video_1/master.m3u8", []byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=2800000\n720p/playlist.m3u8\n"))
+	store.PutObject("hls/video_1/720p/playlist.m3u8", []byte("#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:4.0,\nsegment_000.m4s\n#EXT-X-ENDLIST\n"))
+
+	master, err := PresignPlaylist(store, "secret", "hls/video_1/master.m3u8", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPlaylist: %v", err)
+	}
+	variantURL := strings.Split(string(master), "\n")[2]
+	if !strings.HasPrefix(variantURL, "https://venue.example.com/api/media/hls/video_1/720p/playlist.m3u8?expires=") {
+		t.Errorf("variant playlist not signed: %s", variantURL)
+	}
+
+	variant, err := PresignPlaylist(store, "secret", "hls/video_1/720p/playlist.m3u8", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignPlaylist: %v", err)
+	}
+	for _, want := range []string{`URI="https://venue.example.com/api/media/hls/video_1/720p/init.mp4?expires=`, "\nhttps://venue.example.com/api/media/hls/video_1/720p/segment_000.m4s?expires="} {
+		if !strings.Contains(string(variant), want) {
+			t.Errorf("variant playlist missing %q:\n%s", want, variant)
+		}
+	}
+
+	expires := time.Now().Add(time.Minute).Unix()
+	signature := SignMediaKey("secret", "mp4/video_1.mp4", expires)
+	if !VerifyMediaSignature("secret", "mp4/video_1.mp4", expires, signature) {
+		t.Error("expected valid signature to verify")
+	}
+	if VerifyMediaSignature("secret", "mp4/video_2.mp4", expires, signature) {
+		t.Error("expected signature for another key to be rejected")
+	}
+	if VerifyMediaSignature("secret", "mp4/video_1.mp4", time.Now().Add(-time.Minute).Unix(), SignMediaKey("secret", "mp4/video_1.mp4", time.Now().Add(-time.Minute).Unix())) {
+		t.Error("expected expired signature to be rejected")
+	}
+}
+
+func TestPresignDASHManifest(t *testing.T) {
+	store := NewMemoryStorage("https://venue.example.com/api/media")
+	store.PutObject("hls/video_1/manifest.mpd", []byte(`<?xml version="1.0"?>
+<MPD>
+  <SegmentTemplate timescale="1000" initialization="init_$RepresentationID$.m4s" media="chunk_$RepresentationID$_$Number%05d$.m4s" startNumber="1"/>
+  <SegmentURL media="https://cdn.example.com/absolute.m4s"/>
+</MPD>`))
+
+	manifest, err := PresignDASHManifest(store, "secret", "hls/video_1/manifest.mpd", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignDASHManifest: %v", err)
+	}
+	for _, want := range []string{
+		`initialization="https://venue.example.com/api/media/hls/video_1/init_$RepresentationID$.m4s?expires=`,
+		`media="https://venue.example.com/api/media/hls/video_1/chunk_$RepresentationID$_$Number%05d$.m4s?expires=`,
+		`&amp;scope=hls%2Fvideo_1%2F&amp;signature=`,
+		`media="https://cdn.example.com/absolute.m4s"`,
+	} {
+		if !strings.Contains(string(manifest), want) {
+			t.Errorf("manifest missing %q:\n%s", want, manifest)
+		}
+	}
+
+	if _, err := PresignDASHManifest(store, "secret", "hls/video_2/manifest.mpd", time.Minute); err != ErrObjectNotFound {
+		t.Errorf("expected ErrObjectNotFound for a missing manifest, got %v", err)
+	}
+}
+
+func TestVerifyMediaScopeSignature(t *testing.T) {
+	expires := time.Now().Add(time.Minute).Unix()
+	signature := SignMediaKey("secret", "hls/video_1/", expires)
+
+	cases := map[string]struct {
+		scope string
+		key   string
+		want  bool
+	}{
+		"segment in scope":         {"hls/video_1/", "hls/video_1/chunk_0_00001.m4s", true},
+		"nested key in scope":      {"hls/video_1/", "hls/video_1/720p/playlist.m3u8", true},
+		"key of another video":     {"hls/video_1/", "hls/video_2/chunk_0_00001.m4s", false},
+		"escaping the scope":       {"hls/video_1/", "hls/video_1/../video_2/master.m3u8", false},
+		"scope of a single key":    {"hls/video_1", "hls/video_1/chunk_0_00001.m4s", false},
+		"signature of other scope": {"hls/video_2/", "hls/video_2/chunk_0_00001.m4s", false},
+	}
+	for name, tc := range cases {
+		if got := VerifyMediaScopeSignature("secret", tc.scope, tc.key, expires, signature); got != tc.want {
+			t.Errorf("%s: VerifyMediaScopeSignature = %v, want %v", name, got, tc.want)
+		}
+	}
+}
+
+func TestPresignSpriteTrack(t *testing.T) {
+	store := NewMemoryStorage("https://venue.example.com/api/media")
+	store.PutObject("sprites/video_1/sprites.vtt", []byte("WEBVTT\n\n00:00:00.000 --> 00:00:10.000\nsprite_001.jpg#xywh=0,0,160,90\n\n1\n00:00:10.000 --> 00:00:20.000\nsprite_001.jpg#xywh=160,0,160,90\n"))
+
+	track, err := PresignSpriteTrack(store, "sprites/video_1/sprites.vtt", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignSpriteTrack: %v", err)
+	}
+	lines := strings.Split(string(track), "\n")
+	if lines[0] != "WEBVTT" || lines[2] != "00:00:00.000 --> 00:00:10.000" || lines[5] != "1" {
+		t.Errorf("header, timings and cue identifiers must be kept:\n%s", track)
+	}
+	for _, i := range []int{3, 7} {
+		if !strings.HasPrefix(lines[i], "https://venue.example.com/api/media/sprites/video_1/sprite_001.jpg?expires=") ||
+			!strings.Contains(lines[i], "#xywh=") {
+			t.Errorf("sprite reference on line %d not presigned with its fragment: %s", i+1, lines[i])
+		}
+	}
+}
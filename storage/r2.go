@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,11 +13,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	
+	"ayo-mwr/database"
 	"ayo-mwr/metrics"
 )
 
@@ -31,7 +34,6 @@ type R2Config struct {
 	BaseURL   string // URL publik untuk akses file, contoh: https://media.beligem.com
 }
 
-// R2Storage handles operations with Cloudflare R2
 // Constants for multipart uploads
 const (
 	// 5MB is the minimum chunk size for multipart uploads in R2/S3
@@ -45,11 +47,17 @@ const (
 	maxUploadAttempts = 3
 )
 
+// R2Storage is the ObjectStore for Cloudflare R2 and other S3-compatible buckets
 type R2Storage struct {
 	config   R2Config
 	session  *session.Session
 	client   *s3.S3
 	uploader *s3manager.Uploader
+
+	// Multipart upload state for resumable uploads; nil uploads every file in one go
+	uploadState database.Database
+	// Base URL of our media endpoint when the bucket is private; empty for public buckets
+	resolverBaseURL string
 }
 
 // NewR2Storage creates a new R2Storage instance
@@ -81,6 +89,18 @@ func NewR2StorageWithConcurrency(config R2Config, concurrency int) (*R2Storage,
 		return nil, fmt.Errorf("failed to create AWS session: %v", err)
 	}
 
+	// Send request bodies through the upload bandwidth limiter, keeping the session's transport
+	// (e.g. with a custom CA bundle)
+	httpClient := http.Client{}
+	if sess.Config.HTTPClient != nil {
+		httpClient = *sess.Config.HTTPClient
+	}
+	if httpClient.Transport == nil {
+		httpClient.Transport = http.DefaultTransport
+	}
+	httpClient.Transport = &throttledTransport{base: httpClient.Transport, limiter: uploadLimiter}
+	sess.Config.HTTPClient = &httpClient
+
 	// Create S3 client
 	client := s3.New(sess)
 
@@ -107,6 +127,13 @@ func NewR2StorageWithConcurrency(config R2Config, concurrency int) (*R2Storage,
 	}, nil
 }
 
+// EnableResumableUploads makes uploads larger than one part resumable: the multipart upload
+// ID and completed parts are kept in db, so an upload interrupted by a link drop or restart
+// continues from the last completed part
+func (r *R2Storage) EnableResumableUploads(db database.Database) {
+	r.uploadState = db
+}
+
 // UploadFile uploads a file to R2 storage using chunked uploads for large files
 func (r *R2Storage) UploadFile(localPath, remotePath string) (string, error) {
 	return r.UploadFileWithMetrics(localPath, remotePath, nil)
@@ -127,19 +154,7 @@ func (r *R2Storage) UploadFileWithMetrics(localPath, remotePath string, videoMet
 	}
 
 	// Determine content type based on file extension
-	contentType := "application/octet-stream"
-	switch strings.ToLower(filepath.Ext(localPath)) {
-	case ".mp4":
-		contentType = "video/mp4"
-	case ".ts":
-		contentType = "video/mp2t"
-	case ".m3u8":
-		contentType = "application/vnd.apple.mpegurl"
-	case ".png":
-		contentType = "image/png"
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	}
+	contentType := contentTypeFor(localPath)
 
 	// Common metadata for all upload methods
 	metadata := map[string]*string{
@@ -156,6 +171,21 @@ func (r *R2Storage) UploadFileWithMetrics(localPath, remotePath string, videoMet
 		videoMetrics.StartUpload()
 	}
 
+	// Upload large files part by part so a failed attempt resumes where it stopped
+	if r.uploadState != nil && fileInfo.Size() > resumablePartSize {
+		if err := UploadFileResumable(r, r.uploadState, localPath, remotePath, r.uploader.Concurrency); err != nil {
+			return "", fmt.Errorf("failed to upload file to R2: %w", err)
+		}
+
+		if videoMetrics != nil {
+			videoMetrics.EndUpload()
+		}
+
+		publicURL := r.ObjectURL(remotePath)
+		log.Printf("File uploaded successfully, public URL: %s", publicURL)
+		return publicURL, nil
+	}
+
 	// Ensure we read from the beginning
 	if _, err := file.Seek(0, 0); err != nil {
 		return "", fmt.Errorf("failed to seek to beginning of file: %v", err)
@@ -194,7 +224,7 @@ func (r *R2Storage) UploadFileWithMetrics(localPath, remotePath string, videoMet
 	}
 
 	// Generate public URL
-	publicURL := fmt.Sprintf("%s/%s", r.GetBaseURL(), remotePath)
+	publicURL := r.ObjectURL(remotePath)
 	log.Printf("File uploaded successfully, public URL: %s", publicURL)
 	
 	return publicURL, nil
@@ -350,107 +380,132 @@ func (r *R2Storage) uploadLargeFile(file *os.File, remotePath, contentType strin
 	return *completeResp.Location, nil
 }
 
-// UploadDirectory uploads all files in a directory to R2
-func (r *R2Storage) UploadDirectory(localDir, remotePrefix string) ([]string, error) {
-	var uploadedFiles []string
-
-	err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Calculate remote path
-		relPath, err := filepath.Rel(localDir, path)
-		if err != nil {
-			return fmt.Errorf("failed to determine relative path: %v", err)
-		}
-
-		remotePath := filepath.Join(remotePrefix, relPath)
-		// Ensure forward slashes for S3 keys
-		remotePath = strings.ReplaceAll(remotePath, "\\", "/")
-
-		// Upload file
-		location, err := r.UploadFile(path, remotePath)
-		if err != nil {
-			return fmt.Errorf("failed to upload %s: %v", path, err)
-		}
-
-		uploadedFiles = append(uploadedFiles, location)
-		return nil
+// CreateMultipartUpload starts a multipart upload in the R2 bucket
+func (r *R2Storage) CreateMultipartUpload(key, contentType string) (string, error) {
+	resp, err := r.client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(r.config.Bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("directory upload failed: %v", err)
+		return "", fmt.Errorf("failed to create multipart upload: %v", err)
 	}
-
-	return uploadedFiles, nil
+	return aws.StringValue(resp.UploadId), nil
 }
 
-// UploadHLSStream uploads an HLS stream directory to R2
-func (r *R2Storage) UploadHLSStream(hlsDir, videoID string) (string, string, error) {
-	return r.UploadHLSStreamWithMetrics(hlsDir, videoID, nil)
+// UploadPart uploads one part of a multipart upload
+func (r *R2Storage) UploadPart(key, uploadID string, partNumber int, body io.ReadSeeker, size int64) (string, error) {
+	resp, err := r.client.UploadPart(&s3.UploadPartInput{
+		Body:          body,
+		Bucket:        aws.String(r.config.Bucket),
+		Key:           aws.String(key),
+		PartNumber:    aws.Int64(int64(partNumber)),
+		UploadId:      aws.String(uploadID),
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		if isNoSuchUpload(err) {
+			return "", fmt.Errorf("%w: %s", ErrUploadNotFound, uploadID)
+		}
+		return "", fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return aws.StringValue(resp.ETag), nil
 }
 
-// UploadHLSStreamWithMetrics uploads HLS stream with metrics tracking
-func (r *R2Storage) UploadHLSStreamWithMetrics(hlsDir, videoID string, videoMetrics *metrics.VideoProcessingMetrics) (string, string, error) {
-	// Start upload metrics if provided
-	if videoMetrics != nil {
-		videoMetrics.StartUpload()
-		defer videoMetrics.EndUpload()
+// CompleteMultipartUpload assembles the uploaded parts into the object
+func (r *R2Storage) CompleteMultipartUpload(key, uploadID string, parts []CompletedPart) error {
+	completedParts := make([]*s3.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completedParts = append(completedParts, &s3.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int64(int64(part.PartNumber)),
+		})
 	}
 
-	remotePrefix := fmt.Sprintf("hls/%s", videoID)
-	_, err := r.UploadDirectory(hlsDir, remotePrefix)
+	_, err := r.client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(r.config.Bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{
+			Parts: completedParts,
+		},
+	})
 	if err != nil {
-		return "", "", fmt.Errorf("failed to upload HLS stream: %v", err)
+		if isNoSuchUpload(err) {
+			return fmt.Errorf("%w: %s", ErrUploadNotFound, uploadID)
+		}
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
 	}
-	// Kembalikan path dan URL
-	r2Path := remotePrefix
-	r2URL := fmt.Sprintf("%s/%s/master.m3u8", r.GetBaseURL(), remotePrefix)
-	return r2Path, r2URL, nil
+	return nil
 }
 
-// Upload MP4 to R2
-func (r *R2Storage) UploadMP4(mp4Dir, videoID string) (string, error) {
-	return r.UploadMP4WithMetrics(mp4Dir, videoID, nil)
+// isNoSuchUpload reports whether err means the multipart upload no longer exists in the bucket
+func isNoSuchUpload(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == s3.ErrCodeNoSuchUpload
 }
 
-// UploadMP4WithMetrics uploads MP4 file with metrics tracking
-func (r *R2Storage) UploadMP4WithMetrics(mp4Dir, videoID string, videoMetrics *metrics.VideoProcessingMetrics) (string, error) {
-	remotePrefix := fmt.Sprintf("mp4/%s%s", videoID, filepath.Ext(mp4Dir))
-
-	log.Printf("Uploading MP4 %s to R2 bucket %s with key %s", mp4Dir, r.config.Bucket, remotePrefix)
-
-	// Use UploadFile instead of UploadDirectory since we're uploading a single file
-	_, err := r.UploadFileWithMetrics(mp4Dir, remotePrefix, videoMetrics)
+// AbortMultipartUpload discards a multipart upload and its parts
+func (r *R2Storage) AbortMultipartUpload(key, uploadID string) error {
+	_, err := r.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(r.config.Bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload MP4: %v", err)
+		if isNoSuchUpload(err) {
+			return fmt.Errorf("%w: %s", ErrUploadNotFound, uploadID)
+		}
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
 	}
-
-	// Gunakan GetBaseURL() untuk mendapatkan URL publik yang benar
-	publicURL := fmt.Sprintf("%s/%s", r.GetBaseURL(), remotePrefix)
-	log.Printf("MP4 URL: %s", publicURL)
-	return publicURL, nil
+	return nil
 }
 
 // ListObjects lists objects in the R2 bucket with a given prefix
-func (r *R2Storage) ListObjects(prefix string) ([]*s3.Object, error) {
+func (r *R2Storage) ListObjects(prefix string) ([]ObjectInfo, error) {
 	input := &s3.ListObjectsV2Input{
 		Bucket: aws.String(r.config.Bucket),
 		Prefix: aws.String(prefix),
 	}
 
-	result, err := r.client.ListObjectsV2(input)
+	var objects []ObjectInfo
+	err := r.client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.StringValue(object.Key),
+				Size:         aws.Int64Value(object.Size),
+				ETag:         strings.Trim(aws.StringValue(object.ETag), "\""),
+				LastModified: aws.TimeValue(object.LastModified),
+			})
+		}
+		return true
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %v", err)
 	}
 
-	return result.Contents, nil
+	return objects, nil
+}
+
+// HeadObject returns the info of an object in the R2 bucket
+func (r *R2Storage) HeadObject(key string) (*ObjectInfo, error) {
+	resp, err := r.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(r.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && (aerr.Code() == "NotFound" || aerr.Code() == s3.ErrCodeNoSuchKey) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to head object: %v", err)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(resp.ContentLength),
+		ETag:         strings.Trim(aws.StringValue(resp.ETag), "\""),
+		LastModified: aws.TimeValue(resp.LastModified),
+	}, nil
 }
 
 // DeleteObject deletes an object from the R2 bucket
@@ -468,8 +523,106 @@ func (r *R2Storage) DeleteObject(key string) error {
 	return nil
 }
 
-// GetBaseURL returns the base URL for the R2 bucket
+// DeleteObjects deletes up to deleteBatchSize objects from the R2 bucket in a single request
+func (r *R2Storage) DeleteObjects(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	objects := make([]*s3.ObjectIdentifier, len(keys))
+	for i, key := range keys {
+		objects[i] = &s3.ObjectIdentifier{Key: aws.String(key)}
+	}
+
+	resp, err := r.client.DeleteObjects(&s3.DeleteObjectsInput{
+		Bucket: aws.String(r.config.Bucket),
+		Delete: &s3.Delete{
+			Objects: objects,
+			Quiet:   aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete objects: %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		first := resp.Errors[0]
+		return fmt.Errorf("failed to delete %d of %d objects, first %s: %s",
+			len(resp.Errors), len(keys), aws.StringValue(first.Key), aws.StringValue(first.Message))
+	}
+
+	return nil
+}
+
+// EnablePrivateAccess treats the bucket as private: object URLs become references to our media
+// endpoint at resolverBaseURL (e.g. https://venue.example.com), which hands out short-lived
+// presigned URLs
+func (r *R2Storage) EnablePrivateAccess(resolverBaseURL string) {
+	r.resolverBaseURL = strings.TrimRight(resolverBaseURL, "/")
+}
+
+// GetObject returns the content of a small object in the R2 bucket
+func (r *R2Storage) GetObject(key string) ([]byte, error) {
+	resp, err := r.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(r.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get object: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %v", err)
+	}
+	return data, nil
+}
+
+// DownloadFile writes the content of an object of the R2 bucket to localPath
+func (r *R2Storage) DownloadFile(key, localPath string) error {
+	resp, err := r.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(r.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return ErrObjectNotFound
+		}
+		return fmt.Errorf("failed to get object: %v", err)
+	}
+	defer resp.Body.Close()
+
+	return writeDownloadedFile(localPath, resp.Body)
+}
+
+// PresignGetURL returns a presigned GET URL valid for ttl when the bucket is private, otherwise
+// the object's public URL
+func (r *R2Storage) PresignGetURL(key string, ttl time.Duration) (string, error) {
+	if r.resolverBaseURL == "" {
+		return r.ObjectURL(key), nil
+	}
+
+	req, _ := r.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(r.config.Bucket),
+		Key:    aws.String(key),
+	})
+	url, err := req.Presign(ttl)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object: %v", err)
+	}
+	return url, nil
+}
+
+// GetBaseURL returns the base URL for the R2 bucket, or of our media endpoint when the bucket
+// is private
 func (r *R2Storage) GetBaseURL() string {
+	if r.resolverBaseURL != "" {
+		return r.resolverBaseURL + MediaPathPrefix
+	}
+
 	// Gunakan BaseURL jika ada, jika tidak gunakan endpoint + bucket
 	if r.config.BaseURL != "" {
 		return r.config.BaseURL
@@ -479,6 +632,11 @@ func (r *R2Storage) GetBaseURL() string {
 	return fmt.Sprintf("%s/%s", r.config.Endpoint, r.config.Bucket)
 }
 
+// ObjectURL returns the public URL of an object in the R2 bucket
+func (r *R2Storage) ObjectURL(key string) string {
+	return fmt.Sprintf("%s/%s", r.GetBaseURL(), key)
+}
+
 // min returns the smaller of two int64 values
 func min(a, b int64) int64 {
 	if a < b {
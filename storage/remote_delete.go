@@ -0,0 +1,75 @@
+package storage
+
+import "fmt"
+
+// deleteBatchSize is the maximum number of keys deleted per request (S3 DeleteObjects limit)
+const deleteBatchSize = 1000
+
+// batchDeleter is implemented by stores that can delete several objects in one request
+type batchDeleter interface {
+	DeleteObjects(keys []string) error
+}
+
+// Compile-time check that R2 deletes in batches
+var _ batchDeleter = (*R2Storage)(nil)
+
+// VideoObjectPrefixes returns the key prefixes the objects of a video are stored under: the MP4
+// and its renditions, preview and thumbnail, HLS/DASH stream, sprite sheets and slow-motion replay
+func VideoObjectPrefixes(videoID string) []string {
+	return []string{
+		fmt.Sprintf("mp4/%s.", videoID),
+		fmt.Sprintf("mp4/%s_", videoID),
+		fmt.Sprintf("preview/%s.", videoID),
+		fmt.Sprintf("thumbnail/%s.", videoID),
+		fmt.Sprintf("hls/%s/", videoID),
+		fmt.Sprintf("sprites/%s/", videoID),
+		fmt.Sprintf("slowmo/%s.", videoID),
+	}
+}
+
+// DeleteObjectsWithPrefixes deletes every object whose key starts with one of prefixes. It
+// returns the deleted keys, including the ones deleted before an error.
+func DeleteObjectsWithPrefixes(store ObjectStore, prefixes []string) ([]string, error) {
+	var keys []string
+	for _, prefix := range prefixes {
+		objects, err := store.ListObjects(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects under %s: %v", prefix, err)
+		}
+		for _, object := range objects {
+			keys = append(keys, object.Key)
+		}
+	}
+
+	return DeleteObjects(store, keys)
+}
+
+// DeleteObjects deletes objects in batches of deleteBatchSize when the store supports it. It
+// returns the deleted keys, including the ones deleted before an error.
+func DeleteObjects(store ObjectStore, keys []string) ([]string, error) {
+	var deleted []string
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+
+		if deleter, ok := store.(batchDeleter); ok {
+			if err := deleter.DeleteObjects(batch); err != nil {
+				return deleted, err
+			}
+			deleted = append(deleted, batch...)
+			continue
+		}
+
+		for _, key := range batch {
+			if err := store.DeleteObject(key); err != nil {
+				return deleted, fmt.Errorf("failed to delete %s: %v", key, err)
+			}
+			deleted = append(deleted, key)
+		}
+	}
+
+	return deleted, nil
+}
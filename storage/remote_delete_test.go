@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestDeleteObjectsWithPrefixes(t *testing.T) {
+	store := NewMemoryStorage("https://media.example.com")
+	for _, key := range []string{
+		"mp4/video_1.mp4",
+		"mp4/video_1_preview.mp4",
+		"mp4/video_1_thumbnail.jpg",
+		"hls/video_1/master.m3u8",
+		"hls/video_1/720p/segment_000.ts",
+		"mp4/video_10.mp4",
+		"hls/video_10/master.m3u8",
+	} {
+		store.PutObject(key, []byte("data"))
+	}
+
+	deleted, err := DeleteObjectsWithPrefixes(store, VideoObjectPrefixes("video_1"))
+	if err != nil {
+		t.Fatalf("DeleteObjectsWithPrefixes: %v", err)
+	}
+	if len(deleted) != 5 {
+		t.Errorf("expected 5 deleted objects, got %d: %v", len(deleted), deleted)
+	}
+
+	remaining, _ := store.ListObjects("")
+	var keys []string
+	for _, object := range remaining {
+		keys = append(keys, object.Key)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "hls/video_10/master.m3u8" || keys[1] != "mp4/video_10.mp4" {
+		t.Errorf("objects of other videos must be kept, remaining: %v", keys)
+	}
+}
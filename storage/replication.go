@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"ayo-mwr/config"
+)
+
+const (
+	// unhealthyAfterFailures is the number of consecutive failed health checks after which the
+	// primary store is considered unhealthy
+	unhealthyAfterFailures = 3
+	// healthProbeKey is looked up to check the primary store; it does not have to exist
+	healthProbeKey = "health/probe"
+)
+
+// Replica is a secondary store delivered videos are copied to
+type Replica struct {
+	Name  string
+	Store ObjectStore
+}
+
+// NewReplica creates the store of a replication destination. Replicas of a private primary are
+// private as well, so their references resolve through the same media endpoint.
+func NewReplica(destination config.ReplicationDestination, storageConfig *config.ObjectStorageConfig) (*Replica, error) {
+	store, err := NewR2Storage(R2Config{
+		AccessKey: destination.AccessKey,
+		SecretKey: destination.SecretKey,
+		AccountID: destination.AccountID,
+		Bucket:    destination.Bucket,
+		Endpoint:  destination.Endpoint,
+		Region:    destination.Region,
+		BaseURL:   destination.BaseURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store of destination %s: %v", destination.Name, err)
+	}
+	if storageConfig != nil && storageConfig.Private {
+		store.EnablePrivateAccess(storageConfig.ResolverBaseURL)
+	}
+	return &Replica{Name: destination.Name, Store: store}, nil
+}
+
+// ReplicateObjects copies the objects of src under prefixes to dst and returns the number of
+// objects stored on dst. Objects dst already has with the same size are not copied again, so
+// an interrupted replication continues where it stopped. Copies are verified like uploads.
+func ReplicateObjects(src, dst ObjectStore, prefixes []string) (int, error) {
+	replicated := 0
+	for _, prefix := range prefixes {
+		objects, err := src.ListObjects(prefix)
+		if err != nil {
+			return replicated, fmt.Errorf("failed to list objects under %s: %v", prefix, err)
+		}
+
+		for _, object := range objects {
+			if existing, err := dst.HeadObject(object.Key); err == nil && existing.Size == object.Size {
+				replicated++
+				continue
+			}
+			if err := copyObject(src, dst, object.Key); err != nil {
+				return replicated, err
+			}
+			replicated++
+		}
+	}
+	return replicated, nil
+}
+
+// copyObject copies one object through a temporary file, which keeps the key's extension so the
+// copy gets the same content type
+func copyObject(src, dst ObjectStore, key string) error {
+	tmp, err := os.CreateTemp("", "replica-*"+path.Ext(key))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := src.DownloadFile(key, tmp.Name()); err != nil {
+		return fmt.Errorf("failed to download %s: %v", key, err)
+	}
+	if _, _, err := UploadFileVerified(dst, tmp.Name(), key); err != nil {
+		return fmt.Errorf("failed to copy %s: %v", key, err)
+	}
+	return nil
+}
+
+// ResolveStore returns the store key is read from: the primary while it is healthy, otherwise
+// the first replica holding the object. The primary is returned if no replica has it.
+func ResolveStore(primary ObjectStore, replicas []*Replica, key string) ObjectStore {
+	if PrimaryHealthy() {
+		return primary
+	}
+	for _, replica := range replicas {
+		if _, err := replica.Store.HeadObject(key); err == nil {
+			return replica.Store
+		}
+	}
+	return primary
+}
+
+// PrimaryHealth tracks whether the primary object store is reachable
+type PrimaryHealth struct {
+	mu             sync.RWMutex
+	failures       int // Consecutive failed checks
+	lastError      string
+	checkedAt      time.Time
+	unhealthySince time.Time
+}
+
+// primaryHealth is the health of the store videos are uploaded to
+var primaryHealth = &PrimaryHealth{}
+
+// CheckPrimaryHealth probes the primary store, records the result and reports whether the
+// primary is healthy
+func CheckPrimaryHealth(store ObjectStore) bool {
+	_, err := store.HeadObject(healthProbeKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		err = nil
+	}
+	primaryHealth.Record(err, time.Now())
+	return primaryHealth.Healthy()
+}
+
+// PrimaryHealthy reports whether the primary store is healthy. It is healthy until
+// unhealthyAfterFailures checks in a row failed, and again after the first successful check.
+func PrimaryHealthy() bool {
+	return primaryHealth.Healthy()
+}
+
+// PrimaryHealthStatus returns the result of the last primary health checks
+func PrimaryHealthStatus() map[string]interface{} {
+	return primaryHealth.Status()
+}
+
+// Record records the result of a health check made at t
+func (h *PrimaryHealth) Record(err error, t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checkedAt = t
+	if err == nil {
+		h.failures = 0
+		h.lastError = ""
+		h.unhealthySince = time.Time{}
+		return
+	}
+
+	h.failures++
+	h.lastError = err.Error()
+	if h.failures == unhealthyAfterFailures {
+		h.unhealthySince = t
+	}
+}
+
+// Healthy reports whether fewer than unhealthyAfterFailures checks in a row failed
+func (h *PrimaryHealth) Healthy() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.failures < unhealthyAfterFailures
+}
+
+// Status returns the health, consecutive failures and last error of the primary store
+func (h *PrimaryHealth) Status() map[string]interface{} {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := map[string]interface{}{
+		"healthy":             h.failures < unhealthyAfterFailures,
+		"consecutiveFailures": h.failures,
+	}
+	if !h.checkedAt.IsZero() {
+		status["checkedAt"] = h.checkedAt
+	}
+	if h.lastError != "" {
+		status["lastError"] = h.lastError
+	}
+	if !h.unhealthySince.IsZero() {
+		status["unhealthySince"] = h.unhealthySince
+	}
+	return status
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplicateObjects(t *testing.T) {
+	primary := NewMemoryStorage("https://media.example.com")
+	replica := NewMemoryStorage("https://backup.example.com")
+	primary.PutObject("mp4/video_1.mp4", []byte("full video"))
+	primary.PutObject("hls/video_1/master.m3u8", []byte("#EXTM3U"))
+	primary.PutObject("mp4/video_2.mp4", []byte("other video"))
+	replica.PutObject("hls/video_1/master.m3u8", []byte("#EXTM3U"))
+
+	replicated, err := ReplicateObjects(primary, replica, VideoObjectPrefixes("video_1"))
+	if err != nil {
+		t.Fatalf("ReplicateObjects: %v", err)
+	}
+	if replicated != 2 {
+		t.Errorf("expected 2 objects on the replica, got %d", replicated)
+	}
+
+	data, err := replica.GetObject("mp4/video_1.mp4")
+	if err != nil || string(data) != "full video" {
+		t.Errorf("mp4 not copied to the replica: %q, %v", data, err)
+	}
+	if _, err := replica.HeadObject("mp4/video_2.mp4"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("objects of other videos must not be copied")
+	}
+
+	// Objects uploaded after the video was replicated are copied by the next run
+	primary.PutObject("sprites/video_1/sprite.vtt", []byte("WEBVTT"))
+	replicated, err = ReplicateObjects(primary, replica, VideoObjectPrefixes("video_1"))
+	if err != nil {
+		t.Fatalf("ReplicateObjects: %v", err)
+	}
+	if replicated != 3 {
+		t.Errorf("expected 3 objects on the replica, got %d", replicated)
+	}
+	if data, err := replica.GetObject("sprites/video_1/sprite.vtt"); err != nil || string(data) != "WEBVTT" {
+		t.Errorf("new object not copied to the replica: %q, %v", data, err)
+	}
+}
+
+func TestPrimaryHealth(t *testing.T) {
+	health := &PrimaryHealth{}
+	now := time.Now()
+
+	for i := 1; i < unhealthyAfterFailures; i++ {
+		health.Record(errors.New("timeout"), now)
+		if !health.Healthy() {
+			t.Fatalf("primary must stay healthy after %d failed check(s)", i)
+		}
+	}
+	health.Record(errors.New("timeout"), now)
+	if health.Healthy() {
+		t.Fatalf("primary must be unhealthy after %d failed checks", unhealthyAfterFailures)
+	}
+
+	health.Record(nil, now)
+	if !health.Healthy() {
+		t.Errorf("primary must be healthy again after a successful check")
+	}
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"ayo-mwr/database"
+)
+
+const (
+	// resumablePartSize is the part size of resumable uploads (≥ 5 MB); larger files use bigger
+	// parts to stay within maxUploadParts
+	resumablePartSize = 10 * 1024 * 1024 // 10MB
+	// maxUploadParts is the R2/S3 limit on parts per multipart upload
+	maxUploadParts = 10000
+
+	// StaleMultipartUploadAge is how long an unfinished multipart upload may go without progress
+	// before cleanup aborts it
+	StaleMultipartUploadAge = 72 * time.Hour
+)
+
+// UploadFileResumable uploads a file to store in parts, recording the upload ID and completed
+// part ETags in db. An upload interrupted by a link drop or restart resumes from the last
+// completed part on the next call for the same key.
+func UploadFileResumable(store ObjectStore, db database.Database, localPath, key string, concurrency int) error {
+	err := uploadFileResumable(store, db, localPath, key, concurrency)
+	if errors.Is(err, ErrUploadNotFound) {
+		// The store dropped the upload (e.g. it expired while we were offline); start over
+		log.Printf("Multipart upload of %s no longer exists, restarting from the first part", key)
+		err = uploadFileResumable(store, db, localPath, key, concurrency)
+	}
+	return err
+}
+
+func uploadFileResumable(store ObjectStore, db database.Database, localPath, key string, concurrency int) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %v", localPath, err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %v", err)
+	}
+
+	upload, completed, err := resumeOrCreateUpload(store, db, localPath, key, fileInfo)
+	if err != nil {
+		return err
+	}
+
+	parts, err := uploadMissingParts(store, db, file, upload, completed, concurrency)
+	if err != nil {
+		if errors.Is(err, ErrUploadNotFound) {
+			db.DeleteMultipartUpload(upload.UploadID)
+		}
+		return err
+	}
+
+	if err := store.CompleteMultipartUpload(key, upload.UploadID, parts); err != nil {
+		if errors.Is(err, ErrUploadNotFound) {
+			db.DeleteMultipartUpload(upload.UploadID)
+		}
+		return err
+	}
+
+	if err := db.DeleteMultipartUpload(upload.UploadID); err != nil {
+		log.Printf("Warning: Failed to remove multipart upload state for %s: %v", key, err)
+	}
+	log.Printf("Multipart upload of %s completed (%d parts)", key, len(parts))
+	return nil
+}
+
+// resumablePartSizeFor returns the part size of a resumable upload of a file of size bytes
+func resumablePartSizeFor(size int64) int64 {
+	partSize := int64(resumablePartSize)
+	if minSize := (size + maxUploadParts - 1) / maxUploadParts; minSize > partSize {
+		partSize = minSize
+	}
+	return partSize
+}
+
+// resumeOrCreateUpload returns the recorded upload of localPath to key with its completed parts,
+// or starts a new one if there is none or the file changed since it started
+func resumeOrCreateUpload(store ObjectStore, db database.Database, localPath, key string, fileInfo os.FileInfo) (*database.MultipartUpload, map[int]database.MultipartUploadPart, error) {
+	partSize := resumablePartSizeFor(fileInfo.Size())
+
+	existing, err := db.GetMultipartUploadByKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get multipart upload state: %v", err)
+	}
+	if existing != nil {
+		if existing.LocalPath == localPath && existing.FileSize == fileInfo.Size() &&
+			existing.FileModTime.Unix() == fileInfo.ModTime().Unix() && existing.PartSize == partSize {
+			parts, err := db.GetMultipartUploadParts(existing.UploadID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get multipart upload parts: %v", err)
+			}
+			completed := make(map[int]database.MultipartUploadPart, len(parts))
+			for _, part := range parts {
+				completed[part.PartNumber] = part
+			}
+			log.Printf("Resuming multipart upload of %s: %d part(s) already uploaded", key, len(completed))
+			return existing, completed, nil
+		}
+
+		// The file was replaced since the upload started; its parts are useless
+		log.Printf("File %s changed since its upload started, discarding multipart upload %s", localPath, existing.UploadID)
+		if err := store.AbortMultipartUpload(key, existing.UploadID); err != nil && !errors.Is(err, ErrUploadNotFound) {
+			log.Printf("Warning: Failed to abort multipart upload %s: %v", existing.UploadID, err)
+		}
+		db.DeleteMultipartUpload(existing.UploadID)
+	}
+
+	uploadID, err := store.CreateMultipartUpload(key, contentTypeFor(localPath))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upload := &database.MultipartUpload{
+		UploadID:    uploadID,
+		ObjectKey:   key,
+		LocalPath:   localPath,
+		FileSize:    fileInfo.Size(),
+		FileModTime: fileInfo.ModTime(),
+		PartSize:    partSize,
+	}
+	if err := db.CreateMultipartUpload(*upload); err != nil {
+		return nil, nil, fmt.Errorf("failed to save multipart upload state: %v", err)
+	}
+	log.Printf("Started multipart upload of %s (%.2f MB, part size %.2f MB)", key, float64(fileInfo.Size())/1024/1024, float64(partSize)/1024/1024)
+	return upload, map[int]database.MultipartUploadPart{}, nil
+}
+
+// uploadMissingParts uploads the parts not yet completed, recording each one as it finishes,
+// and returns all parts of the upload in order
+func uploadMissingParts(store ObjectStore, db database.Database, file *os.File, upload *database.MultipartUpload, completed map[int]database.MultipartUploadPart, concurrency int) ([]CompletedPart, error) {
+	numParts := int((upload.FileSize + upload.PartSize - 1) / upload.PartSize)
+	if numParts == 0 {
+		numParts = 1 // An empty file is uploaded as one empty part
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	// Work out the missing parts before any upload starts, since the uploads write to completed
+	type partRange struct {
+		number       int
+		offset, size int64
+	}
+	var missing []partRange
+	for partNumber := 1; partNumber <= numParts; partNumber++ {
+		offset := int64(partNumber-1) * upload.PartSize
+		size := upload.PartSize
+		if offset+size > upload.FileSize {
+			size = upload.FileSize - offset
+		}
+		if part, ok := completed[partNumber]; ok && part.Size == size {
+			continue
+		}
+		missing = append(missing, partRange{number: partNumber, offset: offset, size: size})
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for _, p := range missing {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p partRange) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			etag, err := uploadPartWithRetry(store, upload, p.number, io.NewSectionReader(file, p.offset, p.size), p.size)
+			if err == nil {
+				part := database.MultipartUploadPart{UploadID: upload.UploadID, PartNumber: p.number, ETag: etag, Size: p.size}
+				if err = db.SaveMultipartUploadPart(part); err == nil {
+					mu.Lock()
+					completed[p.number] = part
+					mu.Unlock()
+					return
+				}
+				err = fmt.Errorf("failed to save part %d of %s: %v", p.number, upload.ObjectKey, err)
+			}
+
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	parts := make([]CompletedPart, 0, len(completed))
+	for _, part := range completed {
+		parts = append(parts, CompletedPart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+// uploadPartWithRetry uploads one part, retrying with exponential backoff
+func uploadPartWithRetry(store ObjectStore, upload *database.MultipartUpload, partNumber int, body io.ReadSeeker, size int64) (string, error) {
+	var lastErr error
+	for attempt := 1; attempt <= maxUploadAttempts; attempt++ {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to seek part %d: %v", partNumber, err)
+		}
+
+		etag, err := store.UploadPart(upload.ObjectKey, upload.UploadID, partNumber, body, size)
+		if err == nil {
+			return etag, nil
+		}
+		if errors.Is(err, ErrUploadNotFound) {
+			return "", err
+		}
+		lastErr = err
+
+		log.Printf("Upload attempt %d/%d failed for part %d of %s: %v", attempt, maxUploadAttempts, partNumber, upload.ObjectKey, err)
+		if attempt < maxUploadAttempts {
+			// Exponential backoff: 2s, 4s, ...
+			time.Sleep(time.Duration(1<<uint(attempt)) * time.Second)
+		}
+	}
+	return "", lastErr
+}
+
+// AbortStaleMultipartUploads aborts the recorded multipart uploads without progress for longer
+// than olderThan, freeing the parts they hold in the store. Returns the number aborted.
+func AbortStaleMultipartUploads(store ObjectStore, db database.Database, olderThan time.Duration) (int, error) {
+	uploads, err := db.GetMultipartUploadsUpdatedBefore(time.Now().Add(-olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stale multipart uploads: %v", err)
+	}
+
+	aborted := 0
+	for _, upload := range uploads {
+		if err := store.AbortMultipartUpload(upload.ObjectKey, upload.UploadID); err != nil && !errors.Is(err, ErrUploadNotFound) {
+			log.Printf("Warning: Failed to abort stale multipart upload %s of %s: %v", upload.UploadID, upload.ObjectKey, err)
+			continue
+		}
+		if err := db.DeleteMultipartUpload(upload.UploadID); err != nil {
+			log.Printf("Warning: Failed to remove multipart upload state %s: %v", upload.UploadID, err)
+			continue
+		}
+		log.Printf("Aborted stale multipart upload of %s (last progress %s)", upload.ObjectKey, upload.UpdatedAt.Format(time.RFC3339))
+		aborted++
+	}
+	return aborted, nil
+}
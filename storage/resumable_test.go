@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"ayo-mwr/database"
+)
+
+// countingStore records which parts are uploaded
+type countingStore struct {
+	*MemoryStorage
+	mu    sync.Mutex
+	parts []int
+}
+
+func (c *countingStore) UploadPart(key, uploadID string, partNumber int, body io.ReadSeeker, size int64) (string, error) {
+	c.mu.Lock()
+	c.parts = append(c.parts, partNumber)
+	c.mu.Unlock()
+	return c.MemoryStorage.UploadPart(key, uploadID, partNumber, body, size)
+}
+
+func TestUploadFileResumable(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.NewSQLiteDB(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteDB: %v", err)
+	}
+	defer db.Close()
+
+	data := bytes.Repeat([]byte("0123456789"), 2*resumablePartSize/10+1234) // 3 parts
+	localPath := filepath.Join(dir, "video.mp4")
+	if err := os.WriteFile(localPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := &countingStore{MemoryStorage: NewMemoryStorage("http://localhost/objects")}
+
+	// Simulate an upload interrupted after its first part
+	fileInfo, _ := os.Stat(localPath)
+	upload, _, err := resumeOrCreateUpload(store, db, localPath, "mp4/video_1.mp4", fileInfo)
+	if err != nil {
+		t.Fatalf("resumeOrCreateUpload: %v", err)
+	}
+	etag, err := store.UploadPart(upload.ObjectKey, upload.UploadID, 1, bytes.NewReader(data[:resumablePartSize]), resumablePartSize)
+	if err != nil {
+		t.Fatalf("UploadPart: %v", err)
+	}
+	if err := db.SaveMultipartUploadPart(database.MultipartUploadPart{UploadID: upload.UploadID, PartNumber: 1, ETag: etag, Size: resumablePartSize}); err != nil {
+		t.Fatalf("SaveMultipartUploadPart: %v", err)
+	}
+	store.parts = nil
+
+	if err := UploadFileResumable(store, db, localPath, "mp4/video_1.mp4", 2); err != nil {
+		t.Fatalf("UploadFileResumable: %v", err)
+	}
+	if len(store.parts) != 2 {
+		t.Errorf("expected only parts 2 and 3 to be uploaded, got %v", store.parts)
+	}
+	if got, _ := store.GetObject("mp4/video_1.mp4"); !bytes.Equal(got, data) {
+		t.Errorf("uploaded object differs from the file (%d vs %d bytes)", len(got), len(data))
+	}
+	if state, _ := db.GetMultipartUploadByKey("mp4/video_1.mp4"); state != nil {
+		t.Errorf("expected upload state to be removed after completion, got %+v", state)
+	}
+
+	// Many parts uploaded concurrently around parts that were already completed
+	many := bytes.Repeat([]byte("0123456789abcdef"), 4096) // 64 parts of 1KB
+	manyPath := filepath.Join(dir, "many.mp4")
+	if err := os.WriteFile(manyPath, many, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(manyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	manyID, err := store.CreateMultipartUpload("mp4/video_many.mp4", "video/mp4")
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+	manyUpload := &database.MultipartUpload{UploadID: manyID, ObjectKey: "mp4/video_many.mp4", LocalPath: manyPath, FileSize: int64(len(many)), PartSize: 1024}
+	completed := map[int]database.MultipartUploadPart{}
+	for partNumber := 1; partNumber <= 64; partNumber += 3 {
+		offset := (partNumber - 1) * 1024
+		etag, err := store.UploadPart(manyUpload.ObjectKey, manyID, partNumber, bytes.NewReader(many[offset:offset+1024]), 1024)
+		if err != nil {
+			t.Fatalf("UploadPart: %v", err)
+		}
+		completed[partNumber] = database.MultipartUploadPart{UploadID: manyID, PartNumber: partNumber, ETag: etag, Size: 1024}
+	}
+	store.parts = nil
+
+	parts, err := uploadMissingParts(store, db, file, manyUpload, completed, 8)
+	if err != nil {
+		t.Fatalf("uploadMissingParts: %v", err)
+	}
+	if len(store.parts) != 64-22 {
+		t.Errorf("expected the 42 missing parts to be uploaded, got %d", len(store.parts))
+	}
+	if len(parts) != 64 {
+		t.Fatalf("expected 64 parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if part.PartNumber != i+1 {
+			t.Fatalf("parts out of order at %d: %+v", i, part)
+		}
+	}
+	if err := store.CompleteMultipartUpload(manyUpload.ObjectKey, manyID, parts); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+	if got, _ := store.GetObject(manyUpload.ObjectKey); !bytes.Equal(got, many) {
+		t.Errorf("uploaded object differs from the file (%d vs %d bytes)", len(got), len(many))
+	}
+
+	// An upload without progress is aborted by cleanup
+	if _, _, err := resumeOrCreateUpload(store, db, localPath, "mp4/video_2.mp4", fileInfo); err != nil {
+		t.Fatalf("resumeOrCreateUpload: %v", err)
+	}
+	aborted, err := AbortStaleMultipartUploads(store, db, -time.Minute)
+	if err != nil || aborted != 1 {
+		t.Errorf("AbortStaleMultipartUploads = %d, %v; want 1, nil", aborted, err)
+	}
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"ayo-mwr/database"
+	"ayo-mwr/metrics"
+)
+
+// maxVerifiedUploadAttempts is how many times a file is uploaded before a checksum mismatch is
+// reported as an error
+const maxVerifiedUploadAttempts = 3
+
+// ErrChecksumMismatch is returned when an uploaded object does not match the local file
+var ErrChecksumMismatch = errors.New("uploaded object does not match local file")
+
+// fileChecksum returns the hex MD5 of a file and the S3 multipart ETag it gets when uploaded in
+// parts of partSize bytes: the MD5 of the concatenated part MD5s, followed by "-<parts>"
+func fileChecksum(localPath string, partSize int64) (string, string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open file %s: %v", localPath, err)
+	}
+	defer file.Close()
+
+	whole := md5.New()
+	var partSums []byte
+	parts := 0
+	for {
+		part := md5.New()
+		n, err := io.CopyN(io.MultiWriter(whole, part), file, partSize)
+		if n > 0 {
+			partSums = append(partSums, part.Sum(nil)...)
+			parts++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read file %s: %v", localPath, err)
+		}
+	}
+
+	multipart := md5.Sum(partSums)
+	return hex.EncodeToString(whole.Sum(nil)), fmt.Sprintf("%s-%d", hex.EncodeToString(multipart[:]), parts), nil
+}
+
+// VerifyUpload compares the size and ETag of the object at key with the local file it was
+// uploaded from and returns the hex MD5 of the file. A plain ETag is the MD5 of the object; a
+// multipart ETag ("<md5>-<parts>") is recomputed from the file with the part size our uploads
+// use. Backends without ETags are verified by size only.
+func VerifyUpload(store ObjectStore, localPath, key string) (string, error) {
+	fileInfo, err := os.Stat(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %v", err)
+	}
+
+	object, err := store.HeadObject(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to head uploaded object %s: %v", key, err)
+	}
+	if object.Size != fileInfo.Size() {
+		return "", fmt.Errorf("%w: %s is %d bytes, local file %d bytes", ErrChecksumMismatch, key, object.Size, fileInfo.Size())
+	}
+
+	fileMD5, multipartETag, err := fileChecksum(localPath, resumablePartSizeFor(fileInfo.Size()))
+	if err != nil {
+		return "", err
+	}
+
+	etag := strings.ToLower(object.ETag)
+	switch {
+	case etag == "":
+		// Nothing to compare beyond the size
+	case strings.Contains(etag, "-"):
+		// Uploaded in parts of another size (e.g. by an older version), only the part count is known
+		parts, _ := strconv.Atoi(etag[strings.LastIndex(etag, "-")+1:])
+		if !strings.HasSuffix(multipartETag, fmt.Sprintf("-%d", parts)) {
+			log.Printf("Warning: Cannot verify checksum of %s, multipart ETag %s uses an unknown part size", key, object.ETag)
+			break
+		}
+		if etag != multipartETag {
+			return "", fmt.Errorf("%w: %s has ETag %s, local file %s", ErrChecksumMismatch, key, object.ETag, multipartETag)
+		}
+	case etag != fileMD5:
+		return "", fmt.Errorf("%w: %s has ETag %s, local file %s", ErrChecksumMismatch, key, object.ETag, fileMD5)
+	}
+
+	return fileMD5, nil
+}
+
+// UploadFileVerified uploads a file and verifies the uploaded object, uploading it again while
+// it does not match. It returns the object URL and the verified MD5 of the file.
+func UploadFileVerified(store ObjectStore, localPath, key string) (string, string, error) {
+	return UploadFileVerifiedWithMetrics(store, localPath, key, nil)
+}
+
+// UploadFileVerifiedWithMetrics uploads and verifies a file with metrics tracking
+func UploadFileVerifiedWithMetrics(store ObjectStore, localPath, key string, videoMetrics *metrics.VideoProcessingMetrics) (string, string, error) {
+	var lastErr error
+	for attempt := 1; attempt <= maxVerifiedUploadAttempts; attempt++ {
+		url, err := store.UploadFileWithMetrics(localPath, key, videoMetrics)
+		if err != nil {
+			return "", "", err
+		}
+
+		checksum, err := VerifyUpload(store, localPath, key)
+		if err == nil {
+			return url, checksum, nil
+		}
+		lastErr = err
+		if !errors.Is(err, ErrChecksumMismatch) {
+			return "", "", err
+		}
+		log.Printf("Verification of %s failed (attempt %d/%d), uploading again: %v", key, attempt, maxVerifiedUploadAttempts, err)
+	}
+	return "", "", lastErr
+}
+
+// UploadVideoArtifact uploads and verifies a file belonging to a video and records its verified
+// checksum on the video, so the video may be reported as available
+func UploadVideoArtifact(store ObjectStore, db database.Database, videoID, localPath, key string, videoMetrics *metrics.VideoProcessingMetrics) (string, error) {
+	url, checksum, err := UploadFileVerifiedWithMetrics(store, localPath, key, videoMetrics)
+	if err != nil {
+		return "", err
+	}
+
+	if db != nil {
+		if err := db.UpdateVideoChecksum(videoID, key, checksum); err != nil {
+			return "", fmt.Errorf("failed to record checksum of %s: %v", key, err)
+		}
+	}
+	return url, nil
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ayo-mwr/database"
+	"ayo-mwr/metrics"
+)
+
+// corruptingStore stores a truncated copy of the first upload, like a transfer cut short
+type corruptingStore struct {
+	*MemoryStorage
+	uploads int
+}
+
+func (c *corruptingStore) UploadFileWithMetrics(localPath, key string, videoMetrics *metrics.VideoProcessingMetrics) (string, error) {
+	c.uploads++
+	if c.uploads == 1 {
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			return "", err
+		}
+		c.PutObject(key, data[:len(data)/2])
+		return c.ObjectURL(key), nil
+	}
+	return c.MemoryStorage.UploadFileWithMetrics(localPath, key, videoMetrics)
+}
+
+// checksumDB records the checksums stored on videos
+type checksumDB struct {
+	database.Database
+	checksums map[string]string
+}
+
+func (c *checksumDB) UpdateVideoChecksum(id, objectKey, checksum string) error {
+	c.checksums[id+":"+objectKey] = checksum
+	return nil
+}
+
+func TestUploadsRecordChecksums(t *testing.T) {
+	dir := t.TempDir()
+	hlsDir := filepath.Join(dir, "hls")
+	os.MkdirAll(hlsDir, 0755)
+	os.WriteFile(filepath.Join(hlsDir, "master.m3u8"), []byte("#EXTM3U"), 0644)
+	os.WriteFile(filepath.Join(hlsDir, "segment_000.ts"), []byte("segment"), 0644)
+	mp4Path := filepath.Join(dir, "video.mp4")
+	os.WriteFile(mp4Path, []byte("full video"), 0644)
+
+	store := NewMemoryStorage("https://media.example.com")
+	db := &checksumDB{checksums: map[string]string{}}
+	if _, _, err := UploadHLSStream(store, db, hlsDir, "video_1"); err != nil {
+		t.Fatalf("UploadHLSStream: %v", err)
+	}
+	if _, err := UploadMP4(store, db, mp4Path, "video_1"); err != nil {
+		t.Fatalf("UploadMP4: %v", err)
+	}
+
+	for _, key := range []string{"hls/video_1/master.m3u8", "hls/video_1/segment_000.ts", "mp4/video_1.mp4"} {
+		if db.checksums["video_1:"+key] == "" {
+			t.Errorf("no checksum recorded for %s", key)
+		}
+	}
+}
+
+func TestUploadFileVerified(t *testing.T) {
+	localPath := filepath.Join(t.TempDir(), "video.mp4")
+	data := []byte("not really an mp4 but long enough to cut in half")
+	if err := os.WriteFile(localPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+
+	store := &corruptingStore{MemoryStorage: NewMemoryStorage("https://media.example.com")}
+	if _, err := store.UploadFileWithMetrics(localPath, "mp4/video_1.mp4", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyUpload(store, localPath, "mp4/video_1.mp4"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected a checksum mismatch for the truncated object, got %v", err)
+	}
+
+	store.uploads = 0
+	_, checksum, err := UploadFileVerified(store, localPath, "mp4/video_1.mp4")
+	if err != nil {
+		t.Fatalf("UploadFileVerified: %v", err)
+	}
+	if store.uploads != 2 {
+		t.Errorf("expected the corrupted upload to be repeated once, got %d uploads", store.uploads)
+	}
+	if checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected checksum %s", checksum)
+	}
+}
+
+func TestFileChecksumMultipartETag(t *testing.T) {
+	localPath := filepath.Join(t.TempDir(), "parts")
+	if err := os.WriteFile(localPath, []byte("abcdefg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, etag, err := fileChecksum(localPath, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var partSums []byte
+	for _, part := range []string{"abc", "def", "g"} {
+		sum := md5.Sum([]byte(part))
+		partSums = append(partSums, sum[:]...)
+	}
+	sum := md5.Sum(partSums)
+	if want := fmt.Sprintf("%s-3", hex.EncodeToString(sum[:])); etag != want {
+		t.Errorf("multipart ETag %s, want %s", etag, want)
+	}
+}
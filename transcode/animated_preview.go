@@ -0,0 +1,111 @@
+package transcode
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"ayo-mwr/config"
+	"ayo-mwr/ffmpeg"
+)
+
+// Animated preview formats
+const (
+	AnimatedWebP = "webp"
+	AnimatedGIF  = "gif"
+)
+
+// animatedAttempt holds the encoder settings of one attempt to meet the size budget
+type animatedAttempt struct {
+	width   int
+	fps     int
+	quality int // WebP quality or GIF palette size
+}
+
+// GenerateAnimatedPreview encodes the start of a preview MP4 as an animated WebP or GIF.
+// If the result exceeds the configured size budget it is re-encoded with lower quality,
+// frame rate and width, up to cfg.MaxAttempts times. The last attempt is kept even if it is
+// still over budget so a preview is always available.
+func GenerateAnimatedPreview(videoID, inputPath, outputPath, format string, cfg *config.AnimatedPreviewConfig) error {
+	attempt := animatedAttempt{width: cfg.Width, fps: cfg.FPS, quality: cfg.WebPQuality}
+	budgetKB := cfg.MaxWebPKB
+	if format == AnimatedGIF {
+		attempt.quality = cfg.GIFColors
+		budgetKB = cfg.MaxGIFKB
+	} else if format != AnimatedWebP {
+		return fmt.Errorf("unsupported animated preview format: %s", format)
+	}
+
+	for i := 1; i <= cfg.MaxAttempts; i++ {
+		if err := encodeAnimatedPreview(videoID, inputPath, outputPath, format, cfg.DurationSeconds, attempt); err != nil {
+			return err
+		}
+
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			return fmt.Errorf("animated preview not created: %v", err)
+		}
+		sizeKB := int(info.Size() / 1024)
+		if sizeKB <= budgetKB {
+			log.Printf("[ANIMATED] %s preview %s: %dKB (budget %dKB, attempt %d, %dpx, %dfps)",
+				format, outputPath, sizeKB, budgetKB, i, attempt.width, attempt.fps)
+			return nil
+		}
+
+		log.Printf("[ANIMATED] %s preview is %dKB, over the %dKB budget (attempt %d/%d)", format, sizeKB, budgetKB, i, cfg.MaxAttempts)
+		attempt = attempt.smaller(format)
+	}
+
+	log.Printf("[ANIMATED] Warning: %s preview %s is still over budget after %d attempts", format, outputPath, cfg.MaxAttempts)
+	return nil
+}
+
+// smaller returns the settings for the next attempt: quality drops first, then frame rate and width
+func (a animatedAttempt) smaller(format string) animatedAttempt {
+	if format == AnimatedGIF {
+		a.quality = max(a.quality/2, 16)
+	} else {
+		a.quality = max(a.quality-15, 20)
+	}
+	a.fps = max(a.fps*3/4, 5)
+	a.width = max((a.width*4/5)&^1, 120)
+	return a
+}
+
+// encodeAnimatedPreview runs one ffmpeg encode of the animated preview
+func encodeAnimatedPreview(videoID, inputPath, outputPath, format string, durationSeconds float64, a animatedAttempt) error {
+	args := []string{
+		"-y",
+		"-v", "warning",
+		"-t", fmt.Sprintf("%.2f", durationSeconds),
+		"-i", inputPath,
+		"-an",
+	}
+
+	if format == AnimatedGIF {
+		filter := fmt.Sprintf("[0:v]fps=%d,scale=%d:-2:flags=lanczos,split[a][b];[a]palettegen=max_colors=%d[p];[b][p]paletteuse=dither=bayer",
+			a.fps, a.width, a.quality)
+		args = append(args, "-filter_complex", filter, "-loop", "0", "-f", "gif", outputPath)
+	} else {
+		args = append(args,
+			"-vf", fmt.Sprintf("fps=%d,scale=%d:-2:flags=lanczos", a.fps, a.width),
+			"-c:v", "libwebp",
+			"-lossless", "0",
+			"-q:v", fmt.Sprintf("%d", a.quality),
+			"-compression_level", "6",
+			"-loop", "0",
+			"-f", "webp",
+			outputPath,
+		)
+	}
+
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StagePreview,
+		Duration: durationSeconds,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("ffmpeg %s preview failed: %v, output: %s", format, err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package transcode
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ayo-mwr/config"
+	"ayo-mwr/ffmpeg"
+)
+
+// Manifest names written by GenerateCMAF. The HLS master playlist keeps the name used for
+// MPEG-TS output so existing stream URLs stay valid.
+const (
+	HLSMasterName    = "master.m3u8"
+	DASHManifestName = "manifest.mpd"
+)
+
+// GenerateCMAF creates fMP4 (CMAF) segments for every quality preset in a single FFmpeg run,
+// shared by an HLS master playlist and a DASH manifest in outputDir. Progress is reported under videoID.
+func GenerateCMAF(inputPath, outputDir, videoID string, presets []QualityPreset, cfg *config.Config) error {
+	if len(presets) == 0 {
+		return fmt.Errorf("no quality presets to transcode")
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create CMAF output directory: %v", err)
+	}
+
+	// Hardware decoding keeps frames in GPU memory, which the software AV1 encoder can't read
+	inputParams, _ := GetInputParams(cfg.HardwareAccel)
+	for _, preset := range presets {
+		if NormalizeCodec(preset.Codec) == CodecAV1 {
+			inputParams = nil
+			break
+		}
+	}
+	args := append(inputParams, "-i", inputPath)
+
+	// Split the decoded video once and scale it for each preset
+	var filter strings.Builder
+	fmt.Fprintf(&filter, "[0:v]split=%d", len(presets))
+	for i := range presets {
+		fmt.Fprintf(&filter, "[v%d]", i)
+	}
+	for i, preset := range presets {
+		fmt.Fprintf(&filter, ";[v%d]scale=%d:%d[out%d]", i, preset.Width, preset.Height, i)
+	}
+	args = append(args, "-filter_complex", filter.String())
+
+	// DASH adaptation sets can't mix codecs, so the video streams are grouped per codec
+	var codecs []string
+	streamsByCodec := make(map[string][]string)
+	for i, preset := range presets {
+		args = append(args, "-map", fmt.Sprintf("[out%d]", i))
+		args = append(args, streamOutputParams(GetOutputParams(cfg.HardwareAccel, cfg.Codec, preset), i)...)
+
+		codec := cfg.Codec
+		if preset.Codec != "" {
+			codec = preset.Codec
+		}
+		codec = NormalizeCodec(codec)
+		if _, exists := streamsByCodec[codec]; !exists {
+			codecs = append(codecs, codec)
+		}
+		streamsByCodec[codec] = append(streamsByCodec[codec], strconv.Itoa(i))
+	}
+
+	var adaptationSets []string
+	for i, codec := range codecs {
+		adaptationSets = append(adaptationSets, fmt.Sprintf("id=%d,streams=%s", i, strings.Join(streamsByCodec[codec], ",")))
+	}
+	adaptationSets = append(adaptationSets, fmt.Sprintf("id=%d,streams=a", len(codecs)))
+
+	args = append(args,
+		"-map", "0:a?",
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-f", "dash",
+		"-seg_duration", "4",
+		"-use_template", "1",
+		"-use_timeline", "1",
+		"-adaptation_sets", strings.Join(adaptationSets, " "),
+		"-init_seg_name", "init_$RepresentationID$.m4s",
+		"-media_seg_name", "chunk_$RepresentationID$_$Number%05d$.m4s",
+		"-hls_playlist", "1",
+		"-hls_master_name", HLSMasterName,
+		"-y",
+		filepath.Join(outputDir, DASHManifestName),
+	)
+
+	log.Printf("[TRANSCODE] Executing FFmpeg for CMAF output (%v)", getPresetNames(presets))
+	start := time.Now()
+
+	if output, err := ffmpeg.Run(ffmpeg.Job{VideoID: videoID, Stage: ffmpeg.StageHLS}, args...); err != nil {
+		return fmt.Errorf("error creating CMAF output: %v, output: %s", err, string(output))
+	}
+
+	for _, name := range []string{HLSMasterName, DASHManifestName} {
+		if _, err := os.Stat(filepath.Join(outputDir, name)); err != nil {
+			return fmt.Errorf("CMAF output is missing %s: %v", name, err)
+		}
+	}
+
+	log.Printf("[TRANSCODE] SUCCESS: CMAF output completed in %.2f seconds", time.Since(start).Seconds())
+	return nil
+}
+
+// streamOutputParams turns the video encoder parameters of a preset into parameters for output
+// stream index, dropping the scale filter and audio settings that are set once for the whole output
+func streamOutputParams(params []string, index int) []string {
+	suffix := ":" + strconv.Itoa(index)
+
+	var result []string
+	for i := 0; i+1 < len(params); i += 2 {
+		option, value := params[i], params[i+1]
+		switch {
+		case option == "-vf" || strings.HasSuffix(option, ":a"):
+			continue
+		case strings.HasSuffix(option, ":v"):
+			option += suffix
+		default:
+			option += ":v" + suffix
+		}
+		result = append(result, option, value)
+	}
+	return result
+}
@@ -0,0 +1,152 @@
+package transcode
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"ayo-mwr/config"
+)
+
+// Output video codecs (the codec system config setting)
+const (
+	CodecH264 = "h264"
+	CodecHEVC = "hevc"
+	CodecAV1  = "av1"
+)
+
+var (
+	encoderListOnce sync.Once
+	encoderList     string
+)
+
+// NormalizeCodec maps the configured codec name to CodecH264, CodecHEVC or CodecAV1.
+// Unknown names are treated as H.264.
+func NormalizeCodec(codec string) string {
+	switch strings.ToLower(codec) {
+	case "hevc", "h265":
+		return CodecHEVC
+	case "av1":
+		return CodecAV1
+	default:
+		return CodecH264
+	}
+}
+
+// encoderAvailable reports whether the installed FFmpeg build has the given encoder
+func encoderAvailable(encoder string) bool {
+	encoderListOnce.Do(func() {
+		output, err := exec.Command("ffmpeg", "-hide_banner", "-encoders").Output()
+		if err != nil {
+			log.Printf("[CODEC] WARNING: Failed to list FFmpeg encoders: %v", err)
+			return
+		}
+		encoderList = string(output)
+	})
+
+	for _, line := range strings.Split(encoderList, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == encoder {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveCodec returns the codec to transcode inputPath with. HEVC and AV1 fall back to H.264
+// when the encoder is missing from the FFmpeg build, or when a sample encode estimates that the
+// whole ladder would not finish within the configured deadline.
+func ResolveCodec(inputPath string, cfg *config.Config, presets []QualityPreset, ladderConfig *config.TranscodeLadderConfig) string {
+	codec := NormalizeCodec(cfg.Codec)
+	if codec == CodecH264 || len(presets) == 0 {
+		return CodecH264
+	}
+
+	encoder := GetVideoCodec(cfg.HardwareAccel, codec)
+	if !encoderAvailable(encoder) {
+		log.Printf("[CODEC] WARNING: Encoder %s is not available, falling back to H.264", encoder)
+		return CodecH264
+	}
+
+	if ladderConfig.CodecDeadlineSeconds <= 0 || ladderConfig.CodecProbeSeconds <= 0 {
+		return codec
+	}
+
+	estimate, err := estimateEncodeSeconds(inputPath, cfg.HardwareAccel, codec, presets, ladderConfig.CodecProbeSeconds)
+	if err != nil {
+		log.Printf("[CODEC] WARNING: Failed to estimate %s encode time, falling back to H.264: %v", encoder, err)
+		return CodecH264
+	}
+	if estimate > float64(ladderConfig.CodecDeadlineSeconds) {
+		log.Printf("[CODEC] %s estimated at %.0fs, over the %ds deadline, falling back to H.264",
+			encoder, estimate, ladderConfig.CodecDeadlineSeconds)
+		return CodecH264
+	}
+
+	log.Printf("[CODEC] Using %s (estimated %.0fs, deadline %ds)", encoder, estimate, ladderConfig.CodecDeadlineSeconds)
+	return codec
+}
+
+// estimateEncodeSeconds encodes the first probeSeconds of the input at the largest preset and
+// extrapolates the time to encode the whole input at every preset
+func estimateEncodeSeconds(inputPath, hwAccel, codec string, presets []QualityPreset, probeSeconds int) (float64, error) {
+	duration, err := GetVideoDuration(inputPath)
+	if err != nil {
+		return 0, err
+	}
+
+	largest := presets[0]
+	for _, preset := range presets {
+		if preset.Width*preset.Height > largest.Width*largest.Height {
+			largest = preset
+		}
+	}
+
+	sample := float64(probeSeconds)
+	if duration < sample {
+		sample = duration
+	}
+	if sample <= 0 {
+		return 0, fmt.Errorf("input has no duration")
+	}
+
+	args := []string{"-v", "error", "-t", fmt.Sprintf("%.2f", sample), "-i", inputPath}
+	args = append(args, GetOutputParams(hwAccel, codec, largest)...)
+	args = append(args, "-an", "-f", "null", "-")
+
+	start := time.Now()
+	if output, err := exec.Command("ffmpeg", args...).CombinedOutput(); err != nil {
+		return 0, fmt.Errorf("sample encode failed: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	elapsed := time.Since(start).Seconds()
+
+	// Smaller presets encode faster, so counting each as the largest keeps the estimate on the safe side
+	return elapsed * duration / sample * float64(len(presets)), nil
+}
+
+// compatibilityPreset returns the H.264 rendition kept next to HEVC/AV1 output for old phones:
+// the configured preset if it is enabled, otherwise the smallest enabled preset
+func compatibilityPreset(presets []QualityPreset, name string) QualityPreset {
+	compat := presets[0]
+	found := false
+	for _, preset := range presets {
+		if preset.Name == name {
+			compat = preset
+			found = true
+			break
+		}
+		if preset.Width*preset.Height < compat.Width*compat.Height {
+			compat = preset
+		}
+	}
+	if !found && name != "" {
+		log.Printf("[CODEC] Compatibility preset %q is not enabled, using %s", name, compat.Name)
+	}
+
+	compat.Name = compat.Name + "_avc"
+	compat.Codec = CodecH264
+	return compat
+}
@@ -0,0 +1,120 @@
+package transcode
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ayo-mwr/ffmpeg"
+)
+
+// Sprite sheet layout used for scrub thumbnails
+const (
+	SpriteThumbWidth  = 160
+	SpriteThumbHeight = 90
+	SpriteColumns     = 10
+	SpriteRows        = 10
+
+	SpriteVTTFileName = "thumbnails.vtt"
+)
+
+// GenerateSpriteSheet extracts one thumbnail every intervalSeconds from the video, tiles them into
+// JPEG sprite sheets (sprite_001.jpg, sprite_002.jpg, ...) and writes a WebVTT thumbnails track
+// referencing the sprite coordinates. Returns the path to the VTT file and the sprite sheet paths.
+func GenerateSpriteSheet(videoID, inputPath, outputDir string, intervalSeconds int) (string, []string, error) {
+	if intervalSeconds <= 0 {
+		return "", nil, fmt.Errorf("invalid sprite interval: %d", intervalSeconds)
+	}
+
+	duration, err := GetVideoDuration(inputPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create sprite directory: %v", err)
+	}
+
+	// Remove sprites from a previous run so stale sheets are not uploaded
+	oldSprites, _ := filepath.Glob(filepath.Join(outputDir, "sprite_*.jpg"))
+	for _, oldSprite := range oldSprites {
+		os.Remove(oldSprite)
+	}
+
+	log.Printf("[SPRITE] Generating sprite sheets for %s (duration: %.2fs, interval: %ds)", inputPath, duration, intervalSeconds)
+
+	filter := fmt.Sprintf("fps=1/%d,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+		intervalSeconds, SpriteThumbWidth, SpriteThumbHeight, SpriteThumbWidth, SpriteThumbHeight, SpriteColumns, SpriteRows)
+
+	output, err := ffmpeg.Run(ffmpeg.Job{
+		VideoID:  videoID,
+		Stage:    ffmpeg.StageSprite,
+		Duration: duration,
+	},
+		"-i", inputPath,
+		"-vf", filter,
+		"-an",
+		"-q:v", "5",
+		"-y",
+		filepath.Join(outputDir, "sprite_%03d.jpg"))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate sprite sheets: %v, output: %s", err, string(output))
+	}
+
+	sprites, err := filepath.Glob(filepath.Join(outputDir, "sprite_*.jpg"))
+	if err != nil || len(sprites) == 0 {
+		return "", nil, fmt.Errorf("no sprite sheets were generated")
+	}
+	sort.Strings(sprites)
+
+	vttPath := filepath.Join(outputDir, SpriteVTTFileName)
+	if err := os.WriteFile(vttPath, []byte(buildSpriteVTT(duration, intervalSeconds, len(sprites))), 0644); err != nil {
+		return "", nil, fmt.Errorf("failed to write WebVTT thumbnails track: %v", err)
+	}
+
+	log.Printf("[SPRITE] Generated %d sprite sheet(s) and %s", len(sprites), vttPath)
+	return vttPath, sprites, nil
+}
+
+// buildSpriteVTT builds a WebVTT thumbnails track where each cue points to a tile in a sprite sheet
+// using the media fragment syntax (sprite_001.jpg#xywh=x,y,w,h). Sprite references are relative
+// so the track works wherever the sprites are uploaded alongside it.
+func buildSpriteVTT(duration float64, intervalSeconds int, sheetCount int) string {
+	tilesPerSheet := SpriteColumns * SpriteRows
+	thumbCount := int(math.Ceil(duration / float64(intervalSeconds)))
+	if maxThumbs := sheetCount * tilesPerSheet; thumbCount > maxThumbs {
+		thumbCount = maxThumbs
+	}
+
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+
+	for i := 0; i < thumbCount; i++ {
+		start := float64(i * intervalSeconds)
+		end := math.Min(float64((i+1)*intervalSeconds), duration)
+
+		sheet := i/tilesPerSheet + 1
+		tile := i % tilesPerSheet
+		x := (tile % SpriteColumns) * SpriteThumbWidth
+		y := (tile / SpriteColumns) * SpriteThumbHeight
+
+		sb.WriteString(fmt.Sprintf("%s --> %s\n", formatVTTTimestamp(start), formatVTTTimestamp(end)))
+		sb.WriteString(fmt.Sprintf("sprite_%03d.jpg#xywh=%d,%d,%d,%d\n\n", sheet, x, y, SpriteThumbWidth, SpriteThumbHeight))
+	}
+
+	return sb.String()
+}
+
+// formatVTTTimestamp formats seconds as a WebVTT timestamp (HH:MM:SS.mmm)
+func formatVTTTimestamp(seconds float64) string {
+	totalMillis := int64(math.Round(seconds * 1000))
+	hours := totalMillis / 3600000
+	minutes := (totalMillis % 3600000) / 60000
+	secs := (totalMillis % 60000) / 1000
+	millis := totalMillis % 1000
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, secs, millis)
+}
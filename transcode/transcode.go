@@ -14,6 +14,7 @@ import (
 
 	"ayo-mwr/config"
 	"ayo-mwr/database"
+	"ayo-mwr/ffmpeg"
 	"ayo-mwr/metrics"
 )
 
@@ -23,46 +24,42 @@ type QualityPreset struct {
 	Width     int
 	Height    int
 	Bitrate   string
-	Bandwidth int // For playlist metadata (bits per second)
+	MaxRate   string // Peak bitrate, empty for encoder default
+	BufSize   string // Rate control buffer size, empty for encoder default
+	GOP       int    // Keyframe interval in frames, 0 for encoder default
+	Profile   string // H.264 profile, empty for the codec default
+	CRF       int    // Constant quality, 0 for the codec default
+	Codec     string // Output codec overriding the configured codec (compatibility rendition)
+	Bandwidth int    // For playlist metadata (bits per second)
 }
 
-// GetQualityPresets returns an array of quality presets for transcoding based on configuration
+// GetQualityPresets returns an array of quality presets for transcoding based on configuration,
+// using the built-in transcoding ladder
 func GetQualityPresets(cfg config.Config) []QualityPreset {
-	// Define all available quality presets
-	allPresets := map[string]QualityPreset{
-		"1080p": {
-			Name:      "1080p",
-			Width:     1920,
-			Height:    1080,
-			Bitrate:   "5000k",
-			Bandwidth: 5000000,
-		},
-		"720p": {
-			Name:      "720p",
-			Width:     1280,
-			Height:    720,
-			Bitrate:   "2800k",
-			Bandwidth: 2800000,
-		},
-		"480p": {
-			Name:      "480p",
-			Width:     854,
-			Height:    480,
-			Bitrate:   "1400k",
-			Bandwidth: 1400000,
-		},
-		"360p": {
-			Name:      "360p",
-			Width:     640,
-			Height:    360,
-			Bitrate:   "800k",
-			Bandwidth: 800000,
-		},
+	return filterQualityPresets(config.DefaultTranscodePresets(), cfg.EnabledQualities)
+}
+
+// GetQualityPresetsFromDB returns the quality presets for transcoding from the transcoding ladder
+// stored in the database, filtered by the enabled qualities in configuration
+func GetQualityPresetsFromDB(db database.Database, cfg config.Config) []QualityPreset {
+	ladderConfig, err := config.NewTranscodeLadderConfigService(db).GetTranscodeLadderConfig()
+	if err != nil {
+		log.Printf("[TRANSCODE] WARNING: Failed to load transcoding ladder, using built-in presets: %v", err)
+		return GetQualityPresets(cfg)
+	}
+	return filterQualityPresets(ladderConfig.Presets, cfg.EnabledQualities)
+}
+
+// filterQualityPresets converts the ladder to quality presets, keeping the enabled qualities in order
+func filterQualityPresets(ladder []config.TranscodePreset, enabledQualities []string) []QualityPreset {
+	allPresets := make(map[string]QualityPreset, len(ladder))
+	for _, preset := range ladder {
+		allPresets[preset.Name] = qualityPresetFromConfig(preset)
 	}
 
 	// Filter presets based on enabled qualities from config
 	var enabledPresets []QualityPreset
-	for _, qualityName := range cfg.EnabledQualities {
+	for _, qualityName := range enabledQualities {
 		if preset, exists := allPresets[qualityName]; exists {
 			enabledPresets = append(enabledPresets, preset)
 		}
@@ -70,17 +67,37 @@ func GetQualityPresets(cfg config.Config) []QualityPreset {
 
 	// If no valid presets found, return all presets as fallback
 	if len(enabledPresets) == 0 {
-		return []QualityPreset{
-			allPresets["1080p"],
-			allPresets["720p"],
-			allPresets["480p"],
-			allPresets["360p"],
+		for _, preset := range ladder {
+			enabledPresets = append(enabledPresets, allPresets[preset.Name])
 		}
 	}
 
 	return enabledPresets
 }
 
+// qualityPresetFromConfig converts a ladder preset, advertising the peak bitrate as playlist bandwidth
+func qualityPresetFromConfig(preset config.TranscodePreset) QualityPreset {
+	bandwidth, _ := config.BitsPerSecond(preset.Bitrate)
+	if preset.MaxRate != "" {
+		if maxBandwidth, err := config.BitsPerSecond(preset.MaxRate); err == nil {
+			bandwidth = maxBandwidth
+		}
+	}
+
+	return QualityPreset{
+		Name:      preset.Name,
+		Width:     preset.Width,
+		Height:    preset.Height,
+		Bitrate:   preset.Bitrate,
+		MaxRate:   preset.MaxRate,
+		BufSize:   preset.BufSize,
+		GOP:       preset.GOP,
+		Profile:   preset.Profile,
+		CRF:       preset.CRF,
+		Bandwidth: bandwidth,
+	}
+}
+
 // TranscodeVideo generates multi-quality HLS format from the MP4 file
 func TranscodeVideo(inputPath, videoID, cameraName string, cfg *config.Config) (map[string]string, map[string]float64, error) {
 	return TranscodeVideoWithMetrics(inputPath, videoID, cameraName, cfg, nil)
@@ -245,12 +262,51 @@ func GenerateHLS(inputPath, outputDir, videoID string, cfg *config.Config) error
 	log.Printf("[TRANSCODE] Output directory: %s", outputDir)
 
 	inputParams, _ := GetInputParams(cfg.HardwareAccel)
-	qualityPresets := GetQualityPresets(*cfg)
-	log.Printf("[TRANSCODE] Quality presets enabled: %v", getPresetNames(qualityPresets))
 
 	// Get video metadata from database to get start_time and end_time
 	log.Printf("[TRANSCODE] Attempting to connect to database for quality optimization")
 	db, err := database.NewSQLiteDB(cfg.DatabasePath)
+
+	// The transcoding ladder lives in the database; fall back to the built-in ladder without it
+	var ladderPresets []QualityPreset
+	if err != nil {
+		ladderPresets = GetQualityPresets(*cfg)
+	} else {
+		ladderPresets = GetQualityPresetsFromDB(db, *cfg)
+	}
+	qualityPresets := ladderPresets
+	log.Printf("[TRANSCODE] Quality presets enabled: %v", getPresetNames(qualityPresets))
+
+	ladderConfig := &config.TranscodeLadderConfig{SegmentFormat: config.SegmentFormatTS}
+	if err == nil {
+		ladderConfig, _ = config.NewTranscodeLadderConfigService(db).GetTranscodeLadderConfig()
+	}
+
+	// HEVC and AV1 need fMP4 segments and keep an H.264 rendition for old phones
+	codec := ResolveCodec(inputPath, cfg, qualityPresets, ladderConfig)
+	if codec != CodecH264 {
+		cmafPresets := make([]QualityPreset, 0, len(qualityPresets)+1)
+		for _, preset := range qualityPresets {
+			preset.Codec = codec
+			cmafPresets = append(cmafPresets, preset)
+		}
+		qualityPresets = append(cmafPresets, compatibilityPreset(qualityPresets, ladderConfig.CompatibilityPreset))
+	} else {
+		for i := range qualityPresets {
+			qualityPresets[i].Codec = CodecH264
+		}
+	}
+
+	// CMAF output encodes every quality in one run; recorded MPEG-TS segments can't be reused for it
+	if codec != CodecH264 || ladderConfig.SegmentFormat == config.SegmentFormatCMAF {
+		if err := GenerateCMAF(inputPath, outputDir, videoID, qualityPresets, cfg); err != nil {
+			log.Printf("[TRANSCODE] ERROR: Failed to create CMAF output: %v", err)
+			return err
+		}
+		log.Printf("[TRANSCODE] SUCCESS: CMAF HLS/DASH generation completed for video %s (%s)", videoID, codec)
+		return nil
+	}
+
 	if err != nil {
 		log.Printf("[TRANSCODE] WARNING: Failed to init database for quality optimization: %v", err)
 		log.Printf("[TRANSCODE] Proceeding with standard FFmpeg processing for all qualities")
@@ -323,20 +379,18 @@ func GenerateHLS(inputPath, outputDir, videoID string, cfg *config.Config) error
 
 		outputParams := GetOutputParams(cfg.HardwareAccel, cfg.Codec, preset)
 
-		hlsCmd := exec.Command("ffmpeg", append(append(inputParams, "-i", inputPath),
+		hlsArgs := append(append(inputParams, "-i", inputPath),
 			append(outputParams,
 				"-hls_time", "4",
 				"-hls_playlist_type", "vod",
 				"-hls_segment_filename", filepath.Join(qualityDir, "segment_%03d.ts"),
-				filepath.Join(qualityDir, "playlist.m3u8"))...)...)
-		hlsCmd.Stdout = os.Stdout
-		hlsCmd.Stderr = os.Stderr
+				filepath.Join(qualityDir, "playlist.m3u8"))...)
 
 		log.Printf("[TRANSCODE] Executing FFmpeg for %s quality", preset.Name)
 		start := time.Now()
 
-		if err := hlsCmd.Run(); err != nil {
-			log.Printf("[TRANSCODE] ERROR: Failed to create HLS variant %s: %v", preset.Name, err)
+		if output, err := ffmpeg.Run(ffmpeg.Job{VideoID: videoID, Stage: ffmpeg.StageHLS}, hlsArgs...); err != nil {
+			log.Printf("[TRANSCODE] ERROR: Failed to create HLS variant %s: %v\nOutput: %s", preset.Name, err, string(output))
 			return fmt.Errorf("error creating HLS variant %s: %v", preset.Name, err)
 		}
 
@@ -348,14 +402,13 @@ func GenerateHLS(inputPath, outputDir, videoID string, cfg *config.Config) error
 
 	// Create master playlist with all original presets (including 1080p)
 	log.Printf("[TRANSCODE] Creating master playlist...")
-	originalPresets := GetQualityPresets(*cfg)
-	if err := createHLSMasterPlaylist(outputDir, originalPresets); err != nil {
+	if err := createHLSMasterPlaylist(outputDir, ladderPresets); err != nil {
 		log.Printf("[TRANSCODE] ERROR: Failed to create master playlist: %v", err)
 		return err
 	}
 
 	log.Printf("[TRANSCODE] SUCCESS: HLS generation completed for video %s", videoID)
-	log.Printf("[TRANSCODE] Master playlist created with %d quality variants", len(originalPresets))
+	log.Printf("[TRANSCODE] Master playlist created with %d quality variants", len(ladderPresets))
 	return nil
 }
 
@@ -542,9 +595,9 @@ func GetInputParams(hwAccel string) ([]string, error) {
 
 // GetVideoCodec returns the appropriate video codec for the hardware acceleration and codec
 func GetVideoCodec(hwAccel, codec string) string {
-	// Default to h264 if not specified
-	if codec == "" {
-		codec = "h264"
+	codec = NormalizeCodec(codec)
+	if codec == CodecAV1 {
+		return "libsvtav1"
 	}
 
 	// Default to software encoding if not specified
@@ -586,16 +639,48 @@ func GetOutputParams(hwAccel, codec string, preset QualityPreset) []string {
 		hwAccel = "software"
 	}
 
-	// Default to h264 if not specified
-	if codec == "" {
-		codec = "h264"
+	if preset.Codec != "" {
+		codec = preset.Codec
 	}
+	codec = NormalizeCodec(codec)
 
 	// Base quality parameters
 	baseParams := []string{
 		"-vf", fmt.Sprintf("scale=%d:%d", preset.Width, preset.Height),
 		"-b:v", preset.Bitrate,
 	}
+	if preset.MaxRate != "" {
+		baseParams = append(baseParams, "-maxrate", preset.MaxRate)
+	}
+	if preset.BufSize != "" {
+		baseParams = append(baseParams, "-bufsize", preset.BufSize)
+	}
+	if preset.GOP > 0 {
+		baseParams = append(baseParams,
+			"-g", strconv.Itoa(preset.GOP),
+			"-keyint_min", strconv.Itoa(preset.GOP))
+	}
+
+	// Preset quality and profile override the codec defaults; the profile only applies to H.264
+	crf := "23"
+	switch codec {
+	case CodecHEVC:
+		crf = "28"
+	case CodecAV1:
+		crf = "35"
+	}
+	if preset.CRF > 0 {
+		crf = strconv.Itoa(preset.CRF)
+	}
+	profile := "high"
+	if preset.Profile != "" {
+		profile = preset.Profile
+	}
+
+	// AV1 is encoded in software (SVT-AV1) whatever the hardware acceleration
+	if codec == CodecAV1 {
+		hwAccel = "software"
+	}
 
 	switch hwAccel {
 	case "nvidia":
@@ -605,15 +690,15 @@ func GetOutputParams(hwAccel, codec string, preset QualityPreset) []string {
 				"-preset", "p1",
 				"-profile:v", "main",
 				"-rc", "vbr",
-				"-cq", "28",
+				"-cq", crf,
 			}, baseParams...)
 		} else {
 			outputParams = append([]string{
 				"-c:v", "h264_nvenc",
 				"-preset", "p1",
-				"-profile:v", "high",
+				"-profile:v", profile,
 				"-rc", "vbr",
-				"-cq", "23",
+				"-cq", crf,
 			}, baseParams...)
 		}
 	case "intel":
@@ -627,7 +712,7 @@ func GetOutputParams(hwAccel, codec string, preset QualityPreset) []string {
 			outputParams = append([]string{
 				"-c:v", "h264_qsv",
 				"-preset", "veryfast",
-				"-profile:v", "high",
+				"-profile:v", profile,
 			}, baseParams...)
 		}
 	case "amd":
@@ -642,24 +727,33 @@ func GetOutputParams(hwAccel, codec string, preset QualityPreset) []string {
 			outputParams = append([]string{
 				"-c:v", "h264_amf",
 				"-quality", "speed",
-				"-profile:v", "high",
+				"-profile:v", profile,
 				"-level", "5.2",
 			}, baseParams...)
 		}
 	default:
-		// Software encoding (CPU)
-		if codec == "hevc" {
+		// Software encoding (CPU), presets tuned for speed on CPU-only boxes
+		if codec == CodecAV1 {
+			outputParams = append([]string{
+				"-c:v", "libsvtav1",
+				"-preset", "10",
+				"-crf", crf,
+				"-pix_fmt", "yuv420p",
+			}, baseParams...)
+		} else if codec == "hevc" {
 			outputParams = append([]string{
 				"-c:v", "libx265",
 				"-preset", "ultrafast",
-				"-crf", "28",
+				"-crf", crf,
+				"-tag:v", "hvc1", // Required by Apple players
+				"-x265-params", "log-level=error",
 			}, baseParams...)
 		} else {
 			outputParams = append([]string{
 				"-c:v", "libx264",
 				"-preset", "ultrafast",
-				"-profile:v", "high",
-				"-crf", "23",
+				"-profile:v", profile,
+				"-crf", crf,
 			}, baseParams...)
 		}
 	}
@@ -701,8 +795,20 @@ func ConvertTSToMP4(inputPath, outputPath string) error {
 	return ConvertTSToMP4WithMetrics(inputPath, outputPath, nil)
 }
 
+// ConvertTSToMP4ForVideo converts a TS file of a video to MP4 format, so the conversion can be
+// cancelled with the video
+func ConvertTSToMP4ForVideo(videoID, inputPath, outputPath string) error {
+	return convertTSToMP4(videoID, inputPath, outputPath, nil)
+}
+
 // ConvertTSToMP4WithMetrics converts a TS file to MP4 format with metrics tracking
 func ConvertTSToMP4WithMetrics(inputPath, outputPath string, videoMetrics *metrics.VideoProcessingMetrics) error {
+	return convertTSToMP4("", inputPath, outputPath, videoMetrics)
+}
+
+// convertTSToMP4 converts a TS file to MP4 format. The FFmpeg job belongs to videoID, or to the
+// video of videoMetrics when videoID is empty.
+func convertTSToMP4(videoID, inputPath, outputPath string, videoMetrics *metrics.VideoProcessingMetrics) error {
 	// Create output directory if it doesn't exist (do this first)
 	outputDir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -721,7 +827,7 @@ func ConvertTSToMP4WithMetrics(inputPath, outputPath string, videoMetrics *metri
 
 	// FFmpeg command to convert TS to MP4 without re-encoding
 	// -c copy means copy streams without re-encoding (preserves quality)
-	cmd := exec.Command("ffmpeg",
+	output, err := ffmpeg.Run(ffmpeg.Job{VideoID: videoID, Stage: ffmpeg.StageTranscode, Metrics: videoMetrics},
 		"-i", inputPath,
 		"-c", "copy", // Copy streams without re-encoding
 		"-avoid_negative_ts", "make_zero", // Handle negative timestamps
@@ -731,18 +837,13 @@ func ConvertTSToMP4WithMetrics(inputPath, outputPath string, videoMetrics *metri
 		"-y", // Overwrite output file if exists
 		outputPath)
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
-
 	// End transcode metrics if provided
 	if videoMetrics != nil {
 		videoMetrics.EndTranscode()
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to convert TS to MP4: %v", err)
+		return fmt.Errorf("failed to convert TS to MP4: %v, output: %s", err, string(output))
 	}
 
 	return nil
@@ -750,7 +851,7 @@ func ConvertTSToMP4WithMetrics(inputPath, outputPath string, videoMetrics *metri
 
 // ConvertTSToMP4WithWatermark converts a TS file to MP4 with watermark in a single step
 // This is more efficient than remuxing then watermarking separately
-func ConvertTSToMP4WithWatermark(inputPath, outputPath, watermarkPath string, overlayPosition string, margin int) error {
+func ConvertTSToMP4WithWatermark(inputPath, outputPath, watermarkPath string, overlayPosition string, margin int, videoMetrics *metrics.VideoProcessingMetrics) error {
 	// Create output directory if it doesn't exist
 	outputDir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -785,7 +886,7 @@ func ConvertTSToMP4WithWatermark(inputPath, outputPath, watermarkPath string, ov
 	}
 
 	// Single-step TS to MP4 conversion with watermark overlay
-	cmd := exec.Command("ffmpeg",
+	ffmpegArgs := []string{
 		"-i", inputPath,
 		"-i", watermarkPath,
 		"-filter_complex", fmt.Sprintf("overlay=%s", overlayExpr),
@@ -803,14 +904,12 @@ func ConvertTSToMP4WithWatermark(inputPath, outputPath, watermarkPath string, ov
 		"-max_muxing_queue_size", "1024",
 		"-threads", "4", // Limited threads to prevent resource issues
 		"-y", // Overwrite output file if exists
-		outputPath)
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+		outputPath,
+	}
 
 	log.Printf("[ConvertTSToMP4WithWatermark] Running single-step conversion with watermark...")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to convert TS to MP4 with watermark: %v", err)
+	if output, err := ffmpeg.Run(ffmpeg.Job{Stage: ffmpeg.StageWatermark, Metrics: videoMetrics}, ffmpegArgs...); err != nil {
+		return fmt.Errorf("failed to convert TS to MP4 with watermark: %v, output: %s", err, string(output))
 	}
 
 	// Verify output file was created
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -161,3 +162,80 @@ func TestGetVideoDuration(t *testing.T) {
 	// Note: Testing with actual video files would require ffprobe to be installed
 	// and test video files to be available, which is not practical for unit tests
 }
+
+func TestBuildSpriteVTT(t *testing.T) {
+	vtt := buildSpriteVTT(1005, 10, 2)
+
+	if !strings.HasPrefix(vtt, "WEBVTT\n\n") {
+		t.Fatalf("Expected WEBVTT header, got %q", vtt[:10])
+	}
+	if !strings.Contains(vtt, "00:00:00.000 --> 00:00:10.000\nsprite_001.jpg#xywh=0,0,160,90") {
+		t.Errorf("Expected first cue to reference the first tile of sprite_001.jpg")
+	}
+	// Thumbnail 101 (index 100) is the first tile of the second sheet
+	if !strings.Contains(vtt, "00:16:40.000 --> 00:16:45.000\nsprite_002.jpg#xywh=0,0,160,90") {
+		t.Errorf("Expected last cue to start the second sheet and end at the video duration")
+	}
+	if cues := strings.Count(vtt, " --> "); cues != 101 {
+		t.Errorf("Expected 101 cues, got %d", cues)
+	}
+}
+
+func TestFormatVTTTimestamp(t *testing.T) {
+	if got := formatVTTTimestamp(3725.5); got != "01:02:05.500" {
+		t.Errorf("Expected 01:02:05.500, got %s", got)
+	}
+}
+
+func TestAnimatedAttemptSmaller(t *testing.T) {
+	webp := animatedAttempt{width: 320, fps: 10, quality: 60}.smaller(AnimatedWebP)
+	if webp.width != 256 || webp.fps != 7 || webp.quality != 45 {
+		t.Errorf("Unexpected next WebP attempt: %+v", webp)
+	}
+
+	// Settings never drop below the floor values
+	floor := animatedAttempt{width: 120, fps: 5, quality: 16}.smaller(AnimatedGIF)
+	if floor.width != 120 || floor.fps != 5 || floor.quality != 16 {
+		t.Errorf("Expected floor values to be kept, got %+v", floor)
+	}
+}
+
+func TestFilterQualityPresets(t *testing.T) {
+	ladder := []config.TranscodePreset{
+		{Name: "1080p", Width: 1920, Height: 1080, Bitrate: "5000k", MaxRate: "5350k", BufSize: "7500k"},
+		{Name: "720p", Width: 1280, Height: 720, Bitrate: "2800k", GOP: 50, CRF: 21},
+	}
+
+	presets := filterQualityPresets(ladder, []string{"720p", "1080p"})
+	if len(presets) != 2 || presets[0].Name != "720p" {
+		t.Fatalf("Expected enabled qualities in configured order, got %v", getPresetNames(presets))
+	}
+	// Bandwidth advertises the peak rate when one is set
+	if presets[1].Bandwidth != 5350000 || presets[0].Bandwidth != 2800000 {
+		t.Errorf("Unexpected bandwidths: %d, %d", presets[1].Bandwidth, presets[0].Bandwidth)
+	}
+
+	params := strings.Join(GetOutputParams("software", "h264", presets[0]), " ")
+	if !strings.Contains(params, "-g 50") || !strings.Contains(params, "-crf 21") {
+		t.Errorf("Expected preset GOP and CRF in output params, got %s", params)
+	}
+
+	if fallback := filterQualityPresets(ladder, []string{"4k"}); len(fallback) != 2 {
+		t.Errorf("Expected all presets when no enabled quality matches, got %d", len(fallback))
+	}
+}
+
+func TestCompatibilityRendition(t *testing.T) {
+	presets := filterQualityPresets(config.DefaultTranscodePresets(), []string{"1080p", "480p"})
+
+	// 720p is not enabled, so the smallest enabled preset is kept in H.264
+	compat := compatibilityPreset(presets, "720p")
+	if compat.Name != "480p_avc" || compat.Codec != CodecH264 {
+		t.Errorf("Unexpected compatibility rendition: %+v", compat)
+	}
+
+	params := strings.Join(streamOutputParams(GetOutputParams("software", CodecAV1, presets[0]), 1), " ")
+	if !strings.Contains(params, "-c:v:1 libsvtav1") || strings.Contains(params, "-vf") || strings.Contains(params, "-c:a") {
+		t.Errorf("Unexpected per-stream params: %s", params)
+	}
+}